@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCancelledRankKeepsPreviousRanking(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "data/processed/papers.json", `{"papers": [
+		{"id": "A", "title": "A", "year": 2001, "citations": []},
+		{"id": "B", "title": "B", "year": 2002, "citations": ["A"]}
+	], "citations": [{"from": "B", "to": "A"}]}`)
+	if err := runCLI(t, dir, "build"); err != nil {
+		t.Fatal(err)
+	}
+	previous := `{"scores": {"A": 1}}`
+	pagerankPath := writeTestFile(t, dir, "data/processed/pagerank.json", previous)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := runCLIContext(t, ctx, dir, "rank")
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("got error %v, want a cancellation", err)
+	}
+
+	if got, err := os.ReadFile(pagerankPath); err != nil || string(got) != previous {
+		t.Errorf("pagerank.json is now %q (%v), want the previous ranking", got, err)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "data", "processed", "*.tmp")); len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+
+	// an uncancelled run replaces it
+	if err := runCLI(t, dir, "rank"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(pagerankPath); string(got) == previous {
+		t.Error("pagerank.json not replaced by a completed run")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func authorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "author [name]",
+		Short: "List an author's papers by PageRank",
+		Long: `List every paper by an author, sorted by PageRank, with the author's
+total and mean PageRank.
+
+Names are matched after normalization, so "Manning, Christopher D." and
+"christopher d. manning" are the same author. When there is no exact match,
+initials ("C. Manning"), a last name alone and small typos also match; the
+author with the most papers among the matches is shown and the others are
+listed.`,
+		Example: `  acl-ranker author "Christopher Manning"
+  acl-ranker author "C. Manning"`,
+		Args: cobra.ExactArgs(1),
+		RunE: runAuthor,
+	}
+
+	return cmd
+}
+
+func runAuthor(cmd *cobra.Command, args []string) error {
+	papersPath := filepath.Join("data", "processed", "papers.json")
+	pagerankPath := filepath.Join("data", "processed", "pagerank.json")
+
+	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+		return fmt.Errorf("papers file not found: %s\nRun 'acl-ranker parse' first", papersPath)
+	}
+	if _, err := os.Stat(pagerankPath); os.IsNotExist(err) {
+		return fmt.Errorf("PageRank file not found: %s\nRun 'acl-ranker rank' first", pagerankPath)
+	}
+
+	var papers []data.Paper
+	err := data.StreamPapers(papersPath, func(paper data.Paper) error {
+		paper.AbstractEmbedding = nil
+		paper.Embeddings = nil
+		papers = append(papers, paper)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load papers: %v", err)
+	}
+
+	result, err := graph.LoadPageRankResult(pagerankPath)
+	if err != nil {
+		return err
+	}
+	citations := make(map[string]int, len(result.Rankings))
+	for _, paper := range result.Rankings {
+		citations[paper.PaperID] = paper.Citations
+	}
+
+	index := data.BuildAuthorIndex(papers)
+	matches := index.Match(args[0])
+	if len(matches) == 0 {
+		return fmt.Errorf("no author matching %q", args[0])
+	}
+
+	key := matches[0]
+	authorPapers := make([]graph.PaperScore, 0, len(index.Papers[key]))
+	var total float64
+	for _, i := range index.Papers[key] {
+		paper := papers[i]
+		score := result.Scores[paper.ID]
+		total += score
+		authorPapers = append(authorPapers, graph.PaperScore{
+			PaperID:   paper.ID,
+			Title:     paper.Title,
+			Year:      paper.Year,
+			Score:     score,
+			Citations: citations[paper.ID],
+		})
+	}
+	sort.Slice(authorPapers, func(i, j int) bool {
+		if authorPapers[i].Score != authorPapers[j].Score {
+			return authorPapers[i].Score > authorPapers[j].Score
+		}
+		return authorPapers[i].PaperID < authorPapers[j].PaperID
+	})
+
+	fmt.Printf("\n=== %s ===\n", printer.StyleTitle(index.Names[key]))
+	if len(matches) > 1 {
+		others := make([]string, 0, 5)
+		for _, other := range matches[1:min(len(matches), 6)] {
+			others = append(others, fmt.Sprintf("%s (%d)", index.Names[other], len(index.Papers[other])))
+		}
+		fmt.Printf("Other matches: %s\n", strings.Join(others, ", "))
+	}
+	fmt.Printf("Papers: %d\n", len(authorPapers))
+	fmt.Printf("Total PageRank: %s\n", printer.Score(total))
+	fmt.Printf("Mean PageRank: %s\n", printer.Score(total/float64(len(authorPapers))))
+
+	graph.PrintTopPapers(printer, authorPapers, len(authorPapers))
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"paper-rank/internal/search"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchPapers       = 10000
+	benchEdges        = 50000
+	benchDim          = 384
+	benchSeed   int64 = 42
+)
+
+func benchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark the pipeline on a synthetic citation graph",
+		Long: `Generate a random citation graph of the requested size and time graph
+building, PageRank and search scoring (with a random query embedding, so no
+Python is needed). Prints per-stage durations and allocations; profile it
+with the global --cpuprofile and --memprofile flags.`,
+		Example: `  acl-ranker bench --papers 50000 --edges 300000
+  acl-ranker bench --papers 100000 --edges 1000000 --cpuprofile cpu.pprof --memprofile mem.pprof`,
+		RunE: runBench,
+	}
+
+	cmd.Flags().IntVar(&benchPapers, "papers", 10000, "Number of synthetic papers")
+	cmd.Flags().IntVar(&benchEdges, "edges", 50000, "Number of synthetic citations to attempt")
+	cmd.Flags().IntVar(&benchDim, "dim", 384, "Embedding dimensions")
+	cmd.Flags().Int64Var(&benchSeed, "seed", 42, "Random seed for the synthetic data")
+
+	return cmd
+}
+
+type benchStage struct {
+	name    string
+	elapsed time.Duration
+	allocMB float64
+	mallocs uint64
+}
+
+// timeStage runs fn and records its wall time and allocations.
+func timeStage(name string, fn func() error) (benchStage, error) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	err := fn()
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return benchStage{
+		name:    name,
+		elapsed: elapsed,
+		allocMB: float64(after.TotalAlloc-before.TotalAlloc) / (1024 * 1024),
+		mallocs: after.Mallocs - before.Mallocs,
+	}, err
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchPapers <= 0 || benchEdges < 0 || benchDim <= 0 {
+		return fmt.Errorf("papers and dim must be positive and edges non-negative")
+	}
+
+	var (
+		parsedData    *data.ParsedData
+		citationGraph *graph.Graph
+		result        *graph.PageRankResult
+		stages        []benchStage
+	)
+
+	stage, _ := timeStage("generate", func() error {
+		parsedData = data.GenerateSyntheticData(benchPapers, benchEdges, benchDim, benchSeed)
+		return nil
+	})
+	stages = append(stages, stage)
+
+	stage, _ = timeStage("build", func() error {
+		citationGraph = graph.BuildGraphFromData(parsedData, graph.BuildConfig{})
+		return nil
+	})
+	stages = append(stages, stage)
+
+	stage, err := timeStage("pagerank", func() error {
+		var err error
+		result, err = graph.CalculatePageRank(citationGraph, graph.PageRankConfig{
+			DampingFactor:  dampingFactor,
+			MaxIterations:  maxIterations,
+			Tolerance:      tolerance,
+			HandleDangling: true,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to calculate PageRank: %v", err)
+	}
+	stages = append(stages, stage)
+
+	stage, _ = timeStage("search scoring", func() error {
+		engine := &search.SearchEngine{
+			Papers:   parsedData.Papers,
+			PageRank: result.Scores,
+			Config:   search.DefaultSearchConfig(),
+		}
+		query := search.SearchQuery{Original: "synthetic benchmark query"}
+		engine.SearchEmbedding(query, parsedData.Papers[0].AbstractEmbedding)
+		return nil
+	})
+	stages = append(stages, stage)
+
+	fmt.Printf("\n=== Benchmark (%d papers, %d edges, %d dims) ===\n",
+		len(citationGraph.Nodes), len(citationGraph.Edges), benchDim)
+	fmt.Println("Stage          | Time         | Alloc (MB) | Allocations")
+	fmt.Println("---------------|--------------|------------|------------")
+	for _, s := range stages {
+		fmt.Printf("%-14s | %-12s | %10.1f | %d\n", s.name, s.elapsed.Round(time.Microsecond), s.allocMB, s.mallocs)
+	}
+
+	return nil
+}
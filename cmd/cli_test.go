@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// runCLI runs acl-ranker with args in dir and returns its error. Flags are
+// bound to package variables, so every flag is put back to its default
+// afterwards for the next run.
+func runCLI(t *testing.T, dir string, args ...string) error {
+	t.Helper()
+	return runCLIContext(t, context.Background(), dir, args...)
+}
+
+// runCLIContext is runCLI with ctx standing in for the Ctrl-C context.
+func runCLIContext(t *testing.T, ctx context.Context, dir string, args ...string) error {
+	t.Helper()
+	t.Chdir(dir)
+
+	root := newRootCmd()
+	root.SetArgs(args)
+	root.SilenceUsage = true
+	root.SilenceErrors = true
+	stdout := os.Stdout
+	err := root.ExecuteContext(ctx)
+	os.Stdout = stdout
+	stopProfiling()
+
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			defaults := strings.Trim(f.DefValue, "[]")
+			if defaults == "" {
+				slice.Replace(nil)
+			} else {
+				slice.Replace(strings.Split(defaults, ","))
+			}
+			return
+		}
+		f.Value.Set(f.DefValue)
+	}
+	var walk func(*cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		cmd.LocalFlags().VisitAll(reset)
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+	return err
+}
+
+// writeTestFile writes content to dir/name, creating its directories.
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"paper-rank/internal/search"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	clusterConfig = search.DefaultClusterConfig()
+	clusterOut    string
+)
+
+func clusterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Group papers into topics by k-means over their embeddings",
+		Long: `Group papers into k topic clusters by running k-means over their stored
+embeddings, comparing them by cosine similarity. Each cluster is described
+by its most central papers by PageRank and its most characteristic title
+and abstract terms.
+
+Papers without an embedding are left out of the clustering and listed under
+"excluded" in the output. The initial centroids come from --seed, so the
+same data and seed always give the same clusters.`,
+		Example: `  acl-ranker cluster --k 20 --out clusters.json
+  acl-ranker cluster --k 8 --seed 42 --max-iterations 200`,
+		Args: cobra.NoArgs,
+		RunE: runCluster,
+	}
+	cmd.Flags().IntVar(&clusterConfig.K, "k", clusterConfig.K, "Number of clusters")
+	cmd.Flags().IntVar(&clusterConfig.MaxIterations, "max-iterations", clusterConfig.MaxIterations, "Maximum k-means iterations")
+	cmd.Flags().Int64Var(&clusterConfig.Seed, "seed", clusterConfig.Seed, "Random seed for the initial centroids")
+	cmd.Flags().IntVarP(&clusterConfig.TopPapers, "top", "n", clusterConfig.TopPapers, "Top papers to keep per cluster")
+	cmd.Flags().StringVar(&clusterConfig.EmbeddingField, "embedding-field", clusterConfig.EmbeddingField, "Paper embedding to cluster, e.g. abstract, title or fulltext")
+	cmd.Flags().StringVarP(&clusterOut, "out", "o", filepath.Join("data", "processed", "clusters.json"), "Output file")
+
+	return cmd
+}
+
+func runCluster(cmd *cobra.Command, args []string) error {
+	if clusterConfig.K < 1 {
+		return fmt.Errorf("--k must be at least 1")
+	}
+	if clusterConfig.MaxIterations < 1 {
+		return fmt.Errorf("--max-iterations must be at least 1")
+	}
+	if clusterConfig.TopPapers < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+
+	papersPath := filepath.Join("data", "processed", "papers_with_embeddings.json")
+	pagerankPath := filepath.Join("data", "processed", "pagerank.json")
+	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+		return fmt.Errorf("embeddings file not found: %s\nRun 'acl-ranker embed' first", papersPath)
+	}
+	if _, err := os.Stat(pagerankPath); os.IsNotExist(err) {
+		return fmt.Errorf("PageRank file not found: %s\nRun 'acl-ranker rank' first", pagerankPath)
+	}
+
+	parsedData, err := data.LoadParsedData(papersPath)
+	if err != nil {
+		return err
+	}
+	pagerank, err := graph.LoadPageRankResult(pagerankPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Clustering %d papers into %d clusters...\n", len(parsedData.Papers), clusterConfig.K)
+	result, err := search.ClusterPapers(parsedData.Papers, pagerank.Rankings, clusterConfig)
+	if err != nil {
+		return err
+	}
+	if len(result.Excluded) > 0 {
+		fmt.Printf("Excluded %d papers without a %q embedding\n", len(result.Excluded), clusterConfig.EmbeddingField)
+	}
+
+	if err := search.SaveClusterResult(result, clusterOut); err != nil {
+		return err
+	}
+	fmt.Printf("Clusters saved to %s\n", clusterOut)
+
+	search.PrintClusters(printer, result, 5)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// configFileName is looked up in the working directory, then in data/.
+const configFileName = "acl-ranker.json"
+
+var configPath string
+
+// projectConfig holds flag defaults from the config file: "global" for the
+// persistent flags, and one section per command, each mapping a flag name
+// to its value, e.g.
+//
+//	{
+//	  "global": {"precision": 4},
+//	  "rank":   {"damping": 0.9, "intent-weight": {"method": 2}},
+//	  "search": {"max-results": 10, "embedder": "hash"}
+//	}
+type projectConfig map[string]map[string]any
+
+// findConfigFile returns --config, or the first config file found in the
+// working directory or data/, or "" when there is none.
+func findConfigFile() (string, error) {
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err != nil {
+			return "", fmt.Errorf("config file not found: %s", configPath)
+		}
+		return configPath, nil
+	}
+	for _, path := range []string{configFileName, filepath.Join("data", configFileName)} {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+func loadProjectConfig(path string) (projectConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	var config projectConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// applyProjectConfig sets every flag of cmd named in the config file's
+// "global" or cmd section to the configured value, unless it was given on
+// the command line, so flags always win over the file.
+func applyProjectConfig(cmd *cobra.Command) error {
+	path, err := findConfigFile()
+	if err != nil || path == "" {
+		return err
+	}
+	config, err := loadProjectConfig(path)
+	if err != nil {
+		return err
+	}
+
+	root := cmd.Root()
+	for section := range config {
+		if section == "global" {
+			continue
+		}
+		if found, _, err := root.Find([]string{section}); err != nil || found == root {
+			return fmt.Errorf("config file %s: unknown command %q", path, section)
+		}
+	}
+
+	for _, section := range []string{"global", cmd.Name()} {
+		values := config[section]
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			flags := cmd.Flags()
+			if section == "global" {
+				flags = root.PersistentFlags()
+			}
+			flag := flags.Lookup(name)
+			if flag == nil {
+				return fmt.Errorf("config file %s: %s has no flag --%s", path, section, name)
+			}
+			if flag.Changed {
+				continue
+			}
+			value, err := configValue(values[name])
+			if err != nil {
+				return fmt.Errorf("config file %s: %s.%s: %v", path, section, name, err)
+			}
+			// through the flag set, so the flag counts as given and
+			// satisfies MarkFlagRequired
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("config file %s: %s.%s: %v", path, section, name, err)
+			}
+		}
+	}
+
+	if verbose {
+		fmt.Printf("Using config file: %s\n", path)
+	}
+	return nil
+}
+
+// configValue renders a JSON value the way it would be typed as a flag:
+// lists as comma-separated values and objects as key=value pairs.
+func configValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		// plain digits, so integer flags parse 1000000 rather than 1e+06
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			part, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, ","), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			part, err := configValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			parts[i] = key + "=" + part
+		}
+		return strings.Join(parts, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
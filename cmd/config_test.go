@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"paper-rank/internal/graph"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRankedData writes a small papers.json and builds its graph in dir.
+func writeRankedData(t *testing.T, dir string) {
+	t.Helper()
+	writeTestFile(t, dir, "data/processed/papers.json", `{"papers": [
+		{"id": "A", "title": "A", "year": 2001, "citations": []},
+		{"id": "B", "title": "B", "year": 2002, "citations": ["A"]},
+		{"id": "C", "title": "C", "year": 2003, "citations": []}
+	], "citations": [{"from": "B", "to": "A"}]}`)
+	if err := runCLI(t, dir, "build"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigSatisfiesRequiredFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeRankedData(t, dir)
+	writeTestFile(t, dir, configFileName, `{"export": {"format": "isolated", "out": "isolated.txt"}}`)
+
+	if err := runCLI(t, dir, "export"); err != nil {
+		t.Fatalf("export with --format from the config file: %v", err)
+	}
+	exported, err := os.ReadFile(filepath.Join(dir, "isolated.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(exported), "C") {
+		t.Errorf("isolated papers %q, want C", exported)
+	}
+}
+
+func TestCommandLineOverridesConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeRankedData(t, dir)
+	writeTestFile(t, dir, configFileName, `{"rank": {"damping": 0.5, "max-iterations": 50}}`)
+	rankedWith := func() graph.PageRankConfig {
+		t.Helper()
+		result, err := graph.LoadPageRankResult(filepath.Join(dir, "data", "processed", "pagerank.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Config
+	}
+
+	if err := runCLI(t, dir, "rank"); err != nil {
+		t.Fatal(err)
+	}
+	if config := rankedWith(); config.DampingFactor != 0.5 || config.MaxIterations != 50 {
+		t.Errorf("ranked with damping %v and %d iterations, want the config's 0.5 and 50", config.DampingFactor, config.MaxIterations)
+	}
+
+	if err := runCLI(t, dir, "rank", "--damping", "0.9"); err != nil {
+		t.Fatal(err)
+	}
+	if config := rankedWith(); config.DampingFactor != 0.9 || config.MaxIterations != 50 {
+		t.Errorf("ranked with damping %v and %d iterations, want the flag's 0.9 and the config's 50", config.DampingFactor, config.MaxIterations)
+	}
+}
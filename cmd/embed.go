@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/search"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	embedBatchSize  = 64
+	embedCheckpoint = 10
+	embedScript     = search.DefaultEmbedScript
+	embedReduceDim  int
+	embedReduceBy   = search.ProjectionPCA
+	embedEmbedder   string
+	embedderURL     string
+	embedderModel   string
+)
+
+func embedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "embed",
+		Short: "Compute abstract embeddings for parsed papers",
+		Long: `Stream papers without an embedding to the Python embedding script in
+batches and write the results to papers_with_embeddings.json. Papers that
+already have an embedding there are skipped, so an interrupted run can simply
+be started again. Abstracts cut by 'parse --max-abstract-chars' are embedded
+from their full text in abstracts.json; the stored abstracts stay short.
+
+With --reduce-dim the embeddings are stored projected to fewer dimensions,
+which shrinks the file and speeds up search for a small loss in recall. The
+projection (PCA fitted to the embeddings by default) is created on the
+first run and saved to projection.json; search projects query embeddings
+with it and later runs reuse it. Delete it and papers_with_embeddings.json
+to change the size. Running with --reduce-dim on already embedded papers
+reduces them in place.
+
+--embedder http sends the batches to an OpenAI-compatible embeddings
+endpoint instead (OpenAI, vLLM, llama.cpp, Ollama, text-embeddings-inference
+and others), with the API key, if any, read from ACL_EMBEDDER_API_KEY.
+--embedder hash (or ACL_EMBEDDER=hash) skips Python and hashes the words
+of each abstract into a vector instead. The vectors carry no meaning beyond
+shared words, but they are deterministic, so the whole pipeline can be run
+and tested offline; search with the same embedder.
+
+The script reads one {"ids": [...], "texts": [...]} JSON line per batch on
+stdin and answers with one {"ids": [...], "embeddings": [[...], ...]} line.`,
+		Example: `  acl-ranker embed --batch-size 128`,
+		RunE:    runEmbed,
+	}
+
+	cmd.Flags().IntVar(&embedBatchSize, "batch-size", 64, "Papers per batch sent to the embedding script")
+	cmd.Flags().IntVar(&embedCheckpoint, "checkpoint-every", 10, "Batches between writes of the output file (0 = only at the end)")
+	cmd.Flags().StringVar(&embedScript, "script", search.DefaultEmbedScript, "Batch embedding script to run with python")
+	cmd.Flags().StringVar(&embedEmbedder, "embedder", "", "Embedder: python, http, or hash for deterministic offline embeddings (default $ACL_EMBEDDER, else python)")
+	cmd.Flags().StringVar(&embedderURL, "embedder-url", "", "Endpoint for --embedder http, e.g. http://localhost:8080/v1/embeddings (default $ACL_EMBEDDER_URL)")
+	cmd.Flags().StringVar(&embedderModel, "embedder-model", "", "Model name sent to --embedder http (default $ACL_EMBEDDER_MODEL)")
+	cmd.Flags().StringVar(&outputFormat, "format", "json", "Output format: json, jsonl or msgpack")
+	cmd.Flags().IntVar(&embedReduceDim, "reduce-dim", 0, "Store embeddings projected down to this many dimensions (0 = full size)")
+	cmd.Flags().StringVar(&embedReduceBy, "reduce-method", search.ProjectionPCA, "Projection for --reduce-dim: pca or random (instant, but loses more recall)")
+
+	return cmd
+}
+
+func runEmbed(cmd *cobra.Command, args []string) error {
+	papersPath := filepath.Join("data", "processed", "papers.json")
+	outputPath := filepath.Join("data", "processed", "papers_with_embeddings.json")
+	cachePath := filepath.Join("data", "processed", "search_engine.cache.json")
+	fullAbstractsPath := filepath.Join("data", "processed", data.FullAbstractsFile)
+
+	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file not found: %s\nRun 'acl-ranker parse' first", papersPath)
+	}
+	if embedBatchSize <= 0 {
+		return fmt.Errorf("batch-size must be positive, got: %d", embedBatchSize)
+	}
+	if embedCheckpoint < 0 {
+		return fmt.Errorf("checkpoint-every must not be negative, got: %d", embedCheckpoint)
+	}
+	if embedReduceDim < 0 {
+		return fmt.Errorf("reduce-dim must not be negative, got: %d", embedReduceDim)
+	}
+	if embedReduceBy != search.ProjectionPCA && embedReduceBy != search.ProjectionRandom {
+		return fmt.Errorf("unknown reduce-method %q (want pca or random)", embedReduceBy)
+	}
+	format, err := data.ParseFormatFor(outputFormat, (*data.ParsedData)(nil))
+	if err != nil {
+		return err
+	}
+	fullAbstracts := &data.FullAbstracts{}
+	if _, err := os.Stat(fullAbstractsPath); err == nil {
+		if fullAbstracts, err = data.LoadFullAbstracts(fullAbstractsPath); err != nil {
+			return err
+		}
+		fmt.Printf("Embedding the full text of %d truncated abstracts\n", len(fullAbstracts.Abstracts))
+	}
+
+	err = search.EmbedPapers(papersPath, outputPath, search.EmbedConfig{
+		BatchSize:       embedBatchSize,
+		CheckpointEvery: embedCheckpoint,
+		Script:          embedScript,
+		Embedder:        embedEmbedder,
+		EmbedderURL:     embedderURL,
+		EmbedderModel:   embedderModel,
+		Format:          format,
+
+		ReduceDim:      embedReduceDim,
+		ReduceMethod:   embedReduceBy,
+		ProjectionPath: filepath.Join("data", "processed", search.DefaultProjectionFile),
+		FullAbstracts:  fullAbstracts.Abstracts,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the search cache holds the old embeddings
+	if err := os.Remove(cachePath); err == nil {
+		fmt.Printf("Removed stale search cache: %s\n", cachePath)
+	}
+
+	fmt.Printf("\nEmbeddings saved to: %s\n", outputPath)
+	return nil
+}
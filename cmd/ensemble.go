@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ensembleMethod = graph.EnsembleBorda
+	ensembleOut    string
+	ensembleTop    = 20
+)
+
+func ensembleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ensemble [pagerank.json] [pagerank.json]...",
+		Short: "Combine several PageRank runs into one consensus ranking",
+		Long: `Combine PageRank results computed with different settings, e.g. damping
+factors, --intent-weight or --citation-prior, into one ranking that is more
+robust than any single run.
+
+  borda      each run gives the paper ranked r of n papers n-r points; the
+             most points rank first
+  rank-avg   the lowest mean rank ranks first
+  score-avg  the highest mean PageRank score ranks first
+
+The ranking covers every paper in any run. A paper missing from a run
+shares that run's bottom places (borda and rank-avg) or scores 0 there
+(score-avg).`,
+		Example: `  acl-ranker ensemble d85.json d90.json intent.json --out ensemble.json
+  acl-ranker ensemble d85.json d90.json --method rank-avg --top 50`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: runEnsemble,
+	}
+	cmd.Flags().StringVar(&ensembleMethod, "method", ensembleMethod, "How to combine the runs: borda, rank-avg or score-avg")
+	cmd.Flags().StringVarP(&ensembleOut, "out", "o", "", "Also save the combined ranking as JSON to this file")
+	cmd.Flags().IntVarP(&ensembleTop, "top", "n", ensembleTop, "Number of papers to show")
+
+	return cmd
+}
+
+func runEnsemble(cmd *cobra.Command, args []string) error {
+	method, err := graph.ParseEnsembleMethod(ensembleMethod)
+	if err != nil {
+		return err
+	}
+
+	runs := make([]*graph.PageRankResult, len(args))
+	for i, path := range args {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("PageRank file not found: %s", path)
+		}
+		if runs[i], err = graph.LoadPageRankResult(path); err != nil {
+			return fmt.Errorf("failed to load %s: %v", path, err)
+		}
+		fmt.Printf("Loaded %s: %d papers (damping %.2f)\n", path, len(runs[i].Scores), runs[i].Config.DampingFactor)
+	}
+
+	result := &graph.EnsembleResult{
+		Method:   method,
+		Inputs:   args,
+		Rankings: graph.Ensemble(runs, method),
+	}
+	partial := 0
+	for _, paper := range result.Rankings {
+		if paper.Runs < len(runs) {
+			partial++
+		}
+	}
+	if partial > 0 {
+		fmt.Printf("%d of %d papers are missing from some runs\n", partial, len(result.Rankings))
+	}
+
+	if ensembleOut != "" {
+		if err := data.EncodeFile(ensembleOut, result, data.FormatJSON); err != nil {
+			return fmt.Errorf("failed to write ensemble: %v", err)
+		}
+		fmt.Printf("\nEnsemble saved to %s\n", ensembleOut)
+	}
+
+	graph.PrintEnsemble(printer, result, ensembleTop)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	evolutionFrom int
+	evolutionTo   int
+	evolutionOut  string
+)
+
+func rankEvolutionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rank-evolution [paper_id]",
+		Short: "Show how a paper's PageRank rank developed year by year",
+		Long: `Rank the citation graph as it stood at the end of every year from --from to
+--to (see 'rank --as-of') and show where the paper stood in each ranking,
+so you can see when its influence rose or faded.
+
+--from defaults to the paper's publication year and --to to the latest
+publication year in the graph. Each year is one PageRank run, warm-started
+from the next year's scores.`,
+		Example: `  acl-ranker rank-evolution P18-1001
+  acl-ranker rank-evolution P18-1001 --from 2010 --to 2020 --out evolution.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRankEvolution,
+	}
+	cmd.Flags().IntVar(&evolutionFrom, "from", 0, "First year (0 = the paper's publication year)")
+	cmd.Flags().IntVar(&evolutionTo, "to", 0, "Last year (0 = the latest publication year in the graph)")
+	cmd.Flags().StringVarP(&evolutionOut, "out", "o", "", "Also save the trajectory as JSON to this file")
+
+	return cmd
+}
+
+func runRankEvolution(cmd *cobra.Command, args []string) error {
+	paperID := args[0]
+
+	graphPath := filepath.Join("data", "processed", "graph.json")
+	if _, err := os.Stat(graphPath); os.IsNotExist(err) {
+		return fmt.Errorf("graph file not found: %s\nRun 'acl-ranker build' first", graphPath)
+	}
+	citationGraph, err := graph.LoadGraphLean(graphPath)
+	if err != nil {
+		return fmt.Errorf("failed to load graph: %v", err)
+	}
+
+	from, to := evolutionFrom, evolutionTo
+	found := false
+	for _, node := range citationGraph.Nodes {
+		if node.ID == paperID {
+			found = true
+			if from == 0 {
+				from = node.Year
+			}
+		}
+		if evolutionTo == 0 {
+			to = max(to, node.Year)
+		}
+	}
+	if !found {
+		return fmt.Errorf("paper %s is not in the graph", paperID)
+	}
+	if from == 0 || to == 0 {
+		return fmt.Errorf("cannot tell the year range; pass --from and --to")
+	}
+	if from > to {
+		return fmt.Errorf("--from (%d) is after --to (%d)", from, to)
+	}
+
+	config := graph.PageRankConfig{
+		DampingFactor:  dampingFactor,
+		MaxIterations:  maxIterations,
+		Tolerance:      tolerance,
+		HandleDangling: true,
+	}
+	trajectory, err := graph.RankEvolution(cmd.Context(), citationGraph, paperID, from, to, config)
+	if err != nil {
+		return err
+	}
+
+	if evolutionOut != "" {
+		if err := data.EncodeFile(evolutionOut, trajectory, data.FormatJSON); err != nil {
+			return fmt.Errorf("failed to write trajectory: %v", err)
+		}
+		fmt.Printf("\nTrajectory saved to %s\n", evolutionOut)
+	}
+
+	graph.PrintRankTrajectory(printer, trajectory)
+	return nil
+}
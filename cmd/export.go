@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportOut    = "-"
+	exportTop    = 100
+
+	exportMinCitations int
+)
+
+func exportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export lists and reports derived from the processed data",
+		Long: `Export data derived from the processed files.
+
+Formats:
+  isolated   papers with no citations in or out (ID and title)
+  dangling   papers that cite no other paper in the graph (ID and title)
+  top        the top PageRank papers joined with their metadata; CSV when
+             --out ends in .csv, JSON otherwise
+  bibtex     every paper as a BibTeX @inproceedings entry keyed by its ACL ID
+  jsonld     every paper as a schema.org ScholarlyArticle in one JSON-LD
+             document, citations linked by DOI (or URL when there is none)
+  graphml    the citation graph as GraphML, for Gephi, yEd or networkx, with
+             PageRank when pagerank.json exists
+  dot        the citation graph in Graphviz DOT
+
+The full graph is too large to draw; --min-citations N keeps only the papers
+cited at least N times and the citations among them, with their degrees
+recomputed for that core.
+
+A surprisingly isolated seminal paper usually means its citations failed to link.`,
+		Example: `  acl-ranker export --format isolated --out isolated.txt
+  acl-ranker export --format dangling
+  acl-ranker export --format top --top 100 --out top100.json
+  acl-ranker export --format bibtex --out acl.bib
+  acl-ranker export --format jsonld --out acl.jsonld
+  acl-ranker export --format graphml --min-citations 50 --out core.graphml`,
+		RunE: runExport,
+	}
+
+	cmd.Flags().StringVar(&exportFormat, "format", "", "What to export: isolated, dangling, top, bibtex, jsonld, graphml or dot")
+	cmd.Flags().StringVar(&exportOut, "out", "-", "Output file (- for stdout)")
+	cmd.Flags().IntVar(&exportTop, "top", 100, "Number of papers for --format top")
+	cmd.Flags().IntVar(&exportMinCitations, "min-citations", 0, "For graphml and dot, only keep papers cited at least this many times (0 = all)")
+	cmd.MarkFlagRequired("format")
+
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	graphPath := filepath.Join("data", "processed", "graph.json")
+	papersPath := filepath.Join("data", "processed", "papers.json")
+	pagerankPath := filepath.Join("data", "processed", "pagerank.json")
+
+	divertDiagnostics(exportOut)
+
+	if exportMinCitations != 0 && exportFormat != "graphml" && exportFormat != "dot" {
+		return fmt.Errorf("--min-citations only applies to --format graphml and dot")
+	}
+
+	switch exportFormat {
+	case "isolated", "dangling":
+		if _, err := os.Stat(graphPath); os.IsNotExist(err) {
+			return fmt.Errorf("graph file not found: %s\nRun 'acl-ranker build' first", graphPath)
+		}
+		citationGraph, err := graph.LoadGraph(graphPath)
+		if err != nil {
+			return fmt.Errorf("failed to load graph: %v", err)
+		}
+
+		ids := citationGraph.IsolatedNodes()
+		if exportFormat == "dangling" {
+			ids = citationGraph.DanglingNodes()
+		}
+
+		return writeExport(exportOut, func(w io.Writer) error {
+			return writePaperList(w, citationGraph, ids)
+		})
+	case "top":
+		if exportTop <= 0 {
+			return fmt.Errorf("top must be positive, got: %d", exportTop)
+		}
+		if _, err := os.Stat(pagerankPath); os.IsNotExist(err) {
+			return fmt.Errorf("PageRank file not found: %s\nRun 'acl-ranker rank' first", pagerankPath)
+		}
+		result, err := graph.LoadPageRankResult(pagerankPath)
+		if err != nil {
+			return err
+		}
+
+		wanted := make(map[string]bool, exportTop)
+		for i := 0; i < len(result.Rankings) && i < exportTop; i++ {
+			wanted[result.Rankings[i].PaperID] = true
+		}
+		papers := make(map[string]data.Paper, len(wanted))
+		err = data.StreamPapers(papersPath, func(paper data.Paper) error {
+			if wanted[paper.ID] {
+				paper.AbstractEmbedding = nil
+				papers[paper.ID] = paper
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load papers: %v", err)
+		}
+
+		top, missing := graph.JoinTopPapers(result, papers, exportTop)
+		if len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d ranked papers are missing from %s: %s\n",
+				len(missing), papersPath, strings.Join(missing, ", "))
+		}
+
+		return writeExport(exportOut, func(w io.Writer) error {
+			if strings.HasSuffix(strings.ToLower(exportOut), ".csv") {
+				return writeTopPapersCSV(w, top)
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(top)
+		})
+	case "bibtex":
+		if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+			return fmt.Errorf("papers file not found: %s\nRun 'acl-ranker parse' first", papersPath)
+		}
+		return writeExport(exportOut, func(w io.Writer) error {
+			err := data.StreamPapers(papersPath, func(paper data.Paper) error {
+				return data.WriteBibTeXEntry(w, paper)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to export papers: %v", err)
+			}
+			return nil
+		})
+	case "jsonld":
+		if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+			return fmt.Errorf("papers file not found: %s\nRun 'acl-ranker parse' first", papersPath)
+		}
+		var papers []data.Paper
+		err := data.StreamPapers(papersPath, func(paper data.Paper) error {
+			paper.AbstractEmbedding = nil
+			paper.Embeddings = nil
+			papers = append(papers, paper)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load papers: %v", err)
+		}
+		return writeExport(exportOut, func(w io.Writer) error {
+			return data.WriteJSONLD(w, papers)
+		})
+	case "graphml", "dot":
+		if exportMinCitations < 0 {
+			return fmt.Errorf("min-citations must not be negative, got: %d", exportMinCitations)
+		}
+		if _, err := os.Stat(graphPath); os.IsNotExist(err) {
+			return fmt.Errorf("graph file not found: %s\nRun 'acl-ranker build' first", graphPath)
+		}
+		citationGraph, err := graph.LoadGraphLean(graphPath)
+		if err != nil {
+			return fmt.Errorf("failed to load graph: %v", err)
+		}
+		if exportMinCitations > 0 {
+			core := graph.CoreGraph(citationGraph, exportMinCitations)
+			fmt.Fprintf(os.Stderr, "Papers cited at least %d times: %d of %d, with %d of %d citations among them\n",
+				exportMinCitations, len(core.Nodes), len(citationGraph.Nodes), len(core.Edges), len(citationGraph.Edges))
+			citationGraph = core
+		}
+
+		var scores map[string]float64
+		if _, err := os.Stat(pagerankPath); err == nil {
+			result, err := graph.LoadPageRankResult(pagerankPath)
+			if err != nil {
+				return err
+			}
+			scores = result.Scores
+		}
+
+		write := graph.WriteGraphML
+		if exportFormat == "dot" {
+			write = graph.WriteDOT
+		}
+		return writeExport(exportOut, func(w io.Writer) error {
+			return write(w, citationGraph, scores)
+		})
+	default:
+		return fmt.Errorf("unknown export format %q (want isolated, dangling, top, bibtex, jsonld, graphml or dot)", exportFormat)
+	}
+}
+
+// writeExport writes the export through writeOutput, reporting where a file
+// went.
+func writeExport(outPath string, write func(w io.Writer) error) error {
+	if err := writeOutput(outPath, write); err != nil {
+		return err
+	}
+	if outPath != stdoutPath {
+		fmt.Fprintf(os.Stderr, "Exported %s to: %s\n", exportFormat, outPath)
+	}
+	return nil
+}
+
+// writePaperList writes one "id<TAB>title" line per paper.
+func writePaperList(w io.Writer, g *graph.Graph, ids []string) error {
+	titles := make(map[string]string, len(g.Nodes))
+	for _, node := range g.Nodes {
+		titles[node.ID] = node.Title
+	}
+
+	for _, id := range ids {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", id, titles[id]); err != nil {
+			return fmt.Errorf("failed to write paper list: %v", err)
+		}
+	}
+	return nil
+}
+
+func writeTopPapersCSV(w io.Writer, top []graph.TopPaper) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rank", "paper_id", "title", "authors", "year", "booktitle", "doi", "url", "pagerank", "citations", "abstract"})
+	for _, paper := range top {
+		cw.Write([]string{
+			strconv.Itoa(paper.Rank),
+			paper.PaperID,
+			paper.Title,
+			strings.Join(paper.Authors, "; "),
+			strconv.Itoa(paper.Year),
+			paper.BookTitle,
+			paper.DOI,
+			paper.URL,
+			strconv.FormatFloat(paper.Score, 'g', -1, 64),
+			strconv.Itoa(paper.Citations),
+			paper.Abstract,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportMinCitations(t *testing.T) {
+	dir := t.TempDir()
+	writeSearchData(t, dir)
+
+	// only T1 is cited, by T2
+	var err error
+	stdout, stderr := captureOutput(t, func() {
+		err = runCLI(t, dir, "export", "--format", "dot", "--min-citations", "1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Papers cited at least 1 times: 1 of 4, with 0 of 1 citations among them"; !strings.Contains(stderr, want) {
+		t.Errorf("export reported\n%s\nwithout %q", stderr, want)
+	}
+	if !strings.Contains(stdout, `"T1" [label="T1 (2015)"`) || strings.Contains(stdout, `"T2"`) || strings.Contains(stdout, "->") {
+		t.Errorf("DOT export of the papers cited at least once:\n%s", stdout)
+	}
+
+	for _, args := range [][]string{
+		{"export", "--format", "top", "--min-citations", "1"},
+		{"export", "--format", "graphml", "--min-citations", "-1"},
+	} {
+		if err := runCLI(t, dir, args...); err == nil {
+			t.Errorf("%v accepted", args)
+		}
+	}
+}
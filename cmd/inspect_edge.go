@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func inspectEdgeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect-edge [from] [to]",
+		Short: "Show which citations parquet rows produced an edge",
+		Long: `Trace the citation edge from -> to (the first paper citing the second) back
+to the rows of the citations parquet it was parsed from, with the corpus
+ids and publication years of both papers. Useful for investigating
+surprising edges, such as a paper citing a later one.
+
+Needs provenance.json, written by 'acl-ranker parse --explain-graph'.`,
+		Example: `  acl-ranker inspect-edge P18-1001 N19-1423`,
+		Args:    cobra.ExactArgs(2),
+		RunE:    runInspectEdge,
+	}
+
+	return cmd
+}
+
+func runInspectEdge(cmd *cobra.Command, args []string) error {
+	from, to := args[0], args[1]
+	provenancePath := filepath.Join("data", "processed", data.ProvenanceFile)
+
+	if _, err := os.Stat(provenancePath); os.IsNotExist(err) {
+		return fmt.Errorf("edge provenance not found: %s\nRun 'acl-ranker parse --explain-graph' first", provenancePath)
+	}
+
+	provenance, err := data.LoadProvenance(provenancePath)
+	if err != nil {
+		return err
+	}
+
+	records := provenance.Find(from, to)
+	if len(records) == 0 {
+		fmt.Printf("No recorded edge %s -> %s.\n", from, to)
+		if reverse := provenance.Find(to, from); len(reverse) > 0 {
+			fmt.Printf("The reverse edge %s -> %s exists; run 'acl-ranker inspect-edge %s %s'.\n", to, from, to, from)
+		}
+		if provenance.Dropped > 0 {
+			fmt.Printf("Note: %d edges past the provenance limit of %d were not recorded; re-parse with a higher --provenance-limit.\n",
+				provenance.Dropped, provenance.Limit)
+		}
+		return nil
+	}
+
+	fmt.Printf("Edge %s -> %s, from %s:\n", from, to, provenance.Source)
+	for _, record := range records {
+		fmt.Printf("\n  Row %d\n", record.Row)
+		fmt.Printf("    citing: %s (corpus id %d, year %d)\n", record.From, record.CitingCorpusID, record.CitingYear)
+		fmt.Printf("    cited:  %s (corpus id %d, year %d)\n", record.To, record.CitedCorpusID, record.CitedYear)
+		if record.CitingYear > 0 && record.CitedYear > record.CitingYear {
+			fmt.Printf("    Warning: the citing paper is %d years older than the paper it cites\n", record.CitedYear-record.CitingYear)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"paper-rank/internal/data"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspectEdge(t *testing.T) {
+	dir := t.TempDir()
+	if err := runCLI(t, dir, "inspect-edge", "A", "B"); err == nil || !strings.Contains(err.Error(), "--explain-graph") {
+		t.Errorf("error %v without provenance.json, want one pointing at --explain-graph", err)
+	}
+
+	processed := filepath.Join(dir, "data", "processed")
+	if err := os.MkdirAll(processed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	provenance := &data.Provenance{
+		Source:  "data/citations.parquet",
+		Limit:   2,
+		Dropped: 4,
+		Edges: []data.EdgeProvenance{
+			{From: "A", To: "B", Row: 7, CitingCorpusID: 10, CitedCorpusID: 20, CitingYear: 2001, CitedYear: 2004},
+			{From: "A", To: "B", Row: 9, CitingCorpusID: 10, CitedCorpusID: 20, CitingYear: 2001, CitedYear: 2004},
+		},
+	}
+	if err := data.SaveProvenance(provenance, filepath.Join(processed, data.ProvenanceFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		want     []string
+	}{
+		{"A", "B", []string{
+			"Edge A -> B, from data/citations.parquet:",
+			"Row 7",
+			"Row 9",
+			"citing: A (corpus id 10, year 2001)",
+			"cited:  B (corpus id 20, year 2004)",
+			"the citing paper is 3 years older",
+		}},
+		{"B", "A", []string{
+			"No recorded edge B -> A.",
+			"The reverse edge A -> B exists",
+			"4 edges past the provenance limit of 2",
+		}},
+	}
+	for _, tt := range tests {
+		var err error
+		stdout, _ := captureOutput(t, func() { err = runCLI(t, dir, "inspect-edge", tt.from, tt.to) })
+		if err != nil {
+			t.Errorf("inspect-edge %s %s: %v", tt.from, tt.to, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("inspect-edge %s %s printed\n%s\nwithout %q", tt.from, tt.to, stdout, want)
+			}
+		}
+	}
+}
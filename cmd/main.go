@@ -1,51 +1,212 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"os/signal"
 	"paper-rank/internal/data"
 	"paper-rank/internal/graph"
 	"paper-rank/internal/search"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	maxPapers int
-	outputDir string
-	verbose   bool
-
-	dampingFactor = 0.85
-	maxIterations = 100
-	tolerance     = 1e-6
-
-	pagerankWeight  = 0.3
-	relevanceWeight = 0.7
-	maxResults      = 5
+	maxPapers        int
+	keywords         []string
+	numKeywords      int
+	keywordStopwords bool
+	maxAbstractChars int
+	outputDir        string
+	verbose          bool
+	colorMode        = "auto"
+	noColor          bool
+	dryRun           bool
+	explainGraph     bool
+	provenanceLimit  = data.DefaultProvenanceLimit
+
+	outputFormat = "json"
+
+	keepSelfCitations bool
+	buildOut          = filepath.Join("data", "processed", "graph.json")
+
+	dampingFactor            = 0.85
+	maxIterations            = 100
+	tolerance                = 1e-6
+	intentWeights            map[string]string
+	perCommunity             bool
+	warmStart                bool
+	dampingSweep             []float64
+	pruneIsolated            bool
+	recentYears              int
+	showContext              bool
+	citationPrior            string
+	externalCitationWeight   float64
+	rankDirection            = graph.DirectionCited
+	authorSelfCitations      string
+	authorSelfCitationWeight float64
+	danglingWarn             = 0.5
+	asOfYear                 int
+	scoreHistogram           bool
+	histogramBins            = 20
+	rankOut                  = filepath.Join("data", "processed", "pagerank.json")
+
+	pagerankWeight    = 0.3
+	relevanceWeight   = 0.7
+	maxResults        = 5
+	minRelevance      = 0.0
+	explain           bool
+	relevanceOnly     bool
+	pagerankOnly      bool
+	noWeightNormalize bool
+	countOnly         bool
+	searchOneline     bool
+	stemming          bool
+	lowMemory         bool
+	likeIDs           []string
+	likeWeight        = search.DefaultLikeWeight
+	requireAllEmbs    bool
+	embeddingField    = data.DefaultEmbeddingField
+	similarity        = search.DefaultSimilarityMetric
+	resultTemplate    = search.DefaultTemplate
+	bibtexOut         string
+	searchJSON        string
+	searchCSV         string
+	searchOut         = stdoutPath
+
+	includeUnknownYear bool
+	maxPerAuthor       int
+	communityFilter    = -1
+
+	topicSensitive  bool
+	topicSeeds      = 50
+	topicIterations = 20
+	sortBy          = search.SortByScore
+	sortDesc        = true
+	tieThreshold    = search.DefaultTieThreshold
+	missingPageRank = search.MissingPageRankZero
+	expandGraph     bool
+	expandSeeds     = 10
+	expandSize      = 20
+	relevantIDs     []string
+	irrelevantIDs   []string
+	rocchioAlpha    float64
+	rocchioBeta     float64
+	rocchioGamma    float64
+	queryEmbedder   string
 )
 
 func main() {
+	rootCmd := newRootCmd()
+
+	// the first Ctrl-C cancels the context so long stages stop cleanly
+	// between rows or iterations; stop() then restores the default, so a
+	// second Ctrl-C kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	interrupted := ctx.Err() != nil
+	stop()
+	stopProfiling()
+	if err != nil {
+		if interrupted {
+			fmt.Fprintln(os.Stderr, "Interrupted; files written before the interrupt are intact.")
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+		os.Exit(1)
+	}
+}
+
+// newRootCmd builds the acl-ranker command with all its subcommands.
+func newRootCmd() *cobra.Command {
 	var rootCmd = &cobra.Command{
 		Use:   "acl-ranker",
 		Short: "ACL Paper Recommendation System using PageRank",
 		Long: `A CLI tool that parses ACL papers, builds citation graphs, 
-calculates PageRank scores, and provides intelligent paper search and ranking.`,
+calculates PageRank scores, and provides intelligent paper search and ranking.
+
+--cpuprofile and --memprofile profile any command on real data with pprof
+(inspect with 'go tool pprof'). The heavy ones are parse (parquet decoding),
+build, rank (especially --per-community and rank-evolution), search and
+recommend on a cold cache or with --topic-sensitive or --expand-graph,
+cluster, and embed --reduce-dim.`,
 	}
 
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().IntVar(&printer.Precision, "precision", printer.Precision, "Decimals for printed scores; tiny scores switch to scientific notation")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "Color output: auto (terminal only, honors NO_COLOR), always or never")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Same as --color never")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Flag defaults file (default ./acl-ranker.json, then data/acl-ranker.json); flags on the command line win")
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpuprofile", "", "Write a pprof CPU profile of the command to this file")
+	rootCmd.PersistentFlags().StringVar(&memProfile, "memprofile", "", "Write a pprof heap profile to this file when the command finishes")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := applyProjectConfig(cmd); err != nil {
+			return err
+		}
+		if err := startProfiling(); err != nil {
+			return err
+		}
+		if noColor {
+			colorMode = "never"
+		}
+		useColor, err := resolveColor(colorMode, os.Stdout)
+		if err != nil {
+			return err
+		}
+		printer.Color = useColor
+		return nil
+	}
 
 	rootCmd.AddCommand(parseCmd())
 	rootCmd.AddCommand(buildCmd())
 	rootCmd.AddCommand(rankCmd())
 	rootCmd.AddCommand(searchCmd())
+	rootCmd.AddCommand(serveCmd())
+	rootCmd.AddCommand(schemaCmd())
+	rootCmd.AddCommand(benchCmd())
+	rootCmd.AddCommand(exportCmd())
+	rootCmd.AddCommand(mergeCmd())
+	rootCmd.AddCommand(embedCmd())
+	rootCmd.AddCommand(validateCmd())
+	rootCmd.AddCommand(authorCmd())
+	rootCmd.AddCommand(trendingCmd())
+	rootCmd.AddCommand(recommendCmd())
+	rootCmd.AddCommand(inspectEdgeCmd())
+	rootCmd.AddCommand(clusterCmd())
+	rootCmd.AddCommand(rankEvolutionCmd())
+	rootCmd.AddCommand(timelineCmd())
+	rootCmd.AddCommand(ensembleCmd())
+	rootCmd.AddCommand(relinkCmd())
+	return rootCmd
+}
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+// exitError makes the process exit with code instead of 1.
+type exitError struct {
+	code int
+	err  error
 }
 
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
 func parseCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "parse [papers_file] [citations_file]",
@@ -58,12 +219,21 @@ func parseCmd() *cobra.Command {
 		Args: cobra.ExactArgs(2),
 		Example: `  acl-ranker parse acl_papers.parquet acl_full_citations.parquet
   acl-ranker parse acl_papers.parquet acl_full_citations.parquet --max-papers 5000
-  acl-ranker parse acl_papers.parquet acl_full_citations.parquet --output processed --verbose`,
+  acl-ranker parse acl_papers.parquet acl_full_citations.parquet --output processed --verbose
+  acl-ranker parse acl_papers.parquet acl_full_citations.parquet --dry-run`,
 		RunE: runParse,
 	}
 
 	cmd.Flags().IntVarP(&maxPapers, "max-papers", "m", 0, "Maximum number of papers to process (0 = all)")
+	cmd.Flags().StringSliceVar(&keywords, "filter-keyword", nil, "Only keep papers whose title or abstract contains one of these keywords, e.g. nlp,translation")
+	cmd.Flags().IntVar(&numKeywords, "keywords", 0, "Store this many TF-IDF keywords per paper (0 = none)")
+	cmd.Flags().BoolVar(&keywordStopwords, "keywords-keep-stopwords", false, "Let stopwords count as keywords")
+	cmd.Flags().IntVar(&maxAbstractChars, "max-abstract-chars", 0, "Truncate stored abstracts longer than this many characters, adding \"...\" (0 = no limit); the full text goes to abstracts.json, which 'embed' embeds from")
 	cmd.Flags().StringVarP(&outputDir, "output", "o", "processed", "Output directory for processed files")
+	cmd.Flags().StringVar(&outputFormat, "format", "json", "Output format: json, jsonl or msgpack")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the detected schema and a preview of parsed papers without writing anything")
+	cmd.Flags().BoolVar(&explainGraph, "explain-graph", false, "Record the citations parquet row behind each edge in provenance.json, for 'inspect-edge'")
+	cmd.Flags().IntVar(&provenanceLimit, "provenance-limit", data.DefaultProvenanceLimit, "Most edges --explain-graph records (0 = no limit; about 100 bytes each)")
 
 	return cmd
 }
@@ -75,6 +245,9 @@ func buildCmd() *cobra.Command {
 		Long:  "Build citation graph from parsed paper data and save to JSON format",
 		RunE:  runBuild,
 	}
+	cmd.Flags().BoolVar(&keepSelfCitations, "keep-self-citations", false, "Keep self-citation edges in the graph file for analysis (never used for ranking)")
+	cmd.Flags().StringVar(&outputFormat, "format", "json", "Output format: json, jsonl, msgpack or binary")
+	cmd.Flags().StringVar(&buildOut, "out", buildOut, "Graph output file (- for stdout, with progress on stderr)")
 
 	return cmd
 }
@@ -86,6 +259,27 @@ func rankCmd() *cobra.Command {
 		Long:  "Calculate PageRank scores for all papers using the citation graph",
 		RunE:  runRank,
 	}
+	cmd.Flags().Float64Var(&dampingFactor, "damping", dampingFactor, "Damping factor: the chance of following a citation rather than teleporting, between 0 and 1")
+	cmd.Flags().IntVar(&maxIterations, "max-iterations", maxIterations, "Maximum PageRank iterations")
+	cmd.Flags().Float64Var(&tolerance, "tolerance", tolerance, "Stop once no score changes by more than this between iterations")
+	cmd.Flags().StringVar(&outputFormat, "format", "json", "Output format: json, jsonl or msgpack")
+	cmd.Flags().BoolVar(&warmStart, "warm-start", false, "Start from the previous pagerank.json scores (faster after small graph changes)")
+	cmd.Flags().BoolVar(&perCommunity, "per-community", false, "Also detect citation communities and rank papers within each one")
+	cmd.Flags().StringToStringVar(&intentWeights, "intent-weight", nil, "Weight citations by intent, e.g. method=2,background=1 (needs intent data)")
+	cmd.Flags().BoolVar(&pruneIsolated, "prune-isolated", false, "Leave papers with no citations in or out out of the iteration (same ranking, less work; not with --warm-start)")
+	cmd.Flags().IntVar(&recentYears, "recent-teleport-years", 0, "Only teleport to papers from the last K years, favoring recent influential work (0 = all papers)")
+	cmd.Flags().BoolVar(&showContext, "show-context", false, "Also show the three highest-ranked papers citing each top paper")
+	cmd.Flags().StringVar(&rankOut, "out", rankOut, "PageRank output file (- for stdout, with progress on stderr)")
+	cmd.Flags().Float64Var(&danglingWarn, "min-outdegree-warn", 0.5, "Warn when more than this fraction of papers cite nothing in the graph (0 = never)")
+	cmd.Flags().Float64Var(&externalCitationWeight, "external-citation-weight", 0, "Blend this share of external citation counts (num_cited_by, log-scaled) into the final scores, 0-1")
+	cmd.Flags().StringVar(&rankDirection, "direction", rankDirection, "Which way rank flows along citations: cited (to the cited paper, so highly cited papers rank high) or citing (to the citing paper, favoring surveys)")
+	cmd.Flags().StringVar(&authorSelfCitations, "author-self-citations", "keep", "Citations between papers sharing an author: keep, downweight (see --author-self-citation-weight) or drop")
+	cmd.Flags().Float64Var(&authorSelfCitationWeight, "author-self-citation-weight", graph.DefaultAuthorSelfCitationWeight, "Weight of an author self-citation with --author-self-citations downweight, 0-1")
+	cmd.Flags().StringVar(&citationPrior, "citation-prior", "none", "Bias PageRank toward well-cited papers (with --direction citing, papers with many references): none, init (starting vector), teleport or both")
+	cmd.Flags().IntVar(&asOfYear, "as-of", 0, "Rank the graph as it stood at the end of this year, leaving out later papers and their citations (0 = all); saved to pagerank.asof-<year>.json unless --out is given")
+	cmd.Flags().BoolVar(&scoreHistogram, "score-histogram", false, "Also print a log-scale histogram of the scores with their 50th, 90th and 99th percentiles")
+	cmd.Flags().IntVar(&histogramBins, "histogram-bins", histogramBins, "Bins in the --score-histogram")
+	cmd.Flags().Float64SliceVar(&dampingSweep, "damping-sweep", nil, "Compare rankings across damping factors, e.g. 0.5,0.85,0.95 (does not save results)")
 
 	return cmd
 }
@@ -94,11 +288,92 @@ func searchCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "search [query]",
 		Short: "Search papers using PageRank-enhanced ranking",
-		Long:  "Search for papers by keywords and rank results using PageRank scores",
-		Args:  cobra.ExactArgs(1),
-		RunE:  runSearch,
+		Long: `Search for papers by keywords and rank results using PageRank scores.
+
+Each result's score is relevance-weight x relevance + pagerank-weight x
+PageRank. By default the two weights are scaled to sum to 1 and PageRank is
+the raw score, which is orders of magnitude smaller than relevance (0-1),
+so the weights are shares rather than equal influence. --no-weight-normalize
+uses the weights as given and combines relevance with PageRank normalized to
+the highest score, so both range 0-1: equal weights then mean equal
+influence, and doubling a weight doubles that part of the score.
+
+--low-memory keeps embeddings out of memory for corpora too large to hold
+them: the searched embedding field is written once to a fixed-width vector
+file next to the papers (rewritten whenever the papers file is newer) and
+memory-mapped, so only the vectors read are paged in. Loading skips the
+search cache and each query reads every vector from the mapping, so it is
+slower but far smaller.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSearch,
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "m", 5, "Maximum numbers of papers to show")
+	cmd.Flags().Float64Var(&minRelevance, "min-relevance", 0, "Minimum relevance score (0-1) a paper needs to be returned")
+	cmd.Flags().BoolVar(&explain, "explain", false, "Show how each result's combined score was computed")
+	cmd.Flags().BoolVar(&countOnly, "count-only", false, "Only print how many papers pass the filters, e.g. --min-relevance, with a score summary")
+	cmd.Flags().IntVar(&communityFilter, "community", -1, "Only return papers from this community id (needs 'rank --per-community')")
+	cmd.Flags().IntVar(&maxPerAuthor, "max-per-author", 0, "Maximum results sharing the same first author (0 = no limit)")
+	cmd.Flags().BoolVar(&includeUnknownYear, "include-unknown-year", false, "Keep papers with no known year when the query contains a year filter")
+	cmd.Flags().Float64Var(&pagerankWeight, "pagerank-weight", pagerankWeight, "Weight of PageRank in the combined score (0-1; the two weights are scaled to sum to 1)")
+	cmd.Flags().Float64Var(&relevanceWeight, "relevance-weight", relevanceWeight, "Weight of semantic relevance in the combined score (0-1; the two weights are scaled to sum to 1)")
+	cmd.Flags().BoolVar(&noWeightNormalize, "no-weight-normalize", false, "Use the weights as given instead of scaling them to sum to 1, and PageRank normalized to the highest score, so equal weights mean equal influence")
+	cmd.Flags().BoolVar(&relevanceOnly, "relevance-only", false, "Rank by semantic relevance alone (PageRank weight 0)")
+	cmd.Flags().BoolVar(&pagerankOnly, "pagerank-only", false, "Rank by PageRank alone; skips the embedding model, so it works offline")
+	cmd.Flags().BoolVar(&lowMemory, "low-memory", false, "Memory-map the embeddings from a vector file instead of loading them, for very large corpora")
+	cmd.Flags().BoolVar(&requireAllEmbs, "require-all-embeddings", false, "Fail instead of warning when some ranked papers have no embedding")
+	cmd.Flags().StringVar(&embeddingField, "embedding-field", data.DefaultEmbeddingField, "Paper embedding to search against, e.g. abstract, title or fulltext")
+	cmd.Flags().StringVar(&similarity, "similarity", search.DefaultSimilarityMetric, "Similarity metric: cosine, dot or euclidean (match your embedding model)")
+	cmd.Flags().StringVar(&resultTemplate, "template", search.DefaultTemplate, "Result layout: default, compact, ids, oneline, or a Go text/template such as '{{.ID}} {{.Title}}'")
+	cmd.Flags().BoolVar(&stemming, "stem", false, "Match query terms by word stem when highlighting snippets, so 'translating' matches 'translation'")
+	cmd.Flags().BoolVar(&searchOneline, "oneline", false, "One tab-separated line per result: id, score, year, title (same as --template oneline)")
+	cmd.Flags().StringVar(&searchJSON, "json", "", "Write the results as JSON, to --out, or to a file with --json=results.json")
+	cmd.Flags().Lookup("json").NoOptDefVal = stdoutPath
+	cmd.Flags().StringVar(&searchCSV, "csv", "", "Write the results as CSV, to stdout, or to a file with --csv=results.csv")
+	cmd.Flags().Lookup("csv").NoOptDefVal = stdoutPath
+	cmd.Flags().StringVar(&searchOut, "out", stdoutPath, "Where --template output, or a bare --json, goes (- for stdout, with progress on stderr)")
+	cmd.Flags().StringVar(&bibtexOut, "bibtex", "", "Also write the results as BibTeX entries to this file (- for stdout)")
+	cmd.Flags().BoolVar(&topicSensitive, "topic-sensitive", false, "Use a PageRank personalized to the query's most relevant papers instead of global PageRank (slower; needs graph.json)")
+	cmd.Flags().IntVar(&topicSeeds, "topic-seeds", topicSeeds, "Most relevant papers the topic-sensitive PageRank teleports to")
+	cmd.Flags().IntVar(&topicIterations, "topic-iterations", topicIterations, "Iteration cap for the topic-sensitive PageRank")
+	cmd.Flags().StringVar(&sortBy, "sort", sortBy, "Order of the returned results: score, year, citations, relevance or pagerank (the top results are always picked by score)")
+	cmd.Flags().BoolVar(&sortDesc, "sort-desc", sortDesc, "Sort highest first; --sort-desc=false for ascending, e.g. oldest first with --sort year")
+	cmd.Flags().Float64Var(&tieThreshold, "tie-threshold", tieThreshold, "Score gap below which neighboring results are flagged as tied (shown with --explain)")
+	cmd.Flags().StringVar(&missingPageRank, "missing-pagerank", missingPageRank, "Score for papers without a PageRank score: zero, min, mean or skip (leave them out)")
+	cmd.Flags().BoolVar(&expandGraph, "expand-graph", false, "Also surface papers that cite, are cited by, or are co-cited with the top hits (needs graph.json)")
+	cmd.Flags().IntVar(&expandSeeds, "expand-seeds", expandSeeds, "Top hits --expand-graph expands from")
+	cmd.Flags().IntVar(&expandSize, "expand-size", expandSize, "Most papers --expand-graph pulls in")
+	cmd.Flags().StringVar(&queryEmbedder, "embedder", "", "Query embedder: python, http, or hash to match 'embed --embedder hash' (default $ACL_EMBEDDER, else python)")
+	cmd.Flags().StringVar(&embedderURL, "embedder-url", "", "Endpoint for --embedder http (default $ACL_EMBEDDER_URL)")
+	cmd.Flags().StringVar(&embedderModel, "embedder-model", "", "Model name sent to --embedder http (default $ACL_EMBEDDER_MODEL)")
+	cmd.MarkFlagsMutuallyExclusive("relevance-only", "pagerank-only", "topic-sensitive")
+	cmd.Flags().StringSliceVar(&likeIDs, "like", nil, "Example paper ids to search like, together with the query, e.g. P18-1001,N19-1423")
+	cmd.Flags().Float64Var(&likeWeight, "like-weight", likeWeight, "Share of the --like papers in the blended query embedding, 0-1")
+	cmd.Flags().StringSliceVar(&relevantIDs, "relevant", nil, "Paper ids from earlier results to search more like, e.g. P18-1001,N19-1423 (relevance feedback)")
+	cmd.Flags().StringSliceVar(&irrelevantIDs, "irrelevant", nil, "Paper ids from earlier results to search less like")
+	cmd.Flags().Float64Var(&rocchioAlpha, "rocchio-alpha", search.DefaultRocchioAlpha, "Weight of the original query in relevance feedback")
+	cmd.Flags().Float64Var(&rocchioBeta, "rocchio-beta", search.DefaultRocchioBeta, "Weight of the mean --relevant embedding in relevance feedback")
+	cmd.Flags().Float64Var(&rocchioGamma, "rocchio-gamma", search.DefaultRocchioGamma, "Weight of the mean --irrelevant embedding subtracted in relevance feedback")
+	cmd.MarkFlagsMutuallyExclusive("count-only", "explain")
+	cmd.MarkFlagsMutuallyExclusive("oneline", "template")
+	cmd.MarkFlagsMutuallyExclusive("pagerank-only", "expand-graph")
+	cmd.MarkFlagsMutuallyExclusive("pagerank-only", "like")
+	cmd.MarkFlagsMutuallyExclusive("pagerank-only", "relevant")
+	cmd.MarkFlagsMutuallyExclusive("pagerank-only", "irrelevant")
+
+	return cmd
+}
+
+func schemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema [file.parquet]",
+		Short: "Show the schema of a parquet file",
+		Long: `Dump the schema of a parquet file from the data folder: each column's name,
+arrow type, null count and a couple of sample values. Useful when adapting
+the parser to a new data source. The file is only read.`,
+		Args:    cobra.ExactArgs(1),
+		Example: `  acl-ranker schema acl_papers.parquet`,
+		RunE:    runSchema,
+	}
 
 	return cmd
 }
@@ -108,6 +383,11 @@ func runParse(cmd *cobra.Command, args []string) error {
 	papersPath := filepath.Join("data", args[0])
 	citationsPath := filepath.Join("data", args[1])
 
+	format, err := data.ParseFormatFor(outputFormat, (*data.ParsedData)(nil))
+	if err != nil {
+		return err
+	}
+
 	// Check if input files exist
 	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
 		return fmt.Errorf("papers file not found: %s", papersPath)
@@ -117,6 +397,16 @@ func runParse(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("citations file not found: %s", citationsPath)
 	}
 
+	if dryRun {
+		preview, err := data.PreviewPapersParquet(papersPath, 3)
+		if err != nil {
+			return fmt.Errorf("failed to preview papers file: %v", err)
+		}
+		data.PrintParquetPreview(preview)
+		fmt.Println("\nDry run: nothing was written.")
+		return nil
+	}
+
 	// Create output directory
 	outputPath := filepath.Join("data", outputDir)
 	if err := os.MkdirAll(outputPath, 0755); err != nil {
@@ -125,8 +415,13 @@ func runParse(cmd *cobra.Command, args []string) error {
 	outputFile := filepath.Join(outputPath, "papers.json")
 
 	if verbose {
-		fmt.Printf("Papers file: %s\n", papersPath)
-		fmt.Printf("Citations file: %s\n", citationsPath)
+		for _, input := range []struct{ label, path string }{{"Papers", papersPath}, {"Citations", citationsPath}} {
+			note := ""
+			if gzipped, err := data.IsGzipped(input.path); err == nil && gzipped {
+				note = " (gzipped; decompressed to a temporary file first)"
+			}
+			fmt.Printf("%s file: %s%s\n", input.label, input.path, note)
+		}
 		fmt.Printf("Output file: %s\n", outputFile)
 		if maxPapers > 0 {
 			fmt.Printf("Max papers: %d\n", maxPapers)
@@ -137,18 +432,80 @@ func runParse(cmd *cobra.Command, args []string) error {
 	}
 
 	// run parse data
-	parsedData, err := data.ParseACLData(papersPath, citationsPath, maxPapers)
+	if provenanceLimit < 0 {
+		return fmt.Errorf("provenance-limit must not be negative, got: %d", provenanceLimit)
+	}
+	parsedData, err := data.ParseACLDataWithOptions(cmd.Context(), papersPath, citationsPath, data.ParseOptions{
+		MaxPapers:       maxPapers,
+		Provenance:      explainGraph,
+		ProvenanceLimit: provenanceLimit,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to parse ACL data: %v", err)
 	}
 
-	if err := data.SaveParsedData(parsedData, outputFile); err != nil {
+	logMemStats("parse")
+
+	if len(keywords) > 0 {
+		total := len(parsedData.Papers)
+		parsedData = data.FilterByKeywords(parsedData, keywords)
+		fmt.Printf("Keyword filter kept %d of %d papers (%d citations among them)\n",
+			len(parsedData.Papers), total, len(parsedData.Citations))
+	}
+
+	if numKeywords > 0 {
+		data.AddKeywords(parsedData, numKeywords, keywordStopwords)
+		fmt.Printf("Extracted up to %d keywords per paper\n", numKeywords)
+	}
+
+	// after keyword extraction, which sees the full abstracts
+	fullAbstracts := data.TruncateAbstracts(parsedData, maxAbstractChars)
+	if maxAbstractChars > 0 {
+		fmt.Printf("Truncated %d abstracts to %d characters\n", len(fullAbstracts.Abstracts), maxAbstractChars)
+	}
+
+	if err := data.SaveParsedData(parsedData, outputFile, format); err != nil {
 		return fmt.Errorf("failed to save parsed data: %v", err)
 	}
 
+	// lets 'relink' add citations later without the papers parquet
+	corpusMapPath := filepath.Join(outputPath, data.CorpusMapFile)
+	if err := data.SaveCorpusMap(data.NewCorpusMap(parsedData.Papers), corpusMapPath); err != nil {
+		return fmt.Errorf("failed to save corpus id map: %v", err)
+	}
+
+	// embeddings are computed from the full text of truncated abstracts
+	fullAbstractsPath := filepath.Join(outputPath, data.FullAbstractsFile)
+	if len(fullAbstracts.Abstracts) > 0 {
+		if err := data.SaveFullAbstracts(fullAbstracts, fullAbstractsPath); err != nil {
+			return fmt.Errorf("failed to save full abstracts: %v", err)
+		}
+		fmt.Printf("Full text of truncated abstracts saved to: %s\n", fullAbstractsPath)
+	} else if err := os.Remove(fullAbstractsPath); err == nil {
+		// it described an earlier parse
+		fmt.Printf("Removed stale full abstracts: %s\n", fullAbstractsPath)
+	}
+
+	provenancePath := filepath.Join(outputPath, data.ProvenanceFile)
+	if parsedData.Provenance != nil {
+		if err := data.SaveProvenance(parsedData.Provenance, provenancePath); err != nil {
+			return fmt.Errorf("failed to save edge provenance: %v", err)
+		}
+	} else if err := os.Remove(provenancePath); err == nil {
+		// it described an earlier parse
+		fmt.Printf("Removed stale edge provenance: %s\n", provenancePath)
+	}
+
 	fmt.Println("\nParse completed successfully!")
 	data.PrintParsingStats(parsedData.Stats)
 	fmt.Printf("\nOutput saved to: %s\n", outputFile)
+	if parsedData.Provenance != nil {
+		fmt.Printf("Edge provenance saved to: %s (%d edges", provenancePath, len(parsedData.Provenance.Edges))
+		if parsedData.Provenance.Dropped > 0 {
+			fmt.Printf(", %d more past --provenance-limit", parsedData.Provenance.Dropped)
+		}
+		fmt.Println(")")
+	}
 
 	if stat, err := os.Stat(outputFile); err == nil {
 		fmt.Printf("Output file size: %.2f MB\n", float64(stat.Size())/(1024*1024))
@@ -157,15 +514,37 @@ func runParse(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runSchema(cmd *cobra.Command, args []string) error {
+	parquetPath := filepath.Join("data", args[0])
+
+	if _, err := os.Stat(parquetPath); os.IsNotExist(err) {
+		return fmt.Errorf("parquet file not found: %s", parquetPath)
+	}
+
+	columns, numRows, err := data.InspectParquetSchema(parquetPath, 2)
+	if err != nil {
+		return fmt.Errorf("failed to inspect parquet schema: %v", err)
+	}
+
+	data.PrintParquetSchema(parquetPath, columns, numRows)
+	return nil
+}
+
 func runBuild(cmd *cobra.Command, args []string) error {
 	// Default paths
 	inputPath := filepath.Join("data", "processed", "papers.json")
-	outputPath := filepath.Join("data", "processed", "graph.json")
+	outputPath := buildOut
+	divertDiagnostics(outputPath)
 
 	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
 		return fmt.Errorf("input file not found: %s\nRun 'acl-ranker parse' first to create parsed data", inputPath)
 	}
 
+	format, err := data.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
 	if verbose {
 		fmt.Printf("Input file: %s\n", inputPath)
 		fmt.Printf("Output file: %s\n", outputPath)
@@ -173,21 +552,38 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build the graph
-	citationGraph, err := graph.BuildGraph(inputPath)
+	citationGraph, err := graph.BuildGraph(inputPath, graph.BuildConfig{
+		KeepSelfCitations: keepSelfCitations,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to build graph: %v", err)
 	}
 
-	if err := graph.SaveGraph(citationGraph, outputPath); err != nil {
+	logMemStats("build")
+
+	if outputPath == stdoutPath {
+		err = writeOutput(outputPath, func(w io.Writer) error {
+			return data.Encode(w, citationGraph, format)
+		})
+	} else {
+		err = graph.SaveGraph(citationGraph, outputPath, format)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to save graph: %v", err)
 	}
 
 	fmt.Println("\nGraph build completed successfully!")
 	graph.PrintGraphStats(citationGraph.Stats)
-	fmt.Printf("\nGraph saved to: %s\n", outputPath)
+	if keepSelfCitations {
+		fmt.Printf("Self-citation rate: %.2f%% (%d edges kept for analysis)\n",
+			citationGraph.SelfCitationRate()*100, len(citationGraph.SelfCitationEdges))
+	}
 
-	if stat, err := os.Stat(outputPath); err == nil {
-		fmt.Printf("Graph file size: %.2f MB\n", float64(stat.Size())/(1024*1024))
+	if outputPath != stdoutPath {
+		fmt.Printf("\nGraph saved to: %s\n", outputPath)
+		if stat, err := os.Stat(outputPath); err == nil {
+			fmt.Printf("Graph file size: %.2f MB\n", float64(stat.Size())/(1024*1024))
+		}
 	}
 
 	fmt.Println("\nTop 5 Most Cited Papers:")
@@ -202,7 +598,18 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 func runRank(cmd *cobra.Command, args []string) error {
 	inputPath := filepath.Join("data", "processed", "graph.json")
-	outputPath := filepath.Join("data", "processed", "pagerank.json")
+	outputPath := rankOut
+	if asOfYear > 0 && !cmd.Flags().Changed("out") {
+		// a past ranking must not replace the live one search reads
+		outputPath = filepath.Join("data", "processed", fmt.Sprintf("pagerank.asof-%d.json", asOfYear))
+	}
+	divertDiagnostics(outputPath)
+
+	// previous results for --warm-start; the default file when writing to stdout
+	prevPath := outputPath
+	if outputPath == stdoutPath {
+		prevPath = filepath.Join("data", "processed", "pagerank.json")
+	}
 
 	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
 		return fmt.Errorf("input file not found: %s\nRun 'acl-ranker build' first to create graph", inputPath)
@@ -217,6 +624,49 @@ func runRank(cmd *cobra.Command, args []string) error {
 	if tolerance <= 0 {
 		return fmt.Errorf("tolerance must be positive, got: %.2e", tolerance)
 	}
+	if danglingWarn < 0 || danglingWarn > 1 {
+		return fmt.Errorf("min-outdegree-warn must be between 0 and 1, got: %.3f", danglingWarn)
+	}
+	if externalCitationWeight < 0 || externalCitationWeight > 1 {
+		return fmt.Errorf("external-citation-weight must be between 0 and 1, got: %.3f", externalCitationWeight)
+	}
+	if recentYears < 0 {
+		return fmt.Errorf("recent-teleport-years must not be negative, got: %d", recentYears)
+	}
+	if asOfYear < 0 {
+		return fmt.Errorf("as-of must not be negative, got: %d", asOfYear)
+	}
+	if histogramBins < 1 {
+		return fmt.Errorf("histogram-bins must be at least 1, got: %d", histogramBins)
+	}
+	if pruneIsolated && warmStart {
+		// a warm start runs on the whole graph from the previous scores
+		return fmt.Errorf("--prune-isolated cannot be used with --warm-start")
+	}
+	format, err := data.ParseFormatFor(outputFormat, (*graph.PageRankResult)(nil))
+	if err != nil {
+		return err
+	}
+
+	weights, err := parseIntentWeights(intentWeights)
+	if err != nil {
+		return err
+	}
+	prior, err := graph.ParseCitationPrior(citationPrior)
+	if err != nil {
+		return err
+	}
+	direction, err := graph.ParseDirection(rankDirection)
+	if err != nil {
+		return err
+	}
+	selfCitationPolicy, err := graph.ParseAuthorSelfCitations(authorSelfCitations)
+	if err != nil {
+		return err
+	}
+	if authorSelfCitationWeight < 0 || authorSelfCitationWeight > 1 {
+		return fmt.Errorf("author-self-citation-weight must be between 0 and 1, got: %.3f", authorSelfCitationWeight)
+	}
 
 	if verbose {
 		fmt.Printf("Input file: %s\n", inputPath)
@@ -227,48 +677,186 @@ func runRank(cmd *cobra.Command, args []string) error {
 		fmt.Println("Starting PageRank calculation...")
 	}
 
-	citationGraph, err := graph.LoadGraph(inputPath)
+	citationGraph, err := graph.LoadGraphLean(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to load graph: %v", err)
 	}
+	graph.WarnRecentTeleportWindow(citationGraph, graph.PageRankConfig{
+		RecentTeleportYears: recentYears,
+		AsOfYear:            asOfYear,
+	})
+	if asOfYear > 0 {
+		full := citationGraph
+		var report graph.AsOfReport
+		citationGraph, report = graph.AsOf(full, asOfYear)
+		graph.PrintAsOfReport(report, full)
+		if len(citationGraph.Nodes) == 0 {
+			return fmt.Errorf("no papers were published by %d", asOfYear)
+		}
+	}
 
 	config := graph.PageRankConfig{
 		DampingFactor:  dampingFactor,
 		MaxIterations:  maxIterations,
 		Tolerance:      tolerance,
 		HandleDangling: true,
+		IntentWeights:  weights,
+
+		RecentTeleportYears: recentYears,
+		CitationPrior:       prior,
+
+		ExternalCitationWeight: externalCitationWeight,
+		Direction:              direction,
+
+		AuthorSelfCitations:      selfCitationPolicy,
+		AuthorSelfCitationWeight: authorSelfCitationWeight,
+		AsOfYear:                 asOfYear,
 	}
 
-	result, err := graph.CalculatePageRank(citationGraph, config)
+	if len(dampingSweep) > 0 {
+		if len(dampingSweep) < 2 {
+			return fmt.Errorf("--damping-sweep needs at least two damping factors")
+		}
+		runs, comparisons, err := graph.DampingSweep(cmd.Context(), citationGraph, config, dampingSweep, 20)
+		if err != nil {
+			return fmt.Errorf("damping sweep failed: %v", err)
+		}
+		graph.PrintDampingSweep(runs, comparisons, 20)
+		return nil
+	}
+
+	var result *graph.PageRankResult
+	var prev *graph.PageRankResult
+	if warmStart {
+		if prev, err = graph.LoadPageRankResult(prevPath); err != nil {
+			fmt.Printf("Warning: no usable previous PageRank results (%v), running from scratch\n", err)
+		}
+	}
+
+	if prev != nil {
+		result, err = graph.UpdatePageRank(cmd.Context(), prev, citationGraph, config)
+	} else if pruneIsolated {
+		result, err = graph.CalculatePageRankPruned(cmd.Context(), citationGraph, config)
+	} else {
+		result, err = graph.CalculatePageRankContext(cmd.Context(), citationGraph, config)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to calculate PageRank: %v", err)
 	}
 
-	if err := graph.SavePageRankResult(result, outputPath); err != nil {
+	logMemStats("pagerank")
+
+	if outputPath == stdoutPath {
+		err = writeOutput(outputPath, func(w io.Writer) error {
+			return data.Encode(w, result, format)
+		})
+	} else {
+		err = graph.SavePageRankResult(result, outputPath, format)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to save PageRank results: %v", err)
 	}
 
 	fmt.Println("\nPageRank calculation completed successfully!")
-	graph.PrintPageRankStats(result.Stats, result.Config)
-	fmt.Printf("\nPageRank results saved to: %s\n", outputPath)
+	graph.PrintPageRankStats(printer, result.Stats, result.Config)
+	graph.WarnDangling(result.Stats, result.Config, danglingWarn)
+
+	if outputPath != stdoutPath {
+		fmt.Printf("\nPageRank results saved to: %s\n", outputPath)
+		if stat, err := os.Stat(outputPath); err == nil {
+			fmt.Printf("PageRank file size: %.2f MB\n", float64(stat.Size())/(1024*1024))
+		}
+	}
+
+	graph.PrintTopPapers(printer, result.Rankings, 10)
+	if showContext {
+		graph.PrintInfluenceChains(printer, citationGraph, result, 10, 3)
+	}
 
-	if stat, err := os.Stat(outputPath); err == nil {
-		fmt.Printf("PageRank file size: %.2f MB\n", float64(stat.Size())/(1024*1024))
+	graph.CompareWithCitations(printer, result.Rankings, 5)
+	if scoreHistogram {
+		graph.PrintScoreHistogram(printer, graph.ScoreHistogram(result.Rankings, histogramBins), 50)
 	}
 
-	graph.PrintTopPapers(result.Rankings, 10)
+	if perCommunity {
+		communitiesPath := filepath.Join("data", "processed", "communities.json")
 
-	graph.CompareWithCitations(result.Rankings, 5)
+		fmt.Println("\nDetecting communities...")
+		communities := graph.DetectCommunities(citationGraph)
+
+		communityResult, err := graph.RankCommunities(citationGraph, communities, config, 2, 10)
+		if err != nil {
+			return fmt.Errorf("failed to rank communities: %v", err)
+		}
+
+		if err := graph.SaveCommunityResult(communityResult, communitiesPath); err != nil {
+			return fmt.Errorf("failed to save communities: %v", err)
+		}
+
+		graph.PrintCommunityRankings(printer, communityResult, 10, 3)
+		fmt.Printf("\nCommunity rankings saved to: %s\n", communitiesPath)
+	}
 
 	return nil
 }
 
+func parseIntentWeights(raw map[string]string) (map[string]float64, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	weights := make(map[string]float64, len(raw))
+	for intent, value := range raw {
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("invalid weight for intent %q: %s", intent, value)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("intent weight must not be negative, got %s=%.3f", intent, weight)
+		}
+		weights[strings.ToLower(intent)] = weight
+	}
+	return weights, nil
+}
+
+// ensureVectorFile returns the vector file of the papers' embedding field
+// for 'search --low-memory', writing it first when it is missing, older
+// than the papers file, or does not match its own header.
+func ensureVectorFile(papersPath, field string) (string, error) {
+	if field == "" {
+		field = data.DefaultEmbeddingField
+	}
+	vectorsPath := filepath.Join(filepath.Dir(papersPath), fmt.Sprintf("embeddings.%s.vec", field))
+
+	papersInfo, err := os.Stat(papersPath)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(vectorsPath); err == nil && !info.ModTime().Before(papersInfo.ModTime()) {
+		vectors, err := data.OpenVectorFile(vectorsPath)
+		if err == nil {
+			vectors.Close()
+			return vectorsPath, nil
+		}
+		fmt.Printf("Warning: %v; rewriting it\n", err)
+	}
+
+	fmt.Printf("Writing %s embeddings to vector file: %s\n", field, vectorsPath)
+	n, err := data.WriteVectorFile(papersPath, vectorsPath, field)
+	if err != nil {
+		return "", fmt.Errorf("failed to write vector file: %v", err)
+	}
+	fmt.Printf("Wrote %d embeddings\n", n)
+	return vectorsPath, nil
+}
+
 func runSearch(cmd *cobra.Command, args []string) error {
 	query := args[0]
 
 	papersPath := filepath.Join("data", "processed", "papers_with_embeddings.json")
 	pagerankPath := filepath.Join("data", "processed", "pagerank.json")
 	cachePath := filepath.Join("data", "processed", "search_engine.cache.json")
+	communitiesPath := filepath.Join("data", "processed", "communities.json")
 
 	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
 		return fmt.Errorf("papers file with embeddings not found: %s\nPlease run the Python 'create_embeddings.py' script first", papersPath)
@@ -277,18 +865,92 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("PageRank file not found: %s\nRun 'acl-ranker rank' first", pagerankPath)
 	}
 
-	if pagerankWeight < 0 || pagerankWeight > 1 {
-		return fmt.Errorf("pagerank-weight must be between 0 and 1, got: %.3f", pagerankWeight)
-	}
-	if relevanceWeight < 0 || relevanceWeight > 1 {
-		return fmt.Errorf("relevance-weight must be between 0 and 1, got: %.3f", relevanceWeight)
+	if noWeightNormalize {
+		if pagerankWeight < 0 || relevanceWeight < 0 {
+			return fmt.Errorf("pagerank-weight and relevance-weight must not be negative")
+		}
+	} else {
+		if pagerankWeight < 0 || pagerankWeight > 1 {
+			return fmt.Errorf("pagerank-weight must be between 0 and 1, got: %.3f", pagerankWeight)
+		}
+		if relevanceWeight < 0 || relevanceWeight > 1 {
+			return fmt.Errorf("relevance-weight must be between 0 and 1, got: %.3f", relevanceWeight)
+		}
 	}
 	if maxResults <= 0 {
 		return fmt.Errorf("max-results must be positive, got: %d", maxResults)
 	}
+	if maxPerAuthor < 0 {
+		return fmt.Errorf("max-per-author must not be negative, got: %d", maxPerAuthor)
+	}
+	if minRelevance < 0 || minRelevance > 1 {
+		return fmt.Errorf("min-relevance must be between 0 and 1, got: %.3f", minRelevance)
+	}
+
+	sortKey, err := search.ParseSortKey(sortBy)
+	if err != nil {
+		return err
+	}
+	embedderName, err := search.ResolveEmbedderName(queryEmbedder)
+	if err != nil {
+		return err
+	}
+	missingPolicy, err := search.ParseMissingPageRankPolicy(missingPageRank)
+	if err != nil {
+		return err
+	}
+	if tieThreshold < 0 {
+		return fmt.Errorf("tie-threshold must not be negative, got: %g", tieThreshold)
+	}
+	if expandSeeds <= 0 || expandSize <= 0 {
+		return fmt.Errorf("expand-seeds and expand-size must be positive")
+	}
+	if topicSeeds <= 0 || topicIterations <= 0 {
+		return fmt.Errorf("topic-seeds and topic-iterations must be positive")
+	}
+
+	if likeWeight < 0 || likeWeight > 1 {
+		return fmt.Errorf("like-weight must be between 0 and 1, got: %.3f", likeWeight)
+	}
+	if rocchioAlpha < 0 || rocchioBeta < 0 || rocchioGamma < 0 {
+		return fmt.Errorf("rocchio-alpha, rocchio-beta and rocchio-gamma must not be negative")
+	}
+
+	if pagerankOnly && minRelevance > 0 {
+		return fmt.Errorf("--min-relevance has no effect with --pagerank-only")
+	}
+
+	if searchOneline {
+		resultTemplate = search.OnelineTemplate
+	}
+	tmpl, err := search.ParseResultTemplate(resultTemplate, printer)
+	if err != nil {
+		return err
+	}
+	if searchOut != stdoutPath && searchJSON == "" && tmpl == nil {
+		return fmt.Errorf("--out needs --json or --template")
+	}
+	sinks, err := searchSinks(searchJSON, searchCSV, bibtexOut, tmpl, searchOut)
+	if err != nil {
+		return err
+	}
+	if countOnly && len(sinks) > 0 {
+		return fmt.Errorf("--count-only prints no results, so --json, --csv, --bibtex and --template do not apply")
+	}
+	if writesStdout(sinks) {
+		divertDiagnostics(stdoutPath)
+	}
 
 	totalWeight := pagerankWeight + relevanceWeight
-	if totalWeight <= 0 {
+	if relevanceOnly {
+		relevanceWeight, pagerankWeight = 1, 0
+	} else if pagerankOnly {
+		relevanceWeight, pagerankWeight = 0, 1
+	} else if noWeightNormalize {
+		if totalWeight <= 0 {
+			return fmt.Errorf("pagerank-weight and relevance-weight are both 0")
+		}
+	} else if totalWeight <= 0 {
 
 		fmt.Println("Warning: Weights sum to zero. Using defaults (Relevance: 0.8, PageRank: 0.2)")
 		relevanceWeight = 0.8
@@ -306,35 +968,159 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		fmt.Printf("PageRank weight: %.3f\n", pagerankWeight)
 		fmt.Printf("Relevance weight: %.3f\n", relevanceWeight)
 		fmt.Printf("Max results: %d\n", maxResults)
+		fmt.Printf("Min relevance: %.3f\n", minRelevance)
 		fmt.Println("Initializing search engine...")
 	}
 
 	config := search.SearchConfig{
 		PageRankWeight:  pagerankWeight,
 		RelevanceWeight: relevanceWeight,
+		AbsoluteWeights: noWeightNormalize,
 		MaxResults:      maxResults,
 		SnippetLength:   250,
+		MinRelevance:    minRelevance,
+
+		IncludeUnknownYear: includeUnknownYear,
+		MaxPerAuthor:       maxPerAuthor,
+		Community:          communityFilter,
+
+		RequireAllEmbeddings: requireAllEmbs,
+		EmbeddingField:       embeddingField,
+		SimilarityMetric:     similarity,
+
+		TopicSensitive:  topicSensitive,
+		TopicSeeds:      topicSeeds,
+		TopicIterations: topicIterations,
+		SortBy:          sortKey,
+		SortDescending:  sortDesc,
+		TieThreshold:    tieThreshold,
+		MissingPageRank: missingPolicy,
+		ExpandGraph:     expandGraph,
+		ExpandSeeds:     expandSeeds,
+		ExpandSize:      expandSize,
+		Embedder:        embedderName,
+		EmbedderURL:     embedderURL,
+		EmbedderModel:   embedderModel,
+		Like:            likeIDs,
+		LikeWeight:      likeWeight,
+		Relevant:        relevantIDs,
+		Irrelevant:      irrelevantIDs,
+		RocchioAlpha:    rocchioAlpha,
+		RocchioBeta:     rocchioBeta,
+		RocchioGamma:    rocchioGamma,
+		CountOnly:       countOnly,
+		Stemming:        stemming,
+	}
+	if lowMemory {
+		if config.VectorsPath, err = ensureVectorFile(papersPath, embeddingField); err != nil {
+			return err
+		}
 	}
 
 	engine, err := search.GetOrCreateEngine(papersPath, pagerankPath, cachePath, config)
 	if err != nil {
 		return fmt.Errorf("failed to create search engine: %v", err)
 	}
+	logMemStats("engine load")
+
+	if _, err := os.Stat(communitiesPath); err == nil {
+		communities, err := graph.LoadCommunityResult(communitiesPath)
+		if err != nil {
+			return fmt.Errorf("failed to load communities: %v", err)
+		}
+		engine.AttachCommunities(communities)
+	} else if communityFilter >= 0 {
+		return fmt.Errorf("communities file not found: %s\nRun 'acl-ranker rank --per-community' first", communitiesPath)
+	}
+
+	projectionPath := filepath.Join("data", "processed", search.DefaultProjectionFile)
+	if _, err := os.Stat(projectionPath); err == nil {
+		if engine.Projection, err = search.LoadProjection(projectionPath); err != nil {
+			return err
+		}
+	}
+
+	if topicSensitive || expandGraph {
+		graphPath := filepath.Join("data", "processed", "graph.json")
+		if _, err := os.Stat(graphPath); os.IsNotExist(err) {
+			return fmt.Errorf("graph file not found: %s\nRun 'acl-ranker build' first", graphPath)
+		}
+		// expansion walks the adjacency list, which the lean load skips
+		load := graph.LoadGraphLean
+		if expandGraph {
+			load = graph.LoadGraph
+		}
+		citationGraph, err := load(graphPath)
+		if err != nil {
+			return fmt.Errorf("failed to load graph: %v", err)
+		}
+		engine.AttachGraph(citationGraph)
+	}
 
 	results, err := engine.Search(query)
 	if err != nil {
 		return fmt.Errorf("search failed: %v", err)
 	}
+	if countOnly {
+		search.PrintResultSummary(search.SummarizeResults(results), query)
+		return nil
+	}
 
 	if len(results) == 0 {
 		fmt.Printf("\nNo results found for: \"%s\"\n", query)
 		fmt.Println("Try using different or broader terms.")
+		if minRelevance > 0 {
+			fmt.Printf("All candidates scored below the minimum relevance of %.3f; try lowering --min-relevance.\n", minRelevance)
+		}
+		if len(sinks) == 0 {
+			return nil
+		}
+		results = []search.SearchResult{}
+	}
+
+	if err := writeSinks(sinks, results); err != nil {
+		return err
+	}
+	if writesStdout(sinks) {
 		return nil
 	}
 
-	search.PrintSearchResults(results, query)
-	fmt.Printf("\nSearch completed with %.2f%% relevance + %.2f%% PageRank weighting\n",
-		relevanceWeight*100, pagerankWeight*100)
+	search.PrintSearchResults(printer, results, query, explain, stemming)
+	if explain {
+		coverage := search.SnippetCoverage([]search.QueryRun{{Query: query, Results: results, Stemming: stemming}})
+		fmt.Printf("Snippets containing a query term: %.0f%%\n", coverage*100)
+		if tied := search.TiedResults(results); tied > 0 {
+			fmt.Printf("Results tied with a neighbor (score gap < %g): %d; their relative order is arbitrary\n", tieThreshold, tied)
+		}
+	}
+	if noWeightNormalize {
+		fmt.Printf("\nSearch completed with %.3f x relevance + %.3f x normalized PageRank\n",
+			relevanceWeight, pagerankWeight)
+	} else {
+		fmt.Printf("\nSearch completed with %.2f%% relevance + %.2f%% PageRank weighting\n",
+			relevanceWeight*100, pagerankWeight*100)
+	}
 
 	return nil
 }
+
+// logMemStats prints heap usage after a pipeline stage in verbose mode, to
+// help users size their machine and pick --max-papers. It collects garbage
+// first, so the live heap is what the stage kept, e.g. the loaded engine.
+// Go does not record a true peak; the heap reserved from the OS only grows,
+// so it is reported as an upper bound on the peak so far.
+func logMemStats(stage string) {
+	if !verbose {
+		return
+	}
+
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	fmt.Printf("[mem] after %s: live heap %.1f MB, peak heap at most %.1f MB (reserved from the OS), total alloc %.1f MB, GC cycles %d\n",
+		stage,
+		float64(m.HeapAlloc)/(1024*1024),
+		float64(m.HeapSys)/(1024*1024),
+		float64(m.TotalAlloc)/(1024*1024),
+		m.NumGC)
+}
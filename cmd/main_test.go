@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseIntentWeights(t *testing.T) {
+	weights, err := parseIntentWeights(map[string]string{"Method": "2", "background": " 0.5 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights["method"] != 2 || weights["background"] != 0.5 {
+		t.Errorf("got %v", weights)
+	}
+
+	for _, value := range []string{"2x", "1.5.0", "", "NaN", "Inf", "-1", "0.5 weight"} {
+		if _, err := parseIntentWeights(map[string]string{"method": value}); err == nil {
+			t.Errorf("weight %q accepted", value)
+		}
+	}
+}
+
+func TestRankRejectsPruneIsolatedWithWarmStart(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "data/processed/graph.json", "{}")
+
+	err := runCLI(t, dir, "rank", "--warm-start", "--prune-isolated")
+	if err == nil || !strings.Contains(err.Error(), "--prune-isolated cannot be used with --warm-start") {
+		t.Errorf("got error %v", err)
+	}
+	if pruneIsolated || warmStart {
+		t.Error("flags not reset after the run")
+	}
+}
+
+func TestMergeResolvesPathsUnderData(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "data/processed/papers.json", `{"papers": [{"id": "A", "title": "A"}], "citations": []}`)
+	writeTestFile(t, dir, "data/other/papers.json", `{"papers": [{"id": "B", "title": "B"}], "citations": [{"from": "B", "to": "A"}]}`)
+
+	if err := runCLI(t, dir, "merge", "processed/papers.json", "other/papers.json", "--out", "processed/merged.json"); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := data.LoadParsedData(filepath.Join(dir, "data", "processed", "merged.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Papers) != 2 || len(merged.Citations) != 1 {
+		t.Errorf("merged %d papers and %d citations, want 2 and 1", len(merged.Papers), len(merged.Citations))
+	}
+}
+
+func TestRankAsOfKeepsTheLiveRanking(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "data/processed/papers.json", `{"papers": [
+		{"id": "A", "title": "A", "year": 2001, "citations": []},
+		{"id": "B", "title": "B", "year": 2002, "citations": ["A"]},
+		{"id": "C", "title": "C", "year": 2005, "citations": ["A", "B"]}
+	], "citations": [{"from": "B", "to": "A"}, {"from": "C", "to": "A"}, {"from": "C", "to": "B"}]}`)
+	if err := runCLI(t, dir, "build"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCLI(t, dir, "rank"); err != nil {
+		t.Fatal(err)
+	}
+	livePath := filepath.Join(dir, "data", "processed", "pagerank.json")
+	live, err := os.ReadFile(livePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCLI(t, dir, "rank", "--as-of", "2002"); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(livePath); string(after) != string(live) {
+		t.Error("rank --as-of overwrote pagerank.json")
+	}
+	asOf, err := graph.LoadPageRankResult(filepath.Join(dir, "data", "processed", "pagerank.asof-2002.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := asOf.Scores["C"]; found || len(asOf.Scores) != 2 {
+		t.Errorf("ranking as of 2002 scored %v, want only A and B", asOf.Scores)
+	}
+
+	// --out still decides
+	if err := runCLI(t, dir, "rank", "--as-of", "2002", "--out", "data/processed/past.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", "processed", "past.json")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEnsureVectorFileRewritesDamagedFile(t *testing.T) {
+	dir := t.TempDir()
+	papersPath := writeTestFile(t, dir, "papers_with_embeddings.json", `{"papers": [
+		{"id": "A", "title": "A", "abstract_embedding": [1, 0]},
+		{"id": "B", "title": "B", "abstract_embedding": [0, 1]}
+	]}`)
+	vectorsPath, err := ensureVectorFile(papersPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cut short after the papers file was written, so its mtime is newer
+	info, err := os.Stat(vectorsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(vectorsPath, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ensureVectorFile(papersPath, ""); err != nil {
+		t.Fatal(err)
+	}
+	vectors, err := data.OpenVectorFile(vectorsPath)
+	if err != nil {
+		t.Fatalf("damaged vector file kept: %v", err)
+	}
+	defer vectors.Close()
+	if vectors.Len() != 2 {
+		t.Errorf("rewritten vector file holds %d vectors, want 2", vectors.Len())
+	}
+}
+
+func TestRelinkRefusesCorpusMapOfAnotherParse(t *testing.T) {
+	dir := t.TempDir()
+	papers := `{"papers": [{"id": "A", "title": "A"}, {"id": "B", "title": "B"}], "citations": []}`
+	papersPath := writeTestFile(t, dir, "data/processed/papers.json", papers)
+	stale := data.NewCorpusMap([]data.Paper{{ID: "A", CorpusPaperID: 1}})
+	if err := data.SaveCorpusMap(stale, filepath.Join(dir, "data", "processed", data.CorpusMapFile)); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "data/new_citations.parquet", "")
+
+	err := runCLI(t, dir, "relink", "new_citations.parquet")
+	if err == nil || !strings.Contains(err.Error(), "from another parse") {
+		t.Errorf("got error %v, want the map refused", err)
+	}
+	if got, _ := os.ReadFile(papersPath); string(got) != papers {
+		t.Error("papers.json changed")
+	}
+}
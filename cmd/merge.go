@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var mergeOut string
+
+func mergeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge [a.json] [b.json]",
+		Short: "Merge two parsed datasets into one",
+		Long: `Union the papers and citations of two parsed data files, e.g. ACL and a
+second conference. Papers with the same ID keep the record with more metadata,
+duplicate citations are dropped and stats are recomputed. Papers that share an
+ID but not a title are reported so they can be checked.
+
+Like parse, the inputs and --out are paths inside the data folder.`,
+		Example: `  acl-ranker merge processed/papers.json other/papers.json --out processed/merged.json`,
+		Args:    cobra.ExactArgs(2),
+		RunE:    runMerge,
+	}
+
+	cmd.Flags().StringVar(&mergeOut, "out", "", "Output file for the merged data, inside the data folder")
+	cmd.Flags().StringVar(&outputFormat, "format", "json", "Output format: json, jsonl or msgpack")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	format, err := data.ParseFormatFor(outputFormat, (*data.ParsedData)(nil))
+	if err != nil {
+		return err
+	}
+
+	outputPath := filepath.Join("data", mergeOut)
+	datasets := make([]*data.ParsedData, len(args))
+	for i, arg := range args {
+		path := filepath.Join("data", arg)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("input file not found: %s", path)
+		}
+		if datasets[i], err = data.LoadParsedData(path); err != nil {
+			return fmt.Errorf("failed to load %s: %v", path, err)
+		}
+		fmt.Printf("Loaded %s: %d papers, %d citations\n", path, len(datasets[i].Papers), len(datasets[i].Citations))
+	}
+
+	merged, conflicts := data.MergeParsedData(datasets[0], datasets[1])
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	if err := data.SaveParsedData(merged, outputPath, format); err != nil {
+		return fmt.Errorf("failed to save merged data: %v", err)
+	}
+
+	fmt.Printf("\nMerged into %d papers and %d citations (%d duplicate papers)\n",
+		len(merged.Papers), len(merged.Citations),
+		len(datasets[0].Papers)+len(datasets[1].Papers)-len(merged.Papers))
+	data.PrintMergeConflicts(conflicts, 10)
+	data.PrintParsingStats(merged.Stats)
+	fmt.Printf("\nOutput saved to: %s\n", outputPath)
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"path/filepath"
+)
+
+// printer formats what the commands print; --precision and --color set it.
+var printer = graph.Printer{Precision: graph.DefaultScorePrecision}
+
+// stdoutPath is the --out value that writes results to stdout.
+const stdoutPath = "-"
+
+// resultStdout is where results written to "-" go. Diagnostics are printed
+// to os.Stdout throughout the code, so divertDiagnostics points os.Stdout at
+// stderr to keep the result stream clean.
+var resultStdout = os.Stdout
+
+// divertDiagnostics sends everything printed to os.Stdout to stderr when
+// outPath is stdout, so 'acl-ranker ... --out - | jq' only sees the results.
+func divertDiagnostics(outPath string) {
+	if outPath == stdoutPath && os.Stdout != os.Stderr {
+		resultStdout = os.Stdout
+		os.Stdout = os.Stderr
+	}
+}
+
+// writeOutput runs write against stdout for "-" and against outPath
+// otherwise, creating the directory as needed. Files are replaced
+// atomically, so a failed write keeps the previous output.
+func writeOutput(outPath string, write func(w io.Writer) error) error {
+	if outPath == stdoutPath {
+		w := bufio.NewWriter(resultStdout)
+		if err := write(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	return data.AtomicWrite(outPath, write)
+}
+
+// resolveColor turns a --color mode into printer.Color. "auto" colors only
+// when out is a terminal and NO_COLOR is unset or empty.
+func resolveColor(mode string, out *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		return isTerminal(out), nil
+	}
+	return false, fmt.Errorf("unknown color mode %q (want auto, always or never)", mode)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
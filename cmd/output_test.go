@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"paper-rank/internal/graph"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveColor(t *testing.T) {
+	// a regular file is not a terminal
+	file, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tests := []struct {
+		mode, noColor string
+		want          bool
+	}{
+		{"always", "1", true},
+		{"never", "", false},
+		{"auto", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("NO_COLOR", tt.noColor)
+		got, err := resolveColor(tt.mode, file)
+		if err != nil {
+			t.Errorf("%q: %v", tt.mode, err)
+		} else if got != tt.want {
+			t.Errorf("%q with NO_COLOR=%q: color %v, want %v", tt.mode, tt.noColor, got, tt.want)
+		}
+	}
+	if _, err := resolveColor("sometimes", file); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
+
+func TestColorFlagSetsPrinter(t *testing.T) {
+	dir := t.TempDir()
+	defer func() { printer.Color = false }()
+
+	// the command fails for the missing data, after the flags are applied
+	runCLI(t, dir, "author", "nobody", "--color", "always")
+	if !printer.Color {
+		t.Error("--color always did not turn on the printer's colors")
+	}
+	runCLI(t, dir, "author", "nobody", "--color", "always", "--no-color")
+	if printer.Color {
+		t.Error("--no-color did not turn the colors off")
+	}
+}
+
+// captureOutput runs fn with os.Stdout and os.Stderr pointed at files and
+// returns what was written to each.
+func captureOutput(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outFile.Close()
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errFile.Close()
+
+	savedOut, savedErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outFile, errFile
+	defer func() { os.Stdout, os.Stderr = savedOut, savedErr }()
+	fn()
+
+	out, err := os.ReadFile(outFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	diag, err := os.ReadFile(errFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), string(diag)
+}
+
+func TestOutDashWritesOnlyResultsToStdout(t *testing.T) {
+	dir := t.TempDir()
+	writeRankedData(t, dir)
+
+	var err error
+	stdout, stderr := captureOutput(t, func() { err = runCLI(t, dir, "build", "--out", "-") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var built graph.Graph
+	if err := json.Unmarshal([]byte(stdout), &built); err != nil || len(built.Nodes) != 3 {
+		t.Errorf("build --out - printed %d nodes (%v) to stdout:\n%s", len(built.Nodes), err, stdout)
+	}
+	if !strings.Contains(stderr, "Graph Statistics") {
+		t.Errorf("build diagnostics missing from stderr:\n%s", stderr)
+	}
+
+	stdout, stderr = captureOutput(t, func() { err = runCLI(t, dir, "rank", "--out", "-") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ranked graph.PageRankResult
+	if err := json.Unmarshal([]byte(stdout), &ranked); err != nil || len(ranked.Scores) != 3 {
+		t.Errorf("rank --out - printed %d scores (%v) to stdout:\n%s", len(ranked.Scores), err, stdout)
+	}
+	if !strings.Contains(stderr, "PageRank") {
+		t.Errorf("rank diagnostics missing from stderr:\n%s", stderr)
+	}
+
+	// without --out -, diagnostics stay on stdout
+	stdout, _ = captureOutput(t, func() { err = runCLI(t, dir, "rank") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "PageRank") {
+		t.Errorf("rank printed no diagnostics to stdout:\n%s", stdout)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+var (
+	cpuProfile string
+	memProfile string
+
+	// set by startProfiling; main calls it after the command returns,
+	// error or not
+	stopProfiling = func() {}
+)
+
+// startProfiling starts the CPU profile for --cpuprofile and arranges for
+// stopProfiling to flush it and write the --memprofile heap profile.
+func startProfiling() error {
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %v", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to start CPU profile: %v", err)
+		}
+		cpuFile = f
+	}
+
+	stopProfiling = func() {
+		stopProfiling = func() {}
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to write CPU profile: %v\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "CPU profile written to: %s\n", cpuProfile)
+			}
+		}
+		if memProfile != "" {
+			if err := writeHeapProfile(memProfile); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Memory profile written to: %s\n", memProfile)
+			}
+		}
+	}
+	return nil
+}
+
+// writeHeapProfile writes a heap profile after a GC, so in-use figures are
+// current; allocations over the whole run are in its alloc_space samples.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile: %v", err)
+	}
+	defer f.Close()
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write memory profile: %v", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"paper-rank/internal/search"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var recommendConfig = search.DefaultRecommendConfig()
+
+func recommendCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recommend [paper_id]",
+		Short: "Recommend papers to read after a given paper",
+		Long: `Recommend papers to read after a given paper by blending three signals,
+each scored from 0 to 1:
+
+  embedding  similarity to the paper's stored embedding
+  graph      citation-graph proximity: 1 for a direct citation either way,
+             otherwise shared references (bibliographic coupling) or shared
+             citers (co-citation)
+  pagerank   PageRank relative to the highest score
+
+Only papers with some embedding similarity or graph proximity are
+recommended, and with --exclude-same-authors only those sharing no author
+with the paper. When the paper has no embedding, or papers_with_embeddings.json
+does not exist, the graph and PageRank signals are used alone.
+
+--explain adds a line per recommendation saying why the paper is related:
+whether either paper cites the other, how many references they share, how
+many papers cite them both, and the cosine similarity of their embeddings.`,
+		Example: `  acl-ranker recommend P18-1001 --top 10
+  acl-ranker recommend P18-1001 --graph-weight 0.6 --embedding-weight 0.2
+  acl-ranker recommend P18-1001 --exclude-same-authors
+  acl-ranker recommend P18-1001 --explain`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRecommend,
+	}
+	cmd.Flags().IntVarP(&recommendConfig.MaxResults, "top", "n", recommendConfig.MaxResults, "Number of papers to recommend")
+	cmd.Flags().Float64Var(&recommendConfig.EmbeddingWeight, "embedding-weight", recommendConfig.EmbeddingWeight, "Weight of embedding similarity")
+	cmd.Flags().Float64Var(&recommendConfig.GraphWeight, "graph-weight", recommendConfig.GraphWeight, "Weight of citation-graph proximity")
+	cmd.Flags().Float64Var(&recommendConfig.PageRankWeight, "pagerank-weight", recommendConfig.PageRankWeight, "Weight of PageRank")
+	cmd.Flags().BoolVar(&recommendConfig.ExcludeSameAuthors, "exclude-same-authors", false, "Leave out papers sharing an author with the paper")
+	cmd.Flags().BoolVar(&recommendConfig.Explain, "explain", false, "Show the shared references, shared citers and embedding similarity behind each recommendation")
+	cmd.Flags().StringVar(&embeddingField, "embedding-field", data.DefaultEmbeddingField, "Paper embedding to compare, e.g. abstract, title or fulltext")
+	cmd.Flags().StringVar(&similarity, "similarity", search.DefaultSimilarityMetric, "Similarity metric: cosine, dot or euclidean (match your embedding model)")
+
+	return cmd
+}
+
+func runRecommend(cmd *cobra.Command, args []string) error {
+	targetID := args[0]
+
+	papersPath := filepath.Join("data", "processed", "papers_with_embeddings.json")
+	pagerankPath := filepath.Join("data", "processed", "pagerank.json")
+	graphPath := filepath.Join("data", "processed", "graph.json")
+	cachePath := filepath.Join("data", "processed", "search_engine.cache.json")
+
+	config := recommendConfig
+	if config.EmbeddingWeight < 0 || config.GraphWeight < 0 || config.PageRankWeight < 0 {
+		return fmt.Errorf("recommendation weights must not be negative")
+	}
+	if config.EmbeddingWeight+config.GraphWeight+config.PageRankWeight <= 0 {
+		return fmt.Errorf("at least one recommendation weight must be positive")
+	}
+	if config.MaxResults <= 0 {
+		return fmt.Errorf("top must be positive, got: %d", config.MaxResults)
+	}
+
+	haveEmbeddings := true
+	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+		haveEmbeddings = false
+		fmt.Printf("No embeddings file (%s); loading papers without embeddings\n", papersPath)
+		papersPath = filepath.Join("data", "processed", "papers.json")
+	}
+	for _, path := range []string{papersPath, pagerankPath, graphPath} {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s\nRun 'acl-ranker parse', 'build' and 'rank' first", path)
+		}
+	}
+
+	searchConfig := search.DefaultSearchConfig()
+	searchConfig.RelevanceWeight = 0 // no embeddings to check
+	if haveEmbeddings {
+		searchConfig.RelevanceWeight = config.EmbeddingWeight
+	}
+	searchConfig.EmbeddingField = embeddingField
+	searchConfig.SimilarityMetric = similarity
+
+	var engine *search.SearchEngine
+	var err error
+	if haveEmbeddings {
+		engine, err = search.GetOrCreateEngine(papersPath, pagerankPath, cachePath, searchConfig)
+	} else {
+		engine, err = search.NewSearchEngine(papersPath, pagerankPath, searchConfig)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create search engine: %v", err)
+	}
+
+	citationGraph, err := graph.LoadGraph(graphPath)
+	if err != nil {
+		return fmt.Errorf("failed to load graph: %v", err)
+	}
+
+	recs, err := engine.Recommend(targetID, citationGraph, config)
+	if err != nil {
+		return err
+	}
+
+	for _, paper := range engine.Papers {
+		if paper.ID == targetID {
+			search.PrintRecommendations(printer, paper, recs)
+			break
+		}
+	}
+	return nil
+}
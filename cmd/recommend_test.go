@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"paper-rank/internal/search"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRecommendExcludeSameAuthors(t *testing.T) {
+	dir := t.TempDir()
+	writeSearchData(t, dir)
+
+	// P2 shares Grace Hopper with P1
+	for _, exclude := range []bool{false, true} {
+		args := []string{"recommend", "P1"}
+		if exclude {
+			args = append(args, "--exclude-same-authors")
+		}
+		var err error
+		stdout, _ := captureOutput(t, func() { err = runCLI(t, dir, args...) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(stdout, "ID: P2\n"); got == exclude {
+			t.Errorf("%v: P2 recommended %v, want %v:\n%s", args, got, !exclude, stdout)
+		}
+		if reported := strings.Contains(stdout, "Excluded 1 papers sharing an author with P1"); reported != exclude {
+			t.Errorf("%v: exclusion reported %v, want %v:\n%s", args, reported, exclude, stdout)
+		}
+	}
+}
+
+func TestRecommendExplainAddsUp(t *testing.T) {
+	dir := t.TempDir()
+	writeSearchData(t, dir)
+
+	var err error
+	stdout, _ := captureOutput(t, func() { err = runCLI(t, dir, "recommend", "T1", "--explain") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := regexp.MustCompile(`Score: ([\d.]+) \(Embedding: ([\d.]+), Graph: ([\d.]+), PageRank: ([\d.]+)\)`).FindAllStringSubmatch(stdout, -1)
+	if len(scores) == 0 || strings.Count(stdout, "   Why: ") != len(scores) {
+		t.Fatalf("%d scores and %d explanations:\n%s", len(scores), strings.Count(stdout, "   Why: "), stdout)
+	}
+	config := search.DefaultRecommendConfig()
+	for _, match := range scores {
+		var parts [4]float64
+		for i := range parts {
+			parts[i], _ = strconv.ParseFloat(match[i+1], 64)
+		}
+		want := config.EmbeddingWeight*parts[1] + config.GraphWeight*parts[2] + config.PageRankWeight*parts[3]
+		if math.Abs(parts[0]-want) > 1e-3 {
+			t.Errorf("%q: score is not the weighted sum %.4f of its parts", match[0], want)
+		}
+	}
+	// T2 cites T1
+	if want := "Why: cites the target paper, shares 0 references, cited together 0 times, "; !strings.Contains(stdout, want) {
+		t.Errorf("recommend --explain printed\n%s\nwithout %q", stdout, want)
+	}
+}
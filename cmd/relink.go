@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func relinkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "relink [citations_file]",
+		Short: "Add the citations of a new citations parquet to the parsed data",
+		Long: `Link the rows of a new citations parquet (read from the data folder, like
+parse) to the already parsed papers and add the new edges to papers.json,
+without reading the papers parquet again. Rows are linked through the
+corpus id map parse saves next to papers.json; edges already in the data are
+skipped, only filling in a missing intent or context. Paper metadata is
+left as it is. The map records the papers it was saved with, and a map
+that does not match papers.json is refused.
+
+Run 'acl-ranker build' and 'rank' afterwards to bring the graph and the
+PageRank scores up to date.`,
+		Example: `  acl-ranker relink acl_full_citations_2025.parquet
+  acl-ranker relink new_citations.parquet --output processed`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRelink,
+	}
+	cmd.Flags().StringVarP(&outputDir, "output", "o", "processed", "Directory holding the parsed papers.json")
+
+	return cmd
+}
+
+func runRelink(cmd *cobra.Command, args []string) error {
+	citationsPath := filepath.Join("data", args[0])
+	processedPath := filepath.Join("data", outputDir)
+	papersPath := filepath.Join(processedPath, "papers.json")
+	corpusMapPath := filepath.Join(processedPath, data.CorpusMapFile)
+
+	if _, err := os.Stat(citationsPath); os.IsNotExist(err) {
+		return fmt.Errorf("citations file not found: %s", citationsPath)
+	}
+	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+		return fmt.Errorf("parsed data not found: %s\nRun 'acl-ranker parse' first", papersPath)
+	}
+	if _, err := os.Stat(corpusMapPath); os.IsNotExist(err) {
+		return fmt.Errorf("corpus id map not found: %s\nRe-run 'acl-ranker parse', which now saves it", corpusMapPath)
+	}
+
+	// save in the format papers.json is already in
+	format, err := data.DetectFileFormat(papersPath)
+	if err != nil {
+		return err
+	}
+	parsedData, err := data.LoadParsedData(papersPath)
+	if err != nil {
+		return fmt.Errorf("failed to load parsed data: %v", err)
+	}
+	corpusMap, err := data.LoadCorpusMap(corpusMapPath)
+	if err != nil {
+		return err
+	}
+	if err := corpusMap.CheckPapers(parsedData.Papers); err != nil {
+		return fmt.Errorf("%s: %v\nRe-run 'acl-ranker parse' to save both files together", corpusMapPath, err)
+	}
+	if corpusMap.Fingerprint == "" {
+		fmt.Printf("Warning: %s does not record which papers it was saved with; re-run 'acl-ranker parse' if papers.json changed since\n", corpusMapPath)
+	}
+	fmt.Printf("Loaded %d papers, %d citations and %d corpus ids\n",
+		len(parsedData.Papers), len(parsedData.Citations), len(corpusMap.IDs))
+
+	report, err := data.Relink(cmd.Context(), parsedData, corpusMap, citationsPath)
+	if err != nil {
+		return err
+	}
+	data.PrintRelinkReport(report)
+
+	if report.Added == 0 && report.Duplicates == 0 {
+		fmt.Println("\nNothing to add; papers.json is unchanged.")
+		return nil
+	}
+	if err := data.SaveParsedData(parsedData, papersPath, format); err != nil {
+		return fmt.Errorf("failed to save parsed data: %v", err)
+	}
+	fmt.Printf("\nSaved %d citations to: %s\n", len(parsedData.Citations), papersPath)
+	if report.Added > 0 {
+		fmt.Println("Run 'acl-ranker build' and 'rank' to update the graph and PageRank scores.")
+	}
+	return nil
+}
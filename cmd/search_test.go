@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"paper-rank/internal/search"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// runSearchJSON runs search with args in dir, after the query and the hash
+// embedder, and returns the results it writes as JSON.
+func runSearchJSON(t *testing.T, dir, query string, args ...string) []search.SearchResult {
+	t.Helper()
+	args = append([]string{"search", query, "--embedder", "hash", "--json=results.json"}, args...)
+	var err error
+	captureOutput(t, func() { err = runCLI(t, dir, args...) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []search.SearchResult
+	if err := json.Unmarshal(raw, &results); err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func TestNoWeightNormalizeKeepsTheWeights(t *testing.T) {
+	dir := t.TempDir()
+	writeSearchData(t, dir)
+
+	tests := []struct {
+		args                []string
+		relevance, pagerank float64
+		absolute            bool
+	}{
+		{[]string{"--relevance-weight", "0.6", "--pagerank-weight", "0.2"}, 0.75, 0.25, false},
+		{[]string{"--relevance-weight", "2", "--pagerank-weight", "1", "--no-weight-normalize"}, 2, 1, true},
+	}
+	for _, tt := range tests {
+		results := runSearchJSON(t, dir, "machine translation", tt.args...)
+		if len(results) == 0 {
+			t.Fatalf("%v: no results", tt.args)
+		}
+		for _, result := range results {
+			if math.Abs(result.RelevanceWeight-tt.relevance) > 1e-9 || math.Abs(result.PageRankWeight-tt.pagerank) > 1e-9 ||
+				result.AbsoluteWeights != tt.absolute {
+				t.Errorf("%v: %s scored with weights %v/%v (absolute %v), want %v/%v (absolute %v)", tt.args, result.Paper.ID,
+					result.RelevanceWeight, result.PageRankWeight, result.AbsoluteWeights, tt.relevance, tt.pagerank, tt.absolute)
+			}
+		}
+	}
+
+	if err := runCLI(t, dir, "search", "parsing", "--embedder", "hash", "--no-weight-normalize",
+		"--relevance-weight", "-1"); err == nil {
+		t.Error("negative weight accepted with --no-weight-normalize")
+	}
+}
+
+func TestCountOnlyMatchesTheFilteredResults(t *testing.T) {
+	dir := t.TempDir()
+	writeSearchData(t, dir)
+
+	for _, minRelevance := range []string{"0", "0.55", "0.99"} {
+		results := runSearchJSON(t, dir, "machine translation", "--min-relevance", minRelevance)
+
+		var err error
+		stdout, _ := captureOutput(t, func() {
+			err = runCLI(t, dir, "search", "machine translation", "--embedder", "hash",
+				"--min-relevance", minRelevance, "--count-only")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := fmt.Sprintf("Papers matching \"machine translation\": %d\n", len(results))
+		if !strings.Contains(stdout, want) {
+			t.Errorf("min relevance %s: --count-only printed\n%s\nwithout %q", minRelevance, stdout, want)
+		}
+		if strings.Contains(stdout, "Dependency parsing") {
+			t.Errorf("min relevance %s: --count-only printed results:\n%s", minRelevance, stdout)
+		}
+	}
+}
+
+func TestOnelinePrintsOneLinePerResult(t *testing.T) {
+	dir := t.TempDir()
+	writeSearchData(t, dir)
+	want := runSearchJSON(t, dir, "parsing")
+
+	var err error
+	stdout, _ := captureOutput(t, func() { err = runCLI(t, dir, "search", "parsing", "--embedder", "hash", "--oneline") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("%d lines for %d results:\n%s", len(lines), len(want), stdout)
+	}
+	for i, line := range lines {
+		result := want[i]
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			t.Errorf("line %d has %d fields, want id, score, year and title: %q", i+1, len(fields), line)
+			continue
+		}
+		score, err := strconv.ParseFloat(fields[1], 64)
+		if fields[0] != result.Paper.ID || err != nil || math.Abs(score-result.Score) > 1e-4 ||
+			fields[2] != strconv.Itoa(result.Paper.Year) || fields[3] != result.Paper.Title {
+			t.Errorf("line %d is %q, want %s, %.4f, %d and %q", i+1, line, result.Paper.ID, result.Score, result.Paper.Year, result.Paper.Title)
+		}
+	}
+}
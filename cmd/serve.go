@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"paper-rank/internal/search"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr          string
+	serveWatch         bool
+	serveWatchInterval time.Duration
+	serveConfig        = search.DefaultSearchConfig()
+)
+
+func serveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve search over HTTP",
+		Long: `Load the search engine once and answer searches over HTTP:
+
+  GET /search?q=<query>[&n=<max results>]
+
+returns the results as the JSON array 'search --json' writes.
+
+With --watch, papers_with_embeddings.json and pagerank.json are checked every
+--watch-interval, and the engine is rebuilt from them once they change. A
+change is only acted on after the files have stayed the same for a whole
+interval, so a pipeline rewriting both files in a row triggers one reload.
+The new engine is built completely before it replaces the old one; searches
+already running finish on the old engine, and a reload that fails keeps it.`,
+		Example: `  acl-ranker serve
+  acl-ranker serve --addr :8080 --watch
+  curl 'localhost:8080/search?q=neural+machine+translation&n=5'`,
+		Args: cobra.NoArgs,
+		RunE: runServe,
+	}
+	cmd.Flags().StringVar(&serveAddr, "addr", "localhost:8080", "Address to listen on")
+	cmd.Flags().BoolVar(&serveWatch, "watch", false, "Reload the engine when the papers or PageRank file changes")
+	cmd.Flags().DurationVar(&serveWatchInterval, "watch-interval", 2*time.Second, "How often --watch checks the files; also how long they must stay unchanged before a reload")
+	cmd.Flags().IntVarP(&serveConfig.MaxResults, "max-results", "m", serveConfig.MaxResults, "Maximum number of results per search; n in the request can only lower it")
+	cmd.Flags().Float64Var(&serveConfig.PageRankWeight, "pagerank-weight", serveConfig.PageRankWeight, "Weight for PageRank score (0-1)")
+	cmd.Flags().Float64Var(&serveConfig.RelevanceWeight, "relevance-weight", serveConfig.RelevanceWeight, "Weight for relevance score (0-1)")
+	cmd.Flags().StringVar(&serveConfig.EmbeddingField, "embedding-field", serveConfig.EmbeddingField, "Paper embedding to search, e.g. abstract, title or fulltext")
+	cmd.Flags().StringVar(&serveConfig.SimilarityMetric, "similarity", serveConfig.SimilarityMetric, "Similarity metric: cosine, dot or euclidean (match your embedding model)")
+	cmd.Flags().StringVar(&serveConfig.Embedder, "embedder", "", "Query embedder: python, http, or hash (default $ACL_EMBEDDER, else python)")
+	cmd.Flags().StringVar(&serveConfig.EmbedderURL, "embedder-url", "", "Endpoint for --embedder http (default $ACL_EMBEDDER_URL)")
+	cmd.Flags().StringVar(&serveConfig.EmbedderModel, "embedder-model", "", "Model name sent to --embedder http (default $ACL_EMBEDDER_MODEL)")
+
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	papersPath := filepath.Join("data", "processed", "papers_with_embeddings.json")
+	pagerankPath := filepath.Join("data", "processed", "pagerank.json")
+	cachePath := filepath.Join("data", "processed", "search_engine.cache.json")
+
+	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+		return fmt.Errorf("papers file with embeddings not found: %s\nRun 'acl-ranker embed' first", papersPath)
+	}
+	if _, err := os.Stat(pagerankPath); os.IsNotExist(err) {
+		return fmt.Errorf("PageRank file not found: %s\nRun 'acl-ranker rank' first", pagerankPath)
+	}
+	if serveConfig.MaxResults <= 0 {
+		return fmt.Errorf("max-results must be positive, got: %d", serveConfig.MaxResults)
+	}
+	if serveWatchInterval <= 0 {
+		return fmt.Errorf("watch-interval must be positive, got: %v", serveWatchInterval)
+	}
+	config := serveConfig
+	totalWeight := config.PageRankWeight + config.RelevanceWeight
+	if config.PageRankWeight < 0 || config.RelevanceWeight < 0 || totalWeight <= 0 {
+		return fmt.Errorf("pagerank-weight and relevance-weight must not be negative or both 0")
+	}
+	config.PageRankWeight /= totalWeight
+	config.RelevanceWeight /= totalWeight
+	var err error
+	if config.Embedder, err = search.ResolveEmbedderName(config.Embedder); err != nil {
+		return err
+	}
+
+	server := &searchServer{papersPath: papersPath, pagerankPath: pagerankPath, config: config}
+	engine, err := search.GetOrCreateEngine(papersPath, pagerankPath, cachePath, config)
+	if err != nil {
+		return fmt.Errorf("failed to create search engine: %v", err)
+	}
+	if err := server.swap(engine); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	if serveWatch {
+		go server.watch(ctx, serveWatchInterval)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", server.handleSearch)
+	httpServer := &http.Server{Addr: serveAddr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving search on http://%s/search", serveAddr)
+	if serveWatch {
+		log.Printf("Watching %s and %s for changes every %v", papersPath, pagerankPath, serveWatchInterval)
+	}
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// searchServer answers searches from the current engine. Reloads build the
+// new engine completely, then swap it in under the write lock, which waits
+// for the searches still running on the old one.
+type searchServer struct {
+	papersPath   string
+	pagerankPath string
+	config       search.SearchConfig
+
+	mu     sync.RWMutex
+	engine *search.SearchEngine
+}
+
+// swap makes engine the one searches use.
+func (s *searchServer) swap(engine *search.SearchEngine) error {
+	if err := engine.Prepare(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.engine = engine
+	s.mu.Unlock()
+	return nil
+}
+
+// reload rebuilds the engine from the papers and PageRank files. The cache
+// is skipped, since it is what the files have just outdated.
+func (s *searchServer) reload() error {
+	engine, err := search.NewSearchEngine(s.papersPath, s.pagerankPath, s.config)
+	if err != nil {
+		return err
+	}
+	return s.swap(engine)
+}
+
+func (s *searchServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "missing query parameter q", http.StatusBadRequest)
+		return
+	}
+	limit := s.config.MaxResults
+	if n := r.URL.Query().Get("n"); n != "" {
+		parsed, err := strconv.Atoi(n)
+		if err != nil || parsed <= 0 {
+			http.Error(w, fmt.Sprintf("n must be a positive integer, got %q", n), http.StatusBadRequest)
+			return
+		}
+		limit = min(limit, parsed)
+	}
+
+	s.mu.RLock()
+	results, err := s.engine.Search(query)
+	s.mu.RUnlock()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("search failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if results == nil {
+		results = []search.SearchResult{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeResultsJSON(w, results[:min(limit, len(results))]); err != nil {
+		log.Printf("Failed to write search response: %v", err)
+	}
+}
+
+// watch checks the papers and PageRank files every interval until ctx is
+// done, reloading the engine once a change has settled.
+func (s *searchServer) watch(ctx context.Context, interval time.Duration) {
+	watcher := newFileWatcher(s.papersPath, s.pagerankPath)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !watcher.settled() {
+			continue
+		}
+		log.Printf("Data files changed; reloading the search engine")
+		start := time.Now()
+		if err := s.reload(); err != nil {
+			log.Printf("Reload failed, still serving the previous engine: %v", err)
+			continue
+		}
+		log.Printf("Reloaded the search engine in %v", time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// what fileWatcher compares to notice a rewritten file; the pipeline
+// replaces files by rename, which changes the mtime
+type fileState struct {
+	size    int64
+	modTime int64 // UnixNano; 0 when the file is missing
+}
+
+// fileWatcher notices changes to a set of files by polling their size and
+// mtime.
+type fileWatcher struct {
+	paths   []string
+	seen    []fileState
+	pending bool // changed since the last reload, maybe still being written
+}
+
+func newFileWatcher(paths ...string) *fileWatcher {
+	fw := &fileWatcher{paths: paths}
+	fw.seen = fw.stat()
+	return fw
+}
+
+func (fw *fileWatcher) stat() []fileState {
+	states := make([]fileState, len(fw.paths))
+	for i, path := range fw.paths {
+		if info, err := os.Stat(path); err == nil {
+			states[i] = fileState{size: info.Size(), modTime: info.ModTime().UnixNano()}
+		}
+	}
+	return states
+}
+
+// settled reports whether the files changed since the last reload and have
+// not changed since the previous call, debouncing a burst of writes into
+// one reload. A missing file is waited for rather than reloaded.
+func (fw *fileWatcher) settled() bool {
+	states := fw.stat()
+	if !slices.Equal(states, fw.seen) {
+		fw.seen = states
+		fw.pending = true
+		return false
+	}
+	if !fw.pending || slices.Contains(states, fileState{}) {
+		return false
+	}
+	fw.pending = false
+	return true
+}
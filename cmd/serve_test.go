@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"paper-rank/internal/search"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeServeData(t *testing.T, dir string, scores map[string]float64) (papersPath, pagerankPath string) {
+	t.Helper()
+	papersPath = filepath.Join(dir, "papers_with_embeddings.json")
+	pagerankPath = filepath.Join(dir, "pagerank.json")
+	var papers []data.Paper
+	for _, id := range []string{"A", "B", "C"} {
+		papers = append(papers, data.Paper{ID: id, Title: "Paper " + id, Year: 2020, AbstractEmbedding: []float32{1, 0}})
+	}
+	if err := data.SaveParsedData(&data.ParsedData{Papers: papers}, papersPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.SavePageRankResult(&graph.PageRankResult{Scores: scores}, pagerankPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	return papersPath, pagerankPath
+}
+
+// topResult searches through the handler and returns the first paper id.
+func topResult(t *testing.T, server *searchServer) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.handleSearch(rec, httptest.NewRequest(http.MethodGet, "/search?q=parsing&n=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var results []search.SearchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	return results[0].Paper.ID
+}
+
+func TestServeWatchReloadsChangedPageRank(t *testing.T) {
+	dir := t.TempDir()
+	papersPath, pagerankPath := writeServeData(t, dir, map[string]float64{"A": 0.5, "B": 0.3, "C": 0.2})
+
+	config := search.DefaultSearchConfig()
+	config.PageRankWeight, config.RelevanceWeight = 1, 0
+	server := &searchServer{papersPath: papersPath, pagerankPath: pagerankPath, config: config}
+	if err := server.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := topResult(t, server); got != "A" {
+		t.Fatalf("top result %s, want A", got)
+	}
+
+	ctx := t.Context()
+	go server.watch(ctx, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond) // let the watcher record the files first
+	writeServeData(t, dir, map[string]float64{"A": 0.1, "B": 0.2, "C": 0.7})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for topResult(t, server) != "C" {
+		if time.Now().After(deadline) {
+			t.Fatal("engine not reloaded after pagerank.json changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServeRejectsBadRequests(t *testing.T) {
+	server := &searchServer{config: search.DefaultSearchConfig()}
+	for _, target := range []string{"/search", "/search?q=+", "/search?q=x&n=0", "/search?q=x&n=ten"} {
+		rec := httptest.NewRecorder()
+		server.handleSearch(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, rec.Code)
+		}
+	}
+}
+
+func TestFileWatcherWaitsForChangesToSettle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pagerank.json")
+	writeTestFile(t, dir, "pagerank.json", "{}")
+	fw := newFileWatcher(path)
+
+	if fw.settled() {
+		t.Error("reload before any change")
+	}
+	writeTestFile(t, dir, "pagerank.json", `{"scores": {}}`)
+	if fw.settled() {
+		t.Error("reload while the file may still be written")
+	}
+	writeTestFile(t, dir, "pagerank.json", `{"scores": {"A": 1}}`)
+	if fw.settled() {
+		t.Error("reload in the middle of a burst of writes")
+	}
+	if !fw.settled() {
+		t.Error("no reload once the file stopped changing")
+	}
+	if fw.settled() {
+		t.Error("second reload for the same change")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if fw.settled() || fw.settled() {
+		t.Error("reload while the file is missing")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/search"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// resultSink is one destination for search results: a format and the file,
+// or stdout, it goes to. One search can feed several sinks.
+type resultSink struct {
+	format string // for messages, e.g. "JSON"
+	path   string // a file, or stdoutPath
+	write  func(w io.Writer, results []search.SearchResult) error
+}
+
+// searchSinks collects the sinks configured by --json, --csv, --bibtex and
+// --template. --template writes to --out, as does a bare --json when there
+// is no template. At most one sink may write to stdout.
+func searchSinks(jsonPath, csvPath, bibtexPath string, tmpl *template.Template, outPath string) ([]resultSink, error) {
+	var sinks []resultSink
+	if tmpl != nil {
+		sinks = append(sinks, resultSink{"template", outPath, func(w io.Writer, results []search.SearchResult) error {
+			return search.WriteTemplateResults(w, results, tmpl)
+		}})
+	}
+	if jsonPath != "" {
+		if jsonPath == stdoutPath && tmpl == nil {
+			jsonPath = outPath
+		}
+		sinks = append(sinks, resultSink{"JSON", jsonPath, writeResultsJSON})
+	}
+	if csvPath != "" {
+		sinks = append(sinks, resultSink{"CSV", csvPath, writeResultsCSV})
+	}
+	if bibtexPath != "" {
+		sinks = append(sinks, resultSink{"BibTeX", bibtexPath, writeResultsBibTeX})
+	}
+
+	var toStdout []string
+	for _, sink := range sinks {
+		if sink.path == stdoutPath {
+			toStdout = append(toStdout, sink.format)
+		}
+	}
+	if len(toStdout) > 1 {
+		return nil, fmt.Errorf("only one of %s can write to stdout; send the others to files, e.g. --json=results.json", strings.Join(toStdout, ", "))
+	}
+	return sinks, nil
+}
+
+// writesStdout reports whether one of the sinks writes to stdout, in which
+// case the result table is not printed.
+func writesStdout(sinks []resultSink) bool {
+	for _, sink := range sinks {
+		if sink.path == stdoutPath {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSinks writes the same results to every sink, reporting the files
+// written on stderr.
+func writeSinks(sinks []resultSink, results []search.SearchResult) error {
+	for _, sink := range sinks {
+		err := writeOutput(sink.path, func(w io.Writer) error {
+			return sink.write(w, results)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write %s results: %v", sink.format, err)
+		}
+		if sink.path != stdoutPath {
+			fmt.Fprintf(os.Stderr, "%s for %d results written to: %s\n", sink.format, len(results), sink.path)
+		}
+	}
+	return nil
+}
+
+func writeResultsJSON(w io.Writer, results []search.SearchResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// writeResultsCSV writes one row per result with its scores and metadata.
+func writeResultsCSV(w io.Writer, results []search.SearchResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rank", "paper_id", "title", "year", "authors", "score", "relevance", "pagerank", "citations"})
+	for i, result := range results {
+		cw.Write([]string{
+			strconv.Itoa(i + 1),
+			result.Paper.ID,
+			result.Paper.Title,
+			strconv.Itoa(result.Paper.Year),
+			strings.Join(result.Paper.Authors, "; "),
+			strconv.FormatFloat(result.Score, 'g', -1, 64),
+			strconv.FormatFloat(result.RelevanceScore, 'g', -1, 64),
+			strconv.FormatFloat(result.PageRankScore, 'g', -1, 64),
+			strconv.Itoa(result.Citations),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeResultsBibTeX(w io.Writer, results []search.SearchResult) error {
+	papers := make([]data.Paper, len(results))
+	for i, result := range results {
+		papers[i] = result.Paper
+	}
+	return data.WriteBibTeX(w, papers)
+}
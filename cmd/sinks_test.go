@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"paper-rank/internal/search"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeSearchData builds, ranks and hash-embeds a small corpus in dir, so
+// 'search --embedder hash' runs offline. Only T2 cites T1, and P1 and P2
+// share their first author.
+func writeSearchData(t *testing.T, dir string) {
+	t.Helper()
+	writeTestFile(t, dir, "data/processed/papers.json", `{"papers": [
+		{"id": "T1", "title": "Neural machine translation", "authors": ["Ada Lovelace"], "year": 2015,
+			"abstract": "Neural machine translation with attention.", "citations": []},
+		{"id": "T2", "title": "Translation of rare words", "authors": ["Alan Turing"], "year": 2016,
+			"abstract": "Machine translation of rare words with subword units.", "citations": ["T1"]},
+		{"id": "P1", "title": "Dependency parsing", "authors": ["Grace Hopper"], "year": 2017,
+			"abstract": "Fast dependency parsing with neural networks.", "citations": []},
+		{"id": "P2", "title": "Constituency parsing", "authors": ["Grace Hopper", "Alan Turing"], "year": 2018,
+			"abstract": "Constituency parsing with a self-attentive encoder.", "citations": []}
+	], "citations": [{"from": "T2", "to": "T1"}]}`)
+	for _, args := range [][]string{{"build"}, {"rank"}, {"embed", "--embedder", "hash"}} {
+		if err := runCLI(t, dir, args...); err != nil {
+			t.Fatalf("%s: %v", args[0], err)
+		}
+	}
+}
+
+func TestSearchSinksReceiveTheSameResults(t *testing.T) {
+	dir := t.TempDir()
+	writeSearchData(t, dir)
+
+	var err error
+	stdout, _ := captureOutput(t, func() {
+		err = runCLI(t, dir, "search", "machine translation", "--embedder", "hash",
+			"--json=results.json", "--csv=results.csv", "--bibtex=results.bib")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []search.SearchResult
+	if err := json.Unmarshal(raw, &results); err != nil {
+		t.Fatal(err)
+	}
+	var jsonIDs []string
+	for _, result := range results {
+		jsonIDs = append(jsonIDs, result.Paper.ID)
+	}
+	if len(jsonIDs) != 4 || !strings.HasPrefix(jsonIDs[0], "T") {
+		t.Errorf("JSON results %v, want all 4 papers with a translation paper first", jsonIDs)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "results.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var csvIDs []string
+	for _, row := range rows[1:] {
+		csvIDs = append(csvIDs, row[1])
+	}
+	if !reflect.DeepEqual(csvIDs, jsonIDs) {
+		t.Errorf("CSV results %v, JSON %v", csvIDs, jsonIDs)
+	}
+
+	bib, err := os.ReadFile(filepath.Join(dir, "results.bib"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bibIDs []string
+	for _, line := range strings.Split(string(bib), "\n") {
+		if key, ok := strings.CutPrefix(line, "@inproceedings{"); ok {
+			bibIDs = append(bibIDs, strings.TrimSuffix(key, ","))
+		}
+	}
+	if !reflect.DeepEqual(bibIDs, jsonIDs) {
+		t.Errorf("BibTeX entries %v, JSON results %v", bibIDs, jsonIDs)
+	}
+
+	// the files do not replace the table
+	for _, id := range jsonIDs {
+		if !strings.Contains(stdout, id) {
+			t.Errorf("result table on stdout lacks %s:\n%s", id, stdout)
+		}
+	}
+}
+
+func TestSearchSinksAllowOneStdout(t *testing.T) {
+	tests := []struct {
+		json, csv, bibtex, out string
+		want                   []string // format:path of each sink
+		err                    bool
+	}{
+		{json: stdoutPath, out: stdoutPath, want: []string{"JSON:-"}},
+		// a bare --json follows --out
+		{json: stdoutPath, out: "results.json", want: []string{"JSON:results.json"}},
+		{json: "r.json", csv: stdoutPath, bibtex: "r.bib", out: stdoutPath, want: []string{"JSON:r.json", "CSV:-", "BibTeX:r.bib"}},
+		{json: stdoutPath, csv: stdoutPath, out: stdoutPath, err: true},
+		{csv: stdoutPath, bibtex: stdoutPath, out: stdoutPath, err: true},
+	}
+	for _, tt := range tests {
+		sinks, err := searchSinks(tt.json, tt.csv, tt.bibtex, nil, tt.out)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%+v: two sinks on stdout accepted", tt)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: %v", tt, err)
+			continue
+		}
+		var got []string
+		for _, sink := range sinks {
+			got = append(got, sink.format+":"+sink.path)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: sinks %v, want %v", tt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	timelineAuthor   string
+	timelineKeywords []string
+	timelineOut      string
+)
+
+func timelineCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "timeline",
+		Short: "Show an author's or topic's papers year by year",
+		Long: `Group the papers of an author, or the papers on a topic, by publication
+year and show how many appeared each year and the year's top paper by
+PageRank, for a chronological overview of a researcher's or a topic's
+output. Years without papers between the first and the last are shown
+with a count of 0.
+
+--author matches names like 'author' does, so initials, a last name alone
+and small typos work. --keyword matches papers whose title or abstract
+contains any of the keywords, case-insensitively, like 'parse --filter-keyword'.`,
+		Example: `  acl-ranker timeline --author "Christopher Manning"
+  acl-ranker timeline --keyword "machine translation" --out timeline.json`,
+		Args: cobra.NoArgs,
+		RunE: runTimeline,
+	}
+	cmd.Flags().StringVar(&timelineAuthor, "author", "", "Author whose papers to show")
+	cmd.Flags().StringSliceVar(&timelineKeywords, "keyword", nil, "Show the papers mentioning any of these keywords (comma-separated or repeated)")
+	cmd.Flags().StringVarP(&timelineOut, "out", "o", "", "Also save the timeline as JSON to this file")
+	cmd.MarkFlagsMutuallyExclusive("author", "keyword")
+	cmd.MarkFlagsOneRequired("author", "keyword")
+
+	return cmd
+}
+
+func runTimeline(cmd *cobra.Command, args []string) error {
+	papersPath := filepath.Join("data", "processed", "papers.json")
+	pagerankPath := filepath.Join("data", "processed", "pagerank.json")
+
+	if _, err := os.Stat(papersPath); os.IsNotExist(err) {
+		return fmt.Errorf("papers file not found: %s\nRun 'acl-ranker parse' first", papersPath)
+	}
+	if _, err := os.Stat(pagerankPath); os.IsNotExist(err) {
+		return fmt.Errorf("PageRank file not found: %s\nRun 'acl-ranker rank' first", pagerankPath)
+	}
+
+	var papers []data.Paper
+	err := data.StreamPapers(papersPath, func(paper data.Paper) error {
+		paper.AbstractEmbedding = nil
+		paper.Embeddings = nil
+		papers = append(papers, paper)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load papers: %v", err)
+	}
+
+	result, err := graph.LoadPageRankResult(pagerankPath)
+	if err != nil {
+		return err
+	}
+	citations := make(map[string]int, len(result.Rankings))
+	for _, paper := range result.Rankings {
+		citations[paper.PaperID] = paper.Citations
+	}
+
+	var subject string
+	var matched []int
+	if timelineAuthor != "" {
+		index := data.BuildAuthorIndex(papers)
+		matches := index.Match(timelineAuthor)
+		if len(matches) == 0 {
+			return fmt.Errorf("no author matching %q", timelineAuthor)
+		}
+		subject = index.Names[matches[0]]
+		matched = index.Papers[matches[0]]
+	} else {
+		subject = strings.Join(timelineKeywords, ", ")
+		for i, paper := range papers {
+			if data.MatchesKeywords(paper, timelineKeywords) {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) == 0 {
+			return fmt.Errorf("no paper mentions %s", subject)
+		}
+	}
+
+	scores := make([]graph.PaperScore, len(matched))
+	for i, j := range matched {
+		paper := papers[j]
+		scores[i] = graph.PaperScore{
+			PaperID:   paper.ID,
+			Title:     paper.Title,
+			Year:      paper.Year,
+			Score:     result.Scores[paper.ID],
+			Citations: citations[paper.ID],
+		}
+	}
+
+	timeline := graph.BuildTimeline(subject, scores)
+	if timelineOut != "" {
+		if err := data.EncodeFile(timelineOut, timeline, data.FormatJSON); err != nil {
+			return fmt.Errorf("failed to write timeline: %v", err)
+		}
+		fmt.Printf("\nTimeline saved to %s\n", timelineOut)
+	}
+
+	graph.PrintTimeline(printer, timeline)
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTimelineGroupsMatchingPapersByYear(t *testing.T) {
+	dir := t.TempDir()
+	writeSearchData(t, dir)
+
+	tests := []struct {
+		args    []string
+		subject string
+		counts  []int
+		tops    []string
+	}{
+		// T2 in 2016, nothing in 2017, P2 in 2018
+		{[]string{"--author", "alan turing"}, "Alan Turing", []int{1, 0, 1}, []string{"T2", "", "P2"}},
+		{[]string{"--keyword", "translation"}, "translation", []int{1, 1}, []string{"T1", "T2"}},
+	}
+	for _, tt := range tests {
+		out := filepath.Join(dir, "timeline.json")
+		args := append([]string{"timeline", "--out", out}, tt.args...)
+		if err := runCLI(t, dir, args...); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		var timeline graph.Timeline
+		if err := data.DecodeFile(out, &timeline); err != nil {
+			t.Fatal(err)
+		}
+
+		var counts []int
+		var tops []string
+		for _, year := range timeline.Years {
+			counts = append(counts, year.Count)
+			top := ""
+			if year.Top != nil {
+				top = year.Top.PaperID
+			}
+			tops = append(tops, top)
+		}
+		if timeline.Subject != tt.subject || !reflect.DeepEqual(counts, tt.counts) || !reflect.DeepEqual(tops, tt.tops) {
+			t.Errorf("%v: %q with counts %v and tops %v, want %q with %v and %v",
+				tt.args, timeline.Subject, counts, tops, tt.subject, tt.counts, tt.tops)
+		}
+	}
+
+	if err := runCLI(t, dir, "timeline", "--author", "nobody"); err == nil {
+		t.Error("timeline for an unknown author succeeded")
+	}
+}
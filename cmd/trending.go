@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"paper-rank/internal/graph"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	trendingYears       int
+	trendingTop         int
+	trendingCurrentYear int
+)
+
+func trendingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trending",
+		Short: "Rank recent papers by PageRank per year since publication",
+		Long: `Rank the papers of the last few years by their PageRank divided by their
+age, surfacing recent work that is already influential but still sits
+below the old classics in the absolute ranking.
+
+A paper's age counts its publication year as 1, so this year's papers keep
+their full score. The current year defaults to the latest publication year
+in the data, so an older snapshot still shows its own recent papers. Papers
+without a year are skipped.`,
+		Example: `  acl-ranker trending --years 3 --top 20
+  acl-ranker trending --current-year 2020`,
+		Args: cobra.NoArgs,
+		RunE: runTrending,
+	}
+	cmd.Flags().IntVar(&trendingYears, "years", 3, "Only rank papers from the last N years")
+	cmd.Flags().IntVarP(&trendingTop, "top", "n", 20, "Number of papers to show")
+	cmd.Flags().IntVar(&trendingCurrentYear, "current-year", 0, "Year to measure age from (0 = latest publication year in the data)")
+
+	return cmd
+}
+
+func runTrending(cmd *cobra.Command, args []string) error {
+	if trendingYears < 1 {
+		return fmt.Errorf("--years must be at least 1")
+	}
+
+	pagerankPath := filepath.Join("data", "processed", "pagerank.json")
+	if _, err := os.Stat(pagerankPath); os.IsNotExist(err) {
+		return fmt.Errorf("PageRank file not found: %s\nRun 'acl-ranker rank' first", pagerankPath)
+	}
+
+	result, err := graph.LoadPageRankResult(pagerankPath)
+	if err != nil {
+		return err
+	}
+
+	currentYear := trendingCurrentYear
+	if currentYear == 0 {
+		currentYear = graph.LatestYear(result.Rankings)
+		if currentYear == 0 {
+			return fmt.Errorf("no paper in %s has a publication year", pagerankPath)
+		}
+	}
+
+	trending := graph.TrendingPapers(result.Rankings, currentYear, trendingYears)
+	fmt.Printf("Papers published %d-%d: %d\n", currentYear-trendingYears+1, currentYear, len(trending))
+	if len(trending) == 0 {
+		return nil
+	}
+
+	graph.PrintTrendingPapers(printer, trending, trendingTop)
+	return nil
+}
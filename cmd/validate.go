@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"paper-rank/internal/graph"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateSCC    bool
+	validateJSON   bool
+	validateOut    string
+	validateConfig = graph.DefaultValidateConfig()
+)
+
+// exit codes of 'validate' by the highest severity found; 1 stays the
+// code for the command itself failing
+const (
+	exitValidateWarning = 2
+	exitValidateError   = 3
+)
+
+func validateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the citation graph for data errors",
+		Long: `Run the data-quality checks on the built citation graph and report each
+one with a severity and a few offending papers or edges.
+
+Errors make the ranking wrong: edges to papers missing from the graph,
+self-loops, duplicate paper ids or an empty graph. Warnings skew it:
+duplicate or anachronistic citations, citation cycles, and too many papers
+citing nothing (dangling) or with no citations at all (isolated).
+
+The exit code reflects the most serious finding: 0 when there are at most
+informational notes, 2 for warnings and 3 for errors, so the command can
+gate a pipeline before 'rank'.
+
+  --scc   also list the citation cycles (strongly connected components with
+          more than one paper) in full. Papers cite older work, so cycles
+          usually mean bad ID linking or anachronistic edges.`,
+		Example: `  acl-ranker validate
+  acl-ranker validate --json --out report.json
+  acl-ranker validate --scc`,
+		RunE: runValidate,
+	}
+
+	cmd.Flags().BoolVar(&validateSCC, "scc", false, "Also list the citation cycles (strongly connected components) in full")
+	cmd.Flags().BoolVar(&validateJSON, "json", false, "Write the report as JSON")
+	cmd.Flags().StringVar(&validateOut, "out", stdoutPath, "Where --json output goes (- for stdout, with progress on stderr)")
+	cmd.Flags().IntVar(&validateConfig.Samples, "samples", validateConfig.Samples, "Offending papers or edges listed per check")
+	cmd.Flags().Float64Var(&validateConfig.DanglingThreshold, "dangling-threshold", validateConfig.DanglingThreshold, "Warn when more than this share of papers cite nothing in the graph (0 = never)")
+	cmd.Flags().Float64Var(&validateConfig.IsolatedThreshold, "isolated-threshold", validateConfig.IsolatedThreshold, "Warn when more than this share of papers have no citations in or out (0 = never)")
+
+	return cmd
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if validateOut != stdoutPath && !validateJSON {
+		return fmt.Errorf("--out needs --json")
+	}
+	if validateConfig.Samples < 0 {
+		return fmt.Errorf("--samples must not be negative")
+	}
+	if validateJSON {
+		divertDiagnostics(validateOut)
+	}
+
+	graphPath := filepath.Join("data", "processed", "graph.json")
+	if _, err := os.Stat(graphPath); os.IsNotExist(err) {
+		return fmt.Errorf("graph file not found: %s\nRun 'acl-ranker build' first", graphPath)
+	}
+
+	citationGraph, err := graph.LoadGraphLean(graphPath)
+	if err != nil {
+		return fmt.Errorf("failed to load graph: %v", err)
+	}
+
+	report := graph.ValidateGraph(citationGraph, validateConfig)
+	if validateJSON {
+		err := writeOutput(validateOut, func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		graph.PrintValidationReport(report)
+	}
+
+	if validateSCC {
+		cycles := citationGraph.CycleComponents()
+		graph.PrintCycleComponents(citationGraph, cycles, 10, 10)
+	}
+
+	// findings are not usage mistakes
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	switch report.Severity {
+	case graph.SeverityError:
+		return &exitError{code: exitValidateError, err: fmt.Errorf("validation found errors")}
+	case graph.SeverityWarning:
+		return &exitError{code: exitValidateWarning, err: fmt.Errorf("validation found warnings")}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		edges    []graph.Edge
+		severity string
+		code     int // 0 when validate succeeds
+	}{
+		{"info", []graph.Edge{{From: "a", To: "b"}, {From: "c", To: "b"}}, graph.SeverityInfo, 0},
+		{"warning", []graph.Edge{{From: "a", To: "b"}, {From: "a", To: "b"}, {From: "c", To: "b"}}, graph.SeverityWarning, exitValidateWarning},
+		{"error", []graph.Edge{{From: "a", To: "b"}, {From: "c", To: "missing"}}, graph.SeverityError, exitValidateError},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		g := &graph.Graph{Nodes: []graph.Node{{ID: "a"}, {ID: "b"}, {ID: "c"}}, Edges: tt.edges}
+		if err := graph.SaveGraph(g, filepath.Join(dir, "data", "processed", "graph.json"), data.FormatJSON); err != nil {
+			t.Fatal(err)
+		}
+
+		var err error
+		stdout, _ := captureOutput(t, func() { err = runCLI(t, dir, "validate", "--json", "--out", "-") })
+		var exit *exitError
+		switch {
+		case tt.code == 0 && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.code != 0 && (!errors.As(err, &exit) || exit.code != tt.code):
+			t.Errorf("%s: error %v, want exit code %d", tt.name, err, tt.code)
+		}
+
+		var report graph.ValidationReport
+		if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+			t.Errorf("%s: stdout is not a JSON report: %v\n%s", tt.name, err, stdout)
+			continue
+		}
+		if report.Severity != tt.severity || report.Nodes != 3 || report.Edges != len(tt.edges) {
+			t.Errorf("%s: report of %d papers and %d edges with severity %s, want 3, %d and %s",
+				tt.name, report.Nodes, report.Edges, report.Severity, len(tt.edges), tt.severity)
+		}
+	}
+}
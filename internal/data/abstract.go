@@ -0,0 +1,69 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// FullAbstractsFile is the sidecar 'parse --max-abstract-chars' writes next
+// to papers.json with the full text of the abstracts it cut, so embeddings
+// are still computed from the whole abstract.
+const FullAbstractsFile = "abstracts.json"
+
+// the full text of truncated abstracts, by paper id
+type FullAbstracts struct {
+	Abstracts map[string]string `json:"abstracts"`
+}
+
+// TruncateAbstracts cuts every abstract longer than maxChars characters to
+// at most maxChars, at a word boundary when there is one, and appends "...".
+// It returns the full text of the cut abstracts and records their number in
+// the stats. maxChars <= 0 leaves the abstracts alone.
+func TruncateAbstracts(parsedData *ParsedData, maxChars int) *FullAbstracts {
+	full := &FullAbstracts{Abstracts: make(map[string]string)}
+	if maxChars <= 0 {
+		return full
+	}
+
+	for i := range parsedData.Papers {
+		paper := &parsedData.Papers[i]
+		if cut, ok := truncateText(paper.Abstract, maxChars); ok {
+			full.Abstracts[paper.ID] = paper.Abstract
+			paper.Abstract = cut
+		}
+	}
+
+	parsedData.Stats.Abstracts.Truncated = len(full.Abstracts)
+	return full
+}
+
+func SaveFullAbstracts(full *FullAbstracts, outputPath string) error {
+	return EncodeFile(outputPath, full, FormatJSON)
+}
+
+func LoadFullAbstracts(inputPath string) (*FullAbstracts, error) {
+	var full FullAbstracts
+	if err := DecodeFile(inputPath, &full); err != nil {
+		return nil, fmt.Errorf("failed to load full abstracts: %v", err)
+	}
+	return &full, nil
+}
+
+// truncateText returns text cut to at most maxChars runes plus "...", and
+// whether it had to be cut.
+func truncateText(text string, maxChars int) (string, bool) {
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text, false
+	}
+
+	cut := string(runes[:maxChars])
+	// back up to the last word boundary unless that loses most of the text
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "...", true
+}
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateAbstractsKeepsFullText(t *testing.T) {
+	long := strings.Repeat("oversized abstract text ", 200)
+	parsed := &ParsedData{Papers: []Paper{
+		{ID: "long", Abstract: long},
+		{ID: "short", Abstract: "A short abstract."},
+		{ID: "none"},
+	}}
+
+	full := TruncateAbstracts(parsed, 100)
+
+	cut := parsed.Papers[0].Abstract
+	if !strings.HasSuffix(cut, "...") || utf8.RuneCountInString(cut) > 103 {
+		t.Errorf("truncated abstract %q, want at most 100 characters plus ...", cut)
+	}
+	if !strings.HasPrefix(long, strings.TrimSuffix(cut, "...")) || strings.HasSuffix(strings.TrimSuffix(cut, "..."), " ") {
+		t.Errorf("truncated abstract %q is not a word-boundary prefix of the original", cut)
+	}
+	if parsed.Papers[1].Abstract != "A short abstract." || parsed.Papers[2].Abstract != "" {
+		t.Error("abstracts within the limit changed")
+	}
+	if len(full.Abstracts) != 1 || full.Abstracts["long"] != long {
+		t.Errorf("full abstracts hold %d entries, want only the full text of the cut one", len(full.Abstracts))
+	}
+	if parsed.Stats.Abstracts.Truncated != 1 {
+		t.Errorf("stats count %d truncated abstracts, want 1", parsed.Stats.Abstracts.Truncated)
+	}
+
+	if full := TruncateAbstracts(parsed, 0); len(full.Abstracts) != 0 {
+		t.Error("no limit still truncated abstracts")
+	}
+}
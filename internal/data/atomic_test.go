@@ -0,0 +1,56 @@
+package data
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicWriteFailureKeepsOldFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "papers.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// fails after writing more than the bufio buffer, so part of the new
+	// content has reached the temporary file
+	failed := errors.New("disk full")
+	err := AtomicWrite(path, func(w io.Writer) error {
+		if _, err := w.Write(make([]byte, 64<<10)); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("got error %v, want the write's error", err)
+	}
+	checkFile(t, path, "old")
+
+	// an encoding error mid-way leaves the old file too
+	if err := EncodeFile(path, map[string]any{"bad": make(chan int)}, FormatJSON); err == nil {
+		t.Fatal("encoding a channel succeeded")
+	}
+	checkFile(t, path, "old")
+
+	if err := AtomicWriteFile(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, path, "new")
+}
+
+// checkFile checks path holds want and no temporary file is left next to it.
+func checkFile(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("%s holds %q, want %q", path, got, want)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind (%v)", err)
+	}
+}
@@ -0,0 +1,177 @@
+package data
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeAuthor reduces the spellings of an author name to one key:
+// "Manning, Christopher D." and "Christopher D. Manning" both become
+// "christopher d manning". Accents are dropped and case, punctuation and
+// spacing are folded.
+func NormalizeAuthor(name string) string {
+	name = strings.TrimSpace(name)
+	if last, first, ok := strings.Cut(name, ","); ok && !strings.Contains(first, ",") {
+		name = strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+	}
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining accent
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// papers by normalized author name
+type AuthorIndex struct {
+	Papers map[string][]int  // normalized name -> indexes into the paper slice
+	Names  map[string]string // normalized name -> most common spelling
+}
+
+// BuildAuthorIndex indexes papers by their normalized author names.
+func BuildAuthorIndex(papers []Paper) *AuthorIndex {
+	index := &AuthorIndex{
+		Papers: make(map[string][]int),
+		Names:  make(map[string]string),
+	}
+	spellings := make(map[string]map[string]int)
+
+	for i, paper := range papers {
+		seen := make(map[string]bool, len(paper.Authors))
+		for _, author := range paper.Authors {
+			key := NormalizeAuthor(author)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			index.Papers[key] = append(index.Papers[key], i)
+			if spellings[key] == nil {
+				spellings[key] = make(map[string]int)
+			}
+			spellings[key][strings.TrimSpace(author)]++
+		}
+	}
+
+	for key, counts := range spellings {
+		best, bestCount := "", 0
+		for spelling, count := range counts {
+			if count > bestCount || (count == bestCount && spelling < best) {
+				best, bestCount = spelling, count
+			}
+		}
+		index.Names[key] = best
+	}
+	return index
+}
+
+// Match returns the normalized names matching query, best first. An exact
+// match is returned alone. Otherwise a name matches when every query word
+// matches one of its words in order, where a single letter matches as an
+// initial ("c manning" finds "christopher d manning"), or when it is within
+// a small edit distance of the query, to catch typos.
+func (idx *AuthorIndex) Match(query string) []string {
+	key := NormalizeAuthor(query)
+	if key == "" {
+		return nil
+	}
+	if _, ok := idx.Papers[key]; ok {
+		return []string{key}
+	}
+
+	queryWords := strings.Fields(key)
+	maxDistance := min(2, len(key)/5)
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	for name := range idx.Papers {
+		if wordsMatch(queryWords, strings.Fields(name)) {
+			candidates = append(candidates, candidate{name, 0})
+		} else if d := editDistance(key, name); d <= maxDistance {
+			candidates = append(candidates, candidate{name, d})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if len(idx.Papers[a.name]) != len(idx.Papers[b.name]) {
+			return len(idx.Papers[a.name]) > len(idx.Papers[b.name])
+		}
+		return a.name < b.name
+	})
+
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.name
+	}
+	return names
+}
+
+// wordsMatch reports whether the query words appear in order among the name
+// words, a one-letter query word matching any word with that initial. The
+// last query word must match the last name word, so "manning" finds
+// "christopher manning" but not "manning smith".
+func wordsMatch(query, name []string) bool {
+	if len(query) == 0 || len(query) > len(name) {
+		return false
+	}
+	if !wordMatches(query[len(query)-1], name[len(name)-1]) {
+		return false
+	}
+
+	j := 0
+	for _, q := range query[:len(query)-1] {
+		for j < len(name)-1 && !wordMatches(q, name[j]) {
+			j++
+		}
+		if j == len(name)-1 {
+			return false
+		}
+		j++
+	}
+	return true
+}
+
+func wordMatches(query, word string) bool {
+	if len(query) == 1 {
+		return strings.HasPrefix(word, query)
+	}
+	return query == word
+}
+
+// editDistance is the Levenshtein distance between a and b, in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,98 @@
+package data
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// bibtexEscaper escapes the characters LaTeX treats specially in titles,
+// author names and booktitles.
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// DOIs (bare, see normalizeDOI) and URLs are kept verbatim apart from
+// braces, which would unbalance the field.
+var bibtexURLEscaper = strings.NewReplacer(`{`, `%7B`, `}`, `%7D`)
+
+// BibTeXKey turns an ACL ID into a cite key, replacing characters BibTeX
+// does not allow in keys with underscores.
+func BibTeXKey(id string) string {
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-' || r == '.' || r == ':' || r == '_':
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(id))
+	if key == "" {
+		return "paper"
+	}
+	return key
+}
+
+// WriteBibTeXEntry writes one @inproceedings entry for paper. Empty fields
+// are left out.
+func WriteBibTeXEntry(w io.Writer, paper Paper) error {
+	var fields [][2]string
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, [2]string{name, value})
+		}
+	}
+
+	add("title", bibtexEscaper.Replace(strings.TrimSpace(paper.Title)))
+	authors := make([]string, 0, len(paper.Authors))
+	for _, author := range paper.Authors {
+		if author = strings.TrimSpace(author); author != "" {
+			authors = append(authors, bibtexEscaper.Replace(author))
+		}
+	}
+	add("author", strings.Join(authors, " and "))
+	if paper.Year > 0 {
+		add("year", strconv.Itoa(paper.Year))
+	}
+	add("booktitle", bibtexEscaper.Replace(strings.TrimSpace(paper.BookTitle)))
+	add("publisher", bibtexEscaper.Replace(strings.TrimSpace(paper.Publisher)))
+	add("doi", bibtexURLEscaper.Replace(normalizeDOI(paper.DOI)))
+	add("url", bibtexURLEscaper.Replace(paper.URL))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@inproceedings{%s,\n", BibTeXKey(paper.ID))
+	for i, field := range fields {
+		fmt.Fprintf(&b, "  %s = {%s}", field[0], field[1])
+		if i < len(fields)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write BibTeX entry: %v", err)
+	}
+	return nil
+}
+
+// WriteBibTeX writes one entry per paper, in order.
+func WriteBibTeX(w io.Writer, papers []Paper) error {
+	for _, paper := range papers {
+		if err := WriteBibTeXEntry(w, paper); err != nil {
+			return err
+		}
+	}
+	return nil
+}
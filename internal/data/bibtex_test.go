@@ -0,0 +1,62 @@
+package data
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBibTeXKey(t *testing.T) {
+	tests := []struct {
+		id, want string
+	}{
+		{"P19-1001", "P19-1001"},
+		{" W10.1000 ", "W10.1000"},
+		{"2020.acl-main.1", "2020.acl-main.1"},
+		{"a b{c}d,e%f", "a_b_c_d_e_f"},
+		{"über", "_ber"},
+		{"", "paper"},
+	}
+	for _, tt := range tests {
+		if got := BibTeXKey(tt.id); got != tt.want {
+			t.Errorf("BibTeXKey(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestWriteBibTeXEntry(t *testing.T) {
+	paper := Paper{
+		ID:        "P19-1001",
+		Title:     " Q&A over {nested} tables: 100% of $cost_ #1 ",
+		Authors:   []string{"Ada Lovelace", " ", "A. Turing & co"},
+		Year:      2019,
+		BookTitle: "Proceedings of ACL",
+		DOI:       "https://doi.org/10.18653/V1/P19-1001",
+		URL:       "https://example.org/{x}",
+	}
+	var out bytes.Buffer
+	if err := WriteBibTeXEntry(&out, paper); err != nil {
+		t.Fatal(err)
+	}
+	want := `@inproceedings{P19-1001,
+  title = {Q\&A over \{nested\} tables: 100\% of \$cost\_ \#1},
+  author = {Ada Lovelace and A. Turing \& co},
+  year = {2019},
+  booktitle = {Proceedings of ACL},
+  doi = {10.18653/v1/p19-1001},
+  url = {https://example.org/%7Bx%7D}
+}
+
+`
+	if out.String() != want {
+		t.Errorf("entry\n%s\nwant\n%s", out.String(), want)
+	}
+
+	// empty fields are left out
+	out.Reset()
+	if err := WriteBibTeXEntry(&out, Paper{ID: "X", Title: "Bare"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "@inproceedings{X,\n  title = {Bare}\n}\n\n"; out.String() != want {
+		t.Errorf("entry %q, want %q", out.String(), want)
+	}
+}
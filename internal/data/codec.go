@@ -0,0 +1,85 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v14/parquet/compress"
+	"github.com/apache/arrow/go/v14/parquet/file"
+)
+
+// every codec the parquet format defines, in enum order
+var parquetCodecs = []compress.Compression{
+	compress.Codecs.Uncompressed,
+	compress.Codecs.Snappy,
+	compress.Codecs.Gzip,
+	compress.Codecs.Lzo,
+	compress.Codecs.Brotli,
+	compress.Codecs.Lz4,
+	compress.Codecs.Zstd,
+	compress.Compression(7), // LZ4_RAW, not in compress.Codecs
+}
+
+// SupportedParquetCodecs lists the compression codecs this build can read.
+func SupportedParquetCodecs() []string {
+	var names []string
+	for _, codec := range parquetCodecs {
+		if _, err := compress.GetCodec(codec); err == nil {
+			names = append(names, codec.String())
+		}
+	}
+	return names
+}
+
+// checkParquetCodecs fails with a clear message when a column chunk of pf
+// uses a codec this build cannot decompress, instead of the arrow reader's
+// error deep inside ReadTable.
+func checkParquetCodecs(pf *file.Reader, parquetPath string) error {
+	// codec name -> columns using it
+	unsupported := make(map[string]map[string]bool)
+	meta := pf.MetaData()
+	for rg := 0; rg < pf.NumRowGroups(); rg++ {
+		rowGroup := meta.RowGroup(rg)
+		for col := 0; col < rowGroup.NumColumns(); col++ {
+			chunk, err := rowGroup.ColumnChunk(col)
+			if err != nil {
+				return fmt.Errorf("failed to read column metadata: %v", err)
+			}
+			codec := chunk.Compression()
+			if _, err := compress.GetCodec(codec); err == nil {
+				continue
+			}
+			name := codec.String()
+			if unsupported[name] == nil {
+				unsupported[name] = make(map[string]bool)
+			}
+			unsupported[name][chunk.PathInSchema().String()] = true
+		}
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	var problems []string
+	for name, columns := range unsupported {
+		list := make([]string, 0, len(columns))
+		for column := range columns {
+			list = append(list, column)
+		}
+		sort.Strings(list)
+		if len(list) > 3 {
+			list = append(list[:3], fmt.Sprintf("%d more", len(columns)-3))
+		}
+		noun := "columns"
+		if len(columns) == 1 {
+			noun = "column"
+		}
+		problems = append(problems, fmt.Sprintf("%s (%s %s)", name, noun, strings.Join(list, ", ")))
+	}
+	sort.Strings(problems)
+
+	return fmt.Errorf("%s is compressed with %s, which this build cannot read; supported codecs: %s. "+
+		"Rewrite the file with one of them, e.g. in pyarrow: pq.write_table(table, path, compression=\"zstd\")",
+		parquetPath, strings.Join(problems, " and "), strings.Join(SupportedParquetCodecs(), ", "))
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v14/parquet/compress"
+)
+
+// setColumnCodec rewrites the footer of the uncompressed parquet file at
+// path so column claims to be compressed with codec. The pages stay as
+// written; only the metadata a reader checks first changes.
+func setColumnCodec(t *testing.T, path, column string, codec compress.Compression) {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the footer is followed by its 4-byte length and the PAR1 magic
+	footerLen := int(binary.LittleEndian.Uint32(raw[len(raw)-8:]))
+	footer := raw[len(raw)-8-footerLen : len(raw)-8]
+
+	// in the thrift compact encoding of ColumnMetaData, path_in_schema
+	// (field 3, a one-element list of binary) is followed by codec (field
+	// 4, an i32 written as a zigzag varint)
+	pattern := append([]byte{0x19, 0x18, byte(len(column))}, column...)
+	uncompressed := slices.Concat(pattern, []byte{0x15, 0x00})
+	patched := slices.Concat(pattern, []byte{0x15, byte(codec) << 1})
+	if !bytes.Contains(footer, uncompressed) {
+		t.Fatalf("no uncompressed column %q in the footer of %s", column, path)
+	}
+	copy(footer, bytes.ReplaceAll(footer, uncompressed, patched))
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnsupportedCodecFailsClearly(t *testing.T) {
+	lz4Raw := compress.Compression(7)
+	if _, err := compress.GetCodec(lz4Raw); err == nil {
+		t.Skip("this build reads LZ4_RAW")
+	}
+	if _, err := compress.GetCodec(compress.Codecs.Lzo); err == nil {
+		t.Skip("this build reads LZO")
+	}
+
+	dir := t.TempDir()
+	papersPath, citationsPath := writeFixtureCorpus(t, dir)
+	setColumnCodec(t, papersPath, "title", lz4Raw)
+	setColumnCodec(t, papersPath, "abstract", lz4Raw)
+	setColumnCodec(t, papersPath, "year", compress.Codecs.Lzo)
+
+	_, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+	if err == nil {
+		t.Fatal("parsed a papers file compressed with unsupported codecs")
+	}
+	for _, want := range []string{
+		papersPath,
+		"LZ4_RAW (columns abstract, title) and LZO (column year)",
+		"supported codecs: " + strings.Join(SupportedParquetCodecs(), ", "),
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	setColumnCodec(t, citationsPath, "citedpaperid", lz4Raw)
+	_, _, err = readCitationRows(context.Background(), citationsPath)
+	if err == nil || !strings.Contains(err.Error(), "LZ4_RAW (column citedpaperid)") {
+		t.Errorf("citations error %v, want one naming LZ4_RAW and citedpaperid", err)
+	}
+}
+
+func TestSupportedParquetCodecs(t *testing.T) {
+	supported := SupportedParquetCodecs()
+	// the fixtures are written uncompressed, and every arrow build has
+	// snappy
+	for _, want := range []string{"UNCOMPRESSED", "SNAPPY"} {
+		found := false
+		for _, name := range supported {
+			found = found || name == want
+		}
+		if !found {
+			t.Errorf("supported codecs %v lack %s", supported, want)
+		}
+	}
+}
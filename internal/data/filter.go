@@ -0,0 +1,75 @@
+package data
+
+import (
+	"slices"
+	"strings"
+)
+
+// FilterByKeywords keeps the papers whose title or abstract contains any of
+// the keywords (case-insensitive substring match) and the citations between
+// kept papers. Paper citation lists and stats are recomputed for the
+// filtered set.
+func FilterByKeywords(parsedData *ParsedData, keywords []string) *ParsedData {
+	var needles []string
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			needles = append(needles, keyword)
+		}
+	}
+	if len(needles) == 0 {
+		return parsedData
+	}
+
+	kept := make(map[string]bool)
+	papers := make([]Paper, 0)
+	statsBuilder := newPaperStatsBuilder()
+	for _, paper := range parsedData.Papers {
+		if containsKeyword(paper, needles) {
+			kept[paper.ID] = true
+			papers = append(papers, paper)
+			statsBuilder.add(paper)
+		}
+	}
+
+	var linked []CitationEdge
+	for _, citation := range slices.Concat(parsedData.Citations, parsedData.SelfCitations) {
+		if kept[citation.From] && kept[citation.To] {
+			linked = append(linked, citation)
+		}
+	}
+	citations, selfCitations := splitSelfCitations(linked)
+
+	updatePaperCitations(papers, citations)
+	stats := statsBuilder.finish()
+	countCitations(stats, citations, selfCitations)
+
+	return &ParsedData{
+		Papers:        papers,
+		Citations:     citations,
+		SelfCitations: selfCitations,
+		Stats:         *stats,
+	}
+}
+
+// MatchesKeywords reports whether the paper's title or abstract contains any
+// of the keywords, case-insensitively, the match FilterByKeywords uses.
+func MatchesKeywords(paper Paper, keywords []string) bool {
+	var needles []string
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			needles = append(needles, keyword)
+		}
+	}
+	return containsKeyword(paper, needles)
+}
+
+// containsKeyword matches lowercased, non-empty needles.
+func containsKeyword(paper Paper, needles []string) bool {
+	text := strings.ToLower(paper.Title + "\n" + paper.Abstract)
+	for _, needle := range needles {
+		if strings.Contains(text, needle) {
+			return true
+		}
+	}
+	return false
+}
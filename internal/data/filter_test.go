@@ -0,0 +1,43 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilterByKeywordsDropsEdgesToFilteredPapers(t *testing.T) {
+	parsed := parsedTestData(
+		[]Paper{
+			{ID: "A", Title: "Neural Machine Translation"},
+			{ID: "B", Title: "Parsing", Abstract: "a translation model"},
+			{ID: "C", Title: "Sentiment analysis"},
+			{ID: "D", Title: "Summarization"},
+		},
+		CitationEdge{From: "B", To: "A"},
+		CitationEdge{From: "C", To: "A"}, // from a filtered paper
+		CitationEdge{From: "A", To: "D"}, // to a filtered paper
+		CitationEdge{From: "C", To: "D"},
+		CitationEdge{From: "B", To: "B"},
+	)
+
+	filtered := FilterByKeywords(parsed, []string{" TRANSLATION ", ""})
+
+	if got, want := paperIDs(filtered.Papers), []string{"A", "B"}; !slices.Equal(got, want) {
+		t.Fatalf("papers = %v, want %v", got, want)
+	}
+	if want := []CitationEdge{{From: "B", To: "A"}}; !slices.Equal(filtered.Citations, want) {
+		t.Errorf("citations = %v, want %v", filtered.Citations, want)
+	}
+	if want := []CitationEdge{{From: "B", To: "B"}}; !slices.Equal(filtered.SelfCitations, want) {
+		t.Errorf("self-citations = %v, want %v", filtered.SelfCitations, want)
+	}
+	if got := filtered.Papers[0].Citations; len(got) != 0 {
+		t.Errorf("A still cites %v", got)
+	}
+	if got := filtered.Papers[1].Citations; !slices.Equal(got, []string{"A"}) {
+		t.Errorf("B cites %v, want [A]", got)
+	}
+	if filtered.Stats.TotalPapers != 2 || filtered.Stats.TotalCitations != 1 {
+		t.Errorf("stats = %d papers, %d citations, want 2 and 1", filtered.Stats.TotalPapers, filtered.Stats.TotalCitations)
+	}
+}
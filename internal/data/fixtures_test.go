@@ -0,0 +1,216 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/arrow/go/v14/arrow"
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/memory"
+	"github.com/apache/arrow/go/v14/parquet"
+	"github.com/apache/arrow/go/v14/parquet/pqarrow"
+)
+
+// a papers parquet row for the test fixtures
+type fixturePaper struct {
+	ID       string
+	Title    string
+	Year     int
+	Abstract string
+	CorpusID int64
+}
+
+// a citations parquet row; both ends are ACL papers unless marked otherwise
+type fixtureCitation struct {
+	From, To   int64
+	FromNotACL bool
+	ToNotACL   bool
+	Intent     string
+	Context    string
+}
+
+// the optional columns a citations fixture carries
+type citationColumns struct {
+	Intent, Context bool
+}
+
+// writePapersParquet writes papers to dir/papers.parquet in row groups of
+// rowGroupSize rows (0 for one group) and returns the path.
+func writePapersParquet(t *testing.T, dir string, papers []fixturePaper, rowGroupSize int) string {
+	t.Helper()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "acl_id", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "title", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "author", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "year", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "abstract", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "corpus_paper_id", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	for _, p := range papers {
+		b.Field(0).(*array.StringBuilder).Append(p.ID)
+		b.Field(1).(*array.StringBuilder).Append(p.Title)
+		b.Field(2).(*array.StringBuilder).Append("Ada Lovelace and Alan Turing")
+		b.Field(3).(*array.Int64Builder).Append(int64(p.Year))
+		b.Field(4).(*array.StringBuilder).Append(p.Abstract)
+		b.Field(5).(*array.Int64Builder).Append(p.CorpusID)
+	}
+	path := filepath.Join(dir, "papers.parquet")
+	writeFixtureParquet(t, path, b.NewRecord(), rowGroupSize)
+	return path
+}
+
+// writeListAuthorPapersParquet writes papers to dir/papers.parquet with a
+// list<string> author column holding authors[i] for paper i; a nil list is
+// written as null, an empty string as a null element.
+func writeListAuthorPapersParquet(t *testing.T, dir string, papers []fixturePaper, authors [][]string) string {
+	t.Helper()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "acl_id", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "title", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "author", Type: arrow.ListOf(arrow.BinaryTypes.String), Nullable: true},
+		{Name: "year", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "corpus_paper_id", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	for i, p := range papers {
+		b.Field(0).(*array.StringBuilder).Append(p.ID)
+		b.Field(1).(*array.StringBuilder).Append(p.Title)
+		list := b.Field(2).(*array.ListBuilder)
+		if authors[i] == nil {
+			list.AppendNull()
+		} else {
+			list.Append(true)
+			names := list.ValueBuilder().(*array.StringBuilder)
+			for _, name := range authors[i] {
+				if name == "" {
+					names.AppendNull()
+				} else {
+					names.Append(name)
+				}
+			}
+		}
+		b.Field(3).(*array.Int64Builder).Append(int64(p.Year))
+		b.Field(4).(*array.Int64Builder).Append(p.CorpusID)
+	}
+	path := filepath.Join(dir, "papers.parquet")
+	writeFixtureParquet(t, path, b.NewRecord(), 0)
+	return path
+}
+
+// writeCitationsParquet writes citation rows with an intent column to
+// dir/name and returns the path.
+func writeCitationsParquet(t *testing.T, dir, name string, citations []fixtureCitation) string {
+	t.Helper()
+	return writeCitationColumnsParquet(t, dir, name, citations, citationColumns{Intent: true})
+}
+
+// writeCitationColumnsParquet writes citation rows with the optional
+// columns in cols to dir/name and returns the path.
+func writeCitationColumnsParquet(t *testing.T, dir, name string, citations []fixtureCitation, cols citationColumns) string {
+	t.Helper()
+	fields := []arrow.Field{
+		{Name: "citingpaperid", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "citedpaperid", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "is_citingpaperid_acl", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+		{Name: "is_citedpaperid_acl", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+	}
+	if cols.Intent {
+		fields = append(fields, arrow.Field{Name: "intent", Type: arrow.BinaryTypes.String, Nullable: true})
+	}
+	if cols.Context {
+		fields = append(fields, arrow.Field{Name: "context", Type: arrow.BinaryTypes.String, Nullable: true})
+	}
+	b := array.NewRecordBuilder(memory.DefaultAllocator, arrow.NewSchema(fields, nil))
+	defer b.Release()
+	for _, c := range citations {
+		b.Field(0).(*array.Int64Builder).Append(c.From)
+		b.Field(1).(*array.Int64Builder).Append(c.To)
+		b.Field(2).(*array.BooleanBuilder).Append(!c.FromNotACL)
+		b.Field(3).(*array.BooleanBuilder).Append(!c.ToNotACL)
+		next := 4
+		if cols.Intent {
+			b.Field(next).(*array.StringBuilder).Append(c.Intent)
+			next++
+		}
+		if cols.Context {
+			b.Field(next).(*array.StringBuilder).Append(c.Context)
+		}
+	}
+	path := filepath.Join(dir, name)
+	writeFixtureParquet(t, path, b.NewRecord(), 0)
+	return path
+}
+
+func writeFixtureParquet(t *testing.T, path string, record arrow.Record, rowGroupSize int) {
+	t.Helper()
+	defer record.Release()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var opts []parquet.WriterProperty
+	if rowGroupSize > 0 {
+		opts = append(opts, parquet.WithMaxRowGroupLength(int64(rowGroupSize)))
+	}
+	w, err := pqarrow.NewFileWriter(record.Schema(), f, parquet.NewWriterProperties(opts...), pqarrow.DefaultWriterProps())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeFixtureCorpus writes a small corpus to dir: six papers P1..P6 with
+// corpus ids 1..6, a chain of citations with intents, a self-citation, a
+// row to a non-ACL paper and a row to a corpus id that was never parsed.
+func writeFixtureCorpus(t *testing.T, dir string) (papersPath, citationsPath string) {
+	t.Helper()
+	var papers []fixturePaper
+	for i := 1; i <= 6; i++ {
+		papers = append(papers, fixturePaper{
+			ID:       fmt.Sprintf("P%d", i),
+			Title:    fmt.Sprintf("Paper %d", i),
+			Year:     2000 + i,
+			Abstract: fmt.Sprintf("Abstract of paper %d.", i),
+			CorpusID: int64(i),
+		})
+	}
+	citations := []fixtureCitation{
+		{From: 2, To: 1, Intent: "background"},
+		{From: 3, To: 1, Intent: "method"},
+		{From: 3, To: 2},
+		{From: 4, To: 3, Intent: "result"},
+		{From: 5, To: 3},
+		{From: 6, To: 5},
+		{From: 6, To: 1},
+		{From: 4, To: 4},
+		{From: 5, To: 900, ToNotACL: true},
+		{From: 6, To: 77},
+	}
+	return writePapersParquet(t, dir, papers, 0), writeCitationsParquet(t, dir, "citations.parquet", citations)
+}
+
+// parsedTestData builds parsed data from papers and edges, self-citations
+// split off as parse does.
+func parsedTestData(papers []Paper, citations ...CitationEdge) *ParsedData {
+	cross, self := splitSelfCitations(citations)
+	return &ParsedData{Papers: papers, Citations: cross, SelfCitations: self}
+}
+
+func paperIDs(papers []Paper) []string {
+	ids := make([]string, len(papers))
+	for i, paper := range papers {
+		ids[i] = paper.ID
+	}
+	return ids
+}
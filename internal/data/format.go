@@ -0,0 +1,271 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// serialization format of the pipeline's output files
+type Format string
+
+const (
+	FormatJSON    Format = "json"    // indented JSON, the default
+	FormatJSONL   Format = "jsonl"   // one record per line, for streaming and grep
+	FormatMsgpack Format = "msgpack" // compact binary, fastest to load
+	FormatBinary  Format = "binary"  // type-specific binary encoding (graphs); smallest and fastest
+)
+
+// BinaryMagic starts every FormatBinary file, so DecodeFile can detect it.
+const BinaryMagic = "ACLBIN\x00\x01"
+
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case FormatJSON, FormatJSONL, FormatMsgpack, FormatBinary:
+		return Format(name), nil
+	default:
+		return "", fmt.Errorf("unknown format %q (expected json, jsonl, msgpack or binary)", name)
+	}
+}
+
+// ParseFormatFor is ParseFormat for an output of v's type: it also rejects
+// the formats v has no encoding for, so a command fails before doing its
+// work rather than when it saves. v may be a nil pointer of the type.
+func ParseFormatFor(name string, v any) (Format, error) {
+	format, err := ParseFormat(name)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case FormatJSONL:
+		if _, ok := v.(JSONLMarshaler); !ok {
+			return "", fmt.Errorf("format jsonl is not available for this output (use json or msgpack)")
+		}
+	case FormatBinary:
+		if _, ok := v.(BinaryMarshaler); !ok {
+			return "", fmt.Errorf("format binary is only available for graph files ('build --format binary'); use msgpack")
+		}
+	}
+	return format, nil
+}
+
+// JSONLMarshaler is implemented by outputs that can be written one record
+// per line. Each line is a Record.
+type JSONLMarshaler interface {
+	MarshalJSONL(enc *json.Encoder) error
+}
+
+// JSONLUnmarshaler rebuilds an output from the records written by MarshalJSONL.
+type JSONLUnmarshaler interface {
+	UnmarshalJSONL(dec *json.Decoder) error
+}
+
+// BinaryMarshaler is implemented by outputs with a FormatBinary encoding.
+// The magic header is written by Encode.
+type BinaryMarshaler interface {
+	MarshalBinaryTo(w io.Writer) error
+}
+
+// BinaryUnmarshaler reads what MarshalBinaryTo wrote, after the magic header.
+type BinaryUnmarshaler interface {
+	UnmarshalBinaryFrom(r io.Reader) error
+}
+
+// one line of a JSONL file: Kind says what Data holds
+type Record struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// WriteRecord encodes v as one JSONL line of the given kind.
+func WriteRecord(enc *json.Encoder, kind string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return enc.Encode(Record{Kind: kind, Data: raw})
+}
+
+// EncodeFile writes v to outputPath in the given format. The file is
+// replaced atomically, so an interrupted write leaves the old one intact.
+func EncodeFile(outputPath string, v any, format Format) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	return AtomicWrite(outputPath, func(w io.Writer) error {
+		return Encode(w, v, format)
+	})
+}
+
+// AtomicWriteFile writes data to path + ".tmp" and renames it over path,
+// so readers, and an interrupted or failed write, see either the old file
+// or the complete new one.
+func AtomicWriteFile(path string, data []byte) error {
+	return AtomicWrite(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// AtomicWrite is AtomicWriteFile for output produced by a writer function.
+// Nothing replaces path when write fails.
+func AtomicWrite(path string, write func(w io.Writer) error) error {
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Encode writes v to w in the given format, as EncodeFile would.
+func Encode(w io.Writer, v any, format Format) error {
+	switch format {
+	case FormatJSON, "":
+		jsonData, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal to JSON: %v", err)
+		}
+		if _, err := w.Write(jsonData); err != nil {
+			return err
+		}
+	case FormatJSONL:
+		m, ok := v.(JSONLMarshaler)
+		if !ok {
+			return fmt.Errorf("%T cannot be written as jsonl", v)
+		}
+		if err := m.MarshalJSONL(json.NewEncoder(w)); err != nil {
+			return fmt.Errorf("failed to marshal to jsonl: %v", err)
+		}
+	case FormatMsgpack:
+		enc := msgpack.NewEncoder(w)
+		enc.SetCustomStructTag("json")
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to marshal to msgpack: %v", err)
+		}
+	case FormatBinary:
+		m, ok := v.(BinaryMarshaler)
+		if !ok {
+			return fmt.Errorf("%T cannot be written as binary; use msgpack", v)
+		}
+		if _, err := io.WriteString(w, BinaryMagic); err != nil {
+			return err
+		}
+		if err := m.MarshalBinaryTo(w); err != nil {
+			return fmt.Errorf("failed to marshal to binary: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	return nil
+}
+
+// DecodeFile reads a file written by EncodeFile into v, detecting the format
+// from its content.
+func DecodeFile(inputPath string, v any) error {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %v", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	format, err := detectFormat(r)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatBinary:
+		u, ok := v.(BinaryUnmarshaler)
+		if !ok {
+			return fmt.Errorf("%T cannot be read from binary", v)
+		}
+		if _, err := r.Discard(len(BinaryMagic)); err != nil {
+			return err
+		}
+		if err := u.UnmarshalBinaryFrom(r); err != nil {
+			return fmt.Errorf("failed to unmarshal binary data: %v", err)
+		}
+	case FormatJSONL:
+		u, ok := v.(JSONLUnmarshaler)
+		if !ok {
+			return fmt.Errorf("%T cannot be read from jsonl", v)
+		}
+		if err := u.UnmarshalJSONL(json.NewDecoder(r)); err != nil {
+			return fmt.Errorf("failed to unmarshal jsonl data: %v", err)
+		}
+	case FormatMsgpack:
+		dec := msgpack.NewDecoder(r)
+		dec.SetCustomStructTag("json")
+		if err := dec.Decode(v); err != nil {
+			return fmt.Errorf("failed to unmarshal msgpack data: %v", err)
+		}
+	default:
+		if err := json.NewDecoder(r).Decode(v); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON data: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// DetectFileFormat reports the format DecodeFile would read inputPath as.
+func DetectFileFormat(inputPath string) (Format, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+	defer f.Close()
+	return detectFormat(bufio.NewReader(f))
+}
+
+// detectFormat peeks at the start of r without consuming it. Binary files
+// start with BinaryMagic, JSONL files with a compact {"kind": record,
+// indented JSON with a bare brace; anything else is treated as msgpack.
+func detectFormat(r *bufio.Reader) (Format, error) {
+	head, err := r.Peek(8)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read file header: %v", err)
+	}
+
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+	switch {
+	case bytes.HasPrefix(head, []byte(BinaryMagic)):
+		return FormatBinary, nil
+	case bytes.HasPrefix(trimmed, []byte(`{"kind"`)):
+		return FormatJSONL, nil
+	case len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '['):
+		return FormatJSON, nil
+	case len(head) == 0:
+		return "", fmt.Errorf("file is empty")
+	default:
+		return FormatMsgpack, nil
+	}
+}
@@ -0,0 +1,38 @@
+package data
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsedDataRoundTripsInEveryFormat(t *testing.T) {
+	dir := t.TempDir()
+	papersPath, citationsPath := writeFixtureCorpus(t, dir)
+	parsed, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// corpus ids are not serialized; relink reads them from corpus_ids.json
+	for i := range parsed.Papers {
+		parsed.Papers[i].CorpusPaperID = 0
+	}
+
+	for _, format := range []Format{FormatJSON, FormatJSONL, FormatMsgpack} {
+		path := filepath.Join(dir, "papers."+string(format))
+		if err := SaveParsedData(parsed, path, format); err != nil {
+			t.Fatalf("save %s: %v", format, err)
+		}
+		if detected, err := DetectFileFormat(path); err != nil || detected != format {
+			t.Errorf("%s file detected as %q, %v", format, detected, err)
+		}
+		loaded, err := LoadParsedData(path)
+		if err != nil {
+			t.Fatalf("load %s: %v", format, err)
+		}
+		if !reflect.DeepEqual(loaded, parsed) {
+			t.Errorf("%s round trip changed the data:\n got %+v\nwant %+v", format, loaded, parsed)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package data
+
+import "testing"
+
+func TestParseFormatForRejectsUnsupportedFormats(t *testing.T) {
+	if _, err := ParseFormatFor("binary", (*ParsedData)(nil)); err == nil {
+		t.Error("binary accepted for parsed data, which has no binary encoding")
+	}
+	for _, name := range []string{"json", "jsonl", "msgpack"} {
+		if format, err := ParseFormatFor(name, (*ParsedData)(nil)); err != nil || string(format) != name {
+			t.Errorf("ParseFormatFor(%q) = %q, %v", name, format, err)
+		}
+	}
+	if _, err := ParseFormatFor("yaml", (*ParsedData)(nil)); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// the first bytes of every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// IsGzipped reports whether the file at path starts with the gzip magic
+// bytes, whatever its extension.
+func IsGzipped(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(gzipMagic))
+	if _, err := io.ReadFull(f, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+	return bytes.Equal(header, gzipMagic), nil
+}
+
+// openParquetFile opens a parquet file for the arrow reader, which needs to
+// seek. A gzipped file (such as a .parquet.gz download) is first
+// decompressed to a temporary file. The returned close function closes the
+// file and removes any temporary copy.
+func openParquetFile(path string) (*os.File, func(), error) {
+	gzipped, err := IsGzipped(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !gzipped {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, func() { f.Close() }, nil
+	}
+
+	tmp, err := gunzipToTemp(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompress %s: %v", path, err)
+	}
+	return tmp, func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}, nil
+}
+
+// gunzipToTemp decompresses path into a new temporary file and returns it
+// open and rewound.
+func gunzipToTemp(path string) (*os.File, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	zr, err := gzip.NewReader(bufio.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	tmp, err := os.CreateTemp("", "acl-*.parquet")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Decompressing %s to %s\n", path, tmp.Name())
+
+	written, err := io.Copy(tmp, zr)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	fmt.Printf("Decompressed %.2f MB\n", float64(written)/(1024*1024))
+	return tmp, nil
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// gzipFile writes a gzipped copy of src to dst.
+func gzipFile(t *testing.T, src, dst string) {
+	t.Helper()
+	raw, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseGzippedParquet(t *testing.T) {
+	dir := t.TempDir()
+	papersPath, citationsPath := writeFixtureCorpus(t, dir)
+	want, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// by extension, and by magic bytes alone
+	gzPapers := filepath.Join(dir, "papers.parquet.gz")
+	gzCitations := filepath.Join(dir, "citations-gzipped.parquet")
+	gzipFile(t, papersPath, gzPapers)
+	gzipFile(t, citationsPath, gzCitations)
+	for _, path := range []string{gzPapers, gzCitations} {
+		if gzipped, err := IsGzipped(path); err != nil || !gzipped {
+			t.Errorf("IsGzipped(%s) = %v, %v; want true", path, gzipped, err)
+		}
+	}
+	if gzipped, err := IsGzipped(papersPath); err != nil || gzipped {
+		t.Errorf("IsGzipped(%s) = %v, %v; want false", papersPath, gzipped, err)
+	}
+
+	// the decompressed copies are removed after parsing
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	got, err := ParseACLDataWithOptions(context.Background(), gzPapers, gzCitations, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("gzipped parse differs:\n%+v\nwant\n%+v", got, want)
+	}
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("temporary files left behind: %v", left)
+	}
+
+	// a truncated download fails, and cleans up too
+	raw, err := os.ReadFile(gzPapers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := filepath.Join(dir, "truncated.parquet.gz")
+	if err := os.WriteFile(truncated, raw[:len(raw)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseACLDataWithOptions(context.Background(), truncated, citationsPath, ParseOptions{}); err == nil {
+		t.Error("parsed a truncated gzipped file")
+	}
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("temporary files left behind after a failure: %v", left)
+	}
+}
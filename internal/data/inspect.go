@@ -0,0 +1,175 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// column information reported by dry-run and schema inspection
+type ColumnInfo struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	NullCount int      `json:"null_count,omitempty"`
+	Samples   []string `json:"samples,omitempty"`
+}
+
+// what a parse run would see, without writing anything
+type ParquetPreview struct {
+	Path          string            `json:"path"`
+	TotalRows     int64             `json:"total_rows"`
+	Columns       []ColumnInfo      `json:"columns"`
+	ColumnMapping map[string]string `json:"column_mapping"` // Paper field -> parquet column ("" when missing)
+	Papers        []Paper           `json:"papers"`
+}
+
+// PreviewPapersParquet opens the papers parquet and reports its schema, the
+// resolved column mapping and the first n parsed papers. The schema and row
+// count come from the file footer, and only as many row groups are decoded
+// as it takes to find n papers, so a large file is not read in full.
+func PreviewPapersParquet(parquetPath string, n int) (*ParquetPreview, error) {
+	arrowReader, closeFile, err := openParquetReader(parquetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	schema, err := arrowReader.Schema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parquet schema: %v", err)
+	}
+	pf := arrowReader.ParquetReader()
+
+	preview := &ParquetPreview{
+		Path:          parquetPath,
+		TotalRows:     pf.NumRows(),
+		ColumnMapping: make(map[string]string),
+	}
+
+	columnMap := make(map[string]int)
+	for i, field := range schema.Fields() {
+		columnMap[field.Name] = i
+		preview.Columns = append(preview.Columns, ColumnInfo{
+			Name: field.Name,
+			Type: field.Type.String(),
+		})
+	}
+	for _, pc := range paperColumns {
+		if _, ok := columnMap[pc.Column]; ok {
+			preview.ColumnMapping[pc.Field] = pc.Column
+		} else {
+			preview.ColumnMapping[pc.Field] = ""
+		}
+	}
+
+	leaves := make([]int, pf.MetaData().Schema.NumColumns())
+	for i := range leaves {
+		leaves[i] = i
+	}
+	for rowGroup := 0; rowGroup < pf.NumRowGroups() && len(preview.Papers) < n; rowGroup++ {
+		table, err := arrowReader.ReadRowGroups(context.Background(), leaves, []int{rowGroup})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row group %d: %v", rowGroup, err)
+		}
+		for rowIdx := 0; rowIdx < int(table.NumRows()) && len(preview.Papers) < n; rowIdx++ {
+			paper := parsePaperRow(table, columnMap, rowIdx)
+			if paper.ID == "" || paper.Title == "" {
+				continue
+			}
+			preview.Papers = append(preview.Papers, paper)
+		}
+		table.Release()
+	}
+
+	return preview, nil
+}
+
+// InspectParquetSchema reports every column of a parquet file with its arrow
+// type, null count and up to numSamples non-null sample values. It never
+// modifies the file.
+func InspectParquetSchema(parquetPath string, numSamples int) ([]ColumnInfo, int64, error) {
+	table, err := openParquetTable(context.Background(), parquetPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer table.Release()
+
+	columns := make([]ColumnInfo, 0, table.NumCols())
+	for i, field := range table.Schema().Fields() {
+		info := ColumnInfo{
+			Name: field.Name,
+			Type: field.Type.String(),
+		}
+
+		for _, chunk := range table.Column(i).Data().Chunks() {
+			info.NullCount += chunk.NullN()
+			for j := 0; j < chunk.Len() && len(info.Samples) < numSamples; j++ {
+				if chunk.IsNull(j) {
+					continue
+				}
+				sample := chunk.ValueStr(j)
+				if len(sample) > 60 {
+					sample = sample[:57] + "..."
+				}
+				info.Samples = append(info.Samples, sample)
+			}
+		}
+
+		columns = append(columns, info)
+	}
+
+	return columns, table.NumRows(), nil
+}
+
+func PrintParquetSchema(parquetPath string, columns []ColumnInfo, numRows int64) {
+	fmt.Printf("\n=== Schema: %s ===\n", parquetPath)
+	fmt.Printf("Rows: %d, columns: %d\n\n", numRows, len(columns))
+
+	for _, col := range columns {
+		fmt.Printf("%s\n", col.Name)
+		fmt.Printf("  type:  %s\n", col.Type)
+		fmt.Printf("  nulls: %d", col.NullCount)
+		if numRows > 0 {
+			fmt.Printf(" (%.1f%%)", float64(col.NullCount)/float64(numRows)*100)
+		}
+		fmt.Println()
+		for _, sample := range col.Samples {
+			fmt.Printf("  e.g.   %q\n", sample)
+		}
+	}
+	fmt.Println("========================")
+}
+
+func PrintParquetPreview(preview *ParquetPreview) {
+	fmt.Printf("\n=== Dry Run: %s ===\n", preview.Path)
+	fmt.Printf("Total rows: %d\n", preview.TotalRows)
+
+	fmt.Println("\nSchema:")
+	for _, col := range preview.Columns {
+		fmt.Printf("  %-24s %s\n", col.Name, col.Type)
+	}
+
+	fmt.Println("\nColumn mapping:")
+	for _, pc := range paperColumns {
+		column := preview.ColumnMapping[pc.Field]
+		if column == "" {
+			column = "(missing)"
+		}
+		fmt.Printf("  %-14s <- %s\n", pc.Field, column)
+	}
+
+	fmt.Printf("\nFirst %d parsed papers:\n", len(preview.Papers))
+	for i, paper := range preview.Papers {
+		fmt.Printf("\n%d. [%s] %s (%d)\n", i+1, paper.ID, paper.Title, paper.Year)
+		if len(paper.Authors) > 0 {
+			fmt.Printf("   Authors: %s\n", strings.Join(paper.Authors, ", "))
+		}
+		abstract := paper.Abstract
+		if len(abstract) > 120 {
+			abstract = abstract[:117] + "..."
+		}
+		fmt.Printf("   Abstract: %s\n", abstract)
+		fmt.Printf("   Corpus ID: %d, cited by: %d\n", paper.CorpusPaperID, paper.NumCitedBy)
+	}
+	fmt.Println("========================")
+}
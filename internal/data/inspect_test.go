@@ -0,0 +1,44 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPreviewPapersParquetReadsLeadingRowGroups(t *testing.T) {
+	var papers []fixturePaper
+	for i := 0; i < 10; i++ {
+		papers = append(papers, fixturePaper{
+			ID:       fmt.Sprintf("P%02d", i),
+			Title:    fmt.Sprintf("Paper %d", i),
+			Year:     2010 + i,
+			CorpusID: int64(100 + i),
+		})
+	}
+	papers[1].Title = "" // skipped, as parse would
+	path := writePapersParquet(t, t.TempDir(), papers, 2)
+
+	preview, err := PreviewPapersParquet(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.TotalRows != 10 {
+		t.Errorf("TotalRows = %d, want 10", preview.TotalRows)
+	}
+	if len(preview.Columns) != 6 {
+		t.Errorf("got %d columns, want 6", len(preview.Columns))
+	}
+	if preview.ColumnMapping["CorpusPaperID"] != "corpus_paper_id" || preview.ColumnMapping["DOI"] != "" {
+		t.Errorf("unexpected column mapping %v", preview.ColumnMapping)
+	}
+	var ids []string
+	for _, paper := range preview.Papers {
+		ids = append(ids, paper.ID)
+	}
+	if fmt.Sprint(ids) != "[P00 P02 P03]" {
+		t.Errorf("previewed papers %v, want [P00 P02 P03]", ids)
+	}
+	if preview.Papers[2].CorpusPaperID != 103 || preview.Papers[2].Year != 2013 {
+		t.Errorf("P03 parsed as %+v", preview.Papers[2])
+	}
+}
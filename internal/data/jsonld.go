@@ -0,0 +1,123 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ACLAnthologyURL is where papers without a DOI or URL are identified.
+const ACLAnthologyURL = "https://aclanthology.org/"
+
+// schema.org ScholarlyArticle, as written by 'export --format jsonld'
+type ScholarlyArticle struct {
+	Type          string    `json:"@type"`
+	ID            string    `json:"@id"`
+	Identifier    string    `json:"identifier"`
+	Name          string    `json:"name"`
+	Author        []ThingLD `json:"author,omitempty"`
+	DatePublished string    `json:"datePublished,omitempty"`
+	Abstract      string    `json:"abstract,omitempty"`
+	Publisher     *ThingLD  `json:"publisher,omitempty"`
+	IsPartOf      *ThingLD  `json:"isPartOf,omitempty"` // the proceedings (booktitle)
+	URL           string    `json:"url,omitempty"`
+	Citation      []LinkLD  `json:"citation,omitempty"`
+}
+
+// a typed, named schema.org node: a Person, Organization or CreativeWork
+type ThingLD struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// a reference to another node by its @id
+type LinkLD struct {
+	ID string `json:"@id"`
+}
+
+// PaperLDID is the @id of a paper: its DOI URL, else its URL, else its ACL
+// Anthology URL.
+func PaperLDID(paper Paper) string {
+	if doi := normalizeDOI(paper.DOI); doi != "" {
+		return "https://doi.org/" + doi
+	}
+	if paper.URL != "" {
+		return paper.URL
+	}
+	return ACLAnthologyURL + paper.ID
+}
+
+// ToScholarlyArticle maps a paper to schema.org. ids gives the @id of each
+// cited paper; citations to papers not in ids are linked by ACL Anthology
+// URL.
+func ToScholarlyArticle(paper Paper, ids map[string]string) ScholarlyArticle {
+	article := ScholarlyArticle{
+		Type:       "ScholarlyArticle",
+		ID:         PaperLDID(paper),
+		Identifier: normalizeDOI(paper.DOI),
+		Name:       paper.Title,
+		Abstract:   paper.Abstract,
+		URL:        paper.URL,
+	}
+	if article.Identifier == "" {
+		article.Identifier = article.ID
+	}
+	if article.URL == "" {
+		article.URL = ACLAnthologyURL + paper.ID
+	}
+
+	for _, author := range paper.Authors {
+		if author = strings.TrimSpace(author); author != "" {
+			article.Author = append(article.Author, ThingLD{Type: "Person", Name: author})
+		}
+	}
+	if paper.Year > 0 {
+		article.DatePublished = strconv.Itoa(paper.Year)
+	}
+	if paper.Publisher != "" {
+		article.Publisher = &ThingLD{Type: "Organization", Name: paper.Publisher}
+	}
+	if paper.BookTitle != "" {
+		article.IsPartOf = &ThingLD{Type: "CreativeWork", Name: paper.BookTitle}
+	}
+
+	for _, cited := range paper.Citations {
+		id, ok := ids[cited]
+		if !ok {
+			id = ACLAnthologyURL + cited
+		}
+		article.Citation = append(article.Citation, LinkLD{ID: id})
+	}
+	return article
+}
+
+// WriteJSONLD writes papers as one JSON-LD document: a schema.org @context
+// and a @graph of ScholarlyArticles.
+func WriteJSONLD(w io.Writer, papers []Paper) error {
+	ids := make(map[string]string, len(papers))
+	for _, paper := range papers {
+		ids[paper.ID] = PaperLDID(paper)
+	}
+
+	articles := make([]ScholarlyArticle, len(papers))
+	for i, paper := range papers {
+		articles[i] = ToScholarlyArticle(paper, ids)
+	}
+
+	doc := struct {
+		Context string             `json:"@context"`
+		Graph   []ScholarlyArticle `json:"@graph"`
+	}{
+		Context: "https://schema.org",
+		Graph:   articles,
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write JSON-LD: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToScholarlyArticle(t *testing.T) {
+	paper := Paper{
+		ID:        "P19-1001",
+		Title:     "Neural machine translation",
+		Authors:   []string{"Ada Lovelace", " ", "Alan Turing"},
+		Year:      2019,
+		Abstract:  "About translation.",
+		Publisher: "Association for Computational Linguistics",
+		BookTitle: "Proceedings of ACL",
+		DOI:       "doi:10.18653/V1/P19-1001",
+		Citations: []string{"W10-1000", "X99-9999"},
+	}
+	ids := map[string]string{"W10-1000": "https://example.org/w10-1000"}
+
+	want := ScholarlyArticle{
+		Type:          "ScholarlyArticle",
+		ID:            "https://doi.org/10.18653/v1/p19-1001",
+		Identifier:    "10.18653/v1/p19-1001",
+		Name:          "Neural machine translation",
+		Author:        []ThingLD{{Type: "Person", Name: "Ada Lovelace"}, {Type: "Person", Name: "Alan Turing"}},
+		DatePublished: "2019",
+		Abstract:      "About translation.",
+		Publisher:     &ThingLD{Type: "Organization", Name: "Association for Computational Linguistics"},
+		IsPartOf:      &ThingLD{Type: "CreativeWork", Name: "Proceedings of ACL"},
+		URL:           "https://aclanthology.org/P19-1001",
+		// cited papers outside ids get their ACL Anthology URL
+		Citation: []LinkLD{{ID: "https://example.org/w10-1000"}, {ID: "https://aclanthology.org/X99-9999"}},
+	}
+	if got := ToScholarlyArticle(paper, ids); !reflect.DeepEqual(got, want) {
+		t.Errorf("article\n%+v\nwant\n%+v", got, want)
+	}
+
+	// without a DOI the URL, then the ACL Anthology URL, identifies it
+	bare := Paper{ID: "W10-1000", Title: "Bare", URL: "https://example.org/w10-1000"}
+	if got := ToScholarlyArticle(bare, nil); got.ID != bare.URL || got.Identifier != bare.URL || got.URL != bare.URL {
+		t.Errorf("paper with a URL: @id %q, identifier %q, url %q", got.ID, got.Identifier, got.URL)
+	}
+	bare.URL = ""
+	if got := ToScholarlyArticle(bare, nil); got.ID != "https://aclanthology.org/W10-1000" || got.DatePublished != "" || got.Publisher != nil {
+		t.Errorf("bare paper: %+v", got)
+	}
+}
+
+func TestWriteJSONLDLinksCitationsByID(t *testing.T) {
+	papers := []Paper{
+		{ID: "A", Title: "A", DOI: "10.1/a"},
+		{ID: "B", Title: "B", Citations: []string{"A"}},
+	}
+	var out bytes.Buffer
+	if err := WriteJSONLD(&out, papers); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Context string             `json:"@context"`
+		Graph   []ScholarlyArticle `json:"@graph"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Context != "https://schema.org" || len(doc.Graph) != 2 {
+		t.Fatalf("document %+v", doc)
+	}
+	if got := doc.Graph[1].Citation; len(got) != 1 || got[0].ID != "https://doi.org/10.1/a" {
+		t.Errorf("B cites %v, want A by its DOI", got)
+	}
+}
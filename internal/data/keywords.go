@@ -0,0 +1,126 @@
+package data
+
+import (
+	"math"
+	"sort"
+)
+
+// document frequencies of title and abstract terms over a corpus
+type IDFTable struct {
+	DocCount int            `json:"doc_count"`
+	DocFreq  map[string]int `json:"doc_freq"` // term -> number of papers containing it
+
+	// count stopwords as terms instead of dropping them
+	KeepStopwords bool `json:"keep_stopwords"`
+}
+
+// BuildIDF counts, in one pass over the papers, how many papers each term
+// appears in.
+func BuildIDF(papers []Paper, keepStopwords bool) *IDFTable {
+	table := &IDFTable{
+		DocFreq:       make(map[string]int),
+		KeepStopwords: keepStopwords,
+	}
+
+	for _, paper := range papers {
+		seen := make(map[string]bool)
+		for _, term := range table.terms(paper) {
+			if !seen[term] {
+				seen[term] = true
+				table.DocFreq[term]++
+			}
+		}
+		table.DocCount++
+	}
+
+	return table
+}
+
+// IDF is the smoothed inverse document frequency of a term; terms the
+// corpus has never seen get the highest value.
+func (t *IDFTable) IDF(term string) float64 {
+	return math.Log(float64(t.DocCount+1)/float64(t.DocFreq[term]+1)) + 1
+}
+
+// TopKeywords returns the n terms of the paper's title and abstract with the
+// highest TF-IDF, ties broken alphabetically.
+func (t *IDFTable) TopKeywords(paper Paper, n int) []string {
+	counts := make(map[string]int)
+	for _, term := range t.terms(paper) {
+		counts[term]++
+	}
+
+	scores := make(map[string]float64, len(counts))
+	for term, count := range counts {
+		scores[term] = float64(count) * t.IDF(term)
+	}
+	return topTerms(scores, n)
+}
+
+// GroupKeywords returns the n terms that best describe a group of papers:
+// a term scores the number of papers in the group using it times its IDF,
+// so one paper repeating a word cannot carry the group.
+func (t *IDFTable) GroupKeywords(papers []Paper, n int) []string {
+	scores := make(map[string]float64)
+	for _, paper := range papers {
+		seen := make(map[string]bool)
+		for _, term := range t.terms(paper) {
+			if !seen[term] {
+				seen[term] = true
+				scores[term] += t.IDF(term)
+			}
+		}
+	}
+	return topTerms(scores, n)
+}
+
+// topTerms returns the n highest scoring terms, ties broken alphabetically.
+func topTerms(scores map[string]float64, n int) []string {
+	terms := make([]string, 0, len(scores))
+	for term := range scores {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if scores[terms[i]] != scores[terms[j]] {
+			return scores[terms[i]] > scores[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms
+}
+
+// terms tokenizes the title and abstract, dropping single characters, bare
+// numbers and, unless KeepStopwords is set, stopwords.
+func (t *IDFTable) terms(paper Paper) []string {
+	tokens := Tokenize(paper.Title + " " + paper.Abstract)
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if len(token) < 2 || isNumber(token) || (!t.KeepStopwords && IsStopword(token)) {
+			continue
+		}
+		kept = append(kept, token)
+	}
+	return kept
+}
+
+func isNumber(token string) bool {
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// AddKeywords stores the top n TF-IDF keywords of every paper in its
+// Keywords field, using the papers themselves as the corpus.
+func AddKeywords(parsedData *ParsedData, n int, keepStopwords bool) {
+	table := BuildIDF(parsedData.Papers, keepStopwords)
+	for i := range parsedData.Papers {
+		parsedData.Papers[i].Keywords = table.TopKeywords(parsedData.Papers[i], n)
+	}
+}
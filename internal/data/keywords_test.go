@@ -0,0 +1,58 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+)
+
+// three papers in which attention and parser are rare, translation, model
+// and parsing common
+var keywordPapers = []Paper{
+	{ID: "P1", Title: "Attention for translation", Abstract: "The attention model in 2019."},
+	{ID: "P2", Title: "Translation of parsing", Abstract: "A parser for translation."},
+	{ID: "P3", Title: "Parsing with a model", Abstract: "The model."},
+}
+
+func TestTopKeywords(t *testing.T) {
+	table := BuildIDF(keywordPapers, false)
+	if table.DocCount != 3 || table.DocFreq["translation"] != 2 || table.DocFreq["attention"] != 1 {
+		t.Fatalf("document frequencies %v over %d papers", table.DocFreq, table.DocCount)
+	}
+	if table.IDF("unseen") <= table.IDF("attention") || table.IDF("attention") <= table.IDF("model") {
+		t.Error("IDF does not fall as a term gets more common")
+	}
+
+	// term frequency times IDF; stopwords and numbers never count, and
+	// equal scores go alphabetically
+	want := map[string]string{
+		"P1": "[attention model translation]",
+		"P2": "[translation parser parsing]",
+		"P3": "[model parsing]",
+	}
+	for _, paper := range keywordPapers {
+		if got := fmt.Sprint(table.TopKeywords(paper, 3)); got != want[paper.ID] {
+			t.Errorf("%s keywords %s, want %s", paper.ID, got, want[paper.ID])
+		}
+	}
+
+	withStopwords := BuildIDF(keywordPapers, true)
+	if got := fmt.Sprint(withStopwords.TopKeywords(keywordPapers[0], 3)); got != "[attention in for]" {
+		t.Errorf("P1 keywords with stopwords %s, want [attention in for]", got)
+	}
+}
+
+func TestGroupKeywordsCountsPapersNotRepeats(t *testing.T) {
+	table := BuildIDF(keywordPapers, false)
+	// attention is repeated in P1 alone; translation is in both papers
+	if got := fmt.Sprint(table.GroupKeywords(keywordPapers[:2], 3)); got != "[translation attention parser]" {
+		t.Errorf("group keywords %s, want [translation attention parser]", got)
+	}
+}
+
+func TestAddKeywords(t *testing.T) {
+	parsed := &ParsedData{Papers: append([]Paper(nil), keywordPapers...)}
+	AddKeywords(parsed, 1, false)
+	if got := fmt.Sprint(parsed.Papers[0].Keywords, parsed.Papers[1].Keywords, parsed.Papers[2].Keywords); got != "[attention] [translation] [model]" {
+		t.Errorf("keywords %s, want [attention] [translation] [model]", got)
+	}
+}
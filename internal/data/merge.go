@@ -0,0 +1,123 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// a paper id present in both datasets with different titles
+type MergeConflict struct {
+	ID     string `json:"id"`
+	TitleA string `json:"title_a"`
+	TitleB string `json:"title_b"`
+}
+
+// MergeParsedData unions two datasets. Papers are deduplicated by ID,
+// keeping whichever record has more metadata filled in (a wins ties);
+// citations are deduplicated by (from, to), and a duplicate fills in intent
+// or context the first copy lacked. Paper citation lists and stats are
+// recomputed for the merged data. Papers sharing an ID but not a title are
+// returned as conflicts.
+func MergeParsedData(a, b *ParsedData) (*ParsedData, []MergeConflict) {
+	papers := make([]Paper, 0, len(a.Papers)+len(b.Papers))
+	index := make(map[string]int, len(a.Papers)+len(b.Papers))
+	var conflicts []MergeConflict
+
+	for _, dataset := range []*ParsedData{a, b} {
+		for _, paper := range dataset.Papers {
+			i, seen := index[paper.ID]
+			if !seen {
+				index[paper.ID] = len(papers)
+				papers = append(papers, paper)
+				continue
+			}
+
+			existing := papers[i]
+			if !strings.EqualFold(strings.TrimSpace(existing.Title), strings.TrimSpace(paper.Title)) {
+				conflicts = append(conflicts, MergeConflict{ID: paper.ID, TitleA: existing.Title, TitleB: paper.Title})
+			}
+			if paperRichness(paper) > paperRichness(existing) {
+				papers[i] = paper
+			}
+		}
+	}
+
+	var linked []CitationEdge
+	edgeIndex := make(map[[2]string]int, len(a.Citations)+len(b.Citations))
+	for _, dataset := range []*ParsedData{a, b} {
+		for _, citation := range slices.Concat(dataset.Citations, dataset.SelfCitations) {
+			key := [2]string{citation.From, citation.To}
+			i, seen := edgeIndex[key]
+			if !seen {
+				edgeIndex[key] = len(linked)
+				linked = append(linked, citation)
+				continue
+			}
+			if linked[i].Intent == "" {
+				linked[i].Intent = citation.Intent
+			}
+			if linked[i].Context == "" {
+				linked[i].Context = citation.Context
+			}
+		}
+	}
+	citations, selfCitations := splitSelfCitations(linked)
+
+	updatePaperCitations(papers, citations)
+
+	statsBuilder := newPaperStatsBuilder()
+	for _, paper := range papers {
+		statsBuilder.add(paper)
+	}
+	stats := statsBuilder.finish()
+	countCitations(stats, citations, selfCitations)
+
+	return &ParsedData{
+		Papers:        papers,
+		Citations:     citations,
+		SelfCitations: selfCitations,
+		Stats:         *stats,
+	}, conflicts
+}
+
+// paperRichness counts the filled-in metadata fields of a paper, with the
+// abstract length as a tie-breaker.
+func paperRichness(paper Paper) int {
+	fields := []bool{
+		paper.Title != "",
+		len(paper.Authors) > 0,
+		paper.Year != 0,
+		paper.Abstract != "",
+		paper.Publisher != "",
+		paper.BookTitle != "",
+		paper.DOI != "",
+		paper.URL != "",
+		paper.NumCitedBy != 0,
+		len(paper.AbstractEmbedding) > 0 || len(paper.Embeddings) > 0,
+	}
+
+	score := 0
+	for _, filled := range fields {
+		if filled {
+			score++
+		}
+	}
+	// fields dominate; the abstract only decides between equally full records
+	return score*1_000_000 + min(len(paper.Abstract), 999_999)
+}
+
+func PrintMergeConflicts(conflicts []MergeConflict, n int) {
+	if len(conflicts) == 0 {
+		return
+	}
+
+	fmt.Printf("\nWarning: %d papers share an ID but not a title:\n", len(conflicts))
+	for i, conflict := range conflicts {
+		if i >= n {
+			fmt.Printf("  ... and %d more\n", len(conflicts)-n)
+			break
+		}
+		fmt.Printf("  %s: %q vs %q\n", conflict.ID, conflict.TitleA, conflict.TitleB)
+	}
+}
@@ -0,0 +1,90 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeDisjointDatasets(t *testing.T) {
+	a := parsedTestData(
+		[]Paper{{ID: "A1", Title: "One", Year: 2001}, {ID: "A2", Title: "Two", Year: 2002}},
+		CitationEdge{From: "A2", To: "A1"},
+	)
+	b := parsedTestData(
+		[]Paper{{ID: "B1", Title: "Three", Year: 2003}},
+		CitationEdge{From: "B1", To: "B1"},
+	)
+
+	merged, conflicts := MergeParsedData(a, b)
+	if len(conflicts) != 0 {
+		t.Errorf("conflicts = %v, want none", conflicts)
+	}
+	if got, want := paperIDs(merged.Papers), []string{"A1", "A2", "B1"}; !slices.Equal(got, want) {
+		t.Errorf("papers = %v, want %v", got, want)
+	}
+	if want := []CitationEdge{{From: "A2", To: "A1"}}; !slices.Equal(merged.Citations, want) {
+		t.Errorf("citations = %v, want %v", merged.Citations, want)
+	}
+	if want := []CitationEdge{{From: "B1", To: "B1"}}; !slices.Equal(merged.SelfCitations, want) {
+		t.Errorf("self-citations = %v, want %v", merged.SelfCitations, want)
+	}
+	if merged.Stats.TotalPapers != 3 || merged.Stats.TotalCitations != 1 {
+		t.Errorf("stats = %d papers, %d citations, want 3 and 1", merged.Stats.TotalPapers, merged.Stats.TotalCitations)
+	}
+}
+
+func TestMergeOverlappingDatasets(t *testing.T) {
+	a := parsedTestData(
+		[]Paper{
+			{ID: "P1", Title: "Shared", Year: 2001},
+			{ID: "P2", Title: "Sparse"},
+			{ID: "P3", Title: "Only in a", Year: 2003},
+		},
+		CitationEdge{From: "P2", To: "P1"},
+		CitationEdge{From: "P3", To: "P1", Intent: "method"},
+	)
+	b := parsedTestData(
+		[]Paper{
+			{ID: "P1", Title: " shared ", Year: 2001},
+			{ID: "P2", Title: "Renamed", Year: 2002, Abstract: "more metadata"},
+			{ID: "P4", Title: "Only in b", Year: 2004},
+		},
+		CitationEdge{From: "P2", To: "P1", Intent: "background", Context: "intro"},
+		CitationEdge{From: "P3", To: "P1", Intent: "result"},
+		CitationEdge{From: "P4", To: "P2"},
+	)
+
+	merged, conflicts := MergeParsedData(a, b)
+
+	if got, want := paperIDs(merged.Papers), []string{"P1", "P2", "P3", "P4"}; !slices.Equal(got, want) {
+		t.Fatalf("papers = %v, want %v", got, want)
+	}
+	if merged.Papers[0].Title != "Shared" {
+		t.Errorf("P1 title = %q, want a's record on a tie", merged.Papers[0].Title)
+	}
+	if merged.Papers[1].Abstract != "more metadata" {
+		t.Errorf("P2 = %+v, want b's richer record", merged.Papers[1])
+	}
+	// titles differing only in case and spacing are not a conflict
+	if want := []MergeConflict{{ID: "P2", TitleA: "Sparse", TitleB: "Renamed"}}; !slices.Equal(conflicts, want) {
+		t.Errorf("conflicts = %v, want %v", conflicts, want)
+	}
+
+	want := []CitationEdge{
+		{From: "P2", To: "P1", Intent: "background", Context: "intro"}, // filled in from b
+		{From: "P3", To: "P1", Intent: "method"},                       // a's intent kept
+		{From: "P4", To: "P2"},
+	}
+	if !slices.Equal(merged.Citations, want) {
+		t.Errorf("citations = %v, want %v", merged.Citations, want)
+	}
+	if got := merged.Papers[0].Citations; len(got) != 0 {
+		t.Errorf("P1 cites %v, want nothing", got)
+	}
+	if got := merged.Papers[3].Citations; !slices.Equal(got, []string{"P2"}) {
+		t.Errorf("P4 cites %v, want [P2]", got)
+	}
+	if merged.Stats.TotalPapers != 4 || merged.Stats.TotalCitations != 3 {
+		t.Errorf("stats = %d papers, %d citations, want 4 and 3", merged.Stats.TotalPapers, merged.Stats.TotalCitations)
+	}
+}
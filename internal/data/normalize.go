@@ -0,0 +1,59 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+)
+
+// prefixes that wrap a bare DOI, checked after lowercasing
+var doiPrefixes = []string{
+	"https://doi.org/",
+	"http://doi.org/",
+	"https://dx.doi.org/",
+	"http://dx.doi.org/",
+	"doi.org/",
+	"dx.doi.org/",
+	"doi:",
+}
+
+// normalizeDOI reduces the common spellings of a DOI ("https://doi.org/10.x",
+// "doi: 10.x", "10.X") to the bare lowercase form "10.x". DOIs are case
+// insensitive, so lowercasing is safe.
+func normalizeDOI(s string) string {
+	doi := strings.ToLower(strings.TrimSpace(s))
+	for _, prefix := range doiPrefixes {
+		if strings.HasPrefix(doi, prefix) {
+			doi = strings.TrimSpace(doi[len(prefix):])
+			break
+		}
+	}
+	if unescaped, err := url.PathUnescape(doi); err == nil {
+		doi = unescaped
+	}
+	return strings.TrimSpace(doi)
+}
+
+// normalizeURL trims the URL, adds a missing https scheme, lowercases the
+// scheme and host, and drops any fragment and trailing slash. Strings that
+// do not parse as a URL are only trimmed.
+func normalizeURL(s string) string {
+	raw := strings.TrimSpace(s)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(s)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
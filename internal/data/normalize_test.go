@@ -0,0 +1,42 @@
+package data
+
+import "testing"
+
+func TestNormalizeDOI(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"10.18653/v1/P19-1001", "10.18653/v1/p19-1001"},
+		{"https://doi.org/10.18653/v1/P19-1001", "10.18653/v1/p19-1001"},
+		{"HTTPS://DOI.ORG/10.18653/V1/P19-1001", "10.18653/v1/p19-1001"},
+		{"http://dx.doi.org/10.3115/1073083.1073135", "10.3115/1073083.1073135"},
+		{"doi.org/10.3115/1073083.1073135", "10.3115/1073083.1073135"},
+		{"doi:10.3115/1073083.1073135", "10.3115/1073083.1073135"},
+		{"  DOI: 10.3115/1073083.1073135  ", "10.3115/1073083.1073135"},
+		{"https://doi.org/10.1000/a%3Cb%3E", "10.1000/a<b>"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeDOI(tt.in); got != tt.want {
+			t.Errorf("normalizeDOI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://aclanthology.org/P19-1001/", "https://aclanthology.org/P19-1001"},
+		{"HTTPS://ACLAnthology.org/P19-1001", "https://aclanthology.org/P19-1001"},
+		{"aclanthology.org/P19-1001.pdf#page=2", "https://aclanthology.org/P19-1001.pdf"},
+		{" http://example.org/a?b=c ", "http://example.org/a?b=c"},
+		{"not a url", "not a url"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeURL(tt.in); got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
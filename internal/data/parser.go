@@ -4,14 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/apache/arrow/go/v14/arrow"
 	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/memory"
 	"github.com/apache/arrow/go/v14/parquet/file"
 	"github.com/apache/arrow/go/v14/parquet/pqarrow"
+	"golang.org/x/sync/errgroup"
 )
 
 // metadata of each paper
@@ -27,23 +30,97 @@ type Paper struct {
 	URL               string    `json:"url"`
 	NumCitedBy        int       `json:"num_cited_by"`
 	Citations         []string  `json:"citations"`
+	Keywords          []string  `json:"keywords,omitempty"` // top TF-IDF terms, from 'parse --keywords'
 	CorpusPaperID     int64     `json:"-"`
+	CorpusAliases     []int64   `json:"-"` // corpus ids of rows dropped as repeats of this ID
 	AbstractEmbedding []float32 `json:"abstract_embedding,omitempty"`
+
+	// embeddings by source ("abstract", "fulltext", "title", ...); an
+	// "abstract" entry takes precedence over AbstractEmbedding
+	Embeddings map[string][]float32 `json:"embeddings,omitempty"`
+}
+
+const DefaultEmbeddingField = "abstract"
+
+// Embedding returns the paper's embedding from the given source, falling
+// back to AbstractEmbedding for "abstract" (or an empty field) so data
+// written before Embeddings existed keeps working. It returns nil when the
+// paper has no such embedding.
+func (p Paper) Embedding(field string) []float32 {
+	if field == "" {
+		field = DefaultEmbeddingField
+	}
+	if embedding, ok := p.Embeddings[field]; ok && len(embedding) > 0 {
+		return embedding
+	}
+	if field == DefaultEmbeddingField {
+		return p.AbstractEmbedding
+	}
+	return nil
 }
 
 type CitationEdge struct {
-	From string `json:"from"`
-	To   string `json:"to"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Intent  string `json:"intent,omitempty"`  // e.g. background, method, comparison (when the data has it)
+	Context string `json:"context,omitempty"` // section or sentence the citation appears in
 }
 
 // parsing statistics
 type ParseStats struct {
 	TotalPapers    int `json:"total_papers"`
 	TotalCitations int `json:"total_citations"`
+	SelfCitations  int `json:"self_citations"` // kept apart in ParsedData.SelfCitations
+	DuplicateIDs   int `json:"duplicate_ids"`  // paper rows dropped for repeating an earlier ID
 	YearRange      struct {
 		Min int `json:"min_year"`
 		Max int `json:"max_year"`
 	} `json:"year_range"`
+	Abstracts AbstractStats      `json:"abstracts"`
+	Links     CitationLinkReport `json:"citation_links"`
+}
+
+// what happened to each raw citation row: linked into an edge, or why not
+type CitationLinkReport struct {
+	TotalRows  int `json:"total_rows"`
+	Linked     int `json:"linked"`
+	Unreadable int `json:"unreadable"` // missing ACL flags or corpus ids
+
+	// rows dropped because an endpoint is not an ACL paper
+	CitingNotACL int `json:"citing_not_acl"`
+	CitedNotACL  int `json:"cited_not_acl"`
+	NeitherACL   int `json:"neither_acl"`
+
+	// ACL-to-ACL rows whose corpus ids are not among the parsed papers
+	CitingNotFound int `json:"citing_not_found"`
+	CitedNotFound  int `json:"cited_not_found"`
+	NeitherFound   int `json:"neither_found"`
+}
+
+// Yield is the share of raw citation rows that became edges.
+func (r CitationLinkReport) Yield() float64 {
+	if r.TotalRows == 0 {
+		return 0
+	}
+	return float64(r.Linked) / float64(r.TotalRows)
+}
+
+// abstract length statistics over parsed papers; lengths only count
+// non-empty abstracts
+type AbstractStats struct {
+	Empty         int     `json:"empty"`
+	MeanChars     float64 `json:"mean_chars"`
+	MedianChars   int     `json:"median_chars"`
+	MeanWords     float64 `json:"mean_words"`
+	MedianWords   int     `json:"median_words"`
+	LongestPaper  string  `json:"longest_paper"`
+	LongestChars  int     `json:"longest_chars"`
+	ShortestPaper string  `json:"shortest_paper"`
+	ShortestChars int     `json:"shortest_chars"`
+
+	// abstracts cut by 'parse --max-abstract-chars'; the lengths above are
+	// measured before truncation
+	Truncated int `json:"truncated,omitempty"`
 }
 
 // Accumulation of all data
@@ -51,60 +128,117 @@ type ParsedData struct {
 	Papers    []Paper        `json:"papers"`
 	Citations []CitationEdge `json:"citations"`
 	Stats     ParseStats     `json:"stats"`
+
+	// papers citing themselves, kept out of Citations and the papers'
+	// citation lists; only the graph build decides what to do with them
+	SelfCitations []CitationEdge `json:"self_citations,omitempty"`
+
+	// where each citation came from, with ParseOptions.Provenance; saved
+	// separately by SaveProvenance
+	Provenance *Provenance `json:"-"`
 }
 
+// a citation row from the parquet, before corpus ids are linked to acl ids
+type rawCitation struct {
+	Row      int // row index in the parquet
+	CitingID int64
+	CitedID  int64
+	Intent   string
+	Context  string
+}
+
+type ParseOptions struct {
+	MaxPapers int // 0 = all
+
+	// record the parquet row behind each citation edge, keeping at most
+	// ProvenanceLimit edges (0 = no limit)
+	Provenance      bool
+	ProvenanceLimit int
+}
+
+// rows read between checks for cancellation
+const cancelCheckRows = 10000
+
 func ParseACLData(papersPath, citationsPath string, maxPapers int) (*ParsedData, error) {
+	return ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{MaxPapers: maxPapers})
+}
+
+// ParseACLDataWithOptions parses the papers and citations files. When ctx
+// is cancelled it stops reading and returns an error saying how far it got.
+func ParseACLDataWithOptions(ctx context.Context, papersPath, citationsPath string, opts ParseOptions) (*ParsedData, error) {
+	maxPapers := opts.MaxPapers
+
 	fmt.Println("--- Starting Paper Parsing ---")
-	papers, stats, err := parsePapersParquet(papersPath, maxPapers)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse papers: %v", err)
-	}
 
-	// build a map to link the corpus_id to the acl_id
-	corpusToACL := make(map[int64]string)
-	for _, paper := range papers {
-		if paper.CorpusPaperID != 0 && paper.ID != "" {
-			corpusToACL[paper.CorpusPaperID] = paper.ID
+	// the two files are independent until the corpus_id -> acl_id join,
+	// so read them concurrently
+	var (
+		papers     []Paper
+		stats      *ParseStats
+		rawRows    []rawCitation
+		linkReport CitationLinkReport
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		papers, stats, err = parsePapersParquet(gctx, papersPath, maxPapers)
+		if err != nil {
+			return fmt.Errorf("failed to parse papers: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		rawRows, linkReport, err = readCitationRows(gctx, citationsPath)
+		if err != nil {
+			return fmt.Errorf("failed to parse citations: %w", err)
 		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
+	return joinParsed(papers, stats, rawRows, linkReport, citationsPath, opts), nil
+}
 
-	citations, err := parseCitationsParquet(citationsPath, corpusToACL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse citations: %v", err)
+// joinParsed links the citation rows to the papers and fills in the stats
+// and citation lists once both files have been read.
+func joinParsed(papers []Paper, stats *ParseStats, rawRows []rawCitation, linkReport CitationLinkReport, citationsPath string, opts ParseOptions) *ParsedData {
+	var provenance *Provenance
+	if opts.Provenance {
+		provenance = NewProvenance(citationsPath, opts.ProvenanceLimit)
+	}
+	citations, selfCitations := splitSelfCitations(linkCitations(rawRows, &linkReport, buildCorpusToACL(papers), provenance))
+	if provenance != nil {
+		years := make(map[string]int, len(papers))
+		for _, paper := range papers {
+			years[paper.ID] = paper.Year
+		}
+		for i := range provenance.Edges {
+			provenance.Edges[i].CitingYear = years[provenance.Edges[i].From]
+			provenance.Edges[i].CitedYear = years[provenance.Edges[i].To]
+		}
 	}
 
-	stats.TotalCitations = len(citations)
+	countCitations(stats, citations, selfCitations)
+	stats.Links = linkReport
 
 	updatePaperCitations(papers, citations)
 
 	return &ParsedData{
-		Papers:    papers,
-		Citations: citations,
-		Stats:     *stats,
-	}, nil
-}
-
-func parsePapersParquet(parquetPath string, maxPapers int) ([]Paper, *ParseStats, error) {
-
-	f, err := os.Open(parquetPath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open parquet file: %v", err)
-	}
-	defer f.Close()
-
-	pf, err := file.NewParquetReader(f)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create parquet reader: %v", err)
-	}
-
-	arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create arrow reader: %v", err)
+		Papers:        papers,
+		Citations:     citations,
+		SelfCitations: selfCitations,
+		Stats:         *stats,
+		Provenance:    provenance,
 	}
+}
 
-	table, err := arrowReader.ReadTable(context.Background())
+func parsePapersParquet(ctx context.Context, parquetPath string, maxPapers int) ([]Paper, *ParseStats, error) {
+	table, err := openParquetTable(ctx, parquetPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read table: %v", err)
+		return nil, nil, err
 	}
 	defer table.Release()
 
@@ -116,157 +250,470 @@ func parsePapersParquet(parquetPath string, maxPapers int) ([]Paper, *ParseStats
 	fmt.Printf("Parquet file contains %d rows. Processing %d.\n", table.NumRows(), numRows)
 
 	papers := make([]Paper, 0, numRows)
-	stats := &ParseStats{}
-	minYear, maxYear := 9999, 0
+	index := make(map[string]int, numRows)
+	var duplicates []string
 
-	columnMap := make(map[string]int)
-	for i, field := range table.Schema().Fields() {
-		columnMap[field.Name] = i
+	columnMap := buildColumnMap(table)
+	if colIdx, ok := columnMap["author"]; ok && isListColumn(table.Column(colIdx)) {
+		fmt.Println("Author column is a list; reading one author per element.")
 	}
 
 	for rowIdx := 0; rowIdx < numRows; rowIdx++ {
-		paper := Paper{}
-		for colName, colIdx := range columnMap {
-			column := table.Column(colIdx)
-
-			switch colName {
-			case "acl_id":
-				if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
-					paper.ID = val
-				}
-			case "title":
-				if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
-					paper.Title = val
-				}
-			case "author":
-				if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
-					paper.Authors = parseAuthors(val)
-				}
-			case "year":
-				if val, err := getInt64ValueFromColumn(column, rowIdx); err == nil && val > 1900 && val < 2030 {
-					paper.Year = int(val)
-					if paper.Year < minYear {
-						minYear = paper.Year
-					}
-					if paper.Year > maxYear {
-						maxYear = paper.Year
-					}
-				}
-			case "abstract":
-				if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
-					paper.Abstract = val
-				}
-			case "publisher":
-				if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
-					paper.Publisher = val
-				}
-			case "booktitle":
-				if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
-					paper.BookTitle = val
-				}
-			case "doi":
-				if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
-					paper.DOI = val
-				}
-			case "url":
-				if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
-					paper.URL = val
-				}
-			case "numcitedby":
-				if val, err := getInt64ValueFromColumn(column, rowIdx); err == nil {
-					paper.NumCitedBy = int(val)
-				}
-			case "corpus_paper_id":
-				if val, err := getInt64ValueFromColumn(column, rowIdx); err == nil {
-					paper.CorpusPaperID = val
-				}
+		if rowIdx%cancelCheckRows == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, fmt.Errorf("cancelled after %d of %d paper rows: %w", rowIdx, numRows, err)
 			}
 		}
+		paper := parsePaperRow(table, columnMap, rowIdx)
 
 		if paper.ID == "" || paper.Title == "" {
 			continue
 		}
+
+		// two rows with one ID would become two nodes whose degrees and
+		// corpus mapping overwrite each other; keep the fuller record
+		if i, seen := index[paper.ID]; seen {
+			duplicates = append(duplicates, paper.ID)
+			papers[i] = keepFullerRow(papers[i], paper)
+			continue
+		}
+		index[paper.ID] = len(papers)
 		papers = append(papers, paper)
 	}
 
-	stats.TotalPapers = len(papers)
-	if minYear != 9999 {
-		stats.YearRange.Min = minYear
-		stats.YearRange.Max = maxYear
+	statsBuilder := newPaperStatsBuilder()
+	for _, paper := range papers {
+		statsBuilder.add(paper)
+	}
+	stats := statsBuilder.finish()
+	stats.DuplicateIDs = len(duplicates)
+	if len(duplicates) > 0 {
+		examples := duplicates[:min(len(duplicates), 5)]
+		fmt.Printf("Warning: %d rows repeat an earlier paper ID and were dropped, keeping the record with more metadata; their corpus ids still link to it (e.g. %s)\n",
+			len(duplicates), strings.Join(examples, ", "))
 	}
 
 	fmt.Printf("Successfully parsed %d papers.\n", len(papers))
 	return papers, stats, nil
 }
 
-func parseCitationsParquet(filePath string, corpusToACL map[int64]string) ([]CitationEdge, error) {
+// keepFullerRow returns whichever of two rows with one ID has more
+// metadata. The other row's corpus id is added to its CorpusAliases,
+// so citations made to or by that row still link to the paper.
+func keepFullerRow(existing, row Paper) Paper {
+	kept, dropped := existing, row
+	if paperRichness(row) > paperRichness(existing) {
+		kept, dropped = row, existing
+	}
+	kept.CorpusAliases = existing.CorpusAliases
+	if id := dropped.CorpusPaperID; id != 0 && id != kept.CorpusPaperID && !slices.Contains(kept.CorpusAliases, id) {
+		kept.CorpusAliases = append(kept.CorpusAliases, id)
+	}
+	return kept
+}
+
+// paperStatsBuilder accumulates the paper side of ParseStats one paper at a
+// time.
+type paperStatsBuilder struct {
+	stats                        ParseStats
+	minYear, maxYear             int
+	abstractChars, abstractWords []int
+}
+
+func newPaperStatsBuilder() *paperStatsBuilder {
+	return &paperStatsBuilder{minYear: 9999}
+}
+
+func (b *paperStatsBuilder) add(paper Paper) {
+	b.stats.TotalPapers++
+
+	if abstract := strings.TrimSpace(paper.Abstract); abstract == "" {
+		b.stats.Abstracts.Empty++
+	} else {
+		chars := len([]rune(abstract))
+		b.abstractChars = append(b.abstractChars, chars)
+		b.abstractWords = append(b.abstractWords, len(strings.Fields(abstract)))
+		if chars > b.stats.Abstracts.LongestChars {
+			b.stats.Abstracts.LongestChars = chars
+			b.stats.Abstracts.LongestPaper = paper.ID
+		}
+		if b.stats.Abstracts.ShortestPaper == "" || chars < b.stats.Abstracts.ShortestChars {
+			b.stats.Abstracts.ShortestChars = chars
+			b.stats.Abstracts.ShortestPaper = paper.ID
+		}
+	}
+
+	if paper.Year != 0 {
+		if paper.Year < b.minYear {
+			b.minYear = paper.Year
+		}
+		if paper.Year > b.maxYear {
+			b.maxYear = paper.Year
+		}
+	}
+}
+
+func (b *paperStatsBuilder) finish() *ParseStats {
+	stats := b.stats
+	stats.Abstracts.MeanChars, stats.Abstracts.MedianChars = meanMedian(b.abstractChars)
+	stats.Abstracts.MeanWords, stats.Abstracts.MedianWords = meanMedian(b.abstractWords)
+	if b.minYear != 9999 {
+		stats.YearRange.Min = b.minYear
+		stats.YearRange.Max = b.maxYear
+	}
+	return &stats
+}
+
+// countCitations fills in the citation totals.
+func countCitations(stats *ParseStats, citations, selfCitations []CitationEdge) {
+	stats.TotalCitations = len(citations)
+	stats.SelfCitations = len(selfCitations)
+}
+
+// splitSelfCitations separates the citations of papers citing themselves
+// from the rest, keeping the order of both.
+func splitSelfCitations(citations []CitationEdge) (cross, self []CitationEdge) {
+	cross = make([]CitationEdge, 0, len(citations))
+	for _, citation := range citations {
+		if citation.From == citation.To {
+			self = append(self, citation)
+		} else {
+			cross = append(cross, citation)
+		}
+	}
+	return cross, self
+}
+
+func meanMedian(values []int) (float64, int) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	return float64(total) / float64(len(values)), sorted[len(sorted)/2]
+}
+
+// openParquetTable reads a whole parquet file into an arrow table. The caller
+// must Release the table.
+// readTable reads the whole table unless ctx is done. pqarrow's ReadTable
+// panics on the columns it has not read when its context is cancelled
+// mid-way, so it gets a context that never is, and cancellation is checked
+// around it instead.
+func readTable(ctx context.Context, reader *pqarrow.FileReader) (arrow.Table, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	table, err := reader.ReadTable(context.WithoutCancel(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		table.Release()
+		return nil, err
+	}
+	return table, nil
+}
+
+func openParquetTable(ctx context.Context, parquetPath string) (arrow.Table, error) {
+	arrowReader, closeFile, err := openParquetReader(parquetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	table, err := readTable(ctx, arrowReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table: %w", err)
+	}
+
+	return table, nil
+}
+
+// openParquetReader opens a parquet file for reading into arrow, after
+// checking its codecs are supported. Nothing but the footer is read yet.
+// The returned function closes the file.
+func openParquetReader(parquetPath string) (*pqarrow.FileReader, func(), error) {
+	f, closeFile, err := openParquetFile(parquetPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open parquet file: %v", err)
+	}
+
+	pf, err := file.NewParquetReader(f)
+	if err != nil {
+		closeFile()
+		return nil, nil, fmt.Errorf("failed to create parquet reader: %v", err)
+	}
+	if err := checkParquetCodecs(pf, parquetPath); err != nil {
+		closeFile()
+		return nil, nil, err
+	}
+
+	// nested (list) columns need an allocator; flat ones happen to work
+	// without
+	arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		closeFile()
+		return nil, nil, fmt.Errorf("failed to create arrow reader: %v", err)
+	}
+	return arrowReader, closeFile, nil
+}
+
+func buildColumnMap(table arrow.Table) map[string]int {
+	columnMap := make(map[string]int)
+	for i, field := range table.Schema().Fields() {
+		columnMap[field.Name] = i
+	}
+	return columnMap
+}
+
+// paperColumns lists the parquet columns the papers parser understands and
+// the Paper field each one fills.
+var paperColumns = []struct {
+	Column string
+	Field  string
+}{
+	{"acl_id", "ID"},
+	{"title", "Title"},
+	{"author", "Authors"},
+	{"year", "Year"},
+	{"abstract", "Abstract"},
+	{"publisher", "Publisher"},
+	{"booktitle", "BookTitle"},
+	{"doi", "DOI"},
+	{"url", "URL"},
+	{"numcitedby", "NumCitedBy"},
+	{"corpus_paper_id", "CorpusPaperID"},
+}
+
+func parsePaperRow(table arrow.Table, columnMap map[string]int, rowIdx int) Paper {
+	paper := Paper{}
+	for colName, colIdx := range columnMap {
+		column := table.Column(colIdx)
+
+		switch colName {
+		case "acl_id":
+			if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
+				paper.ID = val
+			}
+		case "title":
+			if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
+				paper.Title = val
+			}
+		case "author":
+			if isListColumn(column) {
+				if vals, err := getStringListValueFromColumn(column, rowIdx); err == nil {
+					paper.Authors = cleanAuthors(vals)
+				}
+			} else if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
+				paper.Authors = parseAuthors(val)
+			}
+		case "year":
+			if val, err := getInt64ValueFromColumn(column, rowIdx); err == nil && val > 1900 && val < 2030 {
+				paper.Year = int(val)
+			}
+		case "abstract":
+			if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
+				paper.Abstract = val
+			}
+		case "publisher":
+			if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
+				paper.Publisher = val
+			}
+		case "booktitle":
+			if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
+				paper.BookTitle = val
+			}
+		case "doi":
+			if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
+				paper.DOI = normalizeDOI(val)
+			}
+		case "url":
+			if val, err := getStringValueFromColumn(column, rowIdx); err == nil {
+				paper.URL = normalizeURL(val)
+			}
+		case "numcitedby":
+			if val, err := getInt64ValueFromColumn(column, rowIdx); err == nil {
+				paper.NumCitedBy = int(val)
+			}
+		case "corpus_paper_id":
+			if val, err := getInt64ValueFromColumn(column, rowIdx); err == nil {
+				paper.CorpusPaperID = val
+			}
+		}
+	}
+	return paper
+}
+
+// buildCorpusToACL maps each paper's corpus_id, and those of the rows
+// dropped as its duplicates, to its acl_id.
+func buildCorpusToACL(papers []Paper) map[int64]string {
+	corpusToACL := make(map[int64]string)
+	for _, paper := range papers {
+		if paper.ID == "" {
+			continue
+		}
+		for _, corpusID := range paper.CorpusAliases {
+			corpusToACL[corpusID] = paper.ID
+		}
+		if paper.CorpusPaperID != 0 {
+			corpusToACL[paper.CorpusPaperID] = paper.ID
+		}
+	}
+	return corpusToACL
+}
+
+// readCitationRows reads the ACL-to-ACL citation rows of the citations
+// parquet. It returns the rows and a link report counting the rows skipped
+// because an endpoint is not an ACL paper or a value is null.
+func readCitationRows(ctx context.Context, filePath string) ([]rawCitation, CitationLinkReport, error) {
 	fmt.Printf("Opening citations parquet file: %s\n", filePath)
 
-	f, err := os.Open(filePath)
+	f, closeFile, err := openParquetFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open citations parquet file: %v", err)
+		return nil, CitationLinkReport{}, fmt.Errorf("failed to open citations parquet file: %v", err)
 	}
-	defer f.Close()
+	defer closeFile()
 
 	pf, err := file.NewParquetReader(f)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create parquet reader for citations: %v", err)
+		return nil, CitationLinkReport{}, fmt.Errorf("failed to create parquet reader for citations: %v", err)
+	}
+	if err := checkParquetCodecs(pf, filePath); err != nil {
+		return nil, CitationLinkReport{}, err
 	}
 
-	arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, nil)
+	arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create arrow reader for citations: %v", err)
+		return nil, CitationLinkReport{}, fmt.Errorf("failed to create arrow reader for citations: %v", err)
 	}
 
-	table, err := arrowReader.ReadTable(context.Background())
+	table, err := readTable(ctx, arrowReader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read citations table: %v", err)
+		return nil, CitationLinkReport{}, fmt.Errorf("failed to read citations table: %w", err)
 	}
 	defer table.Release()
 
 	fmt.Printf("Citations file contains %d rows.\n", table.NumRows())
 
-	var citations []CitationEdge
-	skippedCitations := 0
+	var rows []rawCitation
+	report := CitationLinkReport{TotalRows: int(table.NumRows())}
 
-	colMap := make(map[string]int)
-	for i, field := range table.Schema().Fields() {
-		colMap[field.Name] = i
-	}
+	colMap := buildColumnMap(table)
 
 	citingIDCol := table.Column(colMap["citingpaperid"])
 	citedIDCol := table.Column(colMap["citedpaperid"])
 	isCitingACLCol := table.Column(colMap["is_citingpaperid_acl"])
 	isCitedACLCol := table.Column(colMap["is_citedpaperid_acl"])
 
+	// optional citation metadata, only present in some datasets
+	var intentCol, contextCol *arrow.Column
+	if idx, ok := colMap["intent"]; ok {
+		intentCol = table.Column(idx)
+	}
+	if idx, ok := colMap["context"]; ok {
+		contextCol = table.Column(idx)
+	}
+
 	for r := 0; r < int(table.NumRows()); r++ {
+		if r%cancelCheckRows == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, CitationLinkReport{}, fmt.Errorf("cancelled after %d of %d citation rows: %w", r, table.NumRows(), err)
+			}
+		}
 		isCitingACL, err1 := getBoolValueFromColumn(isCitingACLCol, r)
 		isCitedACL, err2 := getBoolValueFromColumn(isCitedACLCol, r)
-		if err1 != nil || err2 != nil || !isCitingACL || !isCitedACL {
-			skippedCitations++
+		if err1 != nil || err2 != nil {
+			report.Unreadable++
+			continue
+		}
+		switch {
+		case !isCitingACL && !isCitedACL:
+			report.NeitherACL++
+			continue
+		case !isCitingACL:
+			report.CitingNotACL++
+			continue
+		case !isCitedACL:
+			report.CitedNotACL++
 			continue
 		}
 
 		citingID, err1 := getInt64ValueFromColumn(citingIDCol, r)
 		citedID, err2 := getInt64ValueFromColumn(citedIDCol, r)
 		if err1 != nil || err2 != nil {
-			skippedCitations++
+			report.Unreadable++
 			continue
 		}
 
-		fromACLId, fromExists := corpusToACL[citingID]
-		toACLId, toExists := corpusToACL[citedID]
+		row := rawCitation{Row: r, CitingID: citingID, CitedID: citedID}
+		if intentCol != nil {
+			if val, err := getStringValueFromColumn(intentCol, r); err == nil {
+				row.Intent = strings.ToLower(strings.TrimSpace(val))
+			}
+		}
+		if contextCol != nil {
+			if val, err := getStringValueFromColumn(contextCol, r); err == nil {
+				row.Context = val
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, report, nil
+}
+
+// linkCitations turns raw citation rows into acl_id edges, dropping rows whose
+// endpoints are not in the corpus. Self-citations are kept so the graph
+// build can count them or keep them for analysis; the caller splits them
+// off with splitSelfCitations. Each linked row is
+// recorded in provenance when it is not nil.
+func linkCitations(rows []rawCitation, report *CitationLinkReport, corpusToACL map[int64]string, provenance *Provenance) []CitationEdge {
+	var citations []CitationEdge
 
-		if !fromExists || !toExists || fromACLId == toACLId {
-			skippedCitations++
+	for _, row := range rows {
+		fromACLId, fromExists := corpusToACL[row.CitingID]
+		toACLId, toExists := corpusToACL[row.CitedID]
+
+		switch {
+		case !fromExists && !toExists:
+			report.NeitherFound++
+			continue
+		case !fromExists:
+			report.CitingNotFound++
+			continue
+		case !toExists:
+			report.CitedNotFound++
 			continue
 		}
 
-		citations = append(citations, CitationEdge{From: fromACLId, To: toACLId})
+		citations = append(citations, CitationEdge{
+			From:    fromACLId,
+			To:      toACLId,
+			Intent:  row.Intent,
+			Context: row.Context,
+		})
+		if provenance != nil {
+			provenance.add(EdgeProvenance{
+				From:           fromACLId,
+				To:             toACLId,
+				Row:            row.Row,
+				CitingCorpusID: row.CitingID,
+				CitedCorpusID:  row.CitedID,
+			})
+		}
 	}
 
-	fmt.Printf("Successfully parsed %d valid citations (skipped %d).\n", len(citations), skippedCitations)
-	return citations, nil
+	report.Linked = len(citations)
+	fmt.Printf("Successfully parsed %d valid citations (skipped %d).\n", len(citations), report.TotalRows-report.Linked)
+	return citations
 }
 
 func findChunk(column *arrow.Column, rowIdx int) (chunk arrow.Array, localIndex int, err error) {
@@ -305,6 +752,59 @@ func getStringValueFromColumn(column *arrow.Column, rowIdx int) (string, error)
 	}
 }
 
+// isListColumn reports whether column holds list<...> values, as in parquet
+// exports that store authors as a list of strings.
+func isListColumn(column *arrow.Column) bool {
+	switch column.DataType().ID() {
+	case arrow.LIST, arrow.LARGE_LIST:
+		return true
+	}
+	return false
+}
+
+// getStringListValueFromColumn reads a list<string> (or list<binary>) value.
+// Null elements are skipped.
+func getStringListValueFromColumn(column *arrow.Column, rowIdx int) ([]string, error) {
+	chunk, localIdx, err := findChunk(column, rowIdx)
+	if err != nil {
+		return nil, err
+	}
+	if chunk.IsNull(localIdx) {
+		return nil, fmt.Errorf("value is null")
+	}
+
+	var values arrow.Array
+	var start, end int64
+	switch arr := chunk.(type) {
+	case *array.List:
+		values = arr.ListValues()
+		start, end = arr.ValueOffsets(localIdx)
+	case *array.LargeList:
+		values = arr.ListValues()
+		start, end = arr.ValueOffsets(localIdx)
+	default:
+		return nil, fmt.Errorf("column is not a list type")
+	}
+
+	list := make([]string, 0, end-start)
+	for i := int(start); i < int(end); i++ {
+		if values.IsNull(i) {
+			continue
+		}
+		switch elems := values.(type) {
+		case *array.String:
+			list = append(list, elems.Value(i))
+		case *array.LargeString:
+			list = append(list, elems.Value(i))
+		case *array.Binary:
+			list = append(list, string(elems.Value(i)))
+		default:
+			return nil, fmt.Errorf("list elements are not a string/binary type")
+		}
+	}
+	return list, nil
+}
+
 func getInt64ValueFromColumn(column *arrow.Column, rowIdx int) (int64, error) {
 	chunk, localIdx, err := findChunk(column, rowIdx)
 	if err != nil {
@@ -354,7 +854,11 @@ func parseAuthors(authorStr string) []string {
 	if len(authors) == 0 {
 		authors = []string{authorStr}
 	}
+	return cleanAuthors(authors)
+}
 
+// cleanAuthors trims author names and drops empty and single-character ones.
+func cleanAuthors(authors []string) []string {
 	cleanedAuthors := make([]string, 0, len(authors))
 	for _, author := range authors {
 		cleaned := strings.TrimSpace(author)
@@ -368,6 +872,9 @@ func parseAuthors(authorStr string) []string {
 func updatePaperCitations(papers []Paper, citations []CitationEdge) {
 	citationMap := make(map[string][]string)
 	for _, citation := range citations {
+		if citation.From == citation.To {
+			continue
+		}
 		citationMap[citation.From] = append(citationMap[citation.From], citation.To)
 	}
 	for i := range papers {
@@ -380,37 +887,109 @@ func updatePaperCitations(papers []Paper, citations []CitationEdge) {
 	}
 }
 
-func SaveParsedData(data *ParsedData, outputPath string) error {
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %v", err)
-	}
-	jsonData, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal data to JSON: %v", err)
-	}
-	return os.WriteFile(outputPath, jsonData, 0644)
+func SaveParsedData(data *ParsedData, outputPath string, format Format) error {
+	return EncodeFile(outputPath, data, format)
 }
 
 func LoadParsedData(inputPath string) (*ParsedData, error) {
-	jsonData, err := os.ReadFile(inputPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read JSON file: %v", err)
-	}
 	var data ParsedData
-	if err := json.Unmarshal(jsonData, &data); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON data: %v", err)
+	if err := DecodeFile(inputPath, &data); err != nil {
+		return nil, err
 	}
 	return &data, nil
 }
 
+// MarshalJSONL writes the stats, then one line per paper, per citation and
+// per self-citation.
+func (d *ParsedData) MarshalJSONL(enc *json.Encoder) error {
+	if err := WriteRecord(enc, "stats", d.Stats); err != nil {
+		return err
+	}
+	for _, paper := range d.Papers {
+		if err := WriteRecord(enc, "paper", paper); err != nil {
+			return err
+		}
+	}
+	for _, citation := range d.Citations {
+		if err := WriteRecord(enc, "citation", citation); err != nil {
+			return err
+		}
+	}
+	for _, citation := range d.SelfCitations {
+		if err := WriteRecord(enc, "self_citation", citation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *ParsedData) UnmarshalJSONL(dec *json.Decoder) error {
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var err error
+		switch rec.Kind {
+		case "stats":
+			err = json.Unmarshal(rec.Data, &d.Stats)
+		case "paper":
+			var paper Paper
+			err = json.Unmarshal(rec.Data, &paper)
+			d.Papers = append(d.Papers, paper)
+		case "citation":
+			var citation CitationEdge
+			err = json.Unmarshal(rec.Data, &citation)
+			d.Citations = append(d.Citations, citation)
+		case "self_citation":
+			var citation CitationEdge
+			err = json.Unmarshal(rec.Data, &citation)
+			d.SelfCitations = append(d.SelfCitations, citation)
+		}
+		if err != nil {
+			return fmt.Errorf("bad %s record: %v", rec.Kind, err)
+		}
+	}
+}
+
 func PrintParsingStats(stats ParseStats) {
 	fmt.Println("\n=== Parsing Statistics ===")
 	fmt.Printf("Total papers: %d\n", stats.TotalPapers)
 	fmt.Printf("Total citations: %d\n", stats.TotalCitations)
+	fmt.Printf("Self-citations: %d\n", stats.SelfCitations)
+	if stats.DuplicateIDs > 0 {
+		fmt.Printf("Duplicate paper IDs: %d rows dropped (kept the record with more metadata)\n", stats.DuplicateIDs)
+	}
 	fmt.Printf("Year range: %d - %d\n", stats.YearRange.Min, stats.YearRange.Max)
 	if stats.TotalPapers > 0 {
 		avgCitations := float64(stats.TotalCitations) / float64(stats.TotalPapers)
 		fmt.Printf("Average citations per paper: %.2f\n", avgCitations)
+
+		abstracts := stats.Abstracts
+		fmt.Printf("Empty abstracts: %d (%.1f%%)\n",
+			abstracts.Empty, float64(abstracts.Empty)/float64(stats.TotalPapers)*100)
+		fmt.Printf("Abstract length: mean %.0f / median %d chars, mean %.0f / median %d words\n",
+			abstracts.MeanChars, abstracts.MedianChars, abstracts.MeanWords, abstracts.MedianWords)
+		if abstracts.LongestPaper != "" {
+			fmt.Printf("Longest abstract: %s (%d chars)\n", abstracts.LongestPaper, abstracts.LongestChars)
+			fmt.Printf("Shortest abstract: %s (%d chars)\n", abstracts.ShortestPaper, abstracts.ShortestChars)
+		}
+		if abstracts.Truncated > 0 {
+			fmt.Printf("Truncated abstracts: %d\n", abstracts.Truncated)
+		}
+	}
+	if links := stats.Links; links.TotalRows > 0 {
+		fmt.Printf("Citation link yield: %d of %d rows (%.1f%%)\n", links.Linked, links.TotalRows, links.Yield()*100)
+		fmt.Printf("  Not ACL-to-ACL: %d citing not ACL, %d cited not ACL, %d neither\n",
+			links.CitingNotACL, links.CitedNotACL, links.NeitherACL)
+		fmt.Printf("  Corpus id not parsed: %d citing, %d cited, %d both\n",
+			links.CitingNotFound, links.CitedNotFound, links.NeitherFound)
+		if links.Unreadable > 0 {
+			fmt.Printf("  Unreadable rows: %d\n", links.Unreadable)
+		}
 	}
 	fmt.Println("========================")
 }
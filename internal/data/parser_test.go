@@ -0,0 +1,224 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseConcurrentMatchesSerial(t *testing.T) {
+	papersPath, citationsPath := writeFixtureCorpus(t, t.TempDir())
+	opts := ParseOptions{Provenance: true}
+
+	concurrent, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	papers, stats, err := parsePapersParquet(ctx, papersPath, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawRows, linkReport, err := readCitationRows(ctx, citationsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial := joinParsed(papers, stats, rawRows, linkReport, citationsPath, opts)
+
+	if !reflect.DeepEqual(concurrent, serial) {
+		t.Errorf("concurrent parse differs from serial:\n%+v\n%+v", concurrent, serial)
+	}
+	if len(serial.Papers) != 6 || serial.Stats.Links.TotalRows != 10 {
+		t.Errorf("parsed %d papers from %d citation rows, want 6 from 10", len(serial.Papers), serial.Stats.Links.TotalRows)
+	}
+}
+
+func TestParseKeepsSelfCitationsApart(t *testing.T) {
+	papersPath, citationsPath := writeFixtureCorpus(t, t.TempDir())
+	parsed, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CitationEdge{{From: "P4", To: "P4"}}
+	if !reflect.DeepEqual(parsed.SelfCitations, want) {
+		t.Errorf("SelfCitations = %v, want %v", parsed.SelfCitations, want)
+	}
+	for _, citation := range parsed.Citations {
+		if citation.From == citation.To {
+			t.Errorf("self-citation %v in Citations", citation)
+		}
+	}
+	for _, paper := range parsed.Papers {
+		if paper.ID == "P4" && !reflect.DeepEqual(paper.Citations, []string{"P3"}) {
+			t.Errorf("P4 cites %v, want [P3]", paper.Citations)
+		}
+	}
+	if parsed.Stats.SelfCitations != 1 || parsed.Stats.TotalCitations != 7 {
+		t.Errorf("stats count %d citations and %d self-citations, want 7 and 1",
+			parsed.Stats.TotalCitations, parsed.Stats.SelfCitations)
+	}
+}
+
+func TestParseReadsOptionalIntentAndContextColumns(t *testing.T) {
+	papers := []fixturePaper{
+		{ID: "P1", Title: "Paper 1", Year: 2001, CorpusID: 1},
+		{ID: "P2", Title: "Paper 2", Year: 2002, CorpusID: 2},
+		{ID: "P3", Title: "Paper 3", Year: 2003, CorpusID: 3},
+	}
+	citations := []fixtureCitation{
+		{From: 2, To: 1, Intent: " Method ", Context: "Section 2"},
+		{From: 3, To: 1, Intent: "background", Context: "Introduction"},
+		{From: 3, To: 2},
+	}
+	for _, cols := range []citationColumns{{}, {Intent: true}, {Context: true}, {Intent: true, Context: true}} {
+		dir := t.TempDir()
+		papersPath := writePapersParquet(t, dir, papers, 0)
+		citationsPath := writeCitationColumnsParquet(t, dir, "citations.parquet", citations, cols)
+		parsed, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+		if err != nil {
+			t.Fatalf("%+v: %v", cols, err)
+		}
+
+		want := []CitationEdge{{From: "P2", To: "P1"}, {From: "P3", To: "P1"}, {From: "P3", To: "P2"}}
+		if cols.Intent {
+			// intents are trimmed and lowercased
+			want[0].Intent, want[1].Intent = "method", "background"
+		}
+		if cols.Context {
+			want[0].Context, want[1].Context = "Section 2", "Introduction"
+		}
+		if !reflect.DeepEqual(parsed.Citations, want) {
+			t.Errorf("%+v: citations %+v, want %+v", cols, parsed.Citations, want)
+		}
+	}
+}
+
+func TestPaperEmbedding(t *testing.T) {
+	legacy := Paper{AbstractEmbedding: []float32{1, 0}}
+	current := Paper{
+		AbstractEmbedding: []float32{1, 0},
+		Embeddings:        map[string][]float32{"abstract": {0, 1}, "title": {1, 1}, "fulltext": {}},
+	}
+	tests := []struct {
+		paper Paper
+		field string
+		want  []float32
+	}{
+		// data written before Embeddings existed
+		{legacy, "", []float32{1, 0}},
+		{legacy, "abstract", []float32{1, 0}},
+		{legacy, "title", nil},
+		// Embeddings wins over AbstractEmbedding
+		{current, "", []float32{0, 1}},
+		{current, "abstract", []float32{0, 1}},
+		{current, "title", []float32{1, 1}},
+		// an empty embedding is a missing one
+		{current, "fulltext", nil},
+		{current, "summary", nil},
+	}
+	for _, tt := range tests {
+		if got := tt.paper.Embedding(tt.field); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Embedding(%q) of %+v = %v, want %v", tt.field, tt.paper, got, tt.want)
+		}
+	}
+}
+
+func TestParseReadsListAuthorColumn(t *testing.T) {
+	dir := t.TempDir()
+	papers := []fixturePaper{
+		{ID: "P1", Title: "Paper 1", Year: 2001, CorpusID: 1},
+		{ID: "P2", Title: "Paper 2", Year: 2002, CorpusID: 2},
+		{ID: "P3", Title: "Paper 3", Year: 2003, CorpusID: 3},
+		{ID: "P4", Title: "Paper 4", Year: 2004, CorpusID: 4},
+	}
+	authors := [][]string{
+		{"Ada Lovelace", "Alan Turing"},
+		// names are trimmed, and empty, null and one-letter ones dropped;
+		// commas and "and" inside an element do not split it
+		{" Lovelace, Ada ", "", "  ", "X", "Smith and Jones"},
+		{},
+		nil,
+	}
+	papersPath := writeListAuthorPapersParquet(t, dir, papers, authors)
+	citationsPath := writeCitationsParquet(t, dir, "citations.parquet", []fixtureCitation{{From: 2, To: 1}})
+	parsed, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"P1": {"Ada Lovelace", "Alan Turing"},
+		"P2": {"Lovelace, Ada", "Smith and Jones"},
+		"P3": {},
+		"P4": {},
+	}
+	if len(parsed.Papers) != len(want) {
+		t.Fatalf("parsed %v, want 4 papers", paperIDs(parsed.Papers))
+	}
+	for _, paper := range parsed.Papers {
+		if len(paper.Authors) != len(want[paper.ID]) || (len(paper.Authors) > 0 && !reflect.DeepEqual(paper.Authors, want[paper.ID])) {
+			t.Errorf("%s authors %q, want %q", paper.ID, paper.Authors, want[paper.ID])
+		}
+	}
+}
+
+func TestParseStopsWhenCancelled(t *testing.T) {
+	papersPath, citationsPath := writeFixtureCorpus(t, t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	parsed, err := ParseACLDataWithOptions(ctx, papersPath, citationsPath, ParseOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if parsed != nil {
+		t.Error("a cancelled parse returned data")
+	}
+}
+
+func TestParseLinksCitationsOfDroppedDuplicateRows(t *testing.T) {
+	dir := t.TempDir()
+	papersPath := writePapersParquet(t, dir, []fixturePaper{
+		{ID: "P1", Title: "Paper 1", Year: 2001, Abstract: "Abstract of paper 1.", CorpusID: 1},
+		{ID: "P2", Title: "Paper 2", Year: 2002, Abstract: "Abstract of paper 2.", CorpusID: 2},
+		{ID: "P1", Title: "Paper 1", Year: 2001, CorpusID: 11},                                   // dropped: no abstract
+		{ID: "P3", Title: "Paper 3", Year: 2003, CorpusID: 3},                                    // replaced below
+		{ID: "P3", Title: "Paper 3", Year: 2003, Abstract: "Abstract of paper 3.", CorpusID: 13}, // kept
+	}, 0)
+	citationsPath := writeCitationsParquet(t, dir, "citations.parquet", []fixtureCitation{
+		{From: 2, To: 11}, // to the dropped P1 row
+		{From: 3, To: 2},  // from the replaced P3 row
+		{From: 13, To: 1},
+	})
+
+	parsed, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := paperIDs(parsed.Papers); !reflect.DeepEqual(ids, []string{"P1", "P2", "P3"}) {
+		t.Fatalf("papers %v, want [P1 P2 P3]", ids)
+	}
+	if parsed.Papers[0].Abstract == "" || parsed.Papers[2].Abstract == "" {
+		t.Error("kept a duplicate row without the abstract")
+	}
+	if parsed.Stats.DuplicateIDs != 2 {
+		t.Errorf("%d duplicate rows counted, want 2", parsed.Stats.DuplicateIDs)
+	}
+
+	want := []CitationEdge{{From: "P2", To: "P1"}, {From: "P3", To: "P2"}, {From: "P3", To: "P1"}}
+	if !reflect.DeepEqual(parsed.Citations, want) {
+		t.Errorf("citations %v, want %v", parsed.Citations, want)
+	}
+	if parsed.Stats.Links.Linked != 3 {
+		t.Errorf("%d citation rows linked, want all 3", parsed.Stats.Links.Linked)
+	}
+
+	// relink links them the same way
+	wantMap := map[int64]string{1: "P1", 11: "P1", 2: "P2", 3: "P3", 13: "P3"}
+	if got := NewCorpusMap(parsed.Papers).IDs; !reflect.DeepEqual(got, wantMap) {
+		t.Errorf("corpus map %v, want %v", got, wantMap)
+	}
+}
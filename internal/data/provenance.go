@@ -0,0 +1,65 @@
+package data
+
+import "fmt"
+
+// ProvenanceFile is the sidecar 'parse --explain-graph' writes next to
+// papers.json.
+const ProvenanceFile = "provenance.json"
+
+// DefaultProvenanceLimit caps the edges recorded, about 100 bytes each.
+const DefaultProvenanceLimit = 1000000
+
+// where a citation edge came from in the citations parquet
+type EdgeProvenance struct {
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Row            int    `json:"row"` // 0-based row in the citations parquet
+	CitingCorpusID int64  `json:"citing_corpus_id"`
+	CitedCorpusID  int64  `json:"cited_corpus_id"`
+	CitingYear     int    `json:"citing_year"`
+	CitedYear      int    `json:"cited_year"`
+}
+
+// provenance of the linked citation edges, in parse order
+type Provenance struct {
+	Source  string           `json:"source"` // citations parquet path
+	Limit   int              `json:"limit"`
+	Dropped int              `json:"dropped"` // linked edges past Limit, not recorded
+	Edges   []EdgeProvenance `json:"edges"`
+}
+
+func NewProvenance(source string, limit int) *Provenance {
+	return &Provenance{Source: source, Limit: limit}
+}
+
+func (p *Provenance) add(edge EdgeProvenance) {
+	if p.Limit > 0 && len(p.Edges) >= p.Limit {
+		p.Dropped++
+		return
+	}
+	p.Edges = append(p.Edges, edge)
+}
+
+// Find returns the records of every parquet row that produced the edge
+// from -> to; duplicate rows each get one.
+func (p *Provenance) Find(from, to string) []EdgeProvenance {
+	var found []EdgeProvenance
+	for _, edge := range p.Edges {
+		if edge.From == from && edge.To == to {
+			found = append(found, edge)
+		}
+	}
+	return found
+}
+
+func SaveProvenance(p *Provenance, outputPath string) error {
+	return EncodeFile(outputPath, p, FormatJSON)
+}
+
+func LoadProvenance(inputPath string) (*Provenance, error) {
+	var p Provenance
+	if err := DecodeFile(inputPath, &p); err != nil {
+		return nil, fmt.Errorf("failed to load provenance: %v", err)
+	}
+	return &p, nil
+}
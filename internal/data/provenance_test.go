@@ -0,0 +1,71 @@
+package data
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseRecordsProvenance(t *testing.T) {
+	papersPath, citationsPath := writeFixtureCorpus(t, t.TempDir())
+
+	parsed, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Provenance != nil {
+		t.Error("provenance recorded without being asked for")
+	}
+
+	parsed, err = ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{Provenance: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provenance := parsed.Provenance
+	// every linked row, the self-citation included; the rows to a non-ACL
+	// paper and to an unknown corpus id are not
+	if len(provenance.Edges) != 8 || provenance.Dropped != 0 || provenance.Source != citationsPath {
+		t.Errorf("%d edges, %d dropped from %s; want 8, 0 from %s",
+			len(provenance.Edges), provenance.Dropped, provenance.Source, citationsPath)
+	}
+	want := []EdgeProvenance{{From: "P3", To: "P1", Row: 1, CitingCorpusID: 3, CitedCorpusID: 1, CitingYear: 2003, CitedYear: 2001}}
+	if got := provenance.Find("P3", "P1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Find(P3, P1) = %+v, want %+v", got, want)
+	}
+	if got := provenance.Find("P1", "P3"); len(got) != 0 {
+		t.Errorf("Find(P1, P3) = %+v for an edge only the other way", got)
+	}
+
+	path := filepath.Join(t.TempDir(), ProvenanceFile)
+	if err := SaveProvenance(provenance, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadProvenance(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, provenance) {
+		t.Errorf("loaded %+v, saved %+v", loaded, provenance)
+	}
+}
+
+func TestProvenanceLimit(t *testing.T) {
+	papersPath, citationsPath := writeFixtureCorpus(t, t.TempDir())
+	parsed, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath,
+		ParseOptions{Provenance: true, ProvenanceLimit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provenance := parsed.Provenance
+	if len(provenance.Edges) != 3 || provenance.Dropped != 5 || provenance.Limit != 3 {
+		t.Errorf("%d edges, %d dropped with limit %d; want 3, 5 with limit 3",
+			len(provenance.Edges), provenance.Dropped, provenance.Limit)
+	}
+	// the first rows are kept
+	for i, edge := range provenance.Edges {
+		if edge.Row != i {
+			t.Errorf("edge %d is from row %d, want %d", i, edge.Row, i)
+		}
+	}
+}
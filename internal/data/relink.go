@@ -0,0 +1,152 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+)
+
+// CorpusMapFile is the sidecar 'parse' writes next to papers.json so
+// 'relink' can link citation rows without the papers parquet.
+const CorpusMapFile = "corpus_ids.json"
+
+// the corpus_id -> acl_id join of the parsed papers
+type CorpusMap struct {
+	IDs map[int64]string `json:"ids"`
+
+	// the papers the map was made from, so relink can tell it apart from
+	// a map left by another parse; empty in maps saved before it existed
+	Papers      int    `json:"papers"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// NewCorpusMap maps the corpus ids of the papers to their acl ids.
+func NewCorpusMap(papers []Paper) *CorpusMap {
+	return &CorpusMap{
+		IDs:         buildCorpusToACL(papers),
+		Papers:      len(papers),
+		Fingerprint: paperIDsFingerprint(papers),
+	}
+}
+
+// CheckPapers returns an error when the map was made from papers other
+// than these, as when papers.json was re-parsed or filtered since. Relink
+// leaves the paper ids alone, so its own output still matches.
+func (m *CorpusMap) CheckPapers(papers []Paper) error {
+	if m.Fingerprint == "" {
+		return nil
+	}
+	if m.Papers != len(papers) || m.Fingerprint != paperIDsFingerprint(papers) {
+		return fmt.Errorf("corpus id map is from another parse (%d papers, %d loaded)", m.Papers, len(papers))
+	}
+	return nil
+}
+
+// paperIDsFingerprint hashes the sorted paper ids.
+func paperIDsFingerprint(papers []Paper) string {
+	ids := make([]string, len(papers))
+	for i, paper := range papers {
+		ids[i] = paper.ID
+	}
+	slices.Sort(ids)
+	h := fnv.New64a()
+	for _, id := range ids {
+		h.Write([]byte(id))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// SaveCorpusMap writes the map atomically, so an interrupted parse never
+// leaves a partial one for relink.
+func SaveCorpusMap(m *CorpusMap, outputPath string) error {
+	return EncodeFile(outputPath, m, FormatJSON)
+}
+
+func LoadCorpusMap(inputPath string) (*CorpusMap, error) {
+	var m CorpusMap
+	if err := DecodeFile(inputPath, &m); err != nil {
+		return nil, fmt.Errorf("failed to load corpus id map: %v", err)
+	}
+	return &m, nil
+}
+
+// what 'relink' did with a citations file
+type RelinkReport struct {
+	Links      CitationLinkReport `json:"citation_links"`
+	Added      int                `json:"added"`
+	Duplicates int                `json:"duplicates"` // linked edges already in the data
+	NotLoaded  int                `json:"not_loaded"` // linked edges to or from papers no longer in the data
+}
+
+// Relink reads a citations parquet, links its rows through the corpus map
+// and adds the edges not already in parsed, deduplicated by (from, to) as
+// in MergeParsedData: a duplicate only fills in intent or context the
+// existing edge lacks. Paper metadata is left alone; only the papers'
+// citation lists and the citation totals are updated. Stats.Links keeps
+// describing the original parse.
+func Relink(ctx context.Context, parsed *ParsedData, corpusMap *CorpusMap, citationsPath string) (RelinkReport, error) {
+	rows, links, err := readCitationRows(ctx, citationsPath)
+	if err != nil {
+		return RelinkReport{}, fmt.Errorf("failed to parse citations: %w", err)
+	}
+	edges := linkCitations(rows, &links, corpusMap.IDs, nil)
+	report := RelinkReport{Links: links}
+
+	loaded := make(map[string]bool, len(parsed.Papers))
+	for _, paper := range parsed.Papers {
+		loaded[paper.ID] = true
+	}
+	linked := slices.Concat(parsed.Citations, parsed.SelfCitations)
+	edgeIndex := make(map[[2]string]int, len(linked)+len(edges))
+	for i, citation := range linked {
+		key := [2]string{citation.From, citation.To}
+		if _, seen := edgeIndex[key]; !seen {
+			edgeIndex[key] = i
+		}
+	}
+
+	for _, edge := range edges {
+		if !loaded[edge.From] || !loaded[edge.To] {
+			report.NotLoaded++
+			continue
+		}
+		key := [2]string{edge.From, edge.To}
+		i, seen := edgeIndex[key]
+		if !seen {
+			edgeIndex[key] = len(linked)
+			linked = append(linked, edge)
+			report.Added++
+			continue
+		}
+		report.Duplicates++
+		if linked[i].Intent == "" {
+			linked[i].Intent = edge.Intent
+		}
+		if linked[i].Context == "" {
+			linked[i].Context = edge.Context
+		}
+	}
+
+	parsed.Citations, parsed.SelfCitations = splitSelfCitations(linked)
+	updatePaperCitations(parsed.Papers, parsed.Citations)
+	countCitations(&parsed.Stats, parsed.Citations, parsed.SelfCitations)
+	return report, nil
+}
+
+func PrintRelinkReport(report RelinkReport) {
+	links := report.Links
+	fmt.Println("\n=== Relink Summary ===")
+	fmt.Printf("Citation rows: %d, linked %d (%.1f%%)\n", links.TotalRows, links.Linked, links.Yield()*100)
+	fmt.Printf("New edges added: %d\n", report.Added)
+	fmt.Printf("Skipped as already present: %d\n", report.Duplicates)
+	if report.NotLoaded > 0 {
+		fmt.Printf("Skipped for papers no longer in the data: %d\n", report.NotLoaded)
+	}
+	fmt.Printf("Skipped rows: %d not ACL-to-ACL, %d with a corpus id not parsed, %d unreadable\n",
+		links.CitingNotACL+links.CitedNotACL+links.NeitherACL,
+		links.CitingNotFound+links.CitedNotFound+links.NeitherFound,
+		links.Unreadable)
+	fmt.Println("======================")
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestRelinkMergesCitationsAndKeepsMetadata(t *testing.T) {
+	dir := t.TempDir()
+	papersPath, citationsPath := writeFixtureCorpus(t, dir)
+	parsed, err := ParseACLDataWithOptions(context.Background(), papersPath, citationsPath, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// relink works on the saved files, which lack the corpus ids
+	savedPath := filepath.Join(dir, "papers.json")
+	mapPath := filepath.Join(dir, CorpusMapFile)
+	if err := SaveParsedData(parsed, savedPath, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveCorpusMap(NewCorpusMap(parsed.Papers), mapPath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadParsedData(savedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corpusMap, err := LoadCorpusMap(mapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := corpusMap.CheckPapers(loaded.Papers); err != nil {
+		t.Fatal(err)
+	}
+	before, err := LoadParsedData(savedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newCitations := writeCitationsParquet(t, dir, "new_citations.parquet", []fixtureCitation{
+		{From: 6, To: 2, Intent: "method"}, // new
+		{From: 3, To: 2, Intent: "result"}, // present without an intent
+		{From: 2, To: 1, Intent: "method"}, // present as background
+		{From: 6, To: 77},                  // never parsed
+	})
+	report, err := Relink(context.Background(), loaded, corpusMap, newCitations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Added != 1 || report.Duplicates != 2 || report.Links.Linked != 3 {
+		t.Errorf("report %+v, want 1 added and 2 duplicates of 3 linked", report)
+	}
+
+	intents := make(map[[2]string]string)
+	for _, citation := range loaded.Citations {
+		intents[[2]string{citation.From, citation.To}] = citation.Intent
+	}
+	want := map[[2]string]string{
+		{"P6", "P2"}: "method",
+		{"P3", "P2"}: "result",
+		{"P2", "P1"}: "background",
+	}
+	for edge, intent := range want {
+		if got, found := intents[edge]; !found || got != intent {
+			t.Errorf("%s -> %s has intent %q (present: %v), want %q", edge[0], edge[1], got, found, intent)
+		}
+	}
+	if len(loaded.Citations) != len(before.Citations)+1 || loaded.Stats.TotalCitations != before.Stats.TotalCitations+1 {
+		t.Errorf("%d citations (stats %d) after relinking %d, want one more",
+			len(loaded.Citations), loaded.Stats.TotalCitations, len(before.Citations))
+	}
+
+	for i, paper := range loaded.Papers {
+		old := before.Papers[i]
+		if paper.ID == "P6" {
+			if !slices.Contains(paper.Citations, "P2") {
+				t.Errorf("P6 cites %v, want P2 among them", paper.Citations)
+			}
+			paper.Citations = old.Citations
+		}
+		if !reflect.DeepEqual(paper, old) {
+			t.Errorf("relink changed paper %s:\n%+v\nwas\n%+v", paper.ID, paper, old)
+		}
+	}
+}
+
+func TestCorpusMapCheckPapers(t *testing.T) {
+	papers := []Paper{{ID: "P1", CorpusPaperID: 1}, {ID: "P2", CorpusPaperID: 2}}
+	corpusMap := NewCorpusMap(papers)
+
+	reordered := []Paper{papers[1], papers[0]}
+	if err := corpusMap.CheckPapers(reordered); err != nil {
+		t.Errorf("same papers in another order: %v", err)
+	}
+	if err := corpusMap.CheckPapers(papers[:1]); err == nil {
+		t.Error("map accepted for fewer papers")
+	}
+	if err := corpusMap.CheckPapers([]Paper{{ID: "P1"}, {ID: "P3"}}); err == nil {
+		t.Error("map accepted for other papers of the same count")
+	}
+
+	// maps saved before the fingerprint cannot be checked
+	if err := (&CorpusMap{IDs: corpusMap.IDs}).CheckPapers(papers[:1]); err != nil {
+		t.Errorf("map without a fingerprint: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	// examples from Porter's paper, then words left alone
+	tests := map[string]string{
+		"caresses":   "caress",
+		"ponies":     "poni",
+		"ties":       "ti",
+		"cats":       "cat",
+		"feed":       "feed",
+		"agreed":     "agre",
+		"plastered":  "plaster",
+		"motoring":   "motor",
+		"sing":       "sing",
+		"conflated":  "conflat",
+		"hopping":    "hop",
+		"falling":    "fall",
+		"filing":     "file",
+		"happy":      "happi",
+		"relational": "relat",
+		"hopeful":    "hope",
+		"goodness":   "good",
+		"adoption":   "adopt",
+		"controll":   "control",
+
+		"ab":   "ab",
+		"x2":   "x2",
+		"café": "café",
+		"":     "",
+	}
+	for word, want := range tests {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestStemCollisions(t *testing.T) {
+	groups := [][]string{
+		{"translation", "translations", "translating", "translated", "translate", "translator"},
+		{"parse", "parses", "parsed", "parsing"},
+		{"embedding", "embeddings", "embedded"},
+		{"generalize", "generalized", "generalizing", "generalization", "generalizations"},
+		{"university", "universe", "universal"},
+	}
+	for _, group := range groups {
+		for _, word := range group[1:] {
+			if Stem(word) != Stem(group[0]) {
+				t.Errorf("%q stems to %q, %q to %q", word, Stem(word), group[0], Stem(group[0]))
+			}
+		}
+	}
+	// and words Porter keeps apart stay apart
+	for _, pair := range [][2]string{{"parse", "parser"}, {"relate", "relative"}, {"probe", "probate"}} {
+		if Stem(pair[0]) == Stem(pair[1]) {
+			t.Errorf("%q and %q both stem to %q", pair[0], pair[1], Stem(pair[0]))
+		}
+	}
+}
+
+func TestStemTokens(t *testing.T) {
+	tokens := Tokenize("Translating translations, by parsing")
+	if got, want := StemTokens(tokens), []string{"translat", "translat", "by", "pars"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StemTokens = %q, want %q", got, want)
+	}
+}
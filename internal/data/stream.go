@@ -0,0 +1,126 @@
+package data
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// StreamPapers calls fn for each paper in a parsed data file, decoding one
+// paper at a time instead of holding the whole file in memory. JSONL files
+// stream line by line and JSON files stream the "papers" array element by
+// element; msgpack files are loaded whole. Returning an error from fn stops
+// the stream and returns that error.
+func StreamPapers(inputPath string, fn func(Paper) error) error {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %v", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	format, err := detectFormat(r)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSONL:
+		return streamJSONLPapers(json.NewDecoder(r), fn)
+	case FormatJSON:
+		return streamJSONPapers(json.NewDecoder(r), fn)
+	default:
+		parsedData, err := LoadParsedData(inputPath)
+		if err != nil {
+			return err
+		}
+		for _, paper := range parsedData.Papers {
+			if err := fn(paper); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func streamJSONLPapers(dec *json.Decoder, fn func(Paper) error) error {
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to decode jsonl record: %v", err)
+		}
+		if rec.Kind != "paper" {
+			continue
+		}
+
+		var paper Paper
+		if err := json.Unmarshal(rec.Data, &paper); err != nil {
+			return fmt.Errorf("bad paper record: %v", err)
+		}
+		if err := fn(paper); err != nil {
+			return err
+		}
+	}
+}
+
+func streamJSONPapers(dec *json.Decoder, fn func(Paper) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode JSON: %v", err)
+		}
+
+		if key, _ := tok.(string); key != "papers" {
+			// skip stats, citations and anything else
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fmt.Errorf("failed to decode JSON: %v", err)
+			}
+			continue
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode JSON: %v", err)
+		}
+		if tok == nil {
+			continue // "papers": null
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("unexpected JSON token %v, expected [", tok)
+		}
+		for dec.More() {
+			var paper Paper
+			if err := dec.Decode(&paper); err != nil {
+				return fmt.Errorf("failed to decode paper: %v", err)
+			}
+			if err := fn(paper); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode JSON: %v", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("unexpected JSON token %v, expected %v", tok, want)
+	}
+	return nil
+}
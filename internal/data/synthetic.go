@@ -0,0 +1,83 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// GenerateSyntheticData creates a random corpus of numPapers papers and up to
+// numCitations citations (self-citations and duplicates are skipped), with
+// normalized random embeddings of embeddingDim dimensions. Citations always
+// point from a newer or same-year paper to an older one, like real data. The
+// same seed always gives the same corpus.
+func GenerateSyntheticData(numPapers, numCitations, embeddingDim int, seed int64) *ParsedData {
+	rng := rand.New(rand.NewSource(seed))
+
+	papers := make([]Paper, numPapers)
+	minYear, maxYear := 9999, 0
+	for i := range papers {
+		year := 1980 + i*45/max(numPapers, 1)
+		papers[i] = Paper{
+			ID:                fmt.Sprintf("SYN-%06d", i),
+			Title:             fmt.Sprintf("Synthetic paper %d", i),
+			Authors:           []string{fmt.Sprintf("Author %d", rng.Intn(max(numPapers/5, 1)))},
+			Year:              year,
+			Abstract:          fmt.Sprintf("Abstract of synthetic paper %d.", i),
+			AbstractEmbedding: randomUnitVector(rng, embeddingDim),
+		}
+		minYear = min(minYear, year)
+		maxYear = max(maxYear, year)
+	}
+
+	seen := make(map[[2]int]bool)
+	citations := make([]CitationEdge, 0, numCitations)
+	for attempt := 0; attempt < numCitations && numPapers > 1; attempt++ {
+		from := rng.Intn(numPapers)
+		to := rng.Intn(numPapers)
+		if to > from {
+			from, to = to, from
+		}
+		if from == to || seen[[2]int{from, to}] {
+			continue
+		}
+		seen[[2]int{from, to}] = true
+		citations = append(citations, CitationEdge{From: papers[from].ID, To: papers[to].ID})
+	}
+
+	updatePaperCitations(papers, citations)
+
+	stats := ParseStats{
+		TotalPapers:    len(papers),
+		TotalCitations: len(citations),
+	}
+	if numPapers > 0 {
+		stats.YearRange.Min = minYear
+		stats.YearRange.Max = maxYear
+	}
+
+	return &ParsedData{
+		Papers:    papers,
+		Citations: citations,
+		Stats:     stats,
+	}
+}
+
+func randomUnitVector(rng *rand.Rand, dim int) []float32 {
+	if dim <= 0 {
+		return nil
+	}
+
+	vec := make([]float32, dim)
+	var norm float64
+	for i := range vec {
+		v := rng.NormFloat64()
+		vec[i] = float32(v)
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] = float32(float64(vec[i]) / norm)
+	}
+	return vec
+}
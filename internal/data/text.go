@@ -0,0 +1,49 @@
+package data
+
+import (
+	"strings"
+	"unicode"
+)
+
+// common English words that carry no topical signal
+var stopwords = map[string]bool{
+	"a": true, "about": true, "above": true, "after": true, "again": true, "all": true,
+	"also": true, "an": true, "and": true, "any": true, "are": true, "as": true,
+	"at": true, "be": true, "been": true, "being": true, "between": true, "both": true,
+	"but": true, "by": true, "can": true, "could": true, "did": true, "do": true,
+	"does": true, "each": true, "for": true, "from": true, "further": true, "had": true,
+	"has": true, "have": true, "how": true, "however": true, "i": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "may": true,
+	"more": true, "most": true, "new": true, "no": true, "not": true, "of": true,
+	"on": true, "one": true, "only": true, "or": true, "other": true, "our": true,
+	"over": true, "paper": true, "s": true, "such": true, "than": true, "that": true,
+	"the": true, "their": true, "them": true, "then": true, "there": true, "these": true,
+	"they": true, "this": true, "those": true, "through": true, "to": true, "two": true,
+	"under": true, "up": true, "use": true, "used": true, "using": true, "via": true,
+	"was": true, "we": true, "were": true, "what": true, "when": true, "where": true,
+	"which": true, "while": true, "who": true, "will": true, "with": true, "within": true,
+	"without": true, "would": true,
+}
+
+// Tokenize lowercases text and splits it into runs of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func IsStopword(token string) bool {
+	return stopwords[token]
+}
+
+// ContentTokens tokenizes text and drops stopwords and single characters.
+func ContentTokens(text string) []string {
+	tokens := Tokenize(text)
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if len(token) > 1 && !stopwords[token] {
+			kept = append(kept, token)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,298 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"unsafe"
+)
+
+// A vector file holds one embedding field of a papers file in fixed-width
+// records, so search can memory-map it and page in only the vectors it
+// touches instead of decoding every embedding into the Go heap:
+//
+//	magic     8 bytes  "ACLVEC01"
+//	dim       uint32   floats per record
+//	count     uint32   number of records
+//	index     uint64   offset of the index
+//	records   count × dim little-endian float32
+//	index     the field name, then each record's paper id, in record
+//	          order, each a uint16 length followed by the bytes
+//
+// Papers without an embedding for the field get no record.
+var vectorMagic = []byte("ACLVEC01")
+
+const vectorHeaderSize = 24
+
+// VectorWriter writes a vector file one embedding at a time. Like
+// AtomicWrite it writes to path + ".tmp" and only renames that over path
+// once Close has written the whole file.
+type VectorWriter struct {
+	path  string
+	f     *os.File
+	w     *bufio.Writer
+	field string
+	dim   int
+	ids   []string
+}
+
+// CreateVectorFile starts a vector file for the given embedding field.
+func CreateVectorFile(path, field string) (*VectorWriter, error) {
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vector file: %v", err)
+	}
+	vw := &VectorWriter{path: path, f: f, w: bufio.NewWriter(f), field: field}
+	if _, err := vw.w.Write(make([]byte, vectorHeaderSize)); err != nil {
+		vw.Abort()
+		return nil, err
+	}
+	return vw, nil
+}
+
+// Add appends a paper's embedding; every embedding must have the length of
+// the first.
+func (vw *VectorWriter) Add(id string, embedding []float32) error {
+	if len(id) > math.MaxUint16 {
+		return fmt.Errorf("paper id too long: %.40s...", id)
+	}
+	if len(vw.ids) == 0 {
+		vw.dim = len(embedding)
+	} else if len(embedding) != vw.dim {
+		return fmt.Errorf("paper %s has a %d-dimensional embedding, want %d", id, len(embedding), vw.dim)
+	}
+
+	var buf [4]byte
+	for _, x := range embedding {
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(x))
+		if _, err := vw.w.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+	vw.ids = append(vw.ids, id)
+	return nil
+}
+
+// Len returns the number of embeddings added so far.
+func (vw *VectorWriter) Len() int {
+	return len(vw.ids)
+}
+
+// Abort discards the file being written, leaving any file at the path as
+// it was.
+func (vw *VectorWriter) Abort() {
+	vw.f.Close()
+	os.Remove(vw.f.Name())
+}
+
+// Close writes the index and header, then replaces the file at the path
+// with the finished one. On error the path is left as it was.
+func (vw *VectorWriter) Close() error {
+	if err := vw.finish(); err != nil {
+		vw.Abort()
+		return err
+	}
+	if err := vw.f.Close(); err != nil {
+		os.Remove(vw.f.Name())
+		return fmt.Errorf("failed to write vector file: %v", err)
+	}
+	if err := os.Rename(vw.f.Name(), vw.path); err != nil {
+		os.Remove(vw.f.Name())
+		return err
+	}
+	return nil
+}
+
+// finish writes the index and header to the temporary file and syncs it.
+func (vw *VectorWriter) finish() error {
+	index := vectorHeaderSize + int64(len(vw.ids))*int64(vw.dim)*4
+	writeString := func(s string) error {
+		if err := binary.Write(vw.w, binary.LittleEndian, uint16(len(s))); err != nil {
+			return err
+		}
+		_, err := vw.w.WriteString(s)
+		return err
+	}
+	if err := writeString(vw.field); err != nil {
+		return fmt.Errorf("failed to write vector index: %v", err)
+	}
+	for _, id := range vw.ids {
+		if err := writeString(id); err != nil {
+			return fmt.Errorf("failed to write vector index: %v", err)
+		}
+	}
+	if err := vw.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write vector file: %v", err)
+	}
+
+	header := make([]byte, vectorHeaderSize)
+	copy(header, vectorMagic)
+	binary.LittleEndian.PutUint32(header[8:], uint32(vw.dim))
+	binary.LittleEndian.PutUint32(header[12:], uint32(len(vw.ids)))
+	binary.LittleEndian.PutUint64(header[16:], uint64(index))
+	if _, err := vw.f.WriteAt(header, 0); err != nil {
+		return fmt.Errorf("failed to write vector file header: %v", err)
+	}
+	if err := vw.f.Sync(); err != nil {
+		return fmt.Errorf("failed to write vector file: %v", err)
+	}
+	return nil
+}
+
+// WriteVectorFile streams the papers file and writes the embedding field of
+// every paper that has one to a vector file, returning how many it wrote.
+func WriteVectorFile(papersPath, outputPath, field string) (int, error) {
+	if field == "" {
+		field = DefaultEmbeddingField
+	}
+	vw, err := CreateVectorFile(outputPath, field)
+	if err != nil {
+		return 0, err
+	}
+	err = StreamPapers(papersPath, func(paper Paper) error {
+		if embedding := paper.Embedding(field); len(embedding) > 0 {
+			return vw.Add(paper.ID, embedding)
+		}
+		return nil
+	})
+	if err != nil {
+		vw.Abort()
+		return 0, err
+	}
+	if err := vw.Close(); err != nil {
+		return 0, err
+	}
+	return vw.Len(), nil
+}
+
+// VectorFile is a memory-mapped vector file. Only its index is held in the
+// heap; the vectors are read from the mapping, so the operating system
+// pages them in as they are used and can drop them again under pressure.
+type VectorFile struct {
+	Field string
+	Dim   int
+
+	data    []byte
+	records []byte
+	index   map[string]int
+}
+
+// OpenVectorFile maps a vector file written by WriteVectorFile.
+func OpenVectorFile(path string) (*VectorFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vector file: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < vectorHeaderSize {
+		return nil, fmt.Errorf("%s is not a vector file", path)
+	}
+	mapped, err := mmapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to map vector file: %v", err)
+	}
+
+	vf, err := parseVectorFile(mapped)
+	if err != nil {
+		munmapFile(mapped)
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return vf, nil
+}
+
+func parseVectorFile(mapped []byte) (*VectorFile, error) {
+	if !bytes.Equal(mapped[:len(vectorMagic)], vectorMagic) {
+		return nil, fmt.Errorf("not a vector file")
+	}
+	dim := int(binary.LittleEndian.Uint32(mapped[8:]))
+	count := int(binary.LittleEndian.Uint32(mapped[12:]))
+	index := binary.LittleEndian.Uint64(mapped[16:])
+	end := vectorHeaderSize + uint64(count)*uint64(dim)*4
+	if index != end || index > uint64(len(mapped)) {
+		return nil, fmt.Errorf("truncated vector file")
+	}
+
+	vf := &VectorFile{
+		Dim:     dim,
+		data:    mapped,
+		records: mapped[vectorHeaderSize:index],
+		index:   make(map[string]int, count),
+	}
+	r := bytes.NewReader(mapped[index:])
+	readString := func() (string, error) {
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return "", err
+		}
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return string(b), err
+	}
+	var err error
+	if vf.Field, err = readString(); err != nil {
+		return nil, fmt.Errorf("corrupt vector index: %v", err)
+	}
+	for i := 0; i < count; i++ {
+		id, err := readString()
+		if err != nil {
+			return nil, fmt.Errorf("corrupt vector index: %v", err)
+		}
+		vf.index[id] = i
+	}
+	if len(vf.index) != count {
+		return nil, fmt.Errorf("corrupt vector index: %d distinct ids for %d records", len(vf.index), count)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("vector file has %d bytes past its index", r.Len())
+	}
+	return vf, nil
+}
+
+// Len returns the number of vectors in the file.
+func (vf *VectorFile) Len() int {
+	return len(vf.index)
+}
+
+// Vector returns the paper's embedding, or nil when it has none. On
+// little-endian machines the slice points into the mapping and is only
+// valid until Close; elsewhere it is decoded into a copy.
+func (vf *VectorFile) Vector(id string) []float32 {
+	i, ok := vf.index[id]
+	if !ok || vf.Dim == 0 {
+		return nil
+	}
+	record := vf.records[i*vf.Dim*4 : (i+1)*vf.Dim*4]
+	if nativeLittleEndian {
+		// records start at a multiple of 4 from the page-aligned mapping
+		return unsafe.Slice((*float32)(unsafe.Pointer(&record[0])), vf.Dim)
+	}
+	vector := make([]float32, vf.Dim)
+	for j := range vector {
+		vector[j] = math.Float32frombits(binary.LittleEndian.Uint32(record[j*4:]))
+	}
+	return vector
+}
+
+// Close unmaps the file; vectors returned by Vector must not be used after.
+func (vf *VectorFile) Close() error {
+	if vf.data == nil {
+		return nil
+	}
+	err := munmapFile(vf.data)
+	vf.data, vf.records = nil, nil
+	return err
+}
+
+var nativeLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package data
+
+import (
+	"io"
+	"os"
+)
+
+// mmapFile reads the file into memory where mmap is not available, so
+// vector files still work, just without the memory savings.
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(f, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func munmapFile(b []byte) error {
+	return nil
+}
@@ -0,0 +1,96 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeTestVectors writes a vector file with three 2-dimensional
+// embeddings to path.
+func writeTestVectors(t *testing.T, path string) {
+	t.Helper()
+	vw, err := CreateVectorFile(path, "abstract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range []string{"P1", "P2", "P3"} {
+		if err := vw.Add(id, []float32{float32(i), -float32(i) / 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := vw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVectorFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.abstract.vec")
+	writeTestVectors(t, path)
+
+	vf, err := OpenVectorFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vf.Close()
+	if vf.Field != "abstract" || vf.Dim != 2 || vf.Len() != 3 {
+		t.Errorf("opened %s file of %d %d-dimensional vectors, want abstract, 3 and 2", vf.Field, vf.Len(), vf.Dim)
+	}
+	if got := vf.Vector("P3"); !slices.Equal(got, []float32{2, -1}) {
+		t.Errorf("P3 = %v, want [2 -1]", got)
+	}
+	if got := vf.Vector("P9"); got != nil {
+		t.Errorf("P9 = %v, want nil", got)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind (%v)", err)
+	}
+}
+
+func TestVectorWriterFailureKeepsOldFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.abstract.vec")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vw, err := CreateVectorFile(path, "abstract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vw.Add("P1", []float32{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := vw.Add("P2", []float32{1, 2, 3}); err == nil {
+		t.Fatal("mismatched dimension accepted")
+	}
+	vw.Abort()
+	checkFile(t, path, "old")
+}
+
+func TestOpenVectorFileRejectsDamagedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "embeddings.abstract.vec")
+	writeTestVectors(t, path)
+	valid, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	damaged := map[string][]byte{
+		"truncated records": valid[:vectorHeaderSize+4],
+		"truncated index":   valid[:len(valid)-1],
+		"trailing bytes":    append(slices.Clone(valid), 0),
+		"header only":       valid[:vectorHeaderSize],
+	}
+	for name, content := range damaged {
+		damagedPath := filepath.Join(dir, "damaged.vec")
+		if err := os.WriteFile(damagedPath, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if vf, err := OpenVectorFile(damagedPath); err == nil {
+			vf.Close()
+			t.Errorf("%s: opened without error", name)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package graph
+
+import "fmt"
+
+// what AsOf left out
+type AsOfReport struct {
+	Year           int `json:"year"`
+	ExcludedPapers int `json:"excluded_papers"` // published after Year
+	ExcludedEdges  int `json:"excluded_edges"`  // cited by, or citing, such a paper
+}
+
+// AsOf returns the graph as it stood at the end of year: papers published
+// after it are removed, and with them every citation they make, so ranking
+// the result reconstructs influence as of that year. Citations are dated by
+// Edge.Year, or by the citing paper's year for graphs built before edges
+// carried one. Papers and citations of unknown year are kept.
+func AsOf(g *Graph, year int) (*Graph, AsOfReport) {
+	report := AsOfReport{Year: year}
+	years := make(map[string]int, len(g.Nodes))
+	sub := &Graph{
+		Nodes: make([]Node, 0, len(g.Nodes)),
+		Edges: make([]Edge, 0, len(g.Edges)),
+	}
+
+	for _, node := range g.Nodes {
+		years[node.ID] = node.Year
+		if node.Year > year {
+			report.ExcludedPapers++
+			continue
+		}
+		sub.Nodes = append(sub.Nodes, node)
+	}
+	for _, edge := range g.Edges {
+		citedIn := edge.Year
+		if citedIn == 0 {
+			citedIn = years[edge.From]
+		}
+		if citedIn > year || years[edge.From] > year || years[edge.To] > year {
+			report.ExcludedEdges++
+			continue
+		}
+		sub.Edges = append(sub.Edges, edge)
+	}
+
+	// keep a lean graph lean
+	if g.AdjList != nil {
+		sub.buildAdjList()
+	}
+	sub.rebuildDegrees()
+	sub.Stats = calculateGraphStats(sub, 0)
+	return sub, report
+}
+
+func PrintAsOfReport(report AsOfReport, total *Graph) {
+	fmt.Printf("Ranking as of %d: excluded %d of %d papers and %d of %d citations from later years\n",
+		report.Year, report.ExcludedPapers, len(total.Nodes), report.ExcludedEdges, len(total.Edges))
+}
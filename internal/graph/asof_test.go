@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAsOfCutsOffLaterPapersAndCitations(t *testing.T) {
+	// testGraph dates a 2000, b 2001, c 2002, d 2003
+	g := testGraph(t, "a", "b>a", "c>a", "c>b", "d>c", "undated")
+	for i := range g.Nodes {
+		if g.Nodes[i].ID == "undated" {
+			g.Nodes[i].Year = 0
+		}
+	}
+	// b cited a in 2001, but the citation was only recorded in 2003
+	g.Edges[0].Year = 2003
+
+	sub, report := AsOf(g, 2002)
+	var ids []string
+	for _, node := range sub.Nodes {
+		ids = append(ids, node.ID)
+	}
+	if want := []string{"a", "b", "c", "undated"}; !slices.Equal(ids, want) {
+		t.Errorf("papers as of 2002: %v, want %v", ids, want)
+	}
+	var edges []string
+	for _, edge := range sub.Edges {
+		edges = append(edges, edge.From+">"+edge.To)
+	}
+	if want := []string{"c>a", "c>b"}; !slices.Equal(edges, want) {
+		t.Errorf("citations as of 2002: %v, want %v", edges, want)
+	}
+	if report.ExcludedPapers != 1 || report.ExcludedEdges != 2 {
+		t.Errorf("report %+v, want 1 paper and 2 citations excluded", report)
+	}
+	if sub.InDegree["a"] != 1 || sub.OutDegree["c"] != 2 {
+		t.Errorf("degrees not rebuilt: in(a)=%d out(c)=%d", sub.InDegree["a"], sub.OutDegree["c"])
+	}
+
+	// the year a paper was published is included
+	if sub, _ := AsOf(g, 2003); len(sub.Nodes) != len(g.Nodes) || len(sub.Edges) != len(g.Edges) {
+		t.Errorf("as of 2003 kept %d papers and %d citations, want all", len(sub.Nodes), len(sub.Edges))
+	}
+}
@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"paper-rank/internal/data"
+)
+
+// graphBinary is the gob payload of a FormatBinary graph file. The adjacency
+// list and degree maps are rebuilt on load.
+type graphBinary struct {
+	Nodes              []Node
+	Edges              binaryEdges
+	SelfCitationEdges  binaryEdges
+	SelfCitationCounts map[string]int
+	Stats              GraphStats
+}
+
+// binaryEdges stores edges column-wise with endpoints as node indexes, which
+// gob encodes as packed integers. Intent and Context are nil when no edge
+// has one. Edge years are not stored: they are the citing paper's year.
+type binaryEdges struct {
+	From, To        []uint32
+	Intent, Context []string
+}
+
+// SaveGraphBinary writes the graph in the compact binary format.
+func SaveGraphBinary(graph *Graph, outputPath string) error {
+	return SaveGraph(graph, outputPath, data.FormatBinary)
+}
+
+// LoadGraphBinary loads a graph saved by SaveGraphBinary. LoadGraph detects
+// the format on its own; this only rejects other formats.
+func LoadGraphBinary(inputPath string) (*Graph, error) {
+	format, err := data.DetectFileFormat(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load graph data: %v", err)
+	}
+	if format != data.FormatBinary {
+		return nil, fmt.Errorf("%s is %s, not binary", inputPath, format)
+	}
+	return LoadGraph(inputPath)
+}
+
+func (g *Graph) MarshalBinaryTo(w io.Writer) error {
+	index := make(map[string]uint32, len(g.Nodes))
+	for i, node := range g.Nodes {
+		index[node.ID] = uint32(i)
+	}
+
+	payload := graphBinary{Nodes: g.Nodes, SelfCitationCounts: g.SelfCitationCounts, Stats: g.Stats}
+	var err error
+	if payload.Edges, err = encodeBinaryEdges(g.Edges, index); err != nil {
+		return err
+	}
+	if payload.SelfCitationEdges, err = encodeBinaryEdges(g.SelfCitationEdges, index); err != nil {
+		return err
+	}
+	return gob.NewEncoder(w).Encode(payload)
+}
+
+func (g *Graph) UnmarshalBinaryFrom(r io.Reader) error {
+	if err := g.decodeBinary(r); err != nil {
+		return err
+	}
+	g.rebuildIndexes()
+	return nil
+}
+
+func (l *leanGraph) UnmarshalBinaryFrom(r io.Reader) error {
+	var g Graph
+	if err := g.decodeBinary(r); err != nil {
+		return err
+	}
+	*l = leanGraph{
+		Nodes:              g.Nodes,
+		Edges:              g.Edges,
+		Stats:              g.Stats,
+		SelfCitationEdges:  g.SelfCitationEdges,
+		SelfCitationCounts: g.SelfCitationCounts,
+	}
+	return nil
+}
+
+// decodeBinary reads the payload written by MarshalBinaryTo without
+// rebuilding the derived indexes.
+func (g *Graph) decodeBinary(r io.Reader) error {
+	var payload graphBinary
+	if err := gob.NewDecoder(r).Decode(&payload); err != nil {
+		return err
+	}
+
+	var err error
+	g.Nodes = payload.Nodes
+	g.SelfCitationCounts = payload.SelfCitationCounts
+	g.Stats = payload.Stats
+	if g.Edges, err = payload.Edges.decode(g.Nodes); err != nil {
+		return err
+	}
+	if g.SelfCitationEdges, err = payload.SelfCitationEdges.decode(g.Nodes); err != nil {
+		return err
+	}
+	return nil
+}
+
+func encodeBinaryEdges(edges []Edge, index map[string]uint32) (binaryEdges, error) {
+	var out binaryEdges
+	if len(edges) == 0 {
+		return out, nil
+	}
+	out.From = make([]uint32, len(edges))
+	out.To = make([]uint32, len(edges))
+	for i, edge := range edges {
+		from, okFrom := index[edge.From]
+		to, okTo := index[edge.To]
+		if !okFrom || !okTo {
+			return out, fmt.Errorf("edge %s -> %s references a paper not in the graph", edge.From, edge.To)
+		}
+		out.From[i], out.To[i] = from, to
+
+		if edge.Intent != "" && out.Intent == nil {
+			out.Intent = make([]string, len(edges))
+		}
+		if edge.Context != "" && out.Context == nil {
+			out.Context = make([]string, len(edges))
+		}
+		if out.Intent != nil {
+			out.Intent[i] = edge.Intent
+		}
+		if out.Context != nil {
+			out.Context[i] = edge.Context
+		}
+	}
+	return out, nil
+}
+
+func (b binaryEdges) decode(nodes []Node) ([]Edge, error) {
+	if len(b.From) == 0 {
+		return nil, nil
+	}
+	if len(b.To) != len(b.From) ||
+		(b.Intent != nil && len(b.Intent) != len(b.From)) ||
+		(b.Context != nil && len(b.Context) != len(b.From)) {
+		return nil, fmt.Errorf("edge columns have different lengths")
+	}
+
+	edges := make([]Edge, len(b.From))
+	for i := range edges {
+		from, to := int(b.From[i]), int(b.To[i])
+		if from >= len(nodes) || to >= len(nodes) {
+			return nil, fmt.Errorf("edge %d references node out of range", i)
+		}
+		edges[i] = Edge{From: nodes[from].ID, To: nodes[to].ID, Year: nodes[from].Year}
+		if b.Intent != nil {
+			edges[i].Intent = b.Intent[i]
+		}
+		if b.Context != nil {
+			edges[i].Context = b.Context[i]
+		}
+	}
+	return edges, nil
+}
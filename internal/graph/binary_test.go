@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+// binaryFixture is a small graph using every field the binary format
+// stores: node metadata, intents, contexts and set-aside self-citations.
+func binaryFixture() *Graph {
+	g := GenerateRandomGraph(50, 200, 7)
+	yearOf := make(map[string]int, len(g.Nodes))
+	for i, node := range g.Nodes {
+		yearOf[node.ID] = node.Year
+		if i%2 == 0 {
+			g.Nodes[i].NumCitedBy = 10 * i
+			g.Nodes[i].DOI = "10.18653/v1/" + strings.ToLower(node.ID)
+			g.Nodes[i].URL = "https://aclanthology.org/" + node.ID
+		}
+	}
+	for i := range g.Edges {
+		g.Edges[i].Year = yearOf[g.Edges[i].From]
+		if i%3 == 0 {
+			g.Edges[i].Intent = "method"
+			g.Edges[i].Context = "Section 2"
+		}
+	}
+	self := g.Nodes[4].ID
+	g.SelfCitationEdges = []Edge{{From: self, To: self, Year: yearOf[self]}}
+	g.SelfCitationCounts = map[string]int{self: 1}
+	return g
+}
+
+func TestBinaryRoundTripMatchesMsgpack(t *testing.T) {
+	dir := t.TempDir()
+	g := binaryFixture()
+
+	binaryPath := filepath.Join(dir, "graph.bin")
+	msgpackPath := filepath.Join(dir, "graph.msgpack")
+	if err := SaveGraphBinary(g, binaryPath); err != nil {
+		t.Fatalf("SaveGraphBinary: %v", err)
+	}
+	if err := SaveGraph(g, msgpackPath, data.FormatMsgpack); err != nil {
+		t.Fatalf("SaveGraph msgpack: %v", err)
+	}
+
+	fromBinary, err := LoadGraphBinary(binaryPath)
+	if err != nil {
+		t.Fatalf("LoadGraphBinary: %v", err)
+	}
+	fromMsgpack, err := LoadGraph(msgpackPath)
+	if err != nil {
+		t.Fatalf("LoadGraph msgpack: %v", err)
+	}
+
+	for _, field := range []struct {
+		name        string
+		binary, msg any
+	}{
+		{"Nodes", fromBinary.Nodes, fromMsgpack.Nodes},
+		{"Edges", fromBinary.Edges, fromMsgpack.Edges},
+		{"AdjList", fromBinary.AdjList, fromMsgpack.AdjList},
+		{"InDegree", fromBinary.InDegree, fromMsgpack.InDegree},
+		{"OutDegree", fromBinary.OutDegree, fromMsgpack.OutDegree},
+		{"Stats", fromBinary.Stats, fromMsgpack.Stats},
+		{"SelfCitationEdges", fromBinary.SelfCitationEdges, fromMsgpack.SelfCitationEdges},
+		{"SelfCitationCounts", fromBinary.SelfCitationCounts, fromMsgpack.SelfCitationCounts},
+	} {
+		if !reflect.DeepEqual(field.binary, field.msg) {
+			t.Errorf("%s differ between binary and msgpack loads", field.name)
+		}
+	}
+	if !reflect.DeepEqual(fromBinary.Edges, g.Edges) {
+		t.Errorf("binary load changed the edges")
+	}
+}
+
+func TestLoadGraphBinaryRejectsOtherFormats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.json")
+	if err := SaveGraph(binaryFixture(), path, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadGraphBinary(path); err == nil {
+		t.Error("LoadGraphBinary accepted a JSON graph")
+	}
+}
@@ -3,8 +3,9 @@ package graph
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
+	"slices"
+	"sort"
 
 	"paper-rank/internal/data"
 )
@@ -12,10 +13,19 @@ import (
 type Graph struct {
 	Nodes     []Node              `json:"nodes"`
 	Edges     []Edge              `json:"edges"`
-	AdjList   map[string][]string `json:"adj_list"`   // paper_id -> list of cited paper_ids
+	AdjList   map[string][]string `json:"-"`          // paper_id -> list of cited paper_ids; derived from Edges on load
 	InDegree  map[string]int      `json:"in_degree"`  // paper_id -> number of papers citing it
 	OutDegree map[string]int      `json:"out_degree"` // paper_id -> number of papers it cites
 	Stats     GraphStats          `json:"stats"`
+
+	// only filled with --keep-self-citations; never used for ranking
+	SelfCitationEdges  []Edge         `json:"self_citation_edges,omitempty"`
+	SelfCitationCounts map[string]int `json:"self_citation_counts,omitempty"` // paper_id -> times it cites itself
+}
+
+// options for building the graph
+type BuildConfig struct {
+	KeepSelfCitations bool `json:"keep_self_citations"`
 }
 
 type Node struct {
@@ -23,11 +33,20 @@ type Node struct {
 	Title   string   `json:"title"`
 	Year    int      `json:"year"`
 	Authors []string `json:"authors"`
+
+	// metadata carried over from the papers so consumers of the graph need
+	// not reload papers.json; abstracts are left out to keep the file small
+	NumCitedBy int    `json:"num_cited_by,omitempty"` // citations in the source data, including papers outside the corpus
+	DOI        string `json:"doi,omitempty"`
+	URL        string `json:"url,omitempty"`
 }
 
 type Edge struct {
-	From string `json:"from"`
-	To   string `json:"to"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Intent  string `json:"intent,omitempty"`
+	Context string `json:"context,omitempty"`
+	Year    int    `json:"year,omitempty"` // the citing paper's year; 0 when unknown
 }
 
 type PaperInfo struct {
@@ -59,9 +78,20 @@ type GraphStats struct {
 	IsolatedNodes   int     `json:"isolated_nodes"` // nodes with no edges
 	SelfCitations   int     `json:"self_citations"` // node pointing to itself
 	GraphDensity    float64 `json:"graph_density"`  // edges/possible_edges
+
+	// edges whose citing and cited papers share an author; kept in the
+	// graph, and only treated differently by rank --author-self-citations
+	AuthorSelfCitations int `json:"author_self_citations"`
+
+	// strongly connected components with more than one paper; papers cite
+	// older work, so these point at bad ID links or anachronistic edges
+	CycleComponents       int        `json:"cycle_components"`
+	LargestCycleComponent int        `json:"largest_cycle_component"`
+	PapersInCycles        int        `json:"papers_in_cycles"`
+	CycleExamples         [][]string `json:"cycle_examples,omitempty"` // a few ids from the largest components
 }
 
-func BuildGraph(parsedDataPath string) (*Graph, error) {
+func BuildGraph(parsedDataPath string, config BuildConfig) (*Graph, error) {
 	fmt.Printf("Loading parsed data from: %s\n", parsedDataPath)
 
 	parsedData, err := data.LoadParsedData(parsedDataPath)
@@ -69,6 +99,11 @@ func BuildGraph(parsedDataPath string) (*Graph, error) {
 		return nil, fmt.Errorf("failed to load parsed data: %v", err)
 	}
 
+	return BuildGraphFromData(parsedData, config), nil
+}
+
+// BuildGraphFromData builds the citation graph from already-parsed data.
+func BuildGraphFromData(parsedData *data.ParsedData, config BuildConfig) *Graph {
 	fmt.Printf("Building graph from %d papers and %d citations...\n",
 		len(parsedData.Papers), len(parsedData.Citations))
 
@@ -86,6 +121,10 @@ func BuildGraph(parsedDataPath string) (*Graph, error) {
 			Title:   paper.Title,
 			Year:    paper.Year,
 			Authors: paper.Authors,
+
+			NumCitedBy: paper.NumCitedBy,
+			DOI:        paper.DOI,
+			URL:        paper.URL,
 		}
 		graph.Nodes = append(graph.Nodes, node)
 
@@ -94,10 +133,17 @@ func BuildGraph(parsedDataPath string) (*Graph, error) {
 		graph.AdjList[paper.ID] = []string{}
 	}
 
+	years := make(map[string]int, len(parsedData.Papers))
+	for _, paper := range parsedData.Papers {
+		years[paper.ID] = paper.Year
+	}
+
 	validEdges := 0
 	selfCitations := 0
 
-	for _, citation := range parsedData.Citations {
+	// files from before self-citations were split off still hold them in
+	// Citations, so both lists go through the same check
+	for _, citation := range slices.Concat(parsedData.Citations, parsedData.SelfCitations) {
 		_, fromExists := graph.InDegree[citation.From]
 		_, toExists := graph.InDegree[citation.To]
 
@@ -108,12 +154,27 @@ func BuildGraph(parsedDataPath string) (*Graph, error) {
 		// check for self-citations
 		if citation.From == citation.To {
 			selfCitations++
+			if config.KeepSelfCitations {
+				if graph.SelfCitationCounts == nil {
+					graph.SelfCitationCounts = make(map[string]int)
+				}
+				graph.SelfCitationEdges = append(graph.SelfCitationEdges, Edge{
+					From:    citation.From,
+					To:      citation.To,
+					Intent:  citation.Intent,
+					Context: citation.Context,
+				})
+				graph.SelfCitationCounts[citation.From]++
+			}
 			continue
 		}
 
 		edge := Edge{
-			From: citation.From,
-			To:   citation.To,
+			From:    citation.From,
+			To:      citation.To,
+			Intent:  citation.Intent,
+			Context: citation.Context,
+			Year:    years[citation.From],
 		}
 		graph.Edges = append(graph.Edges, edge)
 
@@ -130,7 +191,81 @@ func BuildGraph(parsedDataPath string) (*Graph, error) {
 
 	graph.Stats = calculateGraphStats(graph, selfCitations)
 
-	return graph, nil
+	return graph
+}
+
+// SelfCitationRate returns the share of all citations that are
+// self-citations.
+func (g *Graph) SelfCitationRate() float64 {
+	total := g.Stats.TotalEdges + g.Stats.SelfCitations
+	if total == 0 {
+		return 0
+	}
+	return float64(g.Stats.SelfCitations) / float64(total)
+}
+
+// CitingIndex returns the reverse of AdjList: paper_id -> papers citing it,
+// in edge order.
+func (g *Graph) CitingIndex() map[string][]string {
+	citing := make(map[string][]string, len(g.Nodes))
+	for _, edge := range g.Edges {
+		citing[edge.To] = append(citing[edge.To], edge.From)
+	}
+	return citing
+}
+
+// IsolatedNodes returns the papers that neither cite nor are cited by any
+// paper in the graph, in node order.
+func (g *Graph) IsolatedNodes() []string {
+	var ids []string
+	for _, node := range g.Nodes {
+		if g.InDegree[node.ID] == 0 && g.OutDegree[node.ID] == 0 {
+			ids = append(ids, node.ID)
+		}
+	}
+	return ids
+}
+
+// DanglingNodes returns the papers that cite no paper in the graph
+// (isolated papers included), in node order.
+func (g *Graph) DanglingNodes() []string {
+	var ids []string
+	for _, node := range g.Nodes {
+		if g.OutDegree[node.ID] == 0 {
+			ids = append(ids, node.ID)
+		}
+	}
+	return ids
+}
+
+// AuthorSelfCitations reports for every edge, by index, whether the citing
+// and cited papers share an author, comparing data.NormalizeAuthor keys.
+// Papers without author data never match.
+func (g *Graph) AuthorSelfCitations() []bool {
+	authors := make(map[string]map[string]bool, len(g.Nodes))
+	for _, node := range g.Nodes {
+		if len(node.Authors) == 0 {
+			continue
+		}
+		keys := make(map[string]bool, len(node.Authors))
+		for _, author := range node.Authors {
+			if key := data.NormalizeAuthor(author); key != "" {
+				keys[key] = true
+			}
+		}
+		authors[node.ID] = keys
+	}
+
+	shared := make([]bool, len(g.Edges))
+	for i, edge := range g.Edges {
+		for key := range authors[edge.From] {
+			if authors[edge.To][key] {
+				shared[i] = true
+				break
+			}
+		}
+	}
+	return shared
 }
 
 func calculateGraphStats(graph *Graph, selfCitations int) GraphStats {
@@ -139,6 +274,11 @@ func calculateGraphStats(graph *Graph, selfCitations int) GraphStats {
 		TotalEdges:    len(graph.Edges),
 		SelfCitations: selfCitations,
 	}
+	for _, shared := range graph.AuthorSelfCitations() {
+		if shared {
+			stats.AuthorSelfCitations++
+		}
+	}
 
 	if stats.TotalNodes == 0 {
 		return stats
@@ -155,12 +295,13 @@ func calculateGraphStats(graph *Graph, selfCitations int) GraphStats {
 		totalInDegree += inDegree
 		totalOutDegree += outDegree
 
-		if inDegree > maxInDegree {
+		// ties go to the smallest id so stats don't depend on map order
+		if inDegree > maxInDegree || (inDegree == maxInDegree && inDegree > 0 && paperID < mostCitedPaper) {
 			maxInDegree = inDegree
 			mostCitedPaper = paperID
 		}
 
-		if outDegree > maxOutDegree {
+		if outDegree > maxOutDegree || (outDegree == maxOutDegree && outDegree > 0 && paperID < mostCitingPaper) {
 			maxOutDegree = outDegree
 			mostCitingPaper = paperID
 		}
@@ -183,40 +324,181 @@ func calculateGraphStats(graph *Graph, selfCitations int) GraphStats {
 		stats.GraphDensity = float64(stats.TotalEdges) / float64(maxPossibleEdges)
 	}
 
+	cycleStats(graph, &stats)
 	return stats
 }
 
-func SaveGraph(graph *Graph, outputPath string) error {
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %v", err)
+func SaveGraph(graph *Graph, outputPath string, format data.Format) error {
+	if err := data.EncodeFile(outputPath, graph, format); err != nil {
+		return fmt.Errorf("failed to write graph file: %v", err)
 	}
+	return nil
+}
 
-	jsonData, err := json.MarshalIndent(graph, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal graph to JSON: %v", err)
-	}
+// leanGraph is what LoadGraphLean decodes: the stored graph minus the
+// derived degree maps (and adj_list in older files), which the decoder then
+// skips.
+type leanGraph struct {
+	Nodes              []Node         `json:"nodes"`
+	Edges              []Edge         `json:"edges"`
+	Stats              GraphStats     `json:"stats"`
+	SelfCitationEdges  []Edge         `json:"self_citation_edges,omitempty"`
+	SelfCitationCounts map[string]int `json:"self_citation_counts,omitempty"`
+}
 
-	if err := os.WriteFile(outputPath, jsonData, 0644); err != nil {
-		return fmt.Errorf("failed to write graph file: %v", err)
+func (l *leanGraph) UnmarshalJSONL(dec *json.Decoder) error {
+	var g Graph
+	if err := g.decodeJSONLRecords(dec); err != nil {
+		return err
+	}
+	*l = leanGraph{
+		Nodes:              g.Nodes,
+		Edges:              g.Edges,
+		Stats:              g.Stats,
+		SelfCitationEdges:  g.SelfCitationEdges,
+		SelfCitationCounts: g.SelfCitationCounts,
 	}
-
 	return nil
 }
 
-func LoadGraph(inputPath string) (*Graph, error) {
-	jsonData, err := os.ReadFile(inputPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read graph file: %v", err)
+// LoadGraphLean loads a graph for ranking: nodes, edges, stats and degree
+// maps recomputed from the edges. AdjList is left nil; use LoadGraph for
+// commands that need it.
+func LoadGraphLean(inputPath string) (*Graph, error) {
+	var lean leanGraph
+	if err := data.DecodeFile(inputPath, &lean); err != nil {
+		return nil, fmt.Errorf("failed to load graph data: %v", err)
 	}
 
+	graph := &Graph{
+		Nodes:              lean.Nodes,
+		Edges:              lean.Edges,
+		Stats:              lean.Stats,
+		SelfCitationEdges:  lean.SelfCitationEdges,
+		SelfCitationCounts: lean.SelfCitationCounts,
+	}
+	graph.rebuildDegrees()
+	return graph, nil
+}
+
+// LoadGraph loads a graph with its adjacency list rebuilt from the edges.
+// AdjList is not saved; an adj_list field in files written by older versions
+// is ignored.
+func LoadGraph(inputPath string) (*Graph, error) {
 	var graph Graph
-	if err := json.Unmarshal(jsonData, &graph); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal graph data: %v", err)
+	if err := data.DecodeFile(inputPath, &graph); err != nil {
+		return nil, fmt.Errorf("failed to load graph data: %v", err)
 	}
 
+	if graph.AdjList == nil {
+		graph.buildAdjList()
+	}
 	return &graph, nil
 }
 
+// MarshalJSONL writes the stats, then one line per node and per edge. The
+// adjacency list and degree maps are derived from the edges on load.
+func (g *Graph) MarshalJSONL(enc *json.Encoder) error {
+	if err := data.WriteRecord(enc, "stats", g.Stats); err != nil {
+		return err
+	}
+	for _, node := range g.Nodes {
+		if err := data.WriteRecord(enc, "node", node); err != nil {
+			return err
+		}
+	}
+	for _, edge := range g.Edges {
+		if err := data.WriteRecord(enc, "edge", edge); err != nil {
+			return err
+		}
+	}
+	for _, edge := range g.SelfCitationEdges {
+		if err := data.WriteRecord(enc, "self_citation", edge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Graph) UnmarshalJSONL(dec *json.Decoder) error {
+	if err := g.decodeJSONLRecords(dec); err != nil {
+		return err
+	}
+	g.rebuildIndexes()
+	return nil
+}
+
+// decodeJSONLRecords reads the records written by MarshalJSONL without
+// rebuilding the derived indexes.
+func (g *Graph) decodeJSONLRecords(dec *json.Decoder) error {
+	for {
+		var rec data.Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		var err error
+		switch rec.Kind {
+		case "stats":
+			err = json.Unmarshal(rec.Data, &g.Stats)
+		case "node":
+			var node Node
+			err = json.Unmarshal(rec.Data, &node)
+			g.Nodes = append(g.Nodes, node)
+		case "edge":
+			var edge Edge
+			err = json.Unmarshal(rec.Data, &edge)
+			g.Edges = append(g.Edges, edge)
+		case "self_citation":
+			var edge Edge
+			err = json.Unmarshal(rec.Data, &edge)
+			g.SelfCitationEdges = append(g.SelfCitationEdges, edge)
+			if g.SelfCitationCounts == nil {
+				g.SelfCitationCounts = make(map[string]int)
+			}
+			g.SelfCitationCounts[edge.From]++
+		}
+		if err != nil {
+			return fmt.Errorf("bad %s record: %v", rec.Kind, err)
+		}
+	}
+	return nil
+}
+
+// rebuildIndexes recomputes the adjacency list and degree maps from Nodes
+// and Edges.
+func (g *Graph) rebuildIndexes() {
+	g.buildAdjList()
+	g.rebuildDegrees()
+}
+
+// buildAdjList recomputes the adjacency list from Nodes and Edges.
+func (g *Graph) buildAdjList() {
+	g.AdjList = make(map[string][]string, len(g.Nodes))
+	for _, node := range g.Nodes {
+		g.AdjList[node.ID] = []string{}
+	}
+	for _, edge := range g.Edges {
+		g.AdjList[edge.From] = append(g.AdjList[edge.From], edge.To)
+	}
+}
+
+// rebuildDegrees recomputes the degree maps from Nodes and Edges.
+func (g *Graph) rebuildDegrees() {
+	g.InDegree = make(map[string]int, len(g.Nodes))
+	g.OutDegree = make(map[string]int, len(g.Nodes))
+	for _, node := range g.Nodes {
+		g.InDegree[node.ID] = 0
+		g.OutDegree[node.ID] = 0
+	}
+	for _, edge := range g.Edges {
+		g.OutDegree[edge.From]++
+		g.InDegree[edge.To]++
+	}
+}
+
 func PrintGraphStats(stats GraphStats) {
 	fmt.Println("\n=== Graph Statistics ===")
 	fmt.Printf("Total nodes (papers): %d\n", stats.TotalNodes)
@@ -233,7 +515,15 @@ func PrintGraphStats(stats GraphStats) {
 	fmt.Printf("Isolated nodes: %d (%.1f%%)\n",
 		stats.IsolatedNodes,
 		float64(stats.IsolatedNodes)/float64(stats.TotalNodes)*100)
-	fmt.Printf("Self-citations found: %d (filtered out)\n", stats.SelfCitations)
+	fmt.Printf("Self-citations found: %d (excluded from ranking)\n", stats.SelfCitations)
+	if stats.TotalEdges > 0 {
+		fmt.Printf("Author self-citations: %d (%.1f%% of citations share an author; see rank --author-self-citations)\n",
+			stats.AuthorSelfCitations, float64(stats.AuthorSelfCitations)/float64(stats.TotalEdges)*100)
+	}
+	if stats.CycleComponents > 0 {
+		fmt.Printf("Citation cycles: %d components, %d papers (largest %d; run 'acl-ranker validate --scc')\n",
+			stats.CycleComponents, stats.PapersInCycles, stats.LargestCycleComponent)
+	}
 }
 
 func (g *Graph) GetMostCitedPapers(n int) []PaperRanking {
@@ -251,13 +541,12 @@ func (g *Graph) GetMostCitedPapers(n int) []PaperRanking {
 		rankings = append(rankings, ranking)
 	}
 
-	for i := 0; i < len(rankings)-1; i++ {
-		for j := i + 1; j < len(rankings); j++ {
-			if rankings[j].Citations > rankings[i].Citations {
-				rankings[i], rankings[j] = rankings[j], rankings[i]
-			}
+	sort.Slice(rankings, func(i, j int) bool {
+		if rankings[i].Citations != rankings[j].Citations {
+			return rankings[i].Citations > rankings[j].Citations
 		}
-	}
+		return rankings[i].PaperID < rankings[j].PaperID
+	})
 
 	if n > len(rankings) {
 		n = len(rankings)
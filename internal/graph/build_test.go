@@ -0,0 +1,27 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+func TestBuildCarriesPaperMetadataOntoNodes(t *testing.T) {
+	parsed := &data.ParsedData{
+		Papers: []data.Paper{
+			{ID: "A", Title: "A", Year: 2001, Authors: []string{"Ada Lovelace"}, NumCitedBy: 120, DOI: "10.1/a", URL: "https://aclanthology.org/A"},
+			{ID: "B", Title: "B", Year: 2002, Citations: []string{"A"}},
+		},
+		Citations: []data.CitationEdge{{From: "B", To: "A"}},
+	}
+	g := BuildGraphFromData(parsed, BuildConfig{})
+
+	want := []Node{
+		{ID: "A", Title: "A", Year: 2001, Authors: []string{"Ada Lovelace"}, NumCitedBy: 120, DOI: "10.1/a", URL: "https://aclanthology.org/A"},
+		{ID: "B", Title: "B", Year: 2002},
+	}
+	if !reflect.DeepEqual(g.Nodes, want) {
+		t.Errorf("nodes\n%+v\nwant\n%+v", g.Nodes, want)
+	}
+}
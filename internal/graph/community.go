@@ -0,0 +1,311 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"paper-rank/internal/data"
+)
+
+// community assignment and the per-community PageRank results
+type CommunityResult struct {
+	Assignment map[string]int       `json:"assignment"` // paper_id -> community id
+	Sizes      []int                `json:"sizes"`      // community id -> number of papers
+	Rankings   map[int][]PaperScore `json:"rankings"`   // community id -> top papers by in-community PageRank
+	Labels     map[int]string       `json:"labels"`     // community id -> most frequent title terms
+}
+
+const (
+	maxLabelPropagationRounds = 50
+	labelPropagationSeed      = 1
+)
+
+// DetectCommunities assigns every paper a community id using label
+// propagation on the undirected citation graph. Each round visits the nodes
+// in a shuffled order and moves each to its neighbors' most common label;
+// a node keeps its label when it is among the most common, otherwise ties
+// are broken at random. The shuffle uses a fixed seed, so the result is
+// deterministic. Community ids are numbered by size, largest first.
+func DetectCommunities(g *Graph) map[string]int {
+	neighbors := make(map[string][]string, len(g.Nodes))
+	for _, edge := range g.Edges {
+		neighbors[edge.From] = append(neighbors[edge.From], edge.To)
+		neighbors[edge.To] = append(neighbors[edge.To], edge.From)
+	}
+
+	labels := make(map[string]int, len(g.Nodes))
+	order := make([]string, len(g.Nodes))
+	for i, node := range g.Nodes {
+		labels[node.ID] = i
+		order[i] = node.ID
+	}
+
+	rng := rand.New(rand.NewSource(labelPropagationSeed))
+
+	for round := 0; round < maxLabelPropagationRounds; round++ {
+		rng.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+
+		changed := false
+		for _, paperID := range order {
+			if len(neighbors[paperID]) == 0 {
+				continue
+			}
+
+			counts := make(map[int]int)
+			bestCount := 0
+			for _, neighbor := range neighbors[paperID] {
+				label := labels[neighbor]
+				counts[label]++
+				if counts[label] > bestCount {
+					bestCount = counts[label]
+				}
+			}
+
+			if counts[labels[paperID]] == bestCount {
+				continue
+			}
+
+			candidates := make([]int, 0, len(counts))
+			for label, count := range counts {
+				if count == bestCount {
+					candidates = append(candidates, label)
+				}
+			}
+			sort.Ints(candidates)
+
+			labels[paperID] = candidates[rng.Intn(len(candidates))]
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return renumberCommunities(g, labels)
+}
+
+// renumberCommunities maps raw labels to ids 0..k-1 ordered by community size
+// (largest first), breaking ties by first appearance in the node list.
+func renumberCommunities(g *Graph, labels map[string]int) map[string]int {
+	sizes := make(map[int]int)
+	firstSeen := make(map[int]int)
+	for i, node := range g.Nodes {
+		label := labels[node.ID]
+		if _, ok := firstSeen[label]; !ok {
+			firstSeen[label] = i
+		}
+		sizes[label]++
+	}
+
+	order := make([]int, 0, len(sizes))
+	for label := range sizes {
+		order = append(order, label)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if sizes[order[i]] != sizes[order[j]] {
+			return sizes[order[i]] > sizes[order[j]]
+		}
+		return firstSeen[order[i]] < firstSeen[order[j]]
+	})
+
+	newID := make(map[int]int, len(order))
+	for id, label := range order {
+		newID[label] = id
+	}
+
+	communities := make(map[string]int, len(labels))
+	for paperID, label := range labels {
+		communities[paperID] = newID[label]
+	}
+	return communities
+}
+
+// CommunitySizes returns the number of papers in each community, indexed by
+// community id.
+func CommunitySizes(communities map[string]int) []int {
+	numCommunities := 0
+	for _, id := range communities {
+		if id+1 > numCommunities {
+			numCommunities = id + 1
+		}
+	}
+
+	sizes := make([]int, numCommunities)
+	for _, id := range communities {
+		sizes[id]++
+	}
+	return sizes
+}
+
+// Subgraph returns the graph induced by the papers in keep: those nodes and
+// the edges between them, with degrees and stats recomputed.
+func Subgraph(g *Graph, keep map[string]bool) *Graph {
+	sub := &Graph{
+		Nodes: []Node{},
+		Edges: []Edge{},
+	}
+
+	for _, node := range g.Nodes {
+		if keep[node.ID] {
+			sub.Nodes = append(sub.Nodes, node)
+		}
+	}
+	for _, edge := range g.Edges {
+		if keep[edge.From] && keep[edge.To] {
+			sub.Edges = append(sub.Edges, edge)
+		}
+	}
+
+	sub.rebuildIndexes()
+	sub.Stats = calculateGraphStats(sub, 0)
+	return sub
+}
+
+// RankCommunities runs PageRank independently on the induced subgraph of
+// every community with at least minSize papers and keeps the top papers of
+// each. The subgraphs are split off in one pass over the nodes and edges,
+// and the per-community runs do not print their progress.
+func RankCommunities(g *Graph, communities map[string]int, config PageRankConfig, minSize, top int) (*CommunityResult, error) {
+	result := &CommunityResult{
+		Assignment: communities,
+		Sizes:      CommunitySizes(communities),
+		Rankings:   make(map[int][]PaperScore),
+		Labels:     make(map[int]string),
+	}
+
+	parts := make(map[int]*Graph)
+	for id, size := range result.Sizes {
+		if size >= minSize {
+			parts[id] = &Graph{Nodes: []Node{}, Edges: []Edge{}}
+		}
+	}
+
+	titles := make(map[int][]string)
+	for _, node := range g.Nodes {
+		id, ok := communities[node.ID]
+		if !ok {
+			continue
+		}
+		titles[id] = append(titles[id], node.Title)
+		if part := parts[id]; part != nil {
+			part.Nodes = append(part.Nodes, node)
+		}
+	}
+	for _, edge := range g.Edges {
+		id, ok := communities[edge.From]
+		if to, toOK := communities[edge.To]; !ok || !toOK || to != id {
+			continue
+		}
+		if part := parts[id]; part != nil {
+			part.Edges = append(part.Edges, edge)
+		}
+	}
+
+	fmt.Printf("Ranking %d communities of at least %d papers...\n", len(parts), minSize)
+	for id := range result.Sizes {
+		part := parts[id]
+		if part == nil {
+			continue
+		}
+		part.rebuildIndexes()
+		part.Stats = calculateGraphStats(part, 0)
+
+		pr, err := runPageRank(context.Background(), part, config, nil, teleportVector(part, config, io.Discard), io.Discard)
+		if err != nil {
+			return nil, fmt.Errorf("community %d: %v", id, err)
+		}
+
+		rankings := pr.Rankings
+		if len(rankings) > top {
+			rankings = rankings[:top]
+		}
+		result.Rankings[id] = rankings
+		result.Labels[id] = communityLabel(titles[id], 3)
+	}
+
+	return result, nil
+}
+
+// communityLabel names a community after the n most frequent content terms
+// in its paper titles.
+func communityLabel(titles []string, n int) string {
+	counts := make(map[string]int)
+	for _, title := range titles {
+		for _, token := range data.ContentTokens(title) {
+			counts[token]++
+		}
+	}
+
+	terms := make([]string, 0, len(counts))
+	for term := range counts {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if counts[terms[i]] != counts[terms[j]] {
+			return counts[terms[i]] > counts[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return strings.Join(terms, " / ")
+}
+
+func SaveCommunityResult(result *CommunityResult, outputPath string) error {
+	if err := data.EncodeFile(outputPath, result, data.FormatJSON); err != nil {
+		return fmt.Errorf("failed to write communities file: %v", err)
+	}
+	return nil
+}
+
+func LoadCommunityResult(inputPath string) (*CommunityResult, error) {
+	var result CommunityResult
+	if err := data.DecodeFile(inputPath, &result); err != nil {
+		return nil, fmt.Errorf("failed to load communities data: %v", err)
+	}
+	return &result, nil
+}
+
+func PrintCommunityRankings(p Printer, result *CommunityResult, maxCommunities, n int) {
+	fmt.Println("\n=== Communities ===")
+	fmt.Printf("Communities found: %d\n", len(result.Sizes))
+
+	singletons := 0
+	for _, size := range result.Sizes {
+		if size == 1 {
+			singletons++
+		}
+	}
+	fmt.Printf("Singleton communities: %d\n", singletons)
+
+	for id, size := range result.Sizes {
+		if id >= maxCommunities {
+			break
+		}
+		rankings := result.Rankings[id]
+		if len(rankings) == 0 {
+			continue
+		}
+
+		fmt.Printf("\nCommunity %d (%d papers): %s\n", id, size, result.Labels[id])
+		for i, paper := range rankings {
+			if i >= n {
+				break
+			}
+			titleTrunc := paper.Title
+			if len(titleTrunc) > 60 {
+				titleTrunc = titleTrunc[:57] + "..."
+			}
+			fmt.Printf("  %d. %s  %s (%d)\n", i+1, p.Score(paper.Score), titleTrunc, paper.Year)
+		}
+	}
+	fmt.Println("===================")
+}
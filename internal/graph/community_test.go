@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// twoCommunities plants two five-paper cliques, a0..a4 and b0..b4, joined
+// by a single citation.
+func twoCommunities(t *testing.T) *Graph {
+	var edges []string
+	for _, prefix := range []string{"a", "b"} {
+		for i := 0; i < 5; i++ {
+			for j := 0; j < i; j++ {
+				edges = append(edges, fmt.Sprintf("%s%d>%s%d", prefix, i, prefix, j))
+			}
+		}
+	}
+	edges = append(edges, "b4>a4")
+	return testGraph(t, edges...)
+}
+
+func TestDetectCommunitiesFindsPlantedCommunities(t *testing.T) {
+	communities := DetectCommunities(twoCommunities(t))
+	if sizes := CommunitySizes(communities); len(sizes) != 2 || sizes[0] != 5 || sizes[1] != 5 {
+		t.Fatalf("community sizes %v, want [5 5]", sizes)
+	}
+	for i := 1; i < 5; i++ {
+		if communities[fmt.Sprintf("a%d", i)] != communities["a0"] || communities[fmt.Sprintf("b%d", i)] != communities["b0"] {
+			t.Errorf("a planted community was split: %v", communities)
+		}
+	}
+	if communities["a0"] == communities["b0"] {
+		t.Error("the two planted communities were merged")
+	}
+}
+
+func TestRankCommunitiesMatchesPageRankOnEachSubgraph(t *testing.T) {
+	g := twoCommunities(t)
+	communities := DetectCommunities(g)
+	result, err := RankCommunities(g, communities, testConfig(), 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rankings) != 2 {
+		t.Fatalf("ranked %d communities, want 2", len(result.Rankings))
+	}
+
+	for id, rankings := range result.Rankings {
+		members := make(map[string]bool)
+		for paperID, c := range communities {
+			if c == id {
+				members[paperID] = true
+			}
+		}
+		want, err := CalculatePageRank(Subgraph(g, members), testConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rankings) != 5 {
+			t.Errorf("community %d has %d rankings, want 5", id, len(rankings))
+		}
+		for i, paper := range rankings {
+			if paper.PaperID != want.Rankings[i].PaperID || math.Abs(paper.Score-want.Rankings[i].Score) > 1e-12 {
+				t.Errorf("community %d rank %d: got %s %.6f, want %s %.6f", id, i+1,
+					paper.PaperID, paper.Score, want.Rankings[i].PaperID, want.Rankings[i].Score)
+			}
+		}
+		// the oldest paper of each clique is cited by all the others
+		if top := rankings[0].PaperID; top != "a0" && top != "b0" {
+			t.Errorf("community %d is led by %s, want its oldest paper", id, top)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRankFlowsAlongCitationsOnAChain(t *testing.T) {
+	// A cites B, B cites C
+	g := testGraph(t, "A>B", "B>C")
+
+	config := testConfig()
+	cited, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the default: the most cited end of the chain ranks highest
+	want := map[string]float64{"A": 0.184416, "B": 0.341171, "C": 0.474412}
+	for id, score := range want {
+		if got := cited.Scores[id]; math.Abs(got-score) > 1e-6 {
+			t.Errorf("%s: score %.6f, want %.6f", id, got, score)
+		}
+	}
+	if got := rankingIDs(cited.Rankings); got != "[C B A]" {
+		t.Errorf("ranking %s, want [C B A]", got)
+	}
+
+	// reversed, rank flows to the citing papers and the chain flips
+	config.Direction = DirectionCiting
+	citing, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, mirror := range map[string]string{"A": "C", "B": "B", "C": "A"} {
+		if got, want := citing.Scores[id], cited.Scores[mirror]; math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: citing score %.6f, want %s's cited score %.6f", id, got, mirror, want)
+		}
+	}
+	if got := rankingIDs(citing.Rankings); got != "[A B C]" {
+		t.Errorf("citing ranking %s, want [A B C]", got)
+	}
+}
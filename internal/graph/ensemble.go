@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ways of combining several PageRank runs into one ranking
+const (
+	EnsembleBorda    = "borda"     // sum of Borda points, highest first
+	EnsembleRankAvg  = "rank-avg"  // mean rank, lowest first
+	EnsembleScoreAvg = "score-avg" // mean PageRank score, highest first
+)
+
+// ParseEnsembleMethod validates an --method value for 'ensemble'.
+func ParseEnsembleMethod(s string) (string, error) {
+	switch method := strings.ToLower(strings.TrimSpace(s)); method {
+	case EnsembleBorda, EnsembleRankAvg, EnsembleScoreAvg:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown ensemble method %q (want borda, rank-avg or score-avg)", s)
+}
+
+// a paper's place in the combined ranking
+type EnsembleScore struct {
+	PaperID string  `json:"paper_id"`
+	Title   string  `json:"title"`
+	Year    int     `json:"year"`
+	Score   float64 `json:"score"` // Borda points, mean rank or mean score, by method
+	Runs    int     `json:"runs"`  // runs that ranked the paper
+	Ranks   []int   `json:"ranks"` // 1-based rank in each run, 0 where it is missing
+}
+
+// several PageRank runs combined into one ranking
+type EnsembleResult struct {
+	Method   string          `json:"method"`
+	Inputs   []string        `json:"inputs"`
+	Rankings []EnsembleScore `json:"rankings"`
+}
+
+// Ensemble combines PageRank runs over the union of their papers. Borda
+// gives the paper ranked r of n papers n-r points; rank-avg averages ranks.
+// A paper missing from a run shares that run's bottom places: it gets the
+// mean rank, and points, of the places below the run's last paper. For
+// score-avg it scores 0 there, as a paper outside the graph has no PageRank.
+// Ties are broken by paper id.
+func Ensemble(runs []*PageRankResult, method string) []EnsembleScore {
+	byID := make(map[string]*EnsembleScore)
+	var papers []*EnsembleScore
+	ranked := make([][]string, len(runs))
+	for i, run := range runs {
+		ranked[i] = rankedIDs(run.Scores)
+		info := make(map[string]PaperScore, len(run.Rankings))
+		for _, paper := range run.Rankings {
+			info[paper.PaperID] = paper
+		}
+		for _, id := range ranked[i] {
+			paper := byID[id]
+			if paper == nil {
+				paper = &EnsembleScore{PaperID: id, Ranks: make([]int, len(runs))}
+				byID[id] = paper
+				papers = append(papers, paper)
+			}
+			if paper.Title == "" {
+				paper.Title, paper.Year = info[id].Title, info[id].Year
+			}
+		}
+	}
+
+	total := len(papers)
+	for i, ids := range ranked {
+		for r, id := range ids {
+			byID[id].Ranks[i] = r + 1
+			byID[id].Runs++
+		}
+		// mean of the places len(ids)+1 .. total
+		missingRank := float64(len(ids)+1+total) / 2
+
+		for _, paper := range papers {
+			rank := float64(paper.Ranks[i])
+			if rank == 0 {
+				rank = missingRank
+			}
+			switch method {
+			case EnsembleBorda:
+				paper.Score += float64(total) - rank
+			case EnsembleRankAvg:
+				paper.Score += rank / float64(len(runs))
+			case EnsembleScoreAvg:
+				paper.Score += runs[i].Scores[paper.PaperID] / float64(len(runs))
+			}
+		}
+	}
+
+	ascending := method == EnsembleRankAvg
+	sort.Slice(papers, func(i, j int) bool {
+		if papers[i].Score != papers[j].Score {
+			return (papers[i].Score < papers[j].Score) == ascending
+		}
+		return papers[i].PaperID < papers[j].PaperID
+	})
+
+	rankings := make([]EnsembleScore, len(papers))
+	for i, paper := range papers {
+		rankings[i] = *paper
+	}
+	return rankings
+}
+
+// rankedIDs orders paper ids by score, highest first, ties by id.
+func rankedIDs(scores map[string]float64) []string {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+func PrintEnsemble(p Printer, result *EnsembleResult, n int) {
+	if n > len(result.Rankings) {
+		n = len(result.Rankings)
+	}
+
+	fmt.Printf("\nTop %d Papers by %s over %d runs:\n", n, result.Method, len(result.Inputs))
+	fmt.Println("Rank | Score    | Ranks per run       | Year | Title")
+	fmt.Println("-----|----------|---------------------|------|--------------------------------")
+
+	for i := 0; i < n; i++ {
+		paper := result.Rankings[i]
+		titleTrunc := paper.Title
+		if len(titleTrunc) > 40 {
+			titleTrunc = titleTrunc[:37] + "..."
+		}
+		ranks := make([]string, len(paper.Ranks))
+		for j, rank := range paper.Ranks {
+			ranks[j] = "-"
+			if rank > 0 {
+				ranks[j] = fmt.Sprint(rank)
+			}
+		}
+
+		fmt.Printf("%-4d | %s | %-19s | %-4d | %s\n",
+			i+1, p.StyleScore(fmt.Sprintf("%-8s", p.Score(paper.Score))), strings.Join(ranks, ", "), paper.Year, p.StyleTitle(titleTrunc))
+	}
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// testRun is a PageRank result with the given scores, titled by paper id.
+func testRun(scores map[string]float64) *PageRankResult {
+	result := &PageRankResult{Scores: scores}
+	for _, id := range rankedIDs(scores) {
+		result.Rankings = append(result.Rankings, PaperScore{PaperID: id, Title: "Paper " + id, Score: scores[id]})
+	}
+	return result
+}
+
+func TestEnsembleMethods(t *testing.T) {
+	runs := []*PageRankResult{
+		testRun(map[string]float64{"A": 0.9, "B": 0.3, "C": 0.2}),   // A B C
+		testRun(map[string]float64{"B": 0.6, "C": 0.3, "A": 0.1}),   // B C A
+		testRun(map[string]float64{"B": 0.4, "A": 0.35, "D": 0.25}), // B A D, no C
+	}
+	// four papers in all; a paper missing from a run of three gets the
+	// fourth place there
+	tests := []struct {
+		method string
+		order  []string
+		scores []float64
+	}{
+		// 4 - rank points per run
+		{EnsembleBorda, []string{"B", "A", "C", "D"}, []float64{8, 6, 3, 1}},
+		// mean rank, lowest first
+		{EnsembleRankAvg, []string{"B", "A", "C", "D"}, []float64{4.0 / 3, 2, 3, 11.0 / 3}},
+		// mean score, 0 where missing; A's one high score puts it first
+		{EnsembleScoreAvg, []string{"A", "B", "C", "D"}, []float64{1.35 / 3, 1.3 / 3, 0.5 / 3, 0.25 / 3}},
+	}
+	for _, tt := range tests {
+		rankings := Ensemble(runs, tt.method)
+		var order []string
+		for _, paper := range rankings {
+			order = append(order, paper.PaperID)
+		}
+		if !slices.Equal(order, tt.order) {
+			t.Errorf("%s: order %v, want %v", tt.method, order, tt.order)
+			continue
+		}
+		for i, paper := range rankings {
+			if math.Abs(paper.Score-tt.scores[i]) > 1e-12 {
+				t.Errorf("%s: %s scored %v, want %v", tt.method, paper.PaperID, paper.Score, tt.scores[i])
+			}
+		}
+
+		d := rankings[3]
+		if d.Runs != 1 || !slices.Equal(d.Ranks, []int{0, 0, 3}) || d.Title != "Paper D" {
+			t.Errorf("%s: D ranked in %d runs at %v titled %q, want 1 run at [0 0 3] titled \"Paper D\"", tt.method, d.Runs, d.Ranks, d.Title)
+		}
+	}
+}
+
+func TestEnsembleBreaksTiesByPaperID(t *testing.T) {
+	runs := []*PageRankResult{
+		testRun(map[string]float64{"Q": 0.6, "P": 0.4}),
+		testRun(map[string]float64{"P": 0.6, "Q": 0.4}),
+	}
+	for _, method := range []string{EnsembleBorda, EnsembleRankAvg, EnsembleScoreAvg} {
+		rankings := Ensemble(runs, method)
+		if rankings[0].PaperID != "P" || rankings[1].PaperID != "Q" {
+			t.Errorf("%s: tied papers ranked %s, %s, want P, Q", method, rankings[0].PaperID, rankings[1].PaperID)
+		}
+	}
+}
+
+func TestParseEnsembleMethod(t *testing.T) {
+	if method, err := ParseEnsembleMethod(" Borda "); err != nil || method != EnsembleBorda {
+		t.Errorf("got %q, %v", method, err)
+	}
+	if _, err := ParseEnsembleMethod("median"); err == nil {
+		t.Error("unknown method accepted")
+	}
+}
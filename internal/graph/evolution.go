@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+)
+
+// a paper's standing in the ranking as of one year
+type RankPoint struct {
+	Year   int     `json:"year"`
+	Rank   int     `json:"rank"`   // 1-based; 0 before the paper was published
+	Papers int     `json:"papers"` // papers in the ranking that year
+	Score  float64 `json:"score"`
+}
+
+// a paper's rank at every yearly cutoff
+type RankTrajectory struct {
+	PaperID string      `json:"paper_id"`
+	Title   string      `json:"title"`
+	Year    int         `json:"year"`
+	Points  []RankPoint `json:"points"` // oldest first
+}
+
+// RankEvolution ranks the graph as of every year from..to (see AsOf) and
+// tracks where the paper stands in each. It works from the latest year
+// back, cutting every year's graph from the one after it rather than from
+// the full graph, and warm-starts every run from the later year's scores,
+// which differ only by the papers of one year.
+func RankEvolution(ctx context.Context, g *Graph, paperID string, from, to int, config PageRankConfig) (*RankTrajectory, error) {
+	if from > to {
+		return nil, fmt.Errorf("from (%d) is after to (%d)", from, to)
+	}
+	trajectory := &RankTrajectory{PaperID: paperID}
+	found := false
+	for _, node := range g.Nodes {
+		if node.ID == paperID {
+			trajectory.Title, trajectory.Year = node.Title, node.Year
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("paper %s is not in the graph", paperID)
+	}
+
+	trajectory.Points = make([]RankPoint, to-from+1)
+	current := g
+	var later *PageRankResult
+	for year := to; year >= from; year-- {
+		current, _ = AsOf(current, year)
+		point := RankPoint{Year: year, Papers: len(current.Nodes)}
+		if len(current.Nodes) == 0 {
+			trajectory.Points[year-from] = point
+			continue
+		}
+
+		fmt.Printf("\n--- Ranking as of %d (%d papers) ---\n", year, len(current.Nodes))
+		config.AsOfYear = year
+		var result *PageRankResult
+		var err error
+		if later != nil {
+			result, err = UpdatePageRank(ctx, later, current, config)
+		} else {
+			result, err = CalculatePageRankContext(ctx, current, config)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ranking as of %d failed: %w", year, err)
+		}
+		later = result
+
+		for i, ranking := range result.Rankings {
+			if ranking.PaperID == paperID {
+				point.Rank, point.Score = i+1, ranking.Score
+				break
+			}
+		}
+		trajectory.Points[year-from] = point
+	}
+	return trajectory, nil
+}
+
+// Trend sums up the trajectory from the first year the paper is ranked to
+// the last: "rising", "falling" or "stable".
+func (t *RankTrajectory) Trend() string {
+	first, last := 0, 0
+	for _, point := range t.Points {
+		if point.Rank == 0 {
+			continue
+		}
+		if first == 0 {
+			first = point.Rank
+		}
+		last = point.Rank
+	}
+	switch {
+	case last < first:
+		return "rising"
+	case last > first:
+		return "falling"
+	}
+	return "stable"
+}
+
+func PrintRankTrajectory(p Printer, t *RankTrajectory) {
+	fmt.Println("\n=== Rank Evolution ===")
+	fmt.Printf("Paper: %s (%s, %d)\n", t.Title, t.PaperID, t.Year)
+	fmt.Println()
+	fmt.Println("Year | Rank     | of       | Score      | Change")
+	fmt.Println("-----|----------|----------|------------|-------")
+
+	previous := 0
+	for _, point := range t.Points {
+		if point.Rank == 0 {
+			fmt.Printf("%-4d | %-8s | %-8d | %-10s |\n", point.Year, "-", point.Papers, "-")
+			continue
+		}
+		change := ""
+		if previous > 0 {
+			switch {
+			case point.Rank < previous:
+				change = fmt.Sprintf("up %d", previous-point.Rank)
+			case point.Rank > previous:
+				change = fmt.Sprintf("down %d", point.Rank-previous)
+			default:
+				change = "="
+			}
+		}
+		fmt.Printf("%-4d | %-8d | %-8d | %-10s | %s\n", point.Year, point.Rank, point.Papers, p.Score(point.Score), change)
+		previous = point.Rank
+	}
+	fmt.Println()
+	fmt.Printf("Trend: %s\n", t.Trend())
+}
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+// evolutionGraph has four 1995 papers H1..H4 cited 7, 5, 3 and 1 times in
+// 1996, and T, published in 2000 and cited twice in every year after it
+// up to 2005, so it overtakes one H paper a year until it leads.
+func evolutionGraph() *Graph {
+	parsed := &data.ParsedData{}
+	citer := 0
+	cite := func(to string, year int) {
+		citer++
+		id := fmt.Sprintf("c%02d", citer)
+		parsed.Papers = append(parsed.Papers, data.Paper{ID: id, Year: year})
+		parsed.Citations = append(parsed.Citations, data.CitationEdge{From: id, To: to})
+	}
+	for i, citations := range []int{7, 5, 3, 1} {
+		id := fmt.Sprintf("H%d", i+1)
+		parsed.Papers = append(parsed.Papers, data.Paper{ID: id, Year: 1995})
+		for j := 0; j < citations; j++ {
+			cite(id, 1996)
+		}
+	}
+	parsed.Papers = append(parsed.Papers, data.Paper{ID: "T", Title: "Target", Year: 2000})
+	for year := 2001; year <= 2005; year++ {
+		cite("T", year)
+		cite("T", year)
+	}
+	return BuildGraphFromData(parsed, BuildConfig{})
+}
+
+func TestRankEvolutionRises(t *testing.T) {
+	trajectory, err := RankEvolution(context.Background(), evolutionGraph(), "T", 1999, 2005, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trajectory.Title != "Target" || trajectory.Year != 2000 {
+		t.Errorf("trajectory of %q (%d), want Target (2000)", trajectory.Title, trajectory.Year)
+	}
+
+	var years, ranks, papers []int
+	for _, point := range trajectory.Points {
+		years = append(years, point.Year)
+		ranks = append(ranks, point.Rank)
+		papers = append(papers, point.Papers)
+	}
+	if want := []int{1999, 2000, 2001, 2002, 2003, 2004, 2005}; !reflect.DeepEqual(years, want) {
+		t.Errorf("years %v, want %v", years, want)
+	}
+	// unranked before publication; in 2000, uncited, it ties the 1996
+	// papers, so only the years after are checked exactly
+	if ranks[0] != 0 {
+		t.Errorf("ranked %d in 1999, before it was published", ranks[0])
+	}
+	if want := []int{4, 3, 2, 1, 1}; !reflect.DeepEqual(ranks[2:], want) {
+		t.Errorf("ranks from 2001 %v, want %v", ranks[2:], want)
+	}
+	for i := 2; i < len(ranks); i++ {
+		if ranks[i] > ranks[i-1] {
+			t.Errorf("rank fell from %d to %d in %d", ranks[i-1], ranks[i], years[i])
+		}
+	}
+	if want := []int{20, 21, 23, 25, 27, 29, 31}; !reflect.DeepEqual(papers, want) {
+		t.Errorf("papers ranked each year %v, want %v", papers, want)
+	}
+	if trend := trajectory.Trend(); trend != "rising" {
+		t.Errorf("trend %q, want rising", trend)
+	}
+
+	// the scores match a fresh ranking of each year's graph
+	config := testConfig()
+	config.AsOfYear = 2003
+	cut, _ := AsOf(evolutionGraph(), 2003)
+	full, err := CalculatePageRank(cut, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := trajectory.Points[4].Score, full.Scores["T"]; math.Abs(got-want) > 1e-9 {
+		t.Errorf("2003 score %v, want %v as ranked from scratch", got, want)
+	}
+}
+
+func TestRankEvolutionRejectsBadInput(t *testing.T) {
+	g := evolutionGraph()
+	if _, err := RankEvolution(context.Background(), g, "T", 2005, 2001, testConfig()); err == nil {
+		t.Error("accepted a range running backwards")
+	}
+	if _, err := RankEvolution(context.Background(), g, "missing", 2001, 2005, testConfig()); err == nil {
+		t.Error("traced a paper not in the graph")
+	}
+}
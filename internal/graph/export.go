@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"paper-rank/internal/data"
+)
+
+// a top-ranked paper with its full metadata, as written by 'export --format top'
+type TopPaper struct {
+	Rank      int      `json:"rank"`
+	PaperID   string   `json:"paper_id"`
+	Title     string   `json:"title"`
+	Authors   []string `json:"authors"`
+	Year      int      `json:"year"`
+	BookTitle string   `json:"booktitle,omitempty"`
+	DOI       string   `json:"doi,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Abstract  string   `json:"abstract,omitempty"`
+	Score     float64  `json:"pagerank"`
+	Citations int      `json:"citations"`
+}
+
+// JoinTopPapers joins the top n rankings with the paper metadata on paper id.
+// Ranked papers missing from papers keep the title and year stored with the
+// ranking and are also returned in missing.
+func JoinTopPapers(result *PageRankResult, papers map[string]data.Paper, n int) (top []TopPaper, missing []string) {
+	n = min(n, len(result.Rankings))
+	top = make([]TopPaper, 0, n)
+
+	for i, ranking := range result.Rankings[:n] {
+		record := TopPaper{
+			Rank:      i + 1,
+			PaperID:   ranking.PaperID,
+			Title:     ranking.Title,
+			Year:      ranking.Year,
+			Score:     ranking.Score,
+			Citations: ranking.Citations,
+		}
+
+		if paper, ok := papers[ranking.PaperID]; ok {
+			record.Title = paper.Title
+			record.Authors = paper.Authors
+			record.Year = paper.Year
+			record.BookTitle = paper.BookTitle
+			record.DOI = paper.DOI
+			record.URL = paper.URL
+			record.Abstract = paper.Abstract
+		} else {
+			missing = append(missing, ranking.PaperID)
+		}
+
+		top = append(top, record)
+	}
+
+	return top, missing
+}
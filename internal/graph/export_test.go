@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+func TestJoinTopPapers(t *testing.T) {
+	result := &PageRankResult{Rankings: []PaperScore{
+		{PaperID: "P1", Title: "Stored title 1", Year: 2001, Score: 0.5, Citations: 4},
+		{PaperID: "P2", Title: "Stored title 2", Year: 2002, Score: 0.3, Citations: 2},
+		{PaperID: "P3", Title: "Stored title 3", Year: 2003, Score: 0.2, Citations: 1},
+	}}
+	papers := map[string]data.Paper{
+		"P1": {ID: "P1", Title: "Paper 1", Authors: []string{"Ada Lovelace"}, Year: 2011,
+			BookTitle: "ACL", DOI: "10.1/p1", URL: "https://example.org/p1", Abstract: "About P1."},
+		"P3": {ID: "P3", Title: "Paper 3", Year: 2003},
+	}
+
+	top, missing := JoinTopPapers(result, papers, 2)
+	want := []TopPaper{
+		{Rank: 1, PaperID: "P1", Title: "Paper 1", Authors: []string{"Ada Lovelace"}, Year: 2011,
+			BookTitle: "ACL", DOI: "10.1/p1", URL: "https://example.org/p1", Abstract: "About P1.", Score: 0.5, Citations: 4},
+		// missing from the papers, so only what the ranking stores
+		{Rank: 2, PaperID: "P2", Title: "Stored title 2", Year: 2002, Score: 0.3, Citations: 2},
+	}
+	if !reflect.DeepEqual(top, want) {
+		t.Errorf("top papers\n%+v\nwant\n%+v", top, want)
+	}
+	if !reflect.DeepEqual(missing, []string{"P2"}) {
+		t.Errorf("missing %v, want [P2]", missing)
+	}
+
+	if top, _ := JoinTopPapers(result, papers, 10); len(top) != 3 {
+		t.Errorf("%d papers for a top larger than the ranking, want 3", len(top))
+	}
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+func TestGraphRoundTripsInEveryFormat(t *testing.T) {
+	dir := t.TempDir()
+	g := binaryFixture()
+
+	for _, format := range []data.Format{data.FormatJSON, data.FormatJSONL, data.FormatMsgpack, data.FormatBinary} {
+		path := filepath.Join(dir, "graph."+string(format))
+		if err := SaveGraph(g, path, format); err != nil {
+			t.Fatalf("save %s: %v", format, err)
+		}
+		loaded, err := LoadGraph(path)
+		if err != nil {
+			t.Fatalf("load %s: %v", format, err)
+		}
+		if !reflect.DeepEqual(loaded.Nodes, g.Nodes) || !reflect.DeepEqual(loaded.Edges, g.Edges) {
+			t.Errorf("%s round trip changed the nodes or edges", format)
+		}
+		if !reflect.DeepEqual(loaded.InDegree, g.InDegree) || !reflect.DeepEqual(loaded.OutDegree, g.OutDegree) {
+			t.Errorf("%s round trip changed the degrees", format)
+		}
+	}
+}
+
+func TestPageRankResultRoundTripsInEveryFormat(t *testing.T) {
+	dir := t.TempDir()
+	result, err := CalculatePageRank(binaryFixture(), PageRankConfig{
+		DampingFactor:  0.85,
+		MaxIterations:  100,
+		Tolerance:      1e-10,
+		HandleDangling: true,
+		IntentWeights:  map[string]float64{"method": 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []data.Format{data.FormatJSON, data.FormatJSONL, data.FormatMsgpack} {
+		path := filepath.Join(dir, "pagerank."+string(format))
+		if err := SavePageRankResult(result, path, format); err != nil {
+			t.Fatalf("save %s: %v", format, err)
+		}
+		loaded, err := LoadPageRankResult(path)
+		if err != nil {
+			t.Fatalf("load %s: %v", format, err)
+		}
+		if !reflect.DeepEqual(loaded, result) {
+			t.Errorf("%s round trip changed the result:\n got %+v\nwant %+v", format, loaded.Config, result.Config)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// GenerateRandomGraph returns a uniform random citation graph with numNodes
+// papers and numEdges distinct citations (capped at the number of possible
+// ones). Papers are ordered by year and always cite older papers, so the
+// graph is acyclic like real citation data. Degree maps and stats are
+// consistent, and the same seed always gives the same graph.
+func GenerateRandomGraph(numNodes, numEdges int, seed int64) *Graph {
+	rng := rand.New(rand.NewSource(seed))
+	g := &Graph{Nodes: syntheticNodes(numNodes)}
+
+	maxEdges := numNodes * (numNodes - 1) / 2
+	if numEdges > maxEdges {
+		numEdges = maxEdges
+	}
+
+	seen := make(map[[2]int]bool, numEdges)
+	for len(g.Edges) < numEdges {
+		from := rng.Intn(numNodes)
+		to := rng.Intn(numNodes)
+		if to > from {
+			from, to = to, from
+		}
+		if from == to || seen[[2]int{from, to}] {
+			continue
+		}
+		seen[[2]int{from, to}] = true
+		g.Edges = append(g.Edges, Edge{From: g.Nodes[from].ID, To: g.Nodes[to].ID})
+	}
+
+	g.rebuildIndexes()
+	g.Stats = calculateGraphStats(g, 0)
+	return g
+}
+
+// GenerateScaleFreeGraph returns a citation graph grown by preferential
+// attachment: each new paper cites up to citationsPerPaper distinct earlier
+// papers, picked with probability proportional to their citations + 1. The
+// in-degree distribution is heavy-tailed like real citation graphs.
+func GenerateScaleFreeGraph(numNodes, citationsPerPaper int, seed int64) *Graph {
+	rng := rand.New(rand.NewSource(seed))
+	g := &Graph{Nodes: syntheticNodes(numNodes)}
+
+	// each paper appears once, plus once per citation it receives
+	targets := make([]int, 0, numNodes*(citationsPerPaper+1))
+
+	for i := 0; i < numNodes; i++ {
+		want := citationsPerPaper
+		if want > i {
+			want = i
+		}
+
+		cited := make(map[int]bool, want)
+		for len(cited) < want {
+			cited[targets[rng.Intn(len(targets))]] = true
+		}
+
+		// append in index order so the result does not depend on map order
+		citedIdx := make([]int, 0, len(cited))
+		for j := range cited {
+			citedIdx = append(citedIdx, j)
+		}
+		sort.Ints(citedIdx)
+		for _, j := range citedIdx {
+			g.Edges = append(g.Edges, Edge{From: g.Nodes[i].ID, To: g.Nodes[j].ID})
+			targets = append(targets, j)
+		}
+		targets = append(targets, i)
+	}
+
+	g.rebuildIndexes()
+	g.Stats = calculateGraphStats(g, 0)
+	return g
+}
+
+func syntheticNodes(numNodes int) []Node {
+	nodes := make([]Node, numNodes)
+	for i := range nodes {
+		nodes[i] = Node{
+			ID:      fmt.Sprintf("SYN-%06d", i),
+			Title:   fmt.Sprintf("Synthetic paper %d", i),
+			Year:    1980 + i*45/max(numNodes, 1),
+			Authors: []string{fmt.Sprintf("Author %d", i%max(numNodes/5, 1))},
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CoreGraph keeps the papers cited at least minCitations times and the
+// citations among them, a graph small enough to draw. Degrees and stats are
+// recomputed for the kept papers, so a kept paper may end up with fewer
+// than minCitations citations.
+func CoreGraph(g *Graph, minCitations int) *Graph {
+	kept := make(map[string]bool)
+	core := &Graph{}
+	for _, node := range g.Nodes {
+		if g.InDegree[node.ID] >= minCitations {
+			kept[node.ID] = true
+			core.Nodes = append(core.Nodes, node)
+		}
+	}
+	for _, edge := range g.Edges {
+		if kept[edge.From] && kept[edge.To] {
+			core.Edges = append(core.Edges, edge)
+		}
+	}
+
+	if g.AdjList != nil {
+		core.buildAdjList()
+	}
+	core.rebuildDegrees()
+	core.Stats = calculateGraphStats(core, 0)
+	return core
+}
+
+// WriteGraphML writes the graph as GraphML for Gephi, yEd or networkx, with
+// each paper's title, year, degrees and, when scores is not nil, PageRank.
+func WriteGraphML(w io.Writer, g *Graph, scores map[string]float64) error {
+	bw := bufio.NewWriter(w)
+	esc := func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(bw, `  <key id="title" for="node" attr.name="title" attr.type="string"/>`)
+	fmt.Fprintln(bw, `  <key id="year" for="node" attr.name="year" attr.type="int"/>`)
+	fmt.Fprintln(bw, `  <key id="in_degree" for="node" attr.name="in_degree" attr.type="int"/>`)
+	fmt.Fprintln(bw, `  <key id="out_degree" for="node" attr.name="out_degree" attr.type="int"/>`)
+	if scores != nil {
+		fmt.Fprintln(bw, `  <key id="pagerank" for="node" attr.name="pagerank" attr.type="double"/>`)
+	}
+	fmt.Fprintln(bw, `  <key id="intent" for="edge" attr.name="intent" attr.type="string"/>`)
+	fmt.Fprintln(bw, `  <graph id="citations" edgedefault="directed">`)
+
+	for _, node := range g.Nodes {
+		fmt.Fprintf(bw, "    <node id=\"%s\">\n", esc(node.ID))
+		fmt.Fprintf(bw, "      <data key=\"title\">%s</data>\n", esc(node.Title))
+		fmt.Fprintf(bw, "      <data key=\"year\">%d</data>\n", node.Year)
+		fmt.Fprintf(bw, "      <data key=\"in_degree\">%d</data>\n", g.InDegree[node.ID])
+		fmt.Fprintf(bw, "      <data key=\"out_degree\">%d</data>\n", g.OutDegree[node.ID])
+		if scores != nil {
+			fmt.Fprintf(bw, "      <data key=\"pagerank\">%g</data>\n", scores[node.ID])
+		}
+		fmt.Fprintln(bw, "    </node>")
+	}
+	for _, edge := range g.Edges {
+		if edge.Intent == "" {
+			fmt.Fprintf(bw, "    <edge source=\"%s\" target=\"%s\"/>\n", esc(edge.From), esc(edge.To))
+			continue
+		}
+		fmt.Fprintf(bw, "    <edge source=\"%s\" target=\"%s\"><data key=\"intent\">%s</data></edge>\n",
+			esc(edge.From), esc(edge.To), esc(edge.Intent))
+	}
+
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+	return bw.Flush()
+}
+
+// WriteDOT writes the graph in Graphviz DOT, labeling each paper with its
+// id and year.
+func WriteDOT(w io.Writer, g *Graph, scores map[string]float64) error {
+	bw := bufio.NewWriter(w)
+	quote := func(s string) string {
+		s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ", "\r", " ").Replace(s)
+		return `"` + s + `"`
+	}
+
+	fmt.Fprintln(bw, "digraph citations {")
+	fmt.Fprintln(bw, "  node [shape=box];")
+	for _, node := range g.Nodes {
+		label := node.ID
+		if node.Year > 0 {
+			label = fmt.Sprintf("%s (%d)", node.ID, node.Year)
+		}
+		attrs := fmt.Sprintf("label=%s, tooltip=%s", quote(label), quote(node.Title))
+		if scores != nil {
+			attrs += fmt.Sprintf(", pagerank=%g", scores[node.ID])
+		}
+		fmt.Fprintf(bw, "  %s [%s];\n", quote(node.ID), attrs)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s;\n", quote(edge.From), quote(edge.To))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoreGraph(t *testing.T) {
+	// a is cited 3 times, b twice, c and d once, e never
+	g := testGraph(t, "b>a", "c>a", "d>a", "c>b", "d>b", "e>c", "a>d")
+
+	core := CoreGraph(g, 2)
+	var ids []string
+	for _, node := range core.Nodes {
+		ids = append(ids, node.ID)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("kept %v, want %v", ids, want)
+	}
+	if want := []Edge{{From: "b", To: "a"}}; !reflect.DeepEqual(core.Edges, want) {
+		t.Errorf("kept edges %v, want %v", core.Edges, want)
+	}
+	// degrees count only the kept citations
+	if core.InDegree["a"] != 1 || core.InDegree["b"] != 0 || core.OutDegree["b"] != 1 || core.OutDegree["a"] != 0 {
+		t.Errorf("core in-degrees %v, out-degrees %v; want a cited once, by b", core.InDegree, core.OutDegree)
+	}
+	if core.Stats.TotalNodes != 2 || core.Stats.TotalEdges != 1 || core.Stats.MaxInDegree != 1 {
+		t.Errorf("core stats %+v, want 2 nodes, 1 edge, max in-degree 1", core.Stats)
+	}
+	if g.InDegree["a"] != 3 || len(g.Nodes) != 5 {
+		t.Errorf("the full graph changed: %d nodes, a cited %d times", len(g.Nodes), g.InDegree["a"])
+	}
+
+	if all := CoreGraph(g, 0); len(all.Nodes) != 5 || len(all.Edges) != 7 {
+		t.Errorf("min 0 kept %d nodes and %d edges, want all 5 and 7", len(all.Nodes), len(all.Edges))
+	}
+	if none := CoreGraph(g, 4); len(none.Nodes) != 0 || len(none.Edges) != 0 {
+		t.Errorf("min 4 kept %d nodes and %d edges, want none", len(none.Nodes), len(none.Edges))
+	}
+
+	var out bytes.Buffer
+	if err := WriteGraphML(&out, core, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<node id=\"a\">\n      <data key=\"title\">Paper a</data>\n      <data key=\"year\">2001</data>\n" +
+			"      <data key=\"in_degree\">1</data>\n      <data key=\"out_degree\">0</data>\n",
+		`<edge source="b" target="a"/>`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("GraphML lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), `"c"`) {
+		t.Errorf("GraphML has a dropped paper:\n%s", out.String())
+	}
+}
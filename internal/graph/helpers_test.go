@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testGraph builds a graph from "from>to" edges; papers are numbered by
+// first appearance and get years in that order from 2000.
+func testGraph(t *testing.T, edges ...string) *Graph {
+	t.Helper()
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	seen := make(map[string]bool)
+	addNode := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			g.Nodes = append(g.Nodes, Node{ID: id, Title: "Paper " + id, Year: 2000 + len(g.Nodes)})
+		}
+	}
+	for _, edge := range edges {
+		from, to, ok := strings.Cut(edge, ">")
+		if !ok {
+			// a lone id adds an isolated paper
+			addNode(edge)
+			continue
+		}
+		addNode(from)
+		addNode(to)
+		g.Edges = append(g.Edges, Edge{From: from, To: to})
+	}
+	g.rebuildIndexes()
+	g.Stats = calculateGraphStats(g, 0)
+	return g
+}
+
+// testConfig is the default rank configuration with a tight tolerance.
+func testConfig() PageRankConfig {
+	return PageRankConfig{
+		DampingFactor:  0.85,
+		MaxIterations:  1000,
+		Tolerance:      1e-12,
+		HandleDangling: true,
+	}
+}
+
+// rankingIDs lists the paper ids of rankings in order, formatted for
+// comparison.
+func rankingIDs(rankings []PaperScore) string {
+	ids := make([]string, len(rankings))
+	for i, ranking := range rankings {
+		ids[i] = ranking.PaperID
+	}
+	return fmt.Sprint(ids)
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// one histogram bin, covering [Low, High); the last bin includes High
+type HistogramBin struct {
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Count int     `json:"count"`
+}
+
+// distribution of PageRank scores over log-spaced bins
+type Histogram struct {
+	Bins        []HistogramBin `json:"bins"`
+	NonPositive int            `json:"non_positive"` // scores <= 0, which no log bin holds
+	Total       int            `json:"total"`
+	Min         float64        `json:"min"`
+	Max         float64        `json:"max"`
+	P50         float64        `json:"p50"`
+	P90         float64        `json:"p90"`
+	P99         float64        `json:"p99"`
+}
+
+// ScoreHistogram bins the scores into the given number of bins, evenly
+// spaced in log scale between the smallest positive and the largest score,
+// since PageRank scores are heavy-tailed: most papers sit near the teleport
+// share and a few hold most of the mass. Percentiles use the nearest rank.
+func ScoreHistogram(rankings []PaperScore, bins int) Histogram {
+	hist := Histogram{Total: len(rankings)}
+	if len(rankings) == 0 || bins < 1 {
+		return hist
+	}
+
+	scores := make([]float64, len(rankings))
+	for i, ranking := range rankings {
+		scores[i] = ranking.Score
+	}
+	sort.Float64s(scores)
+	hist.Min, hist.Max = scores[0], scores[len(scores)-1]
+	hist.P50 = percentile(scores, 50)
+	hist.P90 = percentile(scores, 90)
+	hist.P99 = percentile(scores, 99)
+
+	positive := sort.Search(len(scores), func(i int) bool { return scores[i] > 0 })
+	hist.NonPositive = positive
+	if positive == len(scores) {
+		return hist
+	}
+
+	if scores[positive] == hist.Max {
+		bins = 1
+	}
+	low, high := math.Log10(scores[positive]), math.Log10(hist.Max)
+	width := (high - low) / float64(bins)
+	hist.Bins = make([]HistogramBin, bins)
+	for i := range hist.Bins {
+		hist.Bins[i].Low = math.Pow(10, low+float64(i)*width)
+		if i > 0 {
+			hist.Bins[i-1].High = hist.Bins[i].Low
+		}
+	}
+	hist.Bins[0].Low = scores[positive]
+	hist.Bins[bins-1].High = hist.Max
+
+	for _, score := range scores[positive:] {
+		bin := bins - 1
+		if width > 0 {
+			bin = min(int((math.Log10(score)-low)/width), bins-1)
+		}
+		// the log can land a score on an edge in the neighboring bin
+		for bin > 0 && score < hist.Bins[bin].Low {
+			bin--
+		}
+		for bin < bins-1 && score >= hist.Bins[bin+1].Low {
+			bin++
+		}
+		hist.Bins[bin].Count++
+	}
+	return hist
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted scores.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// PrintScoreHistogram prints the histogram as text bars at most width
+// characters long, followed by the percentiles.
+func PrintScoreHistogram(p Printer, hist Histogram, width int) {
+	fmt.Println("\n=== Score Distribution ===")
+	if hist.Total == 0 {
+		fmt.Println("No scores.")
+		return
+	}
+
+	largest := 0
+	for _, bin := range hist.Bins {
+		largest = max(largest, bin.Count)
+	}
+	for _, bin := range hist.Bins {
+		bar := 0
+		if largest > 0 {
+			bar = int(math.Round(float64(bin.Count) / float64(largest) * float64(width)))
+		}
+		if bin.Count > 0 && bar == 0 {
+			bar = 1
+		}
+		fmt.Printf("%.2e - %.2e  %7d  %s\n", bin.Low, bin.High, bin.Count, strings.Repeat("#", bar))
+	}
+	if hist.NonPositive > 0 {
+		fmt.Printf("Scores of 0: %d\n", hist.NonPositive)
+	}
+
+	fmt.Println()
+	fmt.Printf("Papers: %d (min %s, max %s)\n", hist.Total, p.Score(hist.Min), p.Score(hist.Max))
+	fmt.Printf("50th percentile: %s\n", p.Score(hist.P50))
+	fmt.Printf("90th percentile: %s\n", p.Score(hist.P90))
+	fmt.Printf("99th percentile: %s\n", p.Score(hist.P99))
+	fmt.Println("==========================")
+}
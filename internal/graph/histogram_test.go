@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+// scoreRankings wraps scores as rankings.
+func scoreRankings(scores ...float64) []PaperScore {
+	rankings := make([]PaperScore, len(scores))
+	for i, score := range scores {
+		rankings[i] = PaperScore{Score: score}
+	}
+	return rankings
+}
+
+func TestScoreHistogram(t *testing.T) {
+	// three decades, with 0.01 and 0.1 right on bin edges
+	rankings := scoreRankings(0.5, 0.001, 0, 0.02, 1, 0.005, 0.1, 0.01, 0.002, 0.05, 0)
+
+	hist := ScoreHistogram(rankings, 3)
+	var counts []int
+	for _, bin := range hist.Bins {
+		counts = append(counts, bin.Count)
+	}
+	if want := []int{3, 3, 3}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("bin counts %v, want %v", counts, want)
+	}
+	if hist.Bins[0].Low != 0.001 || hist.Bins[2].High != 1 {
+		t.Errorf("bins span %v to %v, want 0.001 to 1", hist.Bins[0].Low, hist.Bins[2].High)
+	}
+	if hist.NonPositive != 2 || hist.Total != 11 || hist.Min != 0 || hist.Max != 1 {
+		t.Errorf("%d non-positive of %d, min %v, max %v; want 2 of 11, 0 and 1", hist.NonPositive, hist.Total, hist.Min, hist.Max)
+	}
+	if hist.P50 != 0.01 || hist.P90 != 0.5 || hist.P99 != 1 {
+		t.Errorf("percentiles %v, %v, %v; want 0.01, 0.5, 1", hist.P50, hist.P90, hist.P99)
+	}
+
+	// every positive score lands in exactly one bin, however many there are
+	for bins := 1; bins <= 7; bins++ {
+		hist := ScoreHistogram(rankings, bins)
+		total := 0
+		for _, bin := range hist.Bins {
+			total += bin.Count
+		}
+		if len(hist.Bins) != bins || total != 9 {
+			t.Errorf("%d bins: got %d bins holding %d scores, want %d holding 9", bins, len(hist.Bins), total, bins)
+		}
+	}
+}
+
+func TestScoreHistogramDegenerateScores(t *testing.T) {
+	tests := []struct {
+		name     string
+		rankings []PaperScore
+		bins     int
+		want     []int
+	}{
+		{"no scores", nil, 3, nil},
+		{"no bins", scoreRankings(0.1, 0.2), 0, nil},
+		{"all zero", scoreRankings(0, 0), 3, nil},
+		{"all equal", scoreRankings(0.2, 0.2, 0.2), 3, []int{3}},
+	}
+	for _, tt := range tests {
+		hist := ScoreHistogram(tt.rankings, tt.bins)
+		var counts []int
+		for _, bin := range hist.Bins {
+			counts = append(counts, bin.Count)
+		}
+		if !reflect.DeepEqual(counts, tt.want) {
+			t.Errorf("%s: bin counts %v, want %v", tt.name, counts, tt.want)
+		}
+		if hist.Total != len(tt.rankings) {
+			t.Errorf("%s: total %d, want %d", tt.name, hist.Total, len(tt.rankings))
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package graph
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"os"
-	"path/filepath"
 	"sort"
+	"strings"
 	"time"
+
+	"paper-rank/internal/data"
 )
 
 type PageRankResult struct {
@@ -22,16 +26,149 @@ type PageRankConfig struct {
 	MaxIterations  int     `json:"max_iterations"`
 	Tolerance      float64 `json:"tolerance"`
 	HandleDangling bool    `json:"handle_dangling"`
+
+	// IntentWeights scales each citation by its intent (e.g. "method": 2).
+	// Edges with no intent or an intent not in the map weigh 1.
+	IntentWeights map[string]float64 `json:"intent_weights,omitempty"`
+
+	// RecentTeleportYears, when positive, restricts teleportation (and the
+	// redistributed dangling mass) to papers from the last K years of the
+	// data, counted back from AsOfYear when set. Older papers only gain
+	// rank through citations.
+	RecentTeleportYears int `json:"recent_teleport_years,omitempty"`
+
+	// CitationPrior starts the iteration from, and/or teleports in
+	// proportion to, citation counts instead of uniformly. See the
+	// CitationPrior constants.
+	CitationPrior string `json:"citation_prior,omitempty"`
+
+	// ExternalCitationWeight, when positive, blends the final scores with
+	// each paper's share of log(1 + NumCitedBy), so papers cited mostly
+	// outside the corpus are not buried: (1-w)*PageRank + w*share.
+	ExternalCitationWeight float64 `json:"external_citation_weight,omitempty"`
+
+	// Direction says which way rank flows along a citation. Edges point
+	// from the citing to the cited paper, and by default (DirectionCited)
+	// rank flows the same way, so a paper accumulates rank from the papers
+	// citing it and influential, highly cited work ranks highest.
+	// DirectionCiting reverses the flow, favoring papers that cite
+	// influential work, such as surveys.
+	Direction string `json:"direction,omitempty"`
+
+	// AuthorSelfCitations says what happens to citations between papers
+	// sharing an author (see Graph.AuthorSelfCitations): they count in full
+	// by default, weigh AuthorSelfCitationWeight with AuthorSelfCitationsDown
+	// or are left out with AuthorSelfCitationsDrop.
+	AuthorSelfCitations      string  `json:"author_self_citations,omitempty"`
+	AuthorSelfCitationWeight float64 `json:"author_self_citation_weight,omitempty"`
+
+	// AsOfYear records that the graph was cut to its state at the end of
+	// this year with AsOf before ranking; PageRank itself ignores it
+	AsOfYear int `json:"as_of_year,omitempty"`
+}
+
+// values of PageRankConfig.Direction; empty means DirectionCited
+const (
+	DirectionCited  = "cited"
+	DirectionCiting = "citing"
+)
+
+// ParseDirection validates a --direction value.
+func ParseDirection(s string) (string, error) {
+	switch direction := strings.ToLower(strings.TrimSpace(s)); direction {
+	case "", DirectionCited:
+		return DirectionCited, nil
+	case DirectionCiting:
+		return DirectionCiting, nil
+	}
+	return "", fmt.Errorf("unknown direction %q (want cited or citing)", s)
+}
+
+// flow returns the paper an edge passes rank from and the one it passes
+// rank to under the configured Direction.
+func (c PageRankConfig) flow(edge Edge) (from, to string) {
+	if c.Direction == DirectionCiting {
+		return edge.To, edge.From
+	}
+	return edge.From, edge.To
+}
+
+// values of PageRankConfig.CitationPrior; the prior is proportional to
+// in-degree + 1 (out-degree + 1 when rank flows to citing papers), so
+// uncited papers keep some mass
+const (
+	CitationPriorNone     = ""
+	CitationPriorInit     = "init"     // initial score vector only
+	CitationPriorTeleport = "teleport" // teleport vector only
+	CitationPriorBoth     = "both"
+)
+
+// ParseCitationPrior validates a --citation-prior value; "none" is the same
+// as empty.
+func ParseCitationPrior(s string) (string, error) {
+	switch prior := strings.ToLower(strings.TrimSpace(s)); prior {
+	case "none", CitationPriorNone:
+		return CitationPriorNone, nil
+	case CitationPriorInit, CitationPriorTeleport, CitationPriorBoth:
+		return prior, nil
+	}
+	return "", fmt.Errorf("unknown citation prior %q (want none, init, teleport or both)", s)
+}
+
+// values of PageRankConfig.AuthorSelfCitations
+const (
+	AuthorSelfCitationsKeep = ""
+	AuthorSelfCitationsDown = "downweight"
+	AuthorSelfCitationsDrop = "drop"
+)
+
+// DefaultAuthorSelfCitationWeight is the weight of an author self-citation
+// under AuthorSelfCitationsDown.
+const DefaultAuthorSelfCitationWeight = 0.5
+
+// ParseAuthorSelfCitations validates an --author-self-citations value;
+// "keep" is the same as empty.
+func ParseAuthorSelfCitations(s string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(s)); policy {
+	case "keep", AuthorSelfCitationsKeep:
+		return AuthorSelfCitationsKeep, nil
+	case AuthorSelfCitationsDown, AuthorSelfCitationsDrop:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown author self-citation policy %q (want keep, downweight or drop)", s)
+}
+
+func (c PageRankConfig) citationPriorInit() bool {
+	return c.CitationPrior == CitationPriorInit || c.CitationPrior == CitationPriorBoth
+}
+
+func (c PageRankConfig) citationPriorTeleport() bool {
+	return c.CitationPrior == CitationPriorTeleport || c.CitationPrior == CitationPriorBoth
 }
 
 type PageRankStats struct {
-	Iterations      int     `json:"iterations"`
-	Converged       bool    `json:"converged"`
-	ComputationTime string  `json:"computation_time"`
-	DanglingNodes   int     `json:"dangling_nodes"`
-	MaxScoreChange  float64 `json:"max_score_change"`
-	TopPaper        string  `json:"top_paper"`
-	TopScore        float64 `json:"top_score"`
+	Iterations      int    `json:"iterations"`
+	Converged       bool   `json:"converged"`
+	ComputationTime string `json:"computation_time"`
+	DanglingNodes   int    `json:"dangling_nodes"`
+	// share of papers citing nothing in the graph; when high, PageRank mass
+	// mostly flows through teleportation, usually because references
+	// failed to link
+	DanglingFraction float64 `json:"dangling_fraction"`
+	MaxScoreChange   float64 `json:"max_score_change"`
+	TopPaper         string  `json:"top_paper"`
+	TopScore         float64 `json:"top_score"`
+
+	// set by UpdatePageRank
+	WarmStarted     bool `json:"warm_started,omitempty"`
+	IterationsSaved int  `json:"iterations_saved,omitempty"` // previous run's iterations minus this run's
+
+	// set by CalculatePageRankPruned
+	PrunedNodes int `json:"pruned_nodes,omitempty"`
+
+	// citations between papers sharing an author that were down-weighted
+	// or dropped; 0 unless PageRankConfig.AuthorSelfCitations is set
+	AuthorSelfCitations int `json:"author_self_citations,omitempty"`
 }
 
 type PaperScore struct {
@@ -43,12 +180,192 @@ type PaperScore struct {
 }
 
 func CalculatePageRank(graph *Graph, config PageRankConfig) (*PageRankResult, error) {
+	return CalculatePageRankContext(context.Background(), graph, config)
+}
+
+// CalculatePageRankContext is CalculatePageRank stopping between
+// iterations once ctx is cancelled, with an error saying how far it got.
+func CalculatePageRankContext(ctx context.Context, graph *Graph, config PageRankConfig) (*PageRankResult, error) {
+	return calculatePageRank(ctx, graph, config, nil)
+}
+
+// UpdatePageRank recomputes PageRank after a small change to the graph by
+// starting from the previous scores instead of a uniform vector. Papers that
+// are new to the graph start at 1/N and the vector is renormalized. For
+// localized changes this converges in far fewer iterations; the result
+// matches a cold run only up to the configured tolerance.
+func UpdatePageRank(ctx context.Context, prev *PageRankResult, graph *Graph, config PageRankConfig) (*PageRankResult, error) {
+	numNodes := len(graph.Nodes)
+	if numNodes == 0 {
+		return nil, fmt.Errorf("graph has no nodes")
+	}
+
+	added, kept := 0, 0
+	for _, node := range graph.Nodes {
+		if _, ok := prev.Scores[node.ID]; ok {
+			kept++
+		} else {
+			added++
+		}
+	}
+	fmt.Printf("Warm-starting PageRank from previous scores (%d papers new, %d gone)\n", added, len(prev.Scores)-kept)
+
+	initial := make([]float64, numNodes)
+	var total float64
+	for i, node := range graph.Nodes {
+		if score, ok := prev.Scores[node.ID]; ok {
+			initial[i] = score
+		} else {
+			initial[i] = 1.0 / float64(numNodes)
+		}
+		total += initial[i]
+	}
+	for i := range initial {
+		initial[i] /= total
+	}
+
+	result, err := calculatePageRank(ctx, graph, config, initial)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Stats.WarmStarted = true
+	result.Stats.IterationsSaved = prev.Stats.Iterations - result.Stats.Iterations
+	return result, nil
+}
+
+// CalculatePageRankPruned drops isolated papers (no citations in or out)
+// before running PageRank and adds them back afterwards. Every node's score
+// is the teleport share times a factor that isolated nodes do not affect, so
+// the reduced scores are rescaled to what a full run gives and the isolated
+// papers get the bare teleport share, the minimum possible score.
+func CalculatePageRankPruned(ctx context.Context, graph *Graph, config PageRankConfig) (*PageRankResult, error) {
+	keep := make(map[string]bool, len(graph.Nodes))
+	var isolated []string
+	for _, node := range graph.Nodes {
+		if graph.InDegree[node.ID] == 0 && graph.OutDegree[node.ID] == 0 {
+			isolated = append(isolated, node.ID)
+		} else {
+			keep[node.ID] = true
+		}
+	}
+
+	if config.RecentTeleportYears > 0 || config.citationPriorTeleport() {
+		// the rescaling below assumes uniform teleportation
+		fmt.Println("Not pruning isolated papers: teleport is not uniform")
+		return CalculatePageRankContext(ctx, graph, config)
+	}
+	if config.ExternalCitationWeight > 0 {
+		// the rescaling below assumes pure PageRank scores
+		fmt.Println("Not pruning isolated papers: scores are blended with external citations")
+		return CalculatePageRankContext(ctx, graph, config)
+	}
+
+	fmt.Printf("Pruned %d isolated papers before ranking\n", len(isolated))
+	if len(isolated) == 0 || len(keep) == 0 {
+		return CalculatePageRankContext(ctx, graph, config)
+	}
+
+	reduced := Subgraph(graph, keep)
+	result, err := CalculatePageRankContext(ctx, reduced, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// teleport share per node in the reduced run: (1-d)/N' plus, with
+	// dangling handling, the spread dangling mass d*D'/N'
+	outWeight := make(map[string]float64, len(reduced.Nodes))
+	receives := make(map[string]bool, len(reduced.Nodes))
+	weights, _ := config.edgeWeights(reduced)
+	for i, edge := range reduced.Edges {
+		from, to := config.flow(edge)
+		outWeight[from] += weights[i]
+		if weights[i] > 0 {
+			receives[to] = true
+		}
+	}
+	reducedShare := 1 - config.DampingFactor
+	if config.HandleDangling {
+		for _, node := range reduced.Nodes {
+			if outWeight[node.ID] == 0 {
+				reducedShare += config.DampingFactor * result.Scores[node.ID]
+			}
+		}
+	}
+	reducedShare /= float64(len(reduced.Nodes))
+
+	// with dangling handling the scores sum to 1 over all papers, which fixes
+	// the full run's share; without it the share is just (1-d)/N
+	fullShare := (1 - config.DampingFactor) / float64(len(graph.Nodes))
+	if config.HandleDangling {
+		fullShare = 1 / (1/reducedShare + float64(len(isolated)))
+	}
+
+	// papers nothing flows into hold exactly the teleport share, as in a
+	// full run, so they tie with the isolated papers instead of missing by
+	// a rounding error
+	scale := fullShare / reducedShare
+	for id, score := range result.Scores {
+		if receives[id] {
+			result.Scores[id] = score * scale
+		} else {
+			result.Scores[id] = fullShare
+		}
+	}
+	for _, id := range isolated {
+		result.Scores[id] = fullShare
+	}
+
+	result.Rankings = createRankings(graph, result.Scores)
+	result.Stats.TopScore *= scale
+	result.Stats.DanglingNodes += len(isolated)
+	result.Stats.DanglingFraction = float64(result.Stats.DanglingNodes) / float64(len(graph.Nodes))
+	result.Stats.PrunedNodes = len(isolated)
+	return result, nil
+}
+
+// PersonalizedPageRank runs PageRank teleporting to the papers in
+// personalization, in proportion to their weights, instead of to the
+// configured teleport vector. Scores then measure importance as seen from
+// those papers. Papers missing from the graph are ignored. Progress is
+// printed to out.
+func PersonalizedPageRank(graph *Graph, config PageRankConfig, personalization map[string]float64, out io.Writer) (*PageRankResult, error) {
+	teleport := make([]float64, len(graph.Nodes))
+	var total float64
+	for i, node := range graph.Nodes {
+		if weight := personalization[node.ID]; weight > 0 {
+			teleport[i] = weight
+			total += weight
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no personalization paper is in the graph")
+	}
+	for i := range teleport {
+		teleport[i] /= total
+	}
+
+	return runPageRank(context.Background(), graph, config, nil, teleport, out)
+}
+
+// calculatePageRank runs the power iteration starting from initial, or when
+// initial is nil from the citation prior or a uniform vector.
+func calculatePageRank(ctx context.Context, graph *Graph, config PageRankConfig, initial []float64) (*PageRankResult, error) {
+	if len(graph.Nodes) == 0 {
+		return nil, fmt.Errorf("graph has no nodes")
+	}
+	return runPageRank(ctx, graph, config, initial, teleportVector(graph, config, os.Stdout), os.Stdout)
+}
+
+// runPageRank is calculatePageRank with the teleport vector given; nil
+// means uniform. Progress is printed to out.
+func runPageRank(ctx context.Context, graph *Graph, config PageRankConfig, initial, teleport []float64, out io.Writer) (*PageRankResult, error) {
 	startTime := time.Now()
 
-	fmt.Printf("Starting PageRank calculation...\n")
-	fmt.Printf("Damping factor: %.2f\n", config.DampingFactor)
-	fmt.Printf("Max iterations: %d\n", config.MaxIterations)
-	fmt.Printf("Tolerance: %.2e\n", config.Tolerance)
+	fmt.Fprintf(out, "Starting PageRank calculation...\n")
+	fmt.Fprintf(out, "Damping factor: %.2f\n", config.DampingFactor)
+	fmt.Fprintf(out, "Max iterations: %d\n", config.MaxIterations)
+	fmt.Fprintf(out, "Tolerance: %.2e\n", config.Tolerance)
 
 	numNodes := len(graph.Nodes)
 	if numNodes == 0 {
@@ -59,20 +376,44 @@ func CalculatePageRank(graph *Graph, config PageRankConfig) (*PageRankResult, er
 	scores := make([]float64, numNodes)
 	newScores := make([]float64, numNodes)
 
+	if initial == nil && config.citationPriorInit() {
+		fmt.Fprintln(out, "Starting from the citation-count prior")
+		initial = citationPrior(graph, config)
+	}
+
 	initialScore := 1.0 / float64(numNodes)
 	for i, node := range graph.Nodes {
 		nodeIndex[node.ID] = i
 		scores[i] = initialScore
+		if initial != nil {
+			scores[i] = initial[i]
+		}
+	}
+
+	// endpoints of each edge in the direction rank flows, and the total
+	// outgoing edge weight of each node; equal to its out-degree unless
+	// intent weights or an author self-citation policy are configured
+	edgeWeights, authorSelfCitations := config.edgeWeights(graph)
+	if authorSelfCitations > 0 {
+		fmt.Fprintf(out, "Author self-citations (%s): %d\n", config.AuthorSelfCitations, authorSelfCitations)
+	}
+	edgeFrom := make([]int, len(graph.Edges))
+	edgeTo := make([]int, len(graph.Edges))
+	outWeight := make([]float64, numNodes)
+	for i, edge := range graph.Edges {
+		from, to := config.flow(edge)
+		edgeFrom[i], edgeTo[i] = nodeIndex[from], nodeIndex[to]
+		outWeight[edgeFrom[i]] += edgeWeights[i]
 	}
 
 	danglingNodes := []int{}
-	for i, node := range graph.Nodes {
-		if graph.OutDegree[node.ID] == 0 {
+	for i := range graph.Nodes {
+		if outWeight[i] == 0 {
 			danglingNodes = append(danglingNodes, i)
 		}
 	}
 
-	fmt.Printf("Found %d dangling nodes (%.1f%%)\n",
+	fmt.Fprintf(out, "Found %d dangling nodes (%.1f%%)\n",
 		len(danglingNodes),
 		float64(len(danglingNodes))/float64(numNodes)*100)
 
@@ -81,6 +422,11 @@ func CalculatePageRank(graph *Graph, config PageRankConfig) (*PageRankResult, er
 	var maxScoreChange float64
 
 	for iteration = 0; iteration < config.MaxIterations; iteration++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("PageRank cancelled after %d of at most %d iterations (max score change %.2e): %w",
+				iteration, config.MaxIterations, maxScoreChange, err)
+		}
+
 		// for dangling nodes distribute their score evenly
 		danglingContribution := 0.0
 		if config.HandleDangling {
@@ -98,16 +444,20 @@ func CalculatePageRank(graph *Graph, config PageRankConfig) (*PageRankResult, er
 			if config.HandleDangling {
 				newScores[i] += config.DampingFactor * danglingContribution
 			}
+
+			if teleport != nil {
+				// same mass as above, spread over the teleport set only
+				newScores[i] *= teleport[i] * float64(numNodes)
+			}
 		}
 
 		// contributions from incoming links
-		for _, edge := range graph.Edges {
-			fromIdx := nodeIndex[edge.From]
-			toIdx := nodeIndex[edge.To]
+		for e := range graph.Edges {
+			fromIdx := edgeFrom[e]
+			toIdx := edgeTo[e]
 
-			outDegree := graph.OutDegree[edge.From]
-			if outDegree > 0 {
-				contribution := config.DampingFactor * scores[fromIdx] / float64(outDegree)
+			if outWeight[fromIdx] > 0 {
+				contribution := config.DampingFactor * scores[fromIdx] * edgeWeights[e] / outWeight[fromIdx]
 				newScores[toIdx] += contribution
 			}
 		}
@@ -124,7 +474,7 @@ func CalculatePageRank(graph *Graph, config PageRankConfig) (*PageRankResult, er
 		scores, newScores = newScores, scores
 
 		if (iteration+1)%10 == 0 {
-			fmt.Printf("Iteration %d: max score change = %.2e\n", iteration+1, maxScoreChange)
+			fmt.Fprintf(out, "Iteration %d: max score change = %.2e\n", iteration+1, maxScoreChange)
 		}
 
 		if maxScoreChange < config.Tolerance {
@@ -135,13 +485,17 @@ func CalculatePageRank(graph *Graph, config PageRankConfig) (*PageRankResult, er
 
 	computationTime := time.Since(startTime)
 
-	fmt.Printf("PageRank completed in %d iterations (%.2f seconds)\n",
+	fmt.Fprintf(out, "PageRank completed in %d iterations (%.2f seconds)\n",
 		iteration+1, computationTime.Seconds())
 
 	if converged {
-		fmt.Printf("Converged with max score change: %.2e\n", maxScoreChange)
+		fmt.Fprintf(out, "Converged with max score change: %.2e\n", maxScoreChange)
 	} else {
-		fmt.Printf("Did not converge after %d iterations\n", config.MaxIterations)
+		fmt.Fprintf(out, "Did not converge after %d iterations\n", config.MaxIterations)
+	}
+
+	if config.ExternalCitationWeight > 0 {
+		blendExternalCitations(graph, scores, config.ExternalCitationWeight, out)
 	}
 
 	scoreMap := make(map[string]float64)
@@ -159,13 +513,16 @@ func CalculatePageRank(graph *Graph, config PageRankConfig) (*PageRankResult, er
 	rankings := createRankings(graph, scoreMap)
 
 	stats := PageRankStats{
-		Iterations:      iteration + 1,
-		Converged:       converged,
-		ComputationTime: computationTime.String(),
-		DanglingNodes:   len(danglingNodes),
-		MaxScoreChange:  maxScoreChange,
-		TopPaper:        topPaper,
-		TopScore:        topScore,
+		Iterations:       iteration + 1,
+		Converged:        converged,
+		ComputationTime:  computationTime.String(),
+		DanglingNodes:    len(danglingNodes),
+		DanglingFraction: float64(len(danglingNodes)) / float64(numNodes),
+		MaxScoreChange:   maxScoreChange,
+		TopPaper:         topPaper,
+		TopScore:         topScore,
+
+		AuthorSelfCitations: authorSelfCitations,
 	}
 
 	result := &PageRankResult{
@@ -178,6 +535,187 @@ func CalculatePageRank(graph *Graph, config PageRankConfig) (*PageRankResult, er
 	return result, nil
 }
 
+// teleportVector returns the teleport probability of each node, or nil for
+// the usual uniform teleport. With RecentTeleportYears set, papers in the
+// last K years of the graph share the mass and the rest get none; with a
+// teleport citation prior the mass is split in proportion to citations.
+func teleportVector(graph *Graph, config PageRankConfig, out io.Writer) []float64 {
+	recent := recentTeleportVector(graph, config, out)
+	if !config.citationPriorTeleport() {
+		return recent
+	}
+
+	fmt.Fprintln(out, "Teleporting in proportion to citation counts")
+	teleport := citationPrior(graph, config)
+	if recent == nil {
+		return teleport
+	}
+
+	var total float64
+	for i := range teleport {
+		teleport[i] *= recent[i]
+		total += teleport[i]
+	}
+	for i := range teleport {
+		teleport[i] /= total
+	}
+	return teleport
+}
+
+// citationPrior returns, for each node, the number of links rank flows in
+// along plus 1, normalized to sum to 1: its in-degree by default, or its
+// out-degree with DirectionCiting, where references carry the rank.
+func citationPrior(graph *Graph, config PageRankConfig) []float64 {
+	degree := graph.InDegree
+	if config.Direction == DirectionCiting {
+		degree = graph.OutDegree
+	}
+	prior := make([]float64, len(graph.Nodes))
+	var total float64
+	for i, node := range graph.Nodes {
+		prior[i] = float64(degree[node.ID] + 1)
+		total += prior[i]
+	}
+	for i := range prior {
+		prior[i] /= total
+	}
+	return prior
+}
+
+// blendExternalCitations mixes each node's share of log(1 + NumCitedBy) into
+// scores with the given weight. Both sum to 1, so the blend does too. Graphs
+// without citation counts are left alone. What it does is printed to out.
+func blendExternalCitations(graph *Graph, scores []float64, weight float64, out io.Writer) {
+	external := make([]float64, len(graph.Nodes))
+	var total float64
+	for i, node := range graph.Nodes {
+		external[i] = math.Log1p(float64(max(node.NumCitedBy, 0)))
+		total += external[i]
+	}
+	if total == 0 {
+		fmt.Fprintln(out, "Warning: no external citation counts in the graph (rebuild it from papers with num_cited_by); not blending")
+		return
+	}
+
+	fmt.Fprintf(out, "Blending in external citation counts with weight %.2f\n", weight)
+	for i := range scores {
+		scores[i] = (1-weight)*scores[i] + weight*external[i]/total
+	}
+}
+
+// RecentTeleportWindow returns the years teleportation is restricted to
+// with RecentTeleportYears set: the last K years up to AsOfYear, or up to
+// the latest paper when AsOfYear is unset. It also returns the year range
+// of the graph's papers and how many of them fall in the window; all are 0
+// when no paper has a year.
+func RecentTeleportWindow(graph *Graph, config PageRankConfig) (from, to, minYear, maxYear, papers int) {
+	for _, node := range graph.Nodes {
+		if node.Year <= 0 {
+			continue
+		}
+		if minYear == 0 || node.Year < minYear {
+			minYear = node.Year
+		}
+		maxYear = max(maxYear, node.Year)
+	}
+	if maxYear == 0 {
+		return 0, 0, 0, 0, 0
+	}
+
+	to = maxYear
+	if config.AsOfYear > 0 {
+		to = config.AsOfYear
+	}
+	from = to - config.RecentTeleportYears + 1
+	for _, node := range graph.Nodes {
+		if node.Year >= from && node.Year <= to {
+			papers++
+		}
+	}
+	return from, to, minYear, maxYear, papers
+}
+
+// WarnRecentTeleportWindow prints a warning when the recent-teleport window
+// takes in no paper of graph, or every one of them. Call it on the whole
+// corpus, before cutting it to AsOfYear, so the warning reports the
+// corpus's year range.
+func WarnRecentTeleportWindow(graph *Graph, config PageRankConfig) {
+	if config.RecentTeleportYears <= 0 {
+		return
+	}
+	from, to, minYear, maxYear, papers := RecentTeleportWindow(graph, config)
+	switch {
+	case maxYear == 0:
+		fmt.Println("Warning: no paper has a year, teleporting uniformly instead of to recent papers")
+	case papers == 0:
+		fmt.Printf("Warning: the %d-year teleport window %d-%d excludes all papers (they span %d-%d); teleporting uniformly\n",
+			config.RecentTeleportYears, from, to, minYear, maxYear)
+	case from <= minYear:
+		fmt.Printf("Warning: the %d-year teleport window covers the whole %d-%d range\n",
+			config.RecentTeleportYears, minYear, maxYear)
+	}
+}
+
+// recentTeleportVector spreads the teleport mass equally over the papers
+// in RecentTeleportWindow, or returns nil when unset or when the window
+// holds no paper.
+func recentTeleportVector(graph *Graph, config PageRankConfig, out io.Writer) []float64 {
+	if config.RecentTeleportYears <= 0 {
+		return nil
+	}
+
+	from, to, _, _, recent := RecentTeleportWindow(graph, config)
+	if recent == 0 {
+		fmt.Fprintln(out, "No paper in the recent-teleport window, teleporting uniformly")
+		return nil
+	}
+
+	teleport := make([]float64, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		if node.Year >= from && node.Year <= to {
+			teleport[i] = 1 / float64(recent)
+		}
+	}
+
+	fmt.Fprintf(out, "Teleporting to %d papers from %d-%d\n", recent, from, to)
+	return teleport
+}
+
+// edgeWeights returns the weight of every edge of graph, by index, and how
+// many of them the author self-citation policy changed.
+func (c PageRankConfig) edgeWeights(graph *Graph) ([]float64, int) {
+	weights := make([]float64, len(graph.Edges))
+	for i, edge := range graph.Edges {
+		weights[i] = c.edgeWeight(edge)
+	}
+	if c.AuthorSelfCitations == AuthorSelfCitationsKeep {
+		return weights, 0
+	}
+
+	factor := 0.0
+	if c.AuthorSelfCitations == AuthorSelfCitationsDown {
+		factor = c.AuthorSelfCitationWeight
+	}
+	changed := 0
+	for i, shared := range graph.AuthorSelfCitations() {
+		if shared {
+			weights[i] *= factor
+			changed++
+		}
+	}
+	return weights, changed
+}
+
+func (c PageRankConfig) edgeWeight(edge Edge) float64 {
+	if edge.Intent == "" || len(c.IntentWeights) == 0 {
+		return 1.0
+	}
+	if weight, ok := c.IntentWeights[edge.Intent]; ok {
+		return weight
+	}
+	return 1.0
+}
+
 func createRankings(graph *Graph, scores map[string]float64) []PaperScore {
 	rankings := make([]PaperScore, 0, len(graph.Nodes))
 
@@ -192,44 +730,107 @@ func createRankings(graph *Graph, scores map[string]float64) []PaperScore {
 		rankings = append(rankings, paperScore)
 	}
 	sort.Slice(rankings, func(i, j int) bool {
-		return rankings[i].Score > rankings[j].Score
+		if rankings[i].Score != rankings[j].Score {
+			return rankings[i].Score > rankings[j].Score
+		}
+		return rankings[i].PaperID < rankings[j].PaperID
 	})
 
 	return rankings
 }
 
-func SavePageRankResult(result *PageRankResult, outputPath string) error {
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %v", err)
+func SavePageRankResult(result *PageRankResult, outputPath string, format data.Format) error {
+	if err := data.EncodeFile(outputPath, result, format); err != nil {
+		return fmt.Errorf("failed to write PageRank file: %v", err)
 	}
+	return nil
+}
 
-	jsonData, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal PageRank result to JSON: %v", err)
+func LoadPageRankResult(inputPath string) (*PageRankResult, error) {
+	var result PageRankResult
+	if err := data.DecodeFile(inputPath, &result); err != nil {
+		return nil, fmt.Errorf("failed to load PageRank data: %v", err)
 	}
 
-	if err := os.WriteFile(outputPath, jsonData, 0644); err != nil {
-		return fmt.Errorf("failed to write PageRank file: %v", err)
-	}
+	return &result, nil
+}
 
+// MarshalJSONL writes the config and stats, then one line per ranked paper.
+// The score map is rebuilt from the rankings on load.
+func (r *PageRankResult) MarshalJSONL(enc *json.Encoder) error {
+	if err := data.WriteRecord(enc, "config", r.Config); err != nil {
+		return err
+	}
+	if err := data.WriteRecord(enc, "stats", r.Stats); err != nil {
+		return err
+	}
+	for _, ranking := range r.Rankings {
+		if err := data.WriteRecord(enc, "ranking", ranking); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func LoadPageRankResult(inputPath string) (*PageRankResult, error) {
-	jsonData, err := os.ReadFile(inputPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read PageRank file: %v", err)
+func (r *PageRankResult) UnmarshalJSONL(dec *json.Decoder) error {
+	r.Scores = make(map[string]float64)
+	for {
+		var rec data.Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var err error
+		switch rec.Kind {
+		case "config":
+			err = json.Unmarshal(rec.Data, &r.Config)
+		case "stats":
+			err = json.Unmarshal(rec.Data, &r.Stats)
+		case "ranking":
+			var ranking PaperScore
+			err = json.Unmarshal(rec.Data, &ranking)
+			r.Rankings = append(r.Rankings, ranking)
+			r.Scores[ranking.PaperID] = ranking.Score
+		}
+		if err != nil {
+			return fmt.Errorf("bad %s record: %v", rec.Kind, err)
+		}
 	}
+}
 
-	var result PageRankResult
-	if err := json.Unmarshal(jsonData, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal PageRank data: %v", err)
+// WarnDangling prints a warning when more than threshold of the papers are
+// dangling, saying where config sends their rank mass.
+func WarnDangling(stats PageRankStats, config PageRankConfig, threshold float64) {
+	if threshold <= 0 || stats.DanglingFraction <= threshold {
+		return
 	}
+	fmt.Printf("\nWarning: %.1f%% of papers cite no other paper in the graph (threshold %.1f%%).\n",
+		stats.DanglingFraction*100, threshold*100)
+	fmt.Println(danglingPolicy(config))
+	fmt.Println("Check the citation link yield printed by 'acl-ranker parse' and list the papers with 'acl-ranker export --format dangling'.")
+}
 
-	return &result, nil
+// danglingPolicy describes what happens to the rank mass of dangling
+// papers: it is dropped, or it follows the teleport vector.
+func danglingPolicy(config PageRankConfig) string {
+	switch {
+	case !config.HandleDangling:
+		return "Their rank mass is dropped every iteration, so the scores sum to less than 1."
+	case config.RecentTeleportYears > 0 && config.citationPriorTeleport():
+		return fmt.Sprintf("Their rank mass goes to the papers of the last %d years in proportion to their citations, which inflates those papers' scores.",
+			config.RecentTeleportYears)
+	case config.RecentTeleportYears > 0:
+		return fmt.Sprintf("Their rank mass goes to the papers of the last %d years, which inflates those papers' scores.",
+			config.RecentTeleportYears)
+	case config.citationPriorTeleport():
+		return "Their rank mass is spread over all papers in proportion to their citations, which favors well-cited papers beyond their links."
+	}
+	return "Their rank mass is spread uniformly over all papers, which flattens the ranking."
 }
 
-func PrintPageRankStats(stats PageRankStats, config PageRankConfig) {
+func PrintPageRankStats(p Printer, stats PageRankStats, config PageRankConfig) {
 	fmt.Println("\n=== PageRank Results ===")
 	fmt.Printf("Algorithm converged: %v\n", stats.Converged)
 	fmt.Printf("Iterations completed: %d/%d\n", stats.Iterations, config.MaxIterations)
@@ -237,17 +838,54 @@ func PrintPageRankStats(stats PageRankStats, config PageRankConfig) {
 	fmt.Printf("Final convergence: %.2e (target: %.2e)\n", stats.MaxScoreChange, config.Tolerance)
 	fmt.Println()
 
-	fmt.Printf("Dangling nodes: %d\n", stats.DanglingNodes)
-	fmt.Printf("Highest PageRank: %.6f (paper: %s)\n", stats.TopScore, stats.TopPaper)
+	if stats.WarmStarted {
+		fmt.Printf("Warm-started: %d iterations saved vs previous run\n", stats.IterationsSaved)
+	}
+	fmt.Printf("Dangling nodes: %d (%.1f%% of papers cite nothing in the graph)\n", stats.DanglingNodes, stats.DanglingFraction*100)
+	if stats.PrunedNodes > 0 {
+		fmt.Printf("Isolated papers pruned: %d (scored with the teleport share only)\n", stats.PrunedNodes)
+	}
+	fmt.Printf("Highest PageRank: %s (paper: %s)\n", p.Score(stats.TopScore), stats.TopPaper)
 	fmt.Println()
 
 	fmt.Printf("Configuration:\n")
+	if config.AsOfYear > 0 {
+		fmt.Printf("  As of: %d\n", config.AsOfYear)
+	}
 	fmt.Printf("  Damping factor: %.2f\n", config.DampingFactor)
 	fmt.Printf("  Handle dangling nodes: %v\n", config.HandleDangling)
+	if config.RecentTeleportYears > 0 {
+		fmt.Printf("  Teleport to last %d years only\n", config.RecentTeleportYears)
+	}
+	if config.CitationPrior != CitationPriorNone {
+		fmt.Printf("  Citation prior: %s\n", config.CitationPrior)
+	}
+	if config.ExternalCitationWeight > 0 {
+		fmt.Printf("  External citation weight: %.2f\n", config.ExternalCitationWeight)
+	}
+	if config.Direction == DirectionCiting {
+		fmt.Println("  Direction: rank flows to citing papers")
+	}
+	switch config.AuthorSelfCitations {
+	case AuthorSelfCitationsDown:
+		fmt.Printf("  Author self-citations: weighted %.2f (%d citations)\n", config.AuthorSelfCitationWeight, stats.AuthorSelfCitations)
+	case AuthorSelfCitationsDrop:
+		fmt.Printf("  Author self-citations: dropped (%d citations)\n", stats.AuthorSelfCitations)
+	}
+	if len(config.IntentWeights) > 0 {
+		intents := make([]string, 0, len(config.IntentWeights))
+		for intent := range config.IntentWeights {
+			intents = append(intents, intent)
+		}
+		sort.Strings(intents)
+		for _, intent := range intents {
+			fmt.Printf("  Intent weight %s: %.2f\n", intent, config.IntentWeights[intent])
+		}
+	}
 	fmt.Println("=======================")
 }
 
-func PrintTopPapers(rankings []PaperScore, n int) {
+func PrintTopPapers(p Printer, rankings []PaperScore, n int) {
 	if n > len(rankings) {
 		n = len(rankings)
 	}
@@ -263,39 +901,180 @@ func PrintTopPapers(rankings []PaperScore, n int) {
 			titleTrunc = titleTrunc[:37] + "..."
 		}
 
-		fmt.Printf("%-4d | %.6f | %-9d | %-4d | %s\n",
-			i+1, paper.Score, paper.Citations, paper.Year, titleTrunc)
+		fmt.Printf("%-4d | %s | %-9d | %-4d | %s\n",
+			i+1, p.StyleScore(fmt.Sprintf("%-8s", p.Score(paper.Score))), paper.Citations, paper.Year, p.StyleTitle(titleTrunc))
 	}
 }
 
-func CompareWithCitations(rankings []PaperScore, n int) {
-	if n > len(rankings) {
-		n = len(rankings)
+// TopCitingPapers returns the n highest-PageRank papers citing each paper,
+// keyed by paper id, for papers with at least one citation.
+func TopCitingPapers(g *Graph, result *PageRankResult, paperIDs []string, n int) map[string][]PaperScore {
+	citing := g.CitingIndex()
+	byID := make(map[string]PaperScore, len(result.Rankings))
+	for _, ranking := range result.Rankings {
+		byID[ranking.PaperID] = ranking
 	}
 
-	// create citation-based ranking
-	citationRankings := make([]PaperScore, len(rankings))
-	copy(citationRankings, rankings)
+	top := make(map[string][]PaperScore, len(paperIDs))
+	for _, paperID := range paperIDs {
+		var citers []PaperScore
+		for _, citerID := range citing[paperID] {
+			citers = append(citers, byID[citerID])
+		}
+		sort.Slice(citers, func(i, j int) bool {
+			if citers[i].Score != citers[j].Score {
+				return citers[i].Score > citers[j].Score
+			}
+			return citers[i].PaperID < citers[j].PaperID
+		})
+		if len(citers) > n {
+			citers = citers[:n]
+		}
+		if len(citers) > 0 {
+			top[paperID] = citers
+		}
+	}
+	return top
+}
 
-	sort.Slice(citationRankings, func(i, j int) bool {
-		return citationRankings[i].Score < citationRankings[j].Score
-	})
+// PrintInfluenceChains prints each of the top n papers followed by its most
+// influential citing papers.
+func PrintInfluenceChains(p Printer, g *Graph, result *PageRankResult, n, citers int) {
+	n = min(n, len(result.Rankings))
+
+	ids := make([]string, n)
+	for i := 0; i < n; i++ {
+		ids[i] = result.Rankings[i].PaperID
+	}
+	top := TopCitingPapers(g, result, ids, citers)
+
+	fmt.Printf("\nTop %d Papers and Their Most Influential Citers:\n", n)
+	for i := 0; i < n; i++ {
+		paper := result.Rankings[i]
+		fmt.Printf("%d. %s (%d)  %s\n", i+1, paper.Title, paper.Year, p.Score(paper.Score))
+		if len(top[paper.PaperID]) == 0 {
+			fmt.Println("     (not cited)")
+		}
+		for _, citer := range top[paper.PaperID] {
+			titleTrunc := citer.Title
+			if len(titleTrunc) > 60 {
+				titleTrunc = titleTrunc[:57] + "..."
+			}
+			fmt.Printf("     <- %s  %s (%d)\n", p.Score(citer.Score), titleTrunc, citer.Year)
+		}
+	}
+}
+
+// CompareWithCitations prints the top n papers by PageRank next to their
+// rank by citation count; see citationRanks.
+func CompareWithCitations(p Printer, rankings []PaperScore, n int) {
+	if n > len(rankings) {
+		n = len(rankings)
+	}
+	citationRank := citationRanks(rankings)
 
 	fmt.Printf("\nPageRank vs Citation Count (Top %d):\n", n)
 	fmt.Println("PageRank Rank | Citation Rank | Paper ID    | PageRank | Citations")
 	fmt.Println("--------------|---------------|-------------|----------|----------")
 
-	// citation rank lookup
-	citationRank := make(map[string]int)
-	for i, paper := range citationRankings {
-		citationRank[paper.PaperID] = i + 1
-	}
-
 	for i := 0; i < n; i++ {
 		paper := rankings[i]
 		cRank := citationRank[paper.PaperID]
 
-		fmt.Printf("%-13d | %-13d | %-11s | %.6f | %d\n",
-			i+1, cRank, paper.PaperID, paper.Score, paper.Citations)
+		fmt.Printf("%-13d | %-13d | %-11s | %-8s | %d\n",
+			i+1, cRank, paper.PaperID, p.Score(paper.Score), paper.Citations)
+	}
+
+	fmt.Printf("\nSpearman rank correlation (PageRank vs citations): %.4f\n", RankCorrelation(rankings))
+}
+
+// citationRanks gives each paper its 1-based rank by citation count, most
+// cited first, ties by paper id.
+func citationRanks(rankings []PaperScore) map[string]int {
+	byCitations := make([]PaperScore, len(rankings))
+	copy(byCitations, rankings)
+	sort.Slice(byCitations, func(i, j int) bool {
+		if byCitations[i].Citations != byCitations[j].Citations {
+			return byCitations[i].Citations > byCitations[j].Citations
+		}
+		return byCitations[i].PaperID < byCitations[j].PaperID
+	})
+
+	ranks := make(map[string]int, len(byCitations))
+	for i, paper := range byCitations {
+		ranks[paper.PaperID] = i + 1
+	}
+	return ranks
+}
+
+// RankCorrelation returns the Spearman rank correlation between the PageRank
+// ordering and the citation-count ordering of the given papers. Tied values
+// share their average rank. A low value means PageRank adds signal beyond
+// raw citation counts.
+func RankCorrelation(rankings []PaperScore) float64 {
+	n := len(rankings)
+	if n < 2 {
+		return 0
+	}
+
+	scores := make([]float64, n)
+	citations := make([]float64, n)
+	for i, paper := range rankings {
+		scores[i] = paper.Score
+		citations[i] = float64(paper.Citations)
+	}
+
+	return pearson(averageRanks(scores), averageRanks(citations))
+}
+
+// averageRanks assigns 1-based ranks in descending order of value, giving
+// tied values the mean of the ranks they span.
+func averageRanks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return values[order[i]] > values[order[j]]
+	})
+
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		avgRank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[order[k]] = avgRank
+		}
+		i = j + 1
+	}
+
+	return ranks
+}
+
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx := x[i] - meanX
+		dy := y[i] - meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+
+	if varX == 0 || varY == 0 {
+		return 0
 	}
+	return cov / math.Sqrt(varX*varY)
 }
@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCitationRanksOrderByCitationsNotScore(t *testing.T) {
+	// PageRank order: A, B, C, D; citation order: C, A, then B and D tied
+	rankings := []PaperScore{
+		{PaperID: "A", Score: 0.4, Citations: 5},
+		{PaperID: "B", Score: 0.3, Citations: 1},
+		{PaperID: "C", Score: 0.2, Citations: 9},
+		{PaperID: "D", Score: 0.1, Citations: 1},
+	}
+	want := map[string]int{"C": 1, "A": 2, "B": 3, "D": 4}
+	got := citationRanks(rankings)
+	for id, rank := range want {
+		if got[id] != rank {
+			t.Errorf("citation rank of %s = %d, want %d", id, got[id], rank)
+		}
+	}
+}
+
+func TestRankCorrelation(t *testing.T) {
+	same := []PaperScore{
+		{PaperID: "A", Score: 0.5, Citations: 10},
+		{PaperID: "B", Score: 0.3, Citations: 5},
+		{PaperID: "C", Score: 0.2, Citations: 1},
+	}
+	if got := RankCorrelation(same); math.Abs(got-1) > 1e-12 {
+		t.Errorf("identical orders: correlation %v, want 1", got)
+	}
+
+	reversed := []PaperScore{
+		{PaperID: "A", Score: 0.5, Citations: 1},
+		{PaperID: "B", Score: 0.3, Citations: 5},
+		{PaperID: "C", Score: 0.2, Citations: 10},
+	}
+	if got := RankCorrelation(reversed); math.Abs(got+1) > 1e-12 {
+		t.Errorf("reversed orders: correlation %v, want -1", got)
+	}
+
+	// no citation variance: undefined, reported as 0
+	flat := []PaperScore{{PaperID: "A", Score: 0.6, Citations: 2}, {PaperID: "B", Score: 0.4, Citations: 2}}
+	if got := RankCorrelation(flat); got != 0 {
+		t.Errorf("tied citations: correlation %v, want 0", got)
+	}
+}
+
+func TestDanglingPolicyFollowsTeleport(t *testing.T) {
+	tests := []struct {
+		configure func(*PageRankConfig)
+		want      string
+	}{
+		{func(c *PageRankConfig) {}, "spread uniformly over all papers"},
+		{func(c *PageRankConfig) { c.HandleDangling = false }, "dropped every iteration"},
+		{func(c *PageRankConfig) { c.RecentTeleportYears = 3 }, "goes to the papers of the last 3 years,"},
+		{func(c *PageRankConfig) { c.CitationPrior = CitationPriorTeleport }, "in proportion to their citations"},
+		{func(c *PageRankConfig) { c.CitationPrior = CitationPriorInit }, "spread uniformly over all papers"},
+		{func(c *PageRankConfig) {
+			c.RecentTeleportYears = 2
+			c.CitationPrior = CitationPriorBoth
+		}, "last 2 years in proportion to their citations"},
+	}
+	for i, tt := range tests {
+		config := testConfig()
+		tt.configure(&config)
+		if got := danglingPolicy(config); !strings.Contains(got, tt.want) {
+			t.Errorf("case %d: %q does not say %q", i, got, tt.want)
+		}
+	}
+}
+
+func TestPageRankStopsWhenCancelled(t *testing.T) {
+	edges := make([]string, 5000)
+	for i := range edges {
+		edges[i] = fmt.Sprintf("P%d>P%d", i+1, i)
+	}
+	g := testGraph(t, edges...)
+	config := testConfig()
+	config.MaxIterations = math.MaxInt
+	config.Tolerance = -1 // never converges, so only the cancel can stop it
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := CalculatePageRankContext(ctx, g, config)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want the context's", err)
+	}
+	if result != nil {
+		t.Error("a cancelled run returned a result")
+	}
+}
+
+func TestPrunedPageRankMatchesFullRun(t *testing.T) {
+	// c and e are dangling; x, y and z are isolated
+	g := testGraph(t, "x", "a>b", "b>c", "d>b", "d>a", "a>c", "e", "y", "f>e", "f>a", "z")
+	for _, handleDangling := range []bool{true, false} {
+		config := testConfig()
+		config.HandleDangling = handleDangling
+		full, err := CalculatePageRank(g, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pruned, err := CalculatePageRankPruned(context.Background(), g, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := rankingIDs(pruned.Rankings), rankingIDs(full.Rankings); got != want {
+			t.Errorf("HandleDangling %v: pruned order %s, full %s", handleDangling, got, want)
+		}
+		for id, want := range full.Scores {
+			if got := pruned.Scores[id]; math.Abs(got-want) > 1e-9 {
+				t.Errorf("HandleDangling %v: %s scored %v pruned, %v in a full run", handleDangling, id, got, want)
+			}
+		}
+	}
+}
+
+func TestTopCitingPapers(t *testing.T) {
+	g := testGraph(t, "b>a", "c>a", "d>a", "e>a", "c>b", "lone")
+	result := &PageRankResult{Rankings: []PaperScore{
+		{PaperID: "a", Score: 0.4},
+		{PaperID: "d", Score: 0.2},
+		{PaperID: "b", Score: 0.1},
+		{PaperID: "e", Score: 0.1},
+		{PaperID: "c", Score: 0.05},
+		{PaperID: "lone", Score: 0.15},
+	}}
+
+	top := TopCitingPapers(g, result, []string{"a", "b", "c", "lone"}, 3)
+	got := make(map[string]string, len(top))
+	for id, citers := range top {
+		got[id] = rankingIDs(citers)
+	}
+	// citers sorted by score, ties by paper id, cut to 3; uncited papers
+	// are left out
+	want := map[string]string{"a": "[d b e]", "b": "[c]"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("top citing papers %v, want %v", got, want)
+	}
+}
+
+func TestExternalCitationsShiftRanking(t *testing.T) {
+	// b is the most cited in the graph, a by far the most cited outside it
+	g := testGraph(t, "a>b", "c>b", "d>b", "d>a", "e")
+	citedBy := map[string]int{"a": 5000, "b": 10, "c": 3, "d": 0, "e": 1}
+	for i := range g.Nodes {
+		g.Nodes[i].NumCitedBy = citedBy[g.Nodes[i].ID]
+	}
+
+	config := testConfig()
+	plain, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Rankings[0].PaperID != "b" {
+		t.Fatalf("graph alone ranks %s first, want b", rankingIDs(plain.Rankings))
+	}
+
+	config.ExternalCitationWeight = 0.8
+	blended, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rankingIDs(blended.Rankings); got != "[a b c e d]" {
+		t.Errorf("blended ranking %s, want [a b c e d]", got)
+	}
+	var sum float64
+	for _, score := range blended.Scores {
+		sum += score
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("blended scores sum to %g, want 1", sum)
+	}
+
+	// without citation counts the scores are left alone
+	for i := range g.Nodes {
+		g.Nodes[i].NumCitedBy = 0
+	}
+	unblended, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, score := range plain.Scores {
+		if unblended.Scores[id] != score {
+			t.Errorf("%s scored %v with no citation counts to blend, %v unblended", id, unblended.Scores[id], score)
+		}
+	}
+}
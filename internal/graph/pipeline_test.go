@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/pipeline")
+
+// the parts of a parse -> build -> rank run the golden files pin down
+type pipelineGolden struct {
+	Parse    data.ParseStats `json:"parse"`
+	Graph    GraphStats      `json:"graph"`
+	Rankings []PaperScore    `json:"top_rankings"`
+	Cited    []PaperRanking  `json:"most_cited"`
+}
+
+// TestPipelineGolden parses the fixture parquet files, builds the graph and
+// ranks it with the command-line defaults, and compares the stats and top
+// rankings with testdata/pipeline/golden.json. Run with -update to accept a
+// change in the output.
+func TestPipelineGolden(t *testing.T) {
+	dir := filepath.Join("testdata", "pipeline")
+	parsed, err := data.ParseACLData(filepath.Join(dir, "papers.parquet"), filepath.Join(dir, "citations.parquet"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := BuildGraphFromData(parsed, BuildConfig{})
+	result, err := CalculatePageRank(g, PageRankConfig{
+		DampingFactor:  0.85,
+		MaxIterations:  100,
+		Tolerance:      1e-6,
+		HandleDangling: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := pipelineGolden{
+		Parse:    parsed.Stats,
+		Graph:    g.Stats,
+		Rankings: result.Rankings[:10],
+		Cited:    g.GetMostCitedPapers(5),
+	}
+	// scores are compared to 9 significant digits, not bit for bit
+	for i := range got.Rankings {
+		got.Rankings[i].Score = roundScore(got.Rankings[i].Score)
+	}
+	gotJSON, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJSON = append(gotJSON, '\n')
+
+	goldenPath := filepath.Join(dir, "golden.json")
+	if *update {
+		if err := os.WriteFile(goldenPath, gotJSON, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("%v (run 'go test ./internal/graph -run Golden -update' to create it)", err)
+	}
+	if !bytes.Equal(gotJSON, want) {
+		t.Errorf("pipeline output differs from %s; if the change is intended, rerun with -update\n got:\n%s", goldenPath, gotJSON)
+	}
+}
+
+func roundScore(score float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(score, 'g', 9, 64), 64)
+	return rounded
+}
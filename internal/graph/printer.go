@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultScorePrecision is the number of decimals scores print with unless
+// --precision says otherwise.
+const DefaultScorePrecision = 6
+
+// Printer holds the presentation settings of the Print functions; cmd
+// builds one from the command-line flags and passes it in.
+type Printer struct {
+	Precision int  // decimals for scores
+	Color     bool // style output with ANSI escape codes
+}
+
+// Score formats a score with p.Precision decimals, switching to scientific
+// notation when fixed point would keep fewer than three significant digits.
+// PageRank scores on large graphs are often ~1e-7 and would otherwise print
+// as 0.000000.
+func (p Printer) Score(score float64) string {
+	precision := max(p.Precision, 1)
+	if score != 0 && math.Abs(score) < math.Pow(10, float64(2-precision)) {
+		return fmt.Sprintf("%.*e", max(precision-3, 2), score)
+	}
+	return fmt.Sprintf("%.*f", precision, score)
+}
+
+// ANSI SGR codes for the few styles the output uses
+const (
+	styleBold   = "1"
+	styleGreen  = "32"
+	styleYellow = "1;33"
+)
+
+func (p Printer) colorize(style, s string) string {
+	if !p.Color || s == "" {
+		return s
+	}
+	return "\x1b[" + style + "m" + s + "\x1b[0m"
+}
+
+// StyleTitle styles a paper title.
+func (p Printer) StyleTitle(s string) string { return p.colorize(styleBold, s) }
+
+// StyleScore styles a formatted score. Pad before styling, since the escape
+// codes count towards fmt widths.
+func (p Printer) StyleScore(s string) string { return p.colorize(styleGreen, s) }
+
+// StyleMatch styles a query term found in the text.
+func (p Printer) StyleMatch(s string) string { return p.colorize(styleYellow, s) }
@@ -0,0 +1,45 @@
+package graph
+
+import "testing"
+
+func TestPrinterScore(t *testing.T) {
+	tests := []struct {
+		precision int
+		score     float64
+		want      string
+	}{
+		{6, 0.123456789, "0.123457"},
+		{3, 0.123456789, "0.123"},
+		{6, 0, "0.000000"},
+		{6, 1.5e-7, "1.500e-07"},
+		{8, 1.5e-7, "1.50000e-07"},
+		{3, 0.05, "5.00e-02"},
+		{0, 25, "25.0"},
+	}
+	for _, tt := range tests {
+		p := Printer{Precision: tt.precision}
+		if got := p.Score(tt.score); got != tt.want {
+			t.Errorf("Printer{Precision: %d}.Score(%g) = %q, want %q", tt.precision, tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestPrinterStylesOnlyWithColor(t *testing.T) {
+	plain := Printer{}
+	for _, style := range []func(string) string{plain.StyleTitle, plain.StyleScore, plain.StyleMatch} {
+		if got := style("text"); got != "text" {
+			t.Errorf("unstyled printer returned %q", got)
+		}
+	}
+
+	color := Printer{Color: true}
+	if got, want := color.StyleTitle("title"), "\x1b[1mtitle\x1b[0m"; got != want {
+		t.Errorf("StyleTitle = %q, want %q", got, want)
+	}
+	if got, want := color.StyleScore("0.5"), "\x1b[32m0.5\x1b[0m"; got != want {
+		t.Errorf("StyleScore = %q, want %q", got, want)
+	}
+	if got := color.StyleMatch(""); got != "" {
+		t.Errorf("StyleMatch of an empty string = %q, want it left empty", got)
+	}
+}
@@ -0,0 +1,131 @@
+package graph
+
+import (
+	"math"
+	"testing"
+)
+
+// priorTestGraph has a cycle, a dangling paper (a) and an isolated one.
+func priorTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	return testGraph(t, "b>a", "c>a", "d>a", "c>b", "d>c", "e>d", "d>e", "f>e", "lone")
+}
+
+// checkStationary checks that scores are a fixed point of one PageRank step
+// with teleport distribution teleport (nil for uniform), dangling mass
+// following teleportation.
+func checkStationary(t *testing.T, g *Graph, config PageRankConfig, scores map[string]float64, teleport []float64) {
+	t.Helper()
+	n := float64(len(g.Nodes))
+	var dangling float64
+	for _, node := range g.Nodes {
+		if g.OutDegree[node.ID] == 0 {
+			dangling += scores[node.ID]
+		}
+	}
+	next := make(map[string]float64, len(g.Nodes))
+	for i, node := range g.Nodes {
+		share := 1 / n
+		if teleport != nil {
+			share = teleport[i]
+		}
+		next[node.ID] = (1 - config.DampingFactor + config.DampingFactor*dangling) * share
+	}
+	for _, edge := range g.Edges {
+		next[edge.To] += config.DampingFactor * scores[edge.From] / float64(g.OutDegree[edge.From])
+	}
+	for id, want := range next {
+		if math.Abs(scores[id]-want) > 1e-9 {
+			t.Errorf("%s: score %g is not a fixed point (one more step gives %g)", id, scores[id], want)
+		}
+	}
+}
+
+func TestCitationPriorModesConverge(t *testing.T) {
+	g := priorTestGraph(t)
+	prior := citationPrior(g, testConfig())
+	var total float64
+	for i, node := range g.Nodes {
+		if want := float64(g.InDegree[node.ID]+1) / float64(len(g.Edges)+len(g.Nodes)); math.Abs(prior[i]-want) > 1e-12 {
+			t.Errorf("prior of %s = %g, want %g", node.ID, prior[i], want)
+		}
+		total += prior[i]
+	}
+	if math.Abs(total-1) > 1e-12 {
+		t.Errorf("prior sums to %g, want 1", total)
+	}
+
+	results := make(map[string]*PageRankResult)
+	for _, mode := range []string{CitationPriorNone, CitationPriorInit, CitationPriorTeleport, CitationPriorBoth} {
+		config := testConfig()
+		config.CitationPrior = mode
+		result, err := CalculatePageRank(g, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Stats.Converged {
+			t.Errorf("%q: did not converge in %d iterations", mode, result.Stats.Iterations)
+		}
+		var sum float64
+		for _, score := range result.Scores {
+			sum += score
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("%q: scores sum to %g, want 1", mode, sum)
+		}
+		var teleport []float64
+		if config.citationPriorTeleport() {
+			teleport = prior
+		}
+		checkStationary(t, g, config, result.Scores, teleport)
+		results[mode] = result
+	}
+
+	// the starting vector only changes the route to the fixed point
+	for id, score := range results[CitationPriorNone].Scores {
+		if got := results[CitationPriorInit].Scores[id]; math.Abs(got-score) > 1e-9 {
+			t.Errorf("%s: init prior converged to %g, uniform start to %g", id, got, score)
+		}
+		if got, want := results[CitationPriorBoth].Scores[id], results[CitationPriorTeleport].Scores[id]; math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: both converged to %g, teleport to %g", id, got, want)
+		}
+	}
+	// the teleport prior favors the most cited paper and starves the
+	// isolated one
+	if results[CitationPriorTeleport].Scores["a"] <= results[CitationPriorNone].Scores["a"] {
+		t.Error("teleport prior did not raise the most cited paper")
+	}
+	if results[CitationPriorTeleport].Scores["lone"] >= results[CitationPriorNone].Scores["lone"] {
+		t.Error("teleport prior did not lower the isolated paper")
+	}
+}
+
+func TestCitationPriorFollowsDirection(t *testing.T) {
+	g := priorTestGraph(t)
+	config := testConfig()
+	config.Direction = DirectionCiting
+	// rank flows to citing papers, so the prior counts references
+	prior := citationPrior(g, config)
+	for i, node := range g.Nodes {
+		if want := float64(g.OutDegree[node.ID]+1) / float64(len(g.Edges)+len(g.Nodes)); math.Abs(prior[i]-want) > 1e-12 {
+			t.Errorf("citing prior of %s = %g, want %g", node.ID, prior[i], want)
+		}
+	}
+
+	uniform, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.CitationPrior = CitationPriorTeleport
+	biased, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// d cites the most papers; a cites none
+	if biased.Scores["d"] <= uniform.Scores["d"] {
+		t.Error("citing teleport prior did not raise the paper with the most references")
+	}
+	if biased.Scores["a"] >= uniform.Scores["a"] {
+		t.Error("citing teleport prior did not lower the paper citing nothing")
+	}
+}
@@ -0,0 +1,116 @@
+package graph
+
+import "math"
+
+// Proximity scores how close each paper is to target in the citation graph,
+// in [0, 1]: 1 for a direct citation either way, otherwise the larger of
+// bibliographic coupling (shared references) and co-citation (shared
+// citers), each the shared count over the geometric mean of the two lists.
+// Only papers within two hops score above zero and appear in the map.
+// citing is the reverse index from CitingIndex.
+func (g *Graph) Proximity(target string, citing map[string][]string) map[string]float64 {
+	proximity := make(map[string]float64)
+
+	// salton returns the cosine overlap of a shared count between two
+	// lists of the given lengths
+	salton := func(shared, a, b int) float64 {
+		if shared == 0 || a == 0 || b == 0 {
+			return 0
+		}
+		return float64(shared) / math.Sqrt(float64(a)*float64(b))
+	}
+
+	refs := uniqueIDs(g.AdjList[target])
+	citers := uniqueIDs(citing[target])
+
+	// papers citing the target's references are coupled with it
+	coupled := make(map[string]int)
+	for _, ref := range refs {
+		for _, other := range uniqueIDs(citing[ref]) {
+			coupled[other]++
+		}
+	}
+	for other, shared := range coupled {
+		score := salton(shared, len(refs), len(uniqueIDs(g.AdjList[other])))
+		proximity[other] = max(proximity[other], score)
+	}
+
+	// papers cited alongside the target are co-cited with it
+	cocited := make(map[string]int)
+	for _, citer := range citers {
+		for _, other := range uniqueIDs(g.AdjList[citer]) {
+			cocited[other]++
+		}
+	}
+	for other, shared := range cocited {
+		score := salton(shared, len(citers), len(uniqueIDs(citing[other])))
+		proximity[other] = max(proximity[other], score)
+	}
+
+	for _, ref := range refs {
+		proximity[ref] = 1
+	}
+	for _, citer := range citers {
+		proximity[citer] = 1
+	}
+
+	delete(proximity, target)
+	return proximity
+}
+
+// uniqueIDs drops repeated ids, keeping the first occurrence.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// how two papers' citation neighborhoods overlap
+type NeighborhoodOverlap struct {
+	SharedReferences int  `json:"shared_references"` // bibliographic coupling
+	SharedCiters     int  `json:"shared_citers"`     // co-citation
+	Cites            bool `json:"cites"`             // the first paper cites the second
+	CitedBy          bool `json:"cited_by"`          // the second paper cites the first
+}
+
+// Overlap counts the references papers a and b both cite and
+// the papers citing both of them, and reports whether a cites b or b cites
+// a. citing is the reverse index from CitingIndex.
+func (g *Graph) Overlap(a, b string, citing map[string][]string) NeighborhoodOverlap {
+	overlap := NeighborhoodOverlap{
+		SharedReferences: countShared(g.AdjList[a], g.AdjList[b]),
+		SharedCiters:     countShared(citing[a], citing[b]),
+	}
+	for _, ref := range g.AdjList[a] {
+		if ref == b {
+			overlap.Cites = true
+		}
+	}
+	for _, ref := range g.AdjList[b] {
+		if ref == a {
+			overlap.CitedBy = true
+		}
+	}
+	return overlap
+}
+
+// countShared counts the distinct ids in both lists.
+func countShared(a, b []string) int {
+	in := make(map[string]bool, len(a))
+	for _, id := range a {
+		in[id] = true
+	}
+	shared := 0
+	for _, id := range uniqueIDs(b) {
+		if in[id] {
+			shared++
+		}
+	}
+	return shared
+}
@@ -0,0 +1,37 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRankingsBreakTiesByPaperID(t *testing.T) {
+	// the leaves of a star tie exactly in both PageRank and citations
+	forward := testGraph(t, "e>hub", "c>hub", "a>hub", "d>hub", "b>hub")
+	backward := testGraph(t, "b>hub", "d>hub", "a>hub", "c>hub", "e>hub")
+
+	var orders []string
+	for _, g := range []*Graph{forward, backward} {
+		result, err := CalculatePageRank(g, testConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, ranking := range result.Rankings {
+			ids = append(ids, ranking.PaperID)
+		}
+		orders = append(orders, fmt.Sprint(ids))
+
+		var cited []string
+		for _, ranking := range g.GetMostCitedPapers(3) {
+			cited = append(cited, ranking.PaperID)
+		}
+		if fmt.Sprint(cited) != "[hub a b]" {
+			t.Errorf("most cited %v, want [hub a b]", cited)
+		}
+	}
+
+	if orders[0] != "[hub a b c d e]" || orders[1] != orders[0] {
+		t.Errorf("rankings %s and %s, want [hub a b c d e] for both", orders[0], orders[1])
+	}
+}
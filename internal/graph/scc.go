@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// number of example cycle components kept in GraphStats
+const maxCycleExamples = 3
+
+// StronglyConnectedComponents returns the graph's strongly connected
+// components using Tarjan's algorithm, largest first. Papers should only
+// cite older work, so every component with more than one paper contains a
+// citation cycle. The search is iterative, so deep citation chains do not
+// overflow the stack.
+func (g *Graph) StronglyConnectedComponents() [][]string {
+	numNodes := len(g.Nodes)
+	nodeIndex := make(map[string]int, numNodes)
+	for i, node := range g.Nodes {
+		nodeIndex[node.ID] = i
+	}
+
+	adj := make([][]int, numNodes)
+	for _, edge := range g.Edges {
+		from, okFrom := nodeIndex[edge.From]
+		to, okTo := nodeIndex[edge.To]
+		if okFrom && okTo {
+			adj[from] = append(adj[from], to)
+		}
+	}
+
+	const unvisited = -1
+	index := make([]int, numNodes)
+	lowlink := make([]int, numNodes)
+	onStack := make([]bool, numNodes)
+	for i := range index {
+		index[i] = unvisited
+	}
+
+	var components [][]string
+	var stack []int
+	nextIndex := 0
+
+	// call frames of the depth-first search: node and next neighbor to try
+	type frame struct{ node, next int }
+
+	for root := 0; root < numNodes; root++ {
+		if index[root] != unvisited {
+			continue
+		}
+
+		frames := []frame{{node: root}}
+		index[root], lowlink[root] = nextIndex, nextIndex
+		nextIndex++
+		stack = append(stack, root)
+		onStack[root] = true
+
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			v := f.node
+
+			if f.next < len(adj[v]) {
+				w := adj[v][f.next]
+				f.next++
+				if index[w] == unvisited {
+					index[w], lowlink[w] = nextIndex, nextIndex
+					nextIndex++
+					stack = append(stack, w)
+					onStack[w] = true
+					frames = append(frames, frame{node: w})
+				} else if onStack[w] {
+					lowlink[v] = min(lowlink[v], index[w])
+				}
+				continue
+			}
+
+			// all neighbors done: pop the frame and close a component if v
+			// is its root
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].node
+				lowlink[parent] = min(lowlink[parent], lowlink[v])
+			}
+
+			if lowlink[v] == index[v] {
+				var component []string
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w] = false
+					component = append(component, g.Nodes[w].ID)
+					if w == v {
+						break
+					}
+				}
+				sort.Strings(component)
+				components = append(components, component)
+			}
+		}
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		if len(components[i]) != len(components[j]) {
+			return len(components[i]) > len(components[j])
+		}
+		return components[i][0] < components[j][0]
+	})
+	return components
+}
+
+// CycleComponents returns the strongly connected components with more than
+// one paper, largest first.
+func (g *Graph) CycleComponents() [][]string {
+	var cycles [][]string
+	for _, component := range g.StronglyConnectedComponents() {
+		if len(component) < 2 {
+			break
+		}
+		cycles = append(cycles, component)
+	}
+	return cycles
+}
+
+// cycleStats fills the cycle fields of stats.
+func cycleStats(g *Graph, stats *GraphStats) {
+	cycles := g.CycleComponents()
+	stats.CycleComponents = len(cycles)
+	stats.PapersInCycles = 0
+	stats.CycleExamples = nil
+	for i, component := range cycles {
+		stats.PapersInCycles += len(component)
+		if i < maxCycleExamples {
+			stats.CycleExamples = append(stats.CycleExamples, exampleMembers(component, 5))
+		}
+	}
+	if len(cycles) > 0 {
+		stats.LargestCycleComponent = len(cycles[0])
+	}
+}
+
+func exampleMembers(component []string, n int) []string {
+	if len(component) > n {
+		return component[:n]
+	}
+	return component
+}
+
+// PrintCycleComponents lists the largest citation cycles with their papers'
+// titles and years; an out-of-order year usually points at the bad link.
+func PrintCycleComponents(g *Graph, cycles [][]string, maxComponents, maxMembers int) {
+	fmt.Println("\n=== Citation Cycles ===")
+	fmt.Printf("Strongly connected components with more than one paper: %d\n", len(cycles))
+	if len(cycles) == 0 {
+		fmt.Println("No citation cycles found.")
+		fmt.Println("=======================")
+		return
+	}
+
+	nodes := make(map[string]Node, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes[node.ID] = node
+	}
+
+	for i, component := range cycles {
+		if i >= maxComponents {
+			fmt.Printf("\n... and %d more\n", len(cycles)-maxComponents)
+			break
+		}
+		fmt.Printf("\nComponent %d (%d papers):\n", i+1, len(component))
+		for j, id := range component {
+			if j >= maxMembers {
+				fmt.Printf("  ... and %d more\n", len(component)-maxMembers)
+				break
+			}
+			node := nodes[id]
+			title := node.Title
+			if len(title) > 60 {
+				title = title[:57] + "..."
+			}
+			fmt.Printf("  %s (%d) %s\n", id, node.Year, title)
+		}
+	}
+	fmt.Println("=======================")
+}
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCycleComponentsFindPlantedCycles(t *testing.T) {
+	// an acyclic citation graph with two planted cycles: a 3-cycle among
+	// a, b and c, and a mutual citation between x and y
+	g := testGraph(t,
+		"b>a", "c>b", "a>c", // planted
+		"d>a", "e>d", "e>b",
+		"x>y", "y>x", // planted
+		"x>e", "f>x",
+		"lone",
+	)
+
+	want := [][]string{{"a", "b", "c"}, {"x", "y"}}
+	if got := g.CycleComponents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cycle components = %v, want %v", got, want)
+	}
+
+	// every other paper is a component of its own
+	components := g.StronglyConnectedComponents()
+	if len(components) != len(g.Nodes)-3 {
+		t.Errorf("%d components for %d papers, want %d", len(components), len(g.Nodes), len(g.Nodes)-3)
+	}
+	for _, component := range components[2:] {
+		if len(component) != 1 {
+			t.Errorf("component %v after the cycles, want singletons", component)
+		}
+	}
+
+	var stats GraphStats
+	cycleStats(g, &stats)
+	if stats.CycleComponents != 2 || stats.PapersInCycles != 5 || stats.LargestCycleComponent != 3 {
+		t.Errorf("stats = %d components, %d papers, largest %d; want 2, 5, 3",
+			stats.CycleComponents, stats.PapersInCycles, stats.LargestCycleComponent)
+	}
+}
+
+func TestCycleComponentsAcyclic(t *testing.T) {
+	g := testGraph(t, "b>a", "c>a", "c>b", "d>c")
+	if cycles := g.CycleComponents(); len(cycles) != 0 {
+		t.Errorf("cycle components %v in an acyclic graph", cycles)
+	}
+}
+
+func TestStronglyConnectedComponentsDeepChain(t *testing.T) {
+	// a long chain closed into one cycle: the search goes n frames deep
+	const n = 20000
+	edges := make([]string, n)
+	for i := range edges {
+		edges[i] = fmt.Sprintf("p%d>p%d", i, (i+1)%n)
+	}
+	g := testGraph(t, edges...)
+	cycles := g.CycleComponents()
+	if len(cycles) != 1 || len(cycles[0]) != n {
+		t.Errorf("got %d cycle components, want one with all %d papers", len(cycles), n)
+	}
+}
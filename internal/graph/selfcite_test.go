@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+func TestPageRankIgnoresSelfCitations(t *testing.T) {
+	parsed := &data.ParsedData{
+		Papers: []data.Paper{{ID: "A"}, {ID: "B"}, {ID: "C"}},
+		Citations: []data.CitationEdge{
+			{From: "B", To: "A"},
+			{From: "C", To: "A"},
+			{From: "C", To: "B"},
+		},
+	}
+	without, err := CalculatePageRank(BuildGraphFromData(parsed, BuildConfig{}), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed.SelfCitations = []data.CitationEdge{{From: "B", To: "B"}, {From: "C", To: "C"}}
+	// an older papers file keeps self-citations in Citations
+	legacy := *parsed
+	legacy.Citations = append(append([]data.CitationEdge{}, parsed.Citations...), data.CitationEdge{From: "A", To: "A"})
+	legacy.SelfCitations = nil
+
+	for _, tc := range []struct {
+		name   string
+		parsed *data.ParsedData
+		config BuildConfig
+		self   int
+	}{
+		{"dropped", parsed, BuildConfig{}, 2},
+		{"kept", parsed, BuildConfig{KeepSelfCitations: true}, 2},
+		{"legacy", &legacy, BuildConfig{KeepSelfCitations: true}, 1},
+	} {
+		g := BuildGraphFromData(tc.parsed, tc.config)
+		if len(g.Edges) != 3 || g.Stats.SelfCitations != tc.self {
+			t.Errorf("%s: %d edges and %d self-citations, want 3 and %d", tc.name, len(g.Edges), g.Stats.SelfCitations, tc.self)
+		}
+		if tc.config.KeepSelfCitations && len(g.SelfCitationEdges) != tc.self {
+			t.Errorf("%s: kept %d self-citation edges, want %d", tc.name, len(g.SelfCitationEdges), tc.self)
+		}
+		with, err := CalculatePageRank(g, testConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(with.Scores, without.Scores) {
+			t.Errorf("%s: self-citations changed the scores: %v, want %v", tc.name, with.Scores, without.Scores)
+		}
+	}
+}
+
+// authorSelfCitationFixture has two author self-citations: B cites A and
+// shares Ada Lovelace with it (spelled differently), and E cites B and
+// shares Grace Hopper. C shares no author with A, and D has no author data.
+// B and E also cite C, so down-weighting their self-citations moves rank
+// to C rather than leaving them dangling.
+func authorSelfCitationFixture() *data.ParsedData {
+	return &data.ParsedData{
+		Papers: []data.Paper{
+			{ID: "A", Authors: []string{"Ada Lovelace"}},
+			{ID: "B", Authors: []string{"ada  lovelace", "Grace Hopper"}},
+			{ID: "C", Authors: []string{"Alan Turing"}},
+			{ID: "D"},
+			{ID: "E", Authors: []string{"Grace Hopper"}},
+		},
+		Citations: []data.CitationEdge{
+			{From: "B", To: "A"},
+			{From: "C", To: "A"},
+			{From: "E", To: "B"},
+			{From: "D", To: "A"},
+			{From: "C", To: "E"},
+			{From: "B", To: "C"},
+			{From: "E", To: "C"},
+		},
+	}
+}
+
+func TestAuthorSelfCitations(t *testing.T) {
+	parsed := authorSelfCitationFixture()
+	g := BuildGraphFromData(parsed, BuildConfig{})
+
+	var shared []string
+	for i, self := range g.AuthorSelfCitations() {
+		if self {
+			shared = append(shared, g.Edges[i].From+">"+g.Edges[i].To)
+		}
+	}
+	if want := []string{"B>A", "E>B"}; !reflect.DeepEqual(shared, want) {
+		t.Errorf("author self-citations %v, want %v", shared, want)
+	}
+	if g.Stats.AuthorSelfCitations != 2 {
+		t.Errorf("stats count %d author self-citations, want 2", g.Stats.AuthorSelfCitations)
+	}
+
+	// dropping them ranks as if the edges were never there
+	independent := authorSelfCitationFixture()
+	independent.Citations = []data.CitationEdge{parsed.Citations[1], parsed.Citations[3], parsed.Citations[4], parsed.Citations[5], parsed.Citations[6]}
+	want, err := CalculatePageRank(BuildGraphFromData(independent, BuildConfig{}), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scores := make(map[string]map[string]float64)
+	for _, policy := range []string{AuthorSelfCitationsKeep, AuthorSelfCitationsDown, AuthorSelfCitationsDrop} {
+		config := testConfig()
+		config.AuthorSelfCitations = policy
+		config.AuthorSelfCitationWeight = DefaultAuthorSelfCitationWeight
+		result, err := CalculatePageRank(g, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantChanged := 2
+		if policy == AuthorSelfCitationsKeep {
+			wantChanged = 0
+		}
+		if result.Stats.AuthorSelfCitations != wantChanged {
+			t.Errorf("%q: %d author self-citations changed, want %d", policy, result.Stats.AuthorSelfCitations, wantChanged)
+		}
+		scores[policy] = result.Scores
+	}
+
+	for id, score := range want.Scores {
+		if math.Abs(scores[AuthorSelfCitationsDrop][id]-score) > 1e-9 {
+			t.Errorf("dropped: %s scored %v, want %v as without the edges", id, scores[AuthorSelfCitationsDrop][id], score)
+		}
+	}
+	// A, cited by its own author, falls as the citation weighs less
+	keep, down, drop := scores[AuthorSelfCitationsKeep]["A"], scores[AuthorSelfCitationsDown]["A"], scores[AuthorSelfCitationsDrop]["A"]
+	if !(keep > down && down > drop) {
+		t.Errorf("A scored %v kept, %v downweighted, %v dropped; want strictly falling", keep, down, drop)
+	}
+}
+
+func TestParseAuthorSelfCitations(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", AuthorSelfCitationsKeep},
+		{"keep", AuthorSelfCitationsKeep},
+		{" Downweight ", AuthorSelfCitationsDown},
+		{"drop", AuthorSelfCitationsDrop},
+	}
+	for _, tt := range tests {
+		if got, err := ParseAuthorSelfCitations(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseAuthorSelfCitations(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseAuthorSelfCitations("ignore"); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
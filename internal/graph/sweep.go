@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+)
+
+// one PageRank run of a damping-factor sweep
+type SweepRun struct {
+	DampingFactor float64         `json:"damping_factor"`
+	Iterations    int             `json:"iterations"`
+	Converged     bool            `json:"converged"`
+	TopPaper      string          `json:"top_paper"`
+	Result        *PageRankResult `json:"-"`
+}
+
+// how much the ranking moved between two damping factors
+type SweepComparison struct {
+	From      float64 `json:"from"`
+	To        float64 `json:"to"`
+	Spearman  float64 `json:"spearman"`   // over all papers
+	OverlapAt float64 `json:"overlap_at"` // shared fraction of the top k papers
+}
+
+// DampingSweep runs PageRank once per damping factor, each from a fresh
+// uniform vector, and compares the rankings of every pair of runs by Spearman
+// correlation and top-k overlap.
+func DampingSweep(ctx context.Context, graph *Graph, config PageRankConfig, factors []float64, k int) ([]SweepRun, []SweepComparison, error) {
+	runs := make([]SweepRun, 0, len(factors))
+	for _, factor := range factors {
+		if factor <= 0 || factor >= 1 {
+			return nil, nil, fmt.Errorf("damping factor must be between 0 and 1, got: %.3f", factor)
+		}
+
+		runConfig := config
+		runConfig.DampingFactor = factor
+		result, err := calculatePageRank(ctx, graph, runConfig, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("damping %.2f: %v", factor, err)
+		}
+
+		runs = append(runs, SweepRun{
+			DampingFactor: factor,
+			Iterations:    result.Stats.Iterations,
+			Converged:     result.Stats.Converged,
+			TopPaper:      result.Stats.TopPaper,
+			Result:        result,
+		})
+	}
+
+	var comparisons []SweepComparison
+	for i := 0; i < len(runs); i++ {
+		for j := i + 1; j < len(runs); j++ {
+			comparisons = append(comparisons, SweepComparison{
+				From:      runs[i].DampingFactor,
+				To:        runs[j].DampingFactor,
+				Spearman:  scoreCorrelation(graph, runs[i].Result.Scores, runs[j].Result.Scores),
+				OverlapAt: topOverlap(runs[i].Result.Rankings, runs[j].Result.Rankings, k),
+			})
+		}
+	}
+
+	return runs, comparisons, nil
+}
+
+// scoreCorrelation is the Spearman correlation between two score maps over
+// the graph's papers.
+func scoreCorrelation(graph *Graph, a, b map[string]float64) float64 {
+	if len(graph.Nodes) < 2 {
+		return 0
+	}
+
+	x := make([]float64, len(graph.Nodes))
+	y := make([]float64, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		x[i] = a[node.ID]
+		y[i] = b[node.ID]
+	}
+	return pearson(averageRanks(x), averageRanks(y))
+}
+
+// topOverlap returns the fraction of the top k papers two rankings share.
+func topOverlap(a, b []PaperScore, k int) float64 {
+	k = min(k, len(a), len(b))
+	if k <= 0 {
+		return 0
+	}
+
+	top := make(map[string]bool, k)
+	for _, paper := range a[:k] {
+		top[paper.PaperID] = true
+	}
+
+	shared := 0
+	for _, paper := range b[:k] {
+		if top[paper.PaperID] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(k)
+}
+
+func PrintDampingSweep(runs []SweepRun, comparisons []SweepComparison, k int) {
+	fmt.Println("\n=== Damping Factor Sweep ===")
+	fmt.Println("Damping | Iterations | Converged | Top Paper")
+	fmt.Println("--------|------------|-----------|-----------")
+	for _, run := range runs {
+		fmt.Printf("%-7.2f | %-10d | %-9v | %s\n", run.DampingFactor, run.Iterations, run.Converged, run.TopPaper)
+	}
+
+	fmt.Println()
+	fmt.Printf("From | To   | Spearman | Overlap@%d\n", k)
+	fmt.Println("-----|------|----------|-----------")
+	for _, c := range comparisons {
+		fmt.Printf("%.2f | %.2f | %8.4f | %.2f\n", c.From, c.To, c.Spearman, c.OverlapAt)
+	}
+	fmt.Println("============================")
+}
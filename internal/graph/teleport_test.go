@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestRecentTeleportWindow(t *testing.T) {
+	// papers from 2000 to 2005, one a year in order of appearance
+	g := testGraph(t, "b>a", "c>a", "d>b", "e>c", "f>d")
+	config := testConfig()
+
+	config.RecentTeleportYears = 2
+	from, to, minYear, maxYear, papers := RecentTeleportWindow(g, config)
+	if from != 2004 || to != 2005 || minYear != 2000 || maxYear != 2005 || papers != 2 {
+		t.Errorf("window %d-%d over %d-%d with %d papers, want 2004-2005 over 2000-2005 with 2",
+			from, to, minYear, maxYear, papers)
+	}
+	teleport := recentTeleportVector(g, config, io.Discard)
+	if want := []float64{0, 0, 0, 0, 0.5, 0.5}; !reflect.DeepEqual(teleport, want) {
+		t.Errorf("teleport vector %v, want %v", teleport, want)
+	}
+
+	// the window counts back from the as-of year, not the latest paper
+	config.AsOfYear = 2003
+	if from, to, _, _, papers := RecentTeleportWindow(g, config); from != 2002 || to != 2003 || papers != 2 {
+		t.Errorf("as of 2003: window %d-%d with %d papers, want 2002-2003 with 2", from, to, papers)
+	}
+
+	// a window past every paper excludes them all and teleports uniformly
+	config.AsOfYear = 2010
+	if _, _, _, _, papers := RecentTeleportWindow(g, config); papers != 0 {
+		t.Errorf("as of 2010: %d papers in the window, want 0", papers)
+	}
+	if teleport := recentTeleportVector(g, config, io.Discard); teleport != nil {
+		t.Errorf("teleport vector %v for an empty window, want uniform", teleport)
+	}
+	excluded, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uniform, err := CalculatePageRank(g, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(excluded.Scores, uniform.Scores) {
+		t.Error("an empty window changed the scores")
+	}
+}
+
+func TestRecentTeleportFavorsRecentPapers(t *testing.T) {
+	// two papers with the same citers, one old and one recent
+	g := testGraph(t, "x", "old>x", "recent>x", "c1>old", "c1>recent", "c2>old", "c2>recent")
+	for i := range g.Nodes {
+		if g.Nodes[i].ID == "recent" {
+			g.Nodes[i].Year = 2020
+		}
+		if g.Nodes[i].ID == "c1" || g.Nodes[i].ID == "c2" {
+			g.Nodes[i].Year = 2021
+		}
+	}
+	config := testConfig()
+	config.RecentTeleportYears = 2
+
+	result, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Scores["recent"] <= result.Scores["old"] {
+		t.Errorf("recent %.6f, old %.6f: teleport did not favor the recent paper", result.Scores["recent"], result.Scores["old"])
+	}
+}
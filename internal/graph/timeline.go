@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"fmt"
+)
+
+// one year of a timeline: how many papers appeared and the best-ranked one
+type TimelineYear struct {
+	Year  int         `json:"year"`
+	Count int         `json:"count"`
+	Top   *PaperScore `json:"top,omitempty"` // nil in a year without papers
+}
+
+// papers grouped by publication year, from the first year to the last
+type Timeline struct {
+	Subject   string         `json:"subject"`
+	Papers    int            `json:"papers"`
+	Undated   int            `json:"undated"` // papers without a year, left out of Years
+	FirstYear int            `json:"first_year"`
+	LastYear  int            `json:"last_year"`
+	Years     []TimelineYear `json:"years"`
+}
+
+// BuildTimeline groups papers by year, keeping each year's paper with the
+// highest PageRank (ties go to the smaller id). Every year between the first
+// and the last is listed, so gaps show up as years with a count of 0.
+func BuildTimeline(subject string, papers []PaperScore) *Timeline {
+	timeline := &Timeline{Subject: subject, Papers: len(papers)}
+	byYear := make(map[int]*TimelineYear)
+	for i := range papers {
+		paper := papers[i]
+		if paper.Year <= 0 {
+			timeline.Undated++
+			continue
+		}
+		year := byYear[paper.Year]
+		if year == nil {
+			year = &TimelineYear{Year: paper.Year}
+			byYear[paper.Year] = year
+		}
+		year.Count++
+		if year.Top == nil || paper.Score > year.Top.Score ||
+			(paper.Score == year.Top.Score && paper.PaperID < year.Top.PaperID) {
+			year.Top = &paper
+		}
+
+		if timeline.FirstYear == 0 || paper.Year < timeline.FirstYear {
+			timeline.FirstYear = paper.Year
+		}
+		timeline.LastYear = max(timeline.LastYear, paper.Year)
+	}
+
+	if timeline.FirstYear == 0 {
+		return timeline
+	}
+	timeline.Years = make([]TimelineYear, 0, timeline.LastYear-timeline.FirstYear+1)
+	for y := timeline.FirstYear; y <= timeline.LastYear; y++ {
+		if year := byYear[y]; year != nil {
+			timeline.Years = append(timeline.Years, *year)
+		} else {
+			timeline.Years = append(timeline.Years, TimelineYear{Year: y})
+		}
+	}
+	return timeline
+}
+
+func PrintTimeline(p Printer, timeline *Timeline) {
+	fmt.Printf("\n=== Timeline: %s ===\n", p.StyleTitle(timeline.Subject))
+	fmt.Printf("Papers: %d", timeline.Papers)
+	if timeline.FirstYear > 0 {
+		fmt.Printf(" (%d-%d)", timeline.FirstYear, timeline.LastYear)
+	}
+	if timeline.Undated > 0 {
+		fmt.Printf(", %d without a year", timeline.Undated)
+	}
+	fmt.Println()
+	if len(timeline.Years) == 0 {
+		return
+	}
+
+	fmt.Println("\nYear | Papers | Top paper (PageRank)")
+	fmt.Println("-----|--------|--------------------------------")
+	for _, year := range timeline.Years {
+		if year.Top == nil {
+			fmt.Printf("%-4d | %-6d | -\n", year.Year, 0)
+			continue
+		}
+		titleTrunc := year.Top.Title
+		if len(titleTrunc) > 40 {
+			titleTrunc = titleTrunc[:37] + "..."
+		}
+		fmt.Printf("%-4d | %-6d | %s (%s) %s\n", year.Year, year.Count,
+			p.StyleTitle(titleTrunc), year.Top.PaperID, p.StyleScore(p.Score(year.Top.Score)))
+	}
+}
@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	papers := []PaperScore{
+		{PaperID: "a", Year: 2010, Score: 0.1},
+		{PaperID: "b", Year: 2010, Score: 0.3},
+		{PaperID: "c", Year: 2010, Score: 0.2},
+		{PaperID: "e", Year: 2013, Score: 0.4},
+		// tied with e; the smaller id wins
+		{PaperID: "d", Year: 2013, Score: 0.4},
+		{PaperID: "undated", Score: 0.9},
+	}
+	timeline := BuildTimeline("Ada Lovelace", papers)
+
+	if timeline.Papers != 6 || timeline.Undated != 1 || timeline.FirstYear != 2010 || timeline.LastYear != 2013 {
+		t.Errorf("%d papers, %d undated, %d-%d; want 6, 1, 2010-2013",
+			timeline.Papers, timeline.Undated, timeline.FirstYear, timeline.LastYear)
+	}
+	type year struct {
+		Year, Count int
+		Top         string
+	}
+	var got []year
+	for _, y := range timeline.Years {
+		top := ""
+		if y.Top != nil {
+			top = y.Top.PaperID
+		}
+		got = append(got, year{y.Year, y.Count, top})
+	}
+	// the years between without papers are listed empty
+	want := []year{{2010, 3, "b"}, {2011, 0, ""}, {2012, 0, ""}, {2013, 2, "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("years %v, want %v", got, want)
+	}
+
+	// the top paper is a copy, not the last paper looked at
+	if top := timeline.Years[0].Top; top.Score != 0.3 {
+		t.Errorf("2010 top scored %v, want 0.3", top.Score)
+	}
+}
+
+func TestBuildTimelineWithoutDatedPapers(t *testing.T) {
+	for _, papers := range [][]PaperScore{nil, {{PaperID: "undated"}}} {
+		timeline := BuildTimeline("nobody", papers)
+		if len(timeline.Years) != 0 || timeline.FirstYear != 0 || timeline.Undated != len(papers) {
+			t.Errorf("%v: timeline %+v, want no years", papers, timeline)
+		}
+	}
+}
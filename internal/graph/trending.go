@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// a paper ranked by PageRank per year since publication
+type TrendingPaper struct {
+	PaperScore
+	Age           int     `json:"age"` // years since publication, counting the publication year as 1
+	TrendingScore float64 `json:"trending_score"`
+}
+
+// TrendingScore is the paper's PageRank divided by its age in years, so a
+// paper published this year keeps its full score and a ten-year-old one a
+// tenth of it. Papers with a missing year, or one after currentYear, score 0.
+func TrendingScore(paper PaperScore, currentYear int) float64 {
+	if paper.Year <= 0 || paper.Year > currentYear {
+		return 0
+	}
+	return paper.Score / float64(currentYear-paper.Year+1)
+}
+
+// LatestYear returns the most recent publication year in rankings, or 0 if
+// no paper has a year.
+func LatestYear(rankings []PaperScore) int {
+	latest := 0
+	for _, paper := range rankings {
+		latest = max(latest, paper.Year)
+	}
+	return latest
+}
+
+// TrendingPapers ranks the papers published in the last years years up to
+// currentYear by TrendingScore. Papers without a year are left out.
+func TrendingPapers(rankings []PaperScore, currentYear, years int) []TrendingPaper {
+	var trending []TrendingPaper
+	for _, paper := range rankings {
+		if paper.Year <= 0 || paper.Year > currentYear || currentYear-paper.Year >= years {
+			continue
+		}
+		trending = append(trending, TrendingPaper{
+			PaperScore:    paper,
+			Age:           currentYear - paper.Year + 1,
+			TrendingScore: TrendingScore(paper, currentYear),
+		})
+	}
+
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].TrendingScore != trending[j].TrendingScore {
+			return trending[i].TrendingScore > trending[j].TrendingScore
+		}
+		return trending[i].PaperID < trending[j].PaperID
+	})
+	return trending
+}
+
+func PrintTrendingPapers(p Printer, trending []TrendingPaper, n int) {
+	if n > len(trending) {
+		n = len(trending)
+	}
+
+	fmt.Printf("\nTop %d Trending Papers (PageRank per year):\n", n)
+	fmt.Println("Rank | Trend    | PageRank | Citations | Year | Title")
+	fmt.Println("-----|----------|----------|-----------|------|--------------------------------")
+
+	for i := 0; i < n; i++ {
+		paper := trending[i]
+		titleTrunc := paper.Title
+		if len(titleTrunc) > 40 {
+			titleTrunc = titleTrunc[:37] + "..."
+		}
+
+		fmt.Printf("%-4d | %s | %-8s | %-9d | %-4d | %s\n",
+			i+1, p.StyleScore(fmt.Sprintf("%-8s", p.Score(paper.TrendingScore))), p.Score(paper.Score),
+			paper.Citations, paper.Year, p.StyleTitle(titleTrunc))
+	}
+}
@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTrendingPapers(t *testing.T) {
+	rankings := []PaperScore{
+		{PaperID: "classic", Year: 2011, Score: 0.5},
+		{PaperID: "recent", Year: 2019, Score: 0.5},
+		{PaperID: "new", Year: 2020, Score: 0.125},
+		{PaperID: "tied", Year: 2018, Score: 0.375},
+		{PaperID: "undated", Score: 0.9},
+		{PaperID: "future", Year: 2021, Score: 0.9},
+	}
+	if latest := LatestYear(rankings); latest != 2021 {
+		t.Errorf("latest year %d, want 2021", latest)
+	}
+
+	trending := TrendingPapers(rankings, 2020, 3)
+	var got []string
+	for _, paper := range trending {
+		got = append(got, fmt.Sprintf("%s:%d:%.3f", paper.PaperID, paper.Age, paper.TrendingScore))
+	}
+	// PageRank per year of age, ties by paper id; classic is older than
+	// the window and undated and future papers are left out
+	want := "[recent:2:0.250 new:1:0.125 tied:3:0.125]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("trending %v, want %s", got, want)
+	}
+
+	for _, paper := range []PaperScore{rankings[4], rankings[5]} {
+		if score := TrendingScore(paper, 2020); score != 0 {
+			t.Errorf("%s: trending score %v, want 0", paper.PaperID, score)
+		}
+	}
+	if score := TrendingScore(rankings[0], 2020); score != 0.05 {
+		t.Errorf("classic: trending score %v, want 0.5 over 10 years", score)
+	}
+}
@@ -0,0 +1,219 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severities of a validation check, from least to most serious
+const (
+	SeverityOK      = "ok"
+	SeverityInfo    = "info"    // worth knowing, ranking is unaffected
+	SeverityWarning = "warning" // ranking runs but is likely skewed
+	SeverityError   = "error"   // ranking is wrong or fails
+)
+
+var severityLevels = map[string]int{
+	SeverityOK:      0,
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// SeverityLevel orders severities: ok 0, info 1, warning 2, error 3.
+func SeverityLevel(severity string) int {
+	return severityLevels[severity]
+}
+
+// thresholds and limits for ValidateGraph
+type ValidateConfig struct {
+	DanglingThreshold float64 // warn above this share of papers citing nothing
+	IsolatedThreshold float64 // warn above this share of papers with no citations at all
+	Samples           int     // offenders listed per check
+	Cycles            bool    // also look for citation cycles, the slowest check
+}
+
+func DefaultValidateConfig() ValidateConfig {
+	return ValidateConfig{
+		DanglingThreshold: 0.5,
+		IsolatedThreshold: 0.5,
+		Samples:           5,
+		Cycles:            true,
+	}
+}
+
+// the outcome of one check
+type ValidationCheck struct {
+	Name     string   `json:"name"`
+	Severity string   `json:"severity"`
+	Count    int      `json:"count"`
+	Message  string   `json:"message"`
+	Samples  []string `json:"samples,omitempty"`
+}
+
+// every check's outcome and the most serious severity among them
+type ValidationReport struct {
+	Nodes    int               `json:"nodes"`
+	Edges    int               `json:"edges"`
+	Severity string            `json:"severity"`
+	Checks   []ValidationCheck `json:"checks"`
+}
+
+// ValidateGraph runs the data-quality checks on the graph: structural
+// problems that break PageRank (orphan endpoints, duplicate paper ids,
+// self-loops, an empty graph) are errors; problems that skew it (duplicate
+// and anachronistic edges, cycles, high dangling or isolated shares) are
+// warnings.
+func ValidateGraph(g *Graph, config ValidateConfig) *ValidationReport {
+	report := &ValidationReport{Nodes: len(g.Nodes), Edges: len(g.Edges)}
+	add := func(check ValidationCheck) {
+		if len(check.Samples) > config.Samples {
+			check.Samples = check.Samples[:config.Samples]
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	years := make(map[string]int, len(g.Nodes))
+	var duplicateIDs []string
+	for _, node := range g.Nodes {
+		if _, seen := years[node.ID]; seen {
+			duplicateIDs = append(duplicateIDs, node.ID)
+		}
+		years[node.ID] = node.Year
+	}
+
+	// problems that make PageRank scores wrong or non-finite
+	structure := ValidationCheck{Name: "non_finite_risk", Severity: SeverityOK, Message: "no structure that can make scores NaN or infinite"}
+	switch {
+	case len(g.Nodes) == 0:
+		structure.Severity, structure.Count = SeverityError, 1
+		structure.Message = "the graph has no papers, so every score would be 0/0"
+	case len(duplicateIDs) > 0:
+		structure.Severity, structure.Count = SeverityError, len(duplicateIDs)
+		structure.Message = "paper ids appear more than once; their scores overwrite each other and no longer sum to 1"
+		structure.Samples = duplicateIDs
+	}
+	add(structure)
+
+	var orphans, selfLoops, anachronistic []string
+	duplicates := make(map[[2]string]int)
+	for _, edge := range g.Edges {
+		_, fromOK := years[edge.From]
+		_, toOK := years[edge.To]
+		if !fromOK || !toOK {
+			orphans = append(orphans, edge.From+" -> "+edge.To)
+			continue
+		}
+		if edge.From == edge.To {
+			selfLoops = append(selfLoops, edge.From)
+		}
+		if from, to := years[edge.From], years[edge.To]; from > 0 && to > 0 && from < to {
+			anachronistic = append(anachronistic, fmt.Sprintf("%s (%d) -> %s (%d)", edge.From, from, edge.To, to))
+		}
+		duplicates[[2]string{edge.From, edge.To}]++
+	}
+
+	add(countCheck("orphan_endpoints", SeverityError, orphans,
+		"edges point at papers missing from the graph; PageRank credits them to the wrong paper",
+		"every edge connects two papers in the graph"))
+	add(countCheck("self_loops", SeverityError, selfLoops,
+		"papers cite themselves in the ranking edges; rebuild the graph, which sets them aside",
+		"no paper cites itself"))
+
+	var duplicateSamples []string
+	duplicateCount := 0
+	for _, edge := range g.Edges {
+		key := [2]string{edge.From, edge.To}
+		if n := duplicates[key]; n > 1 {
+			duplicateCount += n - 1
+			duplicateSamples = append(duplicateSamples, fmt.Sprintf("%s -> %s (x%d)", edge.From, edge.To, n))
+			duplicates[key] = 0
+		}
+	}
+	duplicateCheck := countCheck("duplicate_edges", SeverityWarning, duplicateSamples,
+		"citations appear more than once and count extra in PageRank",
+		"no citation appears twice")
+	duplicateCheck.Count = duplicateCount
+	add(duplicateCheck)
+
+	add(countCheck("anachronistic_edges", SeverityWarning, anachronistic,
+		"papers cite newer papers, which usually means a bad id link",
+		"no paper cites a newer one"))
+
+	add(fractionCheck("dangling_fraction", len(g.DanglingNodes()), len(g.Nodes), config.DanglingThreshold,
+		"cite no paper in the graph; their rank mass is spread uniformly"))
+	add(fractionCheck("isolated_fraction", len(g.IsolatedNodes()), len(g.Nodes), config.IsolatedThreshold,
+		"have no citations in or out and only get the teleport share"))
+
+	if config.Cycles {
+		cycles := g.CycleComponents()
+		check := ValidationCheck{Name: "citation_cycles", Severity: SeverityOK, Message: "no citation cycles"}
+		if len(cycles) > 0 {
+			check.Severity, check.Count = SeverityWarning, len(cycles)
+			check.Message = "groups of papers cite each other in a cycle (see 'validate --scc')"
+			for _, cycle := range cycles {
+				members := cycle
+				if len(members) > 3 {
+					members = members[:3]
+				}
+				check.Samples = append(check.Samples, fmt.Sprintf("%d papers: %s", len(cycle), strings.Join(members, ", ")))
+			}
+		}
+		add(check)
+	}
+
+	report.Severity = SeverityOK
+	for _, check := range report.Checks {
+		if SeverityLevel(check.Severity) > SeverityLevel(report.Severity) {
+			report.Severity = check.Severity
+		}
+	}
+	return report
+}
+
+// countCheck fails with severity when there are offenders.
+func countCheck(name, severity string, offenders []string, failMessage, okMessage string) ValidationCheck {
+	if len(offenders) == 0 {
+		return ValidationCheck{Name: name, Severity: SeverityOK, Message: okMessage}
+	}
+	return ValidationCheck{Name: name, Severity: severity, Count: len(offenders), Message: failMessage, Samples: offenders}
+}
+
+// fractionCheck warns when count/total exceeds threshold and reports the
+// share as info otherwise.
+func fractionCheck(name string, count, total int, threshold float64, what string) ValidationCheck {
+	fraction := 0.0
+	if total > 0 {
+		fraction = float64(count) / float64(total)
+	}
+	check := ValidationCheck{
+		Name:     name,
+		Severity: SeverityOK,
+		Count:    count,
+		Message:  fmt.Sprintf("%.1f%% of papers %s", fraction*100, what),
+	}
+	switch {
+	case threshold > 0 && fraction > threshold:
+		check.Severity = SeverityWarning
+		check.Message += fmt.Sprintf(" (above %.1f%%)", threshold*100)
+	case count > 0:
+		check.Severity = SeverityInfo
+	}
+	return check
+}
+
+func PrintValidationReport(report *ValidationReport) {
+	fmt.Println("\n=== Validation Report ===")
+	fmt.Printf("Papers: %d, citations: %d\n\n", report.Nodes, report.Edges)
+	for _, check := range report.Checks {
+		fmt.Printf("[%-7s] %s: %s\n", strings.ToUpper(check.Severity), check.Name, check.Message)
+		if check.Count > 0 && check.Severity != SeverityInfo {
+			fmt.Printf("          count: %d\n", check.Count)
+		}
+		for _, sample := range check.Samples {
+			fmt.Printf("          %s\n", sample)
+		}
+	}
+	fmt.Printf("\nHighest severity: %s\n", report.Severity)
+	fmt.Println("=========================")
+}
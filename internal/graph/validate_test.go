@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// validationGraph builds a graph from "id" or "id:year" nodes and "from>to" edges
+// as given, without the cleanup BuildGraph does, so it can hold the
+// problems validate looks for.
+func validationGraph(nodes []string, edges ...string) *Graph {
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	for _, node := range nodes {
+		id, year, _ := strings.Cut(node, ":")
+		n, _ := strconv.Atoi(year)
+		g.Nodes = append(g.Nodes, Node{ID: id, Year: n})
+	}
+	for _, edge := range edges {
+		from, to, _ := strings.Cut(edge, ">")
+		g.Edges = append(g.Edges, Edge{From: from, To: to})
+	}
+	g.rebuildIndexes()
+	return g
+}
+
+func TestValidateGraphSeverities(t *testing.T) {
+	tests := []struct {
+		name     string
+		graph    *Graph
+		cycles   bool
+		severity string
+		// the checks not ok, with their severity
+		checks map[string]string
+	}{
+		{"ok", validationGraph([]string{"a", "b"}, "a>b", "b>a"), false, SeverityOK, map[string]string{}},
+		{"info", validationGraph([]string{"a", "b", "c"}, "a>b", "c>b"), true, SeverityInfo,
+			map[string]string{"dangling_fraction": SeverityInfo}},
+		{"warning", validationGraph([]string{"a", "b", "c"}, "a>b", "a>b", "b>c", "c>b"), true, SeverityWarning,
+			map[string]string{"duplicate_edges": SeverityWarning, "citation_cycles": SeverityWarning}},
+		{"anachronism", validationGraph([]string{"old:2001", "new:2003", "c"}, "old>new", "c>new"), true, SeverityWarning,
+			map[string]string{"anachronistic_edges": SeverityWarning, "dangling_fraction": SeverityInfo}},
+		{"dangling", validationGraph([]string{"a", "b", "c", "d"}, "a>b"), true, SeverityWarning,
+			map[string]string{"dangling_fraction": SeverityWarning, "isolated_fraction": SeverityInfo}},
+		{"error", validationGraph([]string{"a", "b"}, "a>b", "a>a", "b>gone"), true, SeverityError,
+			map[string]string{"self_loops": SeverityError, "orphan_endpoints": SeverityError}},
+		{"duplicate ids", validationGraph([]string{"a", "a", "b"}, "a>b", "b>a"), false, SeverityError,
+			map[string]string{"non_finite_risk": SeverityError}},
+		{"empty", validationGraph(nil), true, SeverityError,
+			map[string]string{"non_finite_risk": SeverityError}},
+	}
+	for _, tt := range tests {
+		config := DefaultValidateConfig()
+		config.Cycles = tt.cycles
+		report := ValidateGraph(tt.graph, config)
+		if report.Severity != tt.severity {
+			t.Errorf("%s: severity %s, want %s", tt.name, report.Severity, tt.severity)
+		}
+		got := make(map[string]string)
+		for _, check := range report.Checks {
+			if check.Severity != SeverityOK {
+				got[check.Name] = check.Severity
+			}
+		}
+		if !reflect.DeepEqual(got, tt.checks) {
+			t.Errorf("%s: checks %v, want %v", tt.name, got, tt.checks)
+		}
+	}
+}
+
+func TestValidateGraphSamples(t *testing.T) {
+	g := validationGraph([]string{"a", "b"}, "a>b", "a>b", "a>b", "b>x", "b>y", "b>z")
+	config := DefaultValidateConfig()
+	config.Samples = 2
+	report := ValidateGraph(g, config)
+
+	checks := make(map[string]ValidationCheck)
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	// the count is every offender, the samples only the first few
+	orphans := checks["orphan_endpoints"]
+	if orphans.Count != 3 || !reflect.DeepEqual(orphans.Samples, []string{"b -> x", "b -> y"}) {
+		t.Errorf("orphans: count %d, samples %v; want 3, [b -> x b -> y]", orphans.Count, orphans.Samples)
+	}
+	duplicates := checks["duplicate_edges"]
+	if duplicates.Count != 2 || !reflect.DeepEqual(duplicates.Samples, []string{"a -> b (x3)"}) {
+		t.Errorf("duplicates: count %d, samples %v; want 2 extra, [a -> b (x3)]", duplicates.Count, duplicates.Samples)
+	}
+}
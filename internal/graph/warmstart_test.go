@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestUpdatePageRankMatchesColdRun(t *testing.T) {
+	g := GenerateRandomGraph(200, 800, 3)
+	config := testConfig()
+	config.Tolerance = 1e-10
+
+	prev, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a small change: one new paper citing two existing ones
+	g.Nodes = append(g.Nodes, Node{ID: "new", Title: "A new paper", Year: 2030})
+	g.Edges = append(g.Edges, Edge{From: "new", To: g.Nodes[0].ID}, Edge{From: "new", To: g.Nodes[1].ID})
+	g.rebuildIndexes()
+
+	cold, err := CalculatePageRank(g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warm, err := UpdatePageRank(context.Background(), prev, g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !warm.Stats.WarmStarted || !warm.Stats.Converged {
+		t.Errorf("warm run stats %+v", warm.Stats)
+	}
+	if warm.Stats.Iterations >= cold.Stats.Iterations {
+		t.Errorf("warm start took %d iterations, cold %d", warm.Stats.Iterations, cold.Stats.Iterations)
+	}
+	for id, score := range cold.Scores {
+		if math.Abs(warm.Scores[id]-score) > 1e-8 {
+			t.Errorf("%s: warm %.10f, cold %.10f", id, warm.Scores[id], score)
+		}
+	}
+}
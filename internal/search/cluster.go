@@ -0,0 +1,350 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+// settings for clustering papers by embedding
+type ClusterConfig struct {
+	K              int    // number of clusters
+	MaxIterations  int    // k-means rounds before giving up on convergence
+	Seed           int64  // seed for picking the initial centroids
+	EmbeddingField string // which paper embedding to cluster
+	TopPapers      int    // papers kept per cluster, by PageRank
+	Keywords       int    // keywords kept per cluster
+}
+
+func DefaultClusterConfig() ClusterConfig {
+	return ClusterConfig{
+		K:              20,
+		MaxIterations:  100,
+		Seed:           1,
+		EmbeddingField: data.DefaultEmbeddingField,
+		TopPapers:      10,
+		Keywords:       5,
+	}
+}
+
+// one k-means cluster with its best papers and describing keywords
+type Cluster struct {
+	ID        int                `json:"id"`
+	Size      int                `json:"size"`
+	Keywords  []string           `json:"keywords"`
+	TopPapers []graph.PaperScore `json:"top_papers"` // by global PageRank
+}
+
+// cluster assignment of every paper with an embedding
+type ClusterResult struct {
+	K          int            `json:"k"`
+	Seed       int64          `json:"seed"`
+	Iterations int            `json:"iterations"`
+	Converged  bool           `json:"converged"`
+	Assignment map[string]int `json:"assignment"` // paper_id -> cluster id
+	Clusters   []Cluster      `json:"clusters"`   // largest first; the index is the id
+	Excluded   []string       `json:"excluded"`   // papers without a usable embedding
+}
+
+// ClusterPapers groups the papers by k-means over their embeddings and
+// describes each cluster by its top papers by PageRank and its keywords.
+// Papers without an embedding, or with one of a different length than the
+// first, are left out and listed in Excluded. Cluster ids are numbered by
+// size, largest first.
+func ClusterPapers(papers []data.Paper, rankings []graph.PaperScore, config ClusterConfig) (*ClusterResult, error) {
+	if config.K < 1 {
+		return nil, fmt.Errorf("k must be at least 1, got: %d", config.K)
+	}
+
+	var members []data.Paper
+	var vectors [][]float32
+	var excluded []string
+	for _, paper := range papers {
+		embedding := paper.Embedding(config.EmbeddingField)
+		if len(embedding) == 0 || (len(vectors) > 0 && len(embedding) != len(vectors[0])) {
+			excluded = append(excluded, paper.ID)
+			continue
+		}
+		members = append(members, paper)
+		vectors = append(vectors, embedding)
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no paper has a %q embedding", config.EmbeddingField)
+	}
+	if config.K > len(vectors) {
+		return nil, fmt.Errorf("k (%d) is larger than the number of papers with embeddings (%d)", config.K, len(vectors))
+	}
+
+	assignment, iterations, converged := KMeans(vectors, config.K, config.MaxIterations, config.Seed)
+
+	groups := make([][]data.Paper, config.K)
+	for i, cluster := range assignment {
+		groups[cluster] = append(groups[cluster], members[i])
+	}
+	order := make([]int, config.K)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(groups[order[i]]) > len(groups[order[j]])
+	})
+	renumber := make([]int, config.K)
+	for id, cluster := range order {
+		renumber[cluster] = id
+	}
+
+	scores := make(map[string]graph.PaperScore, len(rankings))
+	for _, ranking := range rankings {
+		scores[ranking.PaperID] = ranking
+	}
+
+	idf := data.BuildIDF(papers, false)
+	result := &ClusterResult{
+		K:          config.K,
+		Seed:       config.Seed,
+		Iterations: iterations,
+		Converged:  converged,
+		Assignment: make(map[string]int, len(members)),
+		Clusters:   make([]Cluster, config.K),
+		Excluded:   excluded,
+	}
+	for i, cluster := range assignment {
+		result.Assignment[members[i].ID] = renumber[cluster]
+	}
+	for id, cluster := range order {
+		group := groups[cluster]
+		result.Clusters[id] = Cluster{
+			ID:        id,
+			Size:      len(group),
+			Keywords:  idf.GroupKeywords(group, config.Keywords),
+			TopPapers: topByPageRank(group, scores, config.TopPapers),
+		}
+	}
+	return result, nil
+}
+
+// topByPageRank returns the n papers with the highest PageRank; papers
+// missing from the rankings score 0.
+func topByPageRank(papers []data.Paper, rankings map[string]graph.PaperScore, n int) []graph.PaperScore {
+	scores := make([]graph.PaperScore, len(papers))
+	for i, paper := range papers {
+		score, ok := rankings[paper.ID]
+		if !ok {
+			score = graph.PaperScore{PaperID: paper.ID, Title: paper.Title, Year: paper.Year}
+		}
+		scores[i] = score
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].PaperID < scores[j].PaperID
+	})
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+	return scores
+}
+
+// KMeans clusters the vectors into k groups by cosine similarity (spherical
+// k-means): vectors are normalized, each is assigned to the centroid it is
+// most similar to, and centroids are the normalized means of their members.
+// Initial centroids are picked k-means++ style from the seeded generator, so
+// the same input and seed give the same clusters. It returns every vector's
+// cluster, the rounds run and whether the assignment stopped changing
+// within maxIterations. k must be between 1 and len(vectors).
+func KMeans(vectors [][]float32, k, maxIterations int, seed int64) ([]int, int, bool) {
+	points := make([][]float64, len(vectors))
+	for i, vector := range vectors {
+		points[i] = normalized(vector)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	centroids := seedCentroids(points, k, rng)
+	assignment := make([]int, len(points))
+	for i := range assignment {
+		assignment[i] = -1
+	}
+
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		changed := false
+		for i, point := range points {
+			best, bestSim := 0, math.Inf(-1)
+			for c, centroid := range centroids {
+				if sim := dot64(point, centroid); sim > bestSim {
+					best, bestSim = c, sim
+				}
+			}
+			if assignment[i] != best {
+				assignment[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			return assignment, iteration, true
+		}
+		updateCentroids(points, assignment, centroids, rng)
+	}
+	return assignment, maxIterations, false
+}
+
+// seedCentroids picks the initial centroids k-means++ style: the first at
+// random, each next one with probability proportional to its cosine
+// distance from the nearest centroid so far. Like greedy k-means++, every
+// pick draws a few candidates and keeps the one that brings the points
+// closest to their centroids, which rarely puts two centroids in the same
+// well-separated cluster.
+func seedCentroids(points [][]float64, k int, rng *rand.Rand) [][]float64 {
+	candidates := 2 + int(math.Log(float64(k)))
+
+	centroids := make([][]float64, 0, k)
+	first := rng.Intn(len(points))
+	centroids = append(centroids, clone64(points[first]))
+	distance := make([]float64, len(points))
+	total := 0.0
+	for i, point := range points {
+		distance[i] = cosineDistance(point, points[first])
+		total += distance[i]
+	}
+
+	trial := make([]float64, len(points))
+	for len(centroids) < k {
+		best, bestTotal := -1, math.Inf(1)
+		var bestDistance []float64
+		for c := 0; c < candidates; c++ {
+			candidate := sampleByDistance(distance, total, rng)
+			trialTotal := 0.0
+			for i, point := range points {
+				trial[i] = math.Min(distance[i], cosineDistance(point, points[candidate]))
+				trialTotal += trial[i]
+			}
+			if trialTotal < bestTotal {
+				best, bestTotal = candidate, trialTotal
+				bestDistance = append(bestDistance[:0], trial...)
+			}
+		}
+		centroids = append(centroids, clone64(points[best]))
+		copy(distance, bestDistance)
+		total = bestTotal
+	}
+	return centroids
+}
+
+// sampleByDistance picks a point index with probability proportional to its
+// distance, or uniformly when every distance is 0.
+func sampleByDistance(distance []float64, total float64, rng *rand.Rand) int {
+	if total <= 0 {
+		return rng.Intn(len(distance))
+	}
+	target := rng.Float64() * total
+	for i, d := range distance {
+		target -= d
+		if target < 0 {
+			return i
+		}
+	}
+	return len(distance) - 1
+}
+
+func cosineDistance(a, b []float64) float64 {
+	return math.Max(0, 1-dot64(a, b))
+}
+
+// updateCentroids moves every centroid to the normalized mean of its
+// members. An empty cluster restarts at a random point.
+func updateCentroids(points [][]float64, assignment []int, centroids [][]float64, rng *rand.Rand) {
+	counts := make([]int, len(centroids))
+	for _, centroid := range centroids {
+		clear(centroid)
+	}
+	for i, point := range points {
+		c := assignment[i]
+		counts[c]++
+		for d, v := range point {
+			centroids[c][d] += v
+		}
+	}
+	for c, centroid := range centroids {
+		if counts[c] == 0 {
+			copy(centroid, points[rng.Intn(len(points))])
+			continue
+		}
+		normalize64(centroid)
+	}
+}
+
+func normalized(vector []float32) []float64 {
+	out := make([]float64, len(vector))
+	for i, v := range vector {
+		out[i] = float64(v)
+	}
+	normalize64(out)
+	return out
+}
+
+func normalize64(vector []float64) {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+}
+
+func dot64(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func clone64(vector []float64) []float64 {
+	return append([]float64(nil), vector...)
+}
+
+func SaveClusterResult(result *ClusterResult, outputPath string) error {
+	if err := data.EncodeFile(outputPath, result, data.FormatJSON); err != nil {
+		return fmt.Errorf("failed to write clusters file: %v", err)
+	}
+	return nil
+}
+
+func PrintClusters(p graph.Printer, result *ClusterResult, n int) {
+	fmt.Println("\n=== Clusters ===")
+	fmt.Printf("Clusters: %d\n", result.K)
+	if result.Converged {
+		fmt.Printf("Converged after %d iterations\n", result.Iterations)
+	} else {
+		fmt.Printf("Did not converge within %d iterations\n", result.Iterations)
+	}
+	fmt.Printf("Papers clustered: %d\n", len(result.Assignment))
+	if len(result.Excluded) > 0 {
+		fmt.Printf("Papers excluded (no usable embedding): %d\n", len(result.Excluded))
+	}
+
+	for _, cluster := range result.Clusters {
+		fmt.Printf("\nCluster %d (%d papers): %s\n", cluster.ID, cluster.Size, strings.Join(cluster.Keywords, ", "))
+		for i, paper := range cluster.TopPapers {
+			if i >= n {
+				break
+			}
+			titleTrunc := paper.Title
+			if len(titleTrunc) > 60 {
+				titleTrunc = titleTrunc[:57] + "..."
+			}
+			fmt.Printf("  %d. %s  %s (%d)\n", i+1, p.Score(paper.Score), titleTrunc, paper.Year)
+		}
+	}
+	fmt.Println("================")
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+// clusterFixture returns papers in three well-separated groups around the
+// first three axes, of 12, 8 and 5 papers; paper ids start with the topic
+// word their abstracts share. A paper without an embedding and one of the
+// wrong length close the list.
+func clusterFixture() []data.Paper {
+	rng := rand.New(rand.NewSource(1))
+	var papers []data.Paper
+	for axis, group := range []struct {
+		topic string
+		size  int
+	}{{"parsing", 12}, {"translation", 8}, {"speech", 5}} {
+		for i := 0; i < group.size; i++ {
+			embedding := make([]float32, 8)
+			for j := range embedding {
+				embedding[j] = float32(0.1 * rng.NormFloat64())
+			}
+			embedding[axis] += 1
+			papers = append(papers, data.Paper{
+				ID:                fmt.Sprintf("%s-%02d", group.topic, i),
+				Title:             fmt.Sprintf("Paper %d on %s", i, group.topic),
+				Abstract:          fmt.Sprintf("We study %s, and %s again.", group.topic, group.topic),
+				AbstractEmbedding: embedding,
+			})
+		}
+	}
+	return append(papers,
+		data.Paper{ID: "unembedded", Title: "No embedding"},
+		data.Paper{ID: "short", Title: "Short embedding", AbstractEmbedding: []float32{1, 0}},
+	)
+}
+
+func TestClusterPapersFindsSeparatedClusters(t *testing.T) {
+	papers := clusterFixture()
+	rankings := []graph.PaperScore{
+		{PaperID: "parsing-03", Score: 0.5},
+		{PaperID: "parsing-07", Score: 0.2},
+		{PaperID: "speech-01", Score: 0.4},
+	}
+	for seed := int64(1); seed <= 5; seed++ {
+		config := DefaultClusterConfig()
+		config.K = 3
+		config.Seed = seed
+		config.TopPapers = 2
+		config.Keywords = 1
+		result, err := ClusterPapers(papers, rankings, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Converged {
+			t.Errorf("seed %d: did not converge in %d iterations", seed, result.Iterations)
+		}
+		if want := []string{"unembedded", "short"}; !slices.Equal(result.Excluded, want) {
+			t.Errorf("seed %d: excluded %v, want %v", seed, result.Excluded, want)
+		}
+
+		// ids go by size, largest first
+		for id, topic := range []string{"parsing", "translation", "speech"} {
+			for _, paper := range papers {
+				if strings.HasPrefix(paper.ID, topic+"-") && result.Assignment[paper.ID] != id {
+					t.Errorf("seed %d: %s in cluster %d, want %d", seed, paper.ID, result.Assignment[paper.ID], id)
+				}
+			}
+			if cluster := result.Clusters[id]; !reflect.DeepEqual(cluster.Keywords, []string{topic}) {
+				t.Errorf("seed %d: cluster %d keywords %v, want [%s]", seed, id, cluster.Keywords, topic)
+			}
+		}
+		var sizes []int
+		for _, cluster := range result.Clusters {
+			sizes = append(sizes, cluster.Size)
+		}
+		if want := []int{12, 8, 5}; !slices.Equal(sizes, want) {
+			t.Errorf("seed %d: cluster sizes %v, want %v", seed, sizes, want)
+		}
+
+		// unranked papers score 0 and follow by id
+		var top []string
+		for _, paper := range result.Clusters[0].TopPapers {
+			top = append(top, paper.PaperID)
+		}
+		if want := []string{"parsing-03", "parsing-07"}; !slices.Equal(top, want) {
+			t.Errorf("seed %d: top papers %v, want %v", seed, top, want)
+		}
+		top = nil
+		for _, paper := range result.Clusters[1].TopPapers {
+			top = append(top, paper.PaperID)
+		}
+		if want := []string{"translation-00", "translation-01"}; !slices.Equal(top, want) {
+			t.Errorf("seed %d: top papers %v, want %v", seed, top, want)
+		}
+	}
+}
+
+func TestClusterPapersRejectsBadK(t *testing.T) {
+	papers := clusterFixture()
+	for _, k := range []int{0, 26} {
+		config := DefaultClusterConfig()
+		config.K = k
+		if _, err := ClusterPapers(papers, nil, config); err == nil {
+			t.Errorf("k %d accepted for 25 embedded papers", k)
+		}
+	}
+}
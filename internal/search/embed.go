@@ -0,0 +1,292 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"paper-rank/internal/data"
+)
+
+// one line sent to the batch embedding script
+type EmbedRequest struct {
+	IDs   []string `json:"ids"`
+	Texts []string `json:"texts"`
+}
+
+// one line the batch embedding script answers with, in request order
+type EmbedResponse struct {
+	IDs        []string    `json:"ids"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+type EmbedConfig struct {
+	BatchSize       int    // papers per request
+	CheckpointEvery int    // batches between writes of the output file
+	Script          string // batch embedding script speaking the protocol above
+	Embedder        string // python (runs Script), hash or http; see NewEmbedder
+	EmbedderURL     string // http endpoint
+	EmbedderModel   string // http model
+	Format          data.Format
+
+	// ReduceDim, when positive, projects embeddings down to this many
+	// dimensions before they are written, with the projection kept at
+	// ProjectionPath so search can project queries the same way
+	ReduceDim      int
+	ReduceMethod   string // ProjectionPCA (default) or ProjectionRandom
+	ProjectionPath string
+
+	// full text of abstracts 'parse --max-abstract-chars' truncated, by
+	// paper id; embedded instead of the stored, truncated abstract
+	FullAbstracts map[string]string
+}
+
+const DefaultEmbedScript = "internal/sentenceEmbeddings/embed_batches.py"
+
+// EmbedPapers adds abstract embeddings to the papers in papersPath and
+// writes them to outputPath. Embeddings already present in outputPath are
+// reused, so an interrupted run resumes where its last checkpoint left off.
+// Papers are sent to the script in batches over stdin/stdout and the output
+// file is rewritten every CheckpointEvery batches and at the end.
+func EmbedPapers(papersPath, outputPath string, config EmbedConfig) error {
+	parsedData, err := data.LoadParsedData(papersPath)
+	if err != nil {
+		return fmt.Errorf("failed to load papers: %v", err)
+	}
+
+	existing := make(map[string][]float32)
+	if _, err := os.Stat(outputPath); err == nil {
+		err := data.StreamPapers(outputPath, func(paper data.Paper) error {
+			if embedding := paper.Embedding(data.DefaultEmbeddingField); len(embedding) > 0 {
+				existing[paper.ID] = embedding
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read existing embeddings: %v", err)
+		}
+	}
+
+	index := make(map[string]int, len(parsedData.Papers))
+	var pending []int
+	for i := range parsedData.Papers {
+		paper := &parsedData.Papers[i]
+		index[paper.ID] = i
+		if embedding, ok := existing[paper.ID]; ok {
+			paper.AbstractEmbedding = embedding
+		} else if len(paper.Embedding(data.DefaultEmbeddingField)) == 0 {
+			pending = append(pending, i)
+		}
+	}
+
+	fmt.Printf("%d papers, %d already embedded, %d to embed\n",
+		len(parsedData.Papers), len(parsedData.Papers)-len(pending), len(pending))
+	if len(pending) == 0 {
+		return saveEmbedded(parsedData, outputPath, config)
+	}
+
+	embedderName, err := ResolveEmbedderName(config.Embedder)
+	if err != nil {
+		return err
+	}
+	if embedderName != EmbedderPython {
+		embedder, err := NewEmbedder(EmbedderConfig{
+			Name:  embedderName,
+			URL:   config.EmbedderURL,
+			Model: config.EmbedderModel,
+			Dim:   fullDim(existing, config.ReduceDim),
+		})
+		if err != nil {
+			return err
+		}
+		if err := embedWith(embedder, parsedData, pending, outputPath, config); err != nil {
+			if saveErr := saveEmbedded(parsedData, outputPath, config); saveErr != nil {
+				fmt.Printf("Warning: could not save partial embeddings: %v\n", saveErr)
+			}
+			return err
+		}
+		return saveEmbedded(parsedData, outputPath, config)
+	}
+
+	cmd := exec.Command("python", config.Script)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open embedder stdin: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open embedder stdout: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start embedding script: %v", err)
+	}
+	defer func() {
+		if cmd.ProcessState == nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}()
+
+	enc := json.NewEncoder(stdin)
+	dec := json.NewDecoder(bufio.NewReader(stdout))
+
+	if err := embedBatches(enc, dec, parsedData, pending, index, outputPath, config); err != nil {
+		// keep what was embedded so far for the next run
+		if saveErr := saveEmbedded(parsedData, outputPath, config); saveErr != nil {
+			fmt.Printf("Warning: could not save partial embeddings: %v\n", saveErr)
+		}
+		return err
+	}
+
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("embedding script failed: %v", err)
+	}
+
+	return saveEmbedded(parsedData, outputPath, config)
+}
+
+// embedBatches sends the pending papers to the script batch by batch and
+// stores the returned embeddings, checkpointing along the way.
+func embedBatches(enc *json.Encoder, dec *json.Decoder, parsedData *data.ParsedData, pending []int, index map[string]int, outputPath string, config EmbedConfig) error {
+	embedded := 0
+	for batch := 0; batch*config.BatchSize < len(pending); batch++ {
+		start := batch * config.BatchSize
+		end := min(start+config.BatchSize, len(pending))
+
+		request := EmbedRequest{}
+		for _, i := range pending[start:end] {
+			paper := parsedData.Papers[i]
+			request.IDs = append(request.IDs, paper.ID)
+			request.Texts = append(request.Texts, embedText(paper, config.FullAbstracts))
+		}
+
+		if err := enc.Encode(request); err != nil {
+			return fmt.Errorf("failed to send batch %d: %v", batch+1, err)
+		}
+
+		var response EmbedResponse
+		if err := dec.Decode(&response); err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("embedding script exited early")
+			}
+			return fmt.Errorf("failed to read batch %d: %v", batch+1, err)
+		}
+		if len(response.Embeddings) != len(response.IDs) {
+			return fmt.Errorf("batch %d: got %d embeddings for %d ids", batch+1, len(response.Embeddings), len(response.IDs))
+		}
+
+		for j, paperID := range response.IDs {
+			if i, ok := index[paperID]; ok {
+				parsedData.Papers[i].AbstractEmbedding = response.Embeddings[j]
+				embedded++
+			}
+		}
+		fmt.Printf("Embedded %d/%d papers\n", embedded, len(pending))
+
+		if config.CheckpointEvery > 0 && (batch+1)%config.CheckpointEvery == 0 && end < len(pending) {
+			if err := saveEmbedded(parsedData, outputPath, config); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// embedWith embeds the pending papers batch by batch with an in-process
+// embedder, checkpointing like embedBatches.
+func embedWith(embedder Embedder, parsedData *data.ParsedData, pending []int, outputPath string, config EmbedConfig) error {
+	for batch := 0; batch*config.BatchSize < len(pending); batch++ {
+		start := batch * config.BatchSize
+		end := min(start+config.BatchSize, len(pending))
+
+		texts := make([]string, 0, end-start)
+		for _, i := range pending[start:end] {
+			texts = append(texts, embedText(parsedData.Papers[i], config.FullAbstracts))
+		}
+		embeddings, err := embedder.EmbedBatch(context.Background(), texts)
+		if err != nil {
+			return fmt.Errorf("batch %d: %v", batch+1, err)
+		}
+		for j, i := range pending[start:end] {
+			parsedData.Papers[i].AbstractEmbedding = embeddings[j]
+		}
+		fmt.Printf("Embedded %d/%d papers\n", end, len(pending))
+
+		if config.CheckpointEvery > 0 && (batch+1)%config.CheckpointEvery == 0 && end < len(pending) {
+			if err := saveEmbedded(parsedData, outputPath, config); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fullDim is the size of the full-size embeddings already present, 0 when
+// there are none.
+func fullDim(existing map[string][]float32, reducedDim int) int {
+	for _, embedding := range existing {
+		if len(embedding) != reducedDim {
+			return len(embedding)
+		}
+	}
+	return 0
+}
+
+// embedText is the text a paper is embedded from: its full abstract, or its
+// title when it has none.
+func embedText(paper data.Paper, fullAbstracts map[string]string) string {
+	if full, ok := fullAbstracts[paper.ID]; ok {
+		return full
+	}
+	if paper.Abstract == "" {
+		return paper.Title
+	}
+	return paper.Abstract
+}
+
+// saveEmbedded reduces the embeddings when configured and writes them out;
+// the write is atomic, so an interrupted one never leaves a truncated
+// output behind.
+func saveEmbedded(parsedData *data.ParsedData, outputPath string, config EmbedConfig) error {
+	if config.ReduceDim > 0 {
+		if err := reduceEmbedded(parsedData, config); err != nil {
+			return fmt.Errorf("failed to reduce embeddings: %v", err)
+		}
+	}
+
+	if err := data.SaveParsedData(parsedData, outputPath, config.Format); err != nil {
+		return fmt.Errorf("failed to save embeddings: %v", err)
+	}
+	return nil
+}
+
+// reduceEmbedded projects the full-size embeddings down to ReduceDim. Papers
+// reduced by an earlier checkpoint or run are left alone.
+func reduceEmbedded(parsedData *data.ParsedData, config EmbedConfig) error {
+	var full [][]float32
+	for _, paper := range parsedData.Papers {
+		if len(paper.AbstractEmbedding) > 0 && len(paper.AbstractEmbedding) != config.ReduceDim {
+			full = append(full, paper.AbstractEmbedding)
+		}
+		for _, embedding := range paper.Embeddings {
+			if len(embedding) > 0 && len(embedding) != config.ReduceDim {
+				full = append(full, embedding)
+			}
+		}
+	}
+	if len(full) == 0 {
+		return nil // nothing left to reduce
+	}
+
+	projection, err := loadOrCreateProjection(config.ProjectionPath, full, config.ReduceDim, config.ReduceMethod)
+	if err != nil {
+		return err
+	}
+	return projection.ReduceEmbeddings(parsedData.Papers)
+}
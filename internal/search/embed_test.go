@@ -0,0 +1,135 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+func TestEmbedPapersEmbedsFullTextOfTruncatedAbstracts(t *testing.T) {
+	dir := t.TempDir()
+	papersPath := filepath.Join(dir, "papers.json")
+	outputPath := filepath.Join(dir, "papers_with_embeddings.json")
+
+	// the tail of the abstract holds words the truncated text lacks
+	long := strings.Repeat("parsing ", 50) + "translation alignment"
+	parsed := &data.ParsedData{Papers: []data.Paper{
+		{ID: "long", Title: "Long", Abstract: long},
+		{ID: "short", Title: "Short", Abstract: "short abstract"},
+	}}
+	full := data.TruncateAbstracts(parsed, 40)
+	if err := data.SaveParsedData(parsed, papersPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	err := EmbedPapers(papersPath, outputPath, EmbedConfig{
+		BatchSize:     8,
+		Embedder:      EmbedderHash,
+		Format:        data.FormatJSON,
+		FullAbstracts: full.Abstracts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedded, err := data.LoadParsedData(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := NewHashEmbedder(0)
+	for _, paper := range embedded.Papers {
+		want, _ := hash.Embed(context.Background(), map[string]string{"long": long, "short": "short abstract"}[paper.ID])
+		if !reflect.DeepEqual(paper.AbstractEmbedding, want) {
+			t.Errorf("%s: embedding not computed from the full abstract", paper.ID)
+		}
+	}
+	if got := embedded.Papers[0].Abstract; got != parsed.Papers[0].Abstract {
+		t.Errorf("stored abstract %q, want the truncated %q", got, parsed.Papers[0].Abstract)
+	}
+
+	// the snippet is taken from the truncated abstract and stays bounded
+	se := &SearchEngine{Config: DefaultSearchConfig()}
+	se.Config.SnippetLength = 30
+	if snippet := se.createSnippet(embedded.Papers[0]); snippet != "parsing parsing parsing..." {
+		t.Errorf("snippet %q", snippet)
+	}
+}
+
+// TestSearchEndToEndWithHashEmbedder embeds papers with the hash embedder,
+// ranks them, loads the engine from the files and searches, with nothing
+// but Go.
+func TestSearchEndToEndWithHashEmbedder(t *testing.T) {
+	dir := t.TempDir()
+	papersPath := filepath.Join(dir, "papers.json")
+	embeddedPath := filepath.Join(dir, "papers_with_embeddings.json")
+	pagerankPath := filepath.Join(dir, "pagerank.json")
+
+	parsed := &data.ParsedData{
+		Papers: []data.Paper{
+			{ID: "mt", Title: "Translation", Year: 2017, Abstract: "Neural machine translation with attention between languages."},
+			{ID: "asr", Title: "Speech", Year: 2018, Abstract: "Acoustic models for speech recognition of spoken audio."},
+			{ID: "parse", Title: "Parsing", Year: 2019, Abstract: "Dependency parsing of sentence syntax with trees."},
+		},
+		Citations: []data.CitationEdge{{From: "parse", To: "mt"}},
+	}
+	if err := data.SaveParsedData(parsed, papersPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedPapers(papersPath, embeddedPath, EmbedConfig{BatchSize: 2, Embedder: EmbedderHash, Format: data.FormatJSON}); err != nil {
+		t.Fatal(err)
+	}
+	g := graph.BuildGraphFromData(parsed, graph.BuildConfig{})
+	result, err := graph.CalculatePageRank(g, graph.PageRankConfig{DampingFactor: 0.85, MaxIterations: 100, Tolerance: 1e-9, HandleDangling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.SavePageRankResult(result, pagerankPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"speech recognition", "asr"},
+		{"dependency parsing trees", "parse"},
+		{"machine translation", "mt"},
+	}
+	// chosen by the config, then by the environment
+	for _, fromEnv := range []bool{false, true} {
+		config := DefaultSearchConfig()
+		config.Embedder = EmbedderHash
+		if fromEnv {
+			config.Embedder = ""
+			t.Setenv(EmbedderEnv, EmbedderHash)
+		}
+		se, err := NewSearchEngine(embeddedPath, pagerankPath, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := se.Prepare(); err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			results, err := se.Search(tt.query)
+			if err != nil {
+				t.Fatalf("%q: %v", tt.query, err)
+			}
+			if len(results) != 3 || results[0].Paper.ID != tt.want {
+				t.Errorf("%q (embedder from env %v): results %v, want %s first", tt.query, fromEnv, resultIDs(results), tt.want)
+			}
+			again, err := se.Search(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(again, results) {
+				t.Errorf("%q: searching twice gave different results", tt.query)
+			}
+		}
+	}
+}
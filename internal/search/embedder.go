@@ -0,0 +1,302 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"paper-rank/internal/data"
+)
+
+// Embedder turns text into embeddings comparable with the stored paper
+// embeddings. EmbedBatch returns one embedding per text, in order.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// names for EmbedderConfig.Name and EmbedderEnv
+const (
+	EmbedderPython = "python" // the sentence-transformers model, run as a subprocess
+	EmbedderHash   = "hash"   // deterministic token hashing; offline, for tests
+	EmbedderHTTP   = "http"   // an OpenAI-compatible /v1/embeddings endpoint
+)
+
+// environment variables read when the matching EmbedderConfig field is
+// empty; the API key is only ever read from the environment
+const (
+	EmbedderEnv       = "ACL_EMBEDDER"
+	EmbedderURLEnv    = "ACL_EMBEDDER_URL"
+	EmbedderModelEnv  = "ACL_EMBEDDER_MODEL"
+	EmbedderAPIKeyEnv = "ACL_EMBEDDER_API_KEY"
+)
+
+// DefaultHashDim is the hash embedder's size when there are no stored
+// embeddings to match; it matches all-MiniLM-L6-v2.
+const DefaultHashDim = 384
+
+const DefaultQueryScript = "internal/sentenceEmbeddings/embed_query.py"
+
+// how long one request to an HTTP embedder may take
+const httpEmbedTimeout = 60 * time.Second
+
+// which embedder to create and how
+type EmbedderConfig struct {
+	Name  string // python, hash or http; see ResolveEmbedderName
+	URL   string // http: the endpoint, e.g. http://localhost:8080/v1/embeddings
+	Model string // http: the model named in every request
+	Dim   int    // hash: the vector size (DefaultHashDim when 0)
+}
+
+// ResolveEmbedderName returns name, or the EmbedderEnv value when name is
+// empty, or python, and checks it is a known embedder.
+func ResolveEmbedderName(name string) (string, error) {
+	if name == "" {
+		name = os.Getenv(EmbedderEnv)
+	}
+	switch resolved := strings.ToLower(strings.TrimSpace(name)); resolved {
+	case "", EmbedderPython:
+		return EmbedderPython, nil
+	case EmbedderHash, EmbedderHTTP:
+		return resolved, nil
+	}
+	return "", fmt.Errorf("unknown embedder %q (want python, hash or http)", name)
+}
+
+// NewEmbedder creates the embedder config names, filling empty HTTP
+// settings from the environment.
+func NewEmbedder(config EmbedderConfig) (Embedder, error) {
+	name, err := ResolveEmbedderName(config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case EmbedderHash:
+		return NewHashEmbedder(config.Dim), nil
+	case EmbedderHTTP:
+		url := config.URL
+		if url == "" {
+			url = os.Getenv(EmbedderURLEnv)
+		}
+		if url == "" {
+			return nil, fmt.Errorf("the http embedder needs a URL (--embedder-url or $%s)", EmbedderURLEnv)
+		}
+		model := config.Model
+		if model == "" {
+			model = os.Getenv(EmbedderModelEnv)
+		}
+		return NewHTTPEmbedder(url, model, os.Getenv(EmbedderAPIKeyEnv)), nil
+	}
+	return NewPythonEmbedder(DefaultQueryScript, DefaultEmbedScript), nil
+}
+
+// pythonEmbedder runs the embedding scripts: the query script once per
+// text, the batch script (see EmbedRequest) once per batch.
+type pythonEmbedder struct {
+	queryScript string
+	batchScript string
+}
+
+// NewPythonEmbedder returns an embedder running the given query and batch
+// scripts with python.
+func NewPythonEmbedder(queryScript, batchScript string) Embedder {
+	return pythonEmbedder{queryScript: queryScript, batchScript: batchScript}
+}
+
+func (e pythonEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	// run python script in a new process, passing the query on stdin so that
+	// quotes, newlines and very long queries survive intact
+	cmd := exec.CommandContext(ctx, "python", e.queryScript)
+	cmd.Stdin = strings.NewReader(text)
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("embedding script failed: %s, stderr: %s", err, string(exitError.Stderr))
+		}
+		return nil, fmt.Errorf("failed to run embedding script: %w", err)
+	}
+
+	var embedding []float32
+	if err := json.Unmarshal(output, &embedding); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding from python script: %w", err)
+	}
+
+	return embedding, nil
+}
+
+func (e pythonEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	request := EmbedRequest{Texts: texts}
+	for i := range texts {
+		request.IDs = append(request.IDs, strconv.Itoa(i))
+	}
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "python", e.batchScript)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("embedding script failed: %w", err)
+	}
+
+	var response EmbedResponse
+	if err := json.Unmarshal(output, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse embeddings from python script: %w", err)
+	}
+	if len(response.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(response.Embeddings), len(texts))
+	}
+	return response.Embeddings, nil
+}
+
+// httpEmbedder posts texts to an OpenAI-compatible embeddings endpoint, as
+// served by OpenAI and by local servers such as vLLM, llama.cpp, Ollama
+// and text-embeddings-inference.
+type httpEmbedder struct {
+	url    string
+	model  string
+	apiKey string // sent as a bearer token when set
+	client *http.Client
+}
+
+// NewHTTPEmbedder returns an embedder for the endpoint at url.
+func NewHTTPEmbedder(url, model, apiKey string) Embedder {
+	return httpEmbedder{
+		url:    url,
+		model:  model,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: httpEmbedTimeout},
+	}
+}
+
+type httpEmbedRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type httpEmbedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (e httpEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e httpEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(httpEmbedRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedder URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embedding request failed: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var response httpEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding response: %w", err)
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, item := range response.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding response has index %d for %d texts", item.Index, len(texts))
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+	for i, embedding := range embeddings {
+		if len(embedding) == 0 {
+			return nil, fmt.Errorf("embedding response is missing text %d", i)
+		}
+	}
+	return embeddings, nil
+}
+
+// hashEmbedder hashes every token into one of dim buckets with a hashed
+// sign and normalizes the counts, so texts sharing words get similar
+// vectors. It has no notion of meaning, but it is fast, needs neither
+// Python nor a model, and gives the same vector for the same text on every
+// machine, which is what tests need.
+type hashEmbedder struct {
+	dim int
+}
+
+// NewHashEmbedder returns a hash embedder producing dim-sized vectors, or
+// DefaultHashDim-sized ones when dim is not positive.
+func NewHashEmbedder(dim int) Embedder {
+	if dim <= 0 {
+		dim = DefaultHashDim
+	}
+	return hashEmbedder{dim: dim}
+}
+
+func (e hashEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embedding := make([]float32, e.dim)
+	for _, token := range data.Tokenize(text) {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		sum := h.Sum64()
+		sign := float32(1)
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		embedding[sum%uint64(e.dim)] += sign
+	}
+
+	var norm float64
+	for _, v := range embedding {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range embedding {
+			embedding[i] *= scale
+		}
+	}
+	return embedding, nil
+}
+
+func (e hashEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i], _ = e.Embed(ctx, text)
+	}
+	return embeddings, nil
+}
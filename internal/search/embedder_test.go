@@ -0,0 +1,168 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// echoScript answers with the code points of the text it reads on stdin,
+// so a test can check the query arrived unchanged.
+const echoScript = `import json, sys
+print(json.dumps([ord(c) for c in sys.stdin.read()]))
+`
+
+func TestPythonEmbedderPassesQueryIntact(t *testing.T) {
+	if _, err := exec.LookPath("python"); err != nil {
+		t.Skip("python not installed")
+	}
+	script := filepath.Join(t.TempDir(), "echo.py")
+	if err := os.WriteFile(script, []byte(echoScript), 0644); err != nil {
+		t.Fatal(err)
+	}
+	embedder := NewPythonEmbedder(script, "")
+
+	for _, query := range []string{
+		`"neural machine translation" -"statistical"`,
+		"first line\nsecond line\r\n\ttabbed",
+		`it's a "quoted" $HOME; rm -rf / && echo $(whoami) \ backslash`,
+		"überprüfung 翻訳 — dash",
+	} {
+		embedding, err := embedder.Embed(context.Background(), query)
+		if err != nil {
+			t.Fatalf("Embed(%q): %v", query, err)
+		}
+		got := make([]rune, len(embedding))
+		for i, code := range embedding {
+			got[i] = rune(code)
+		}
+		if string(got) != query {
+			t.Errorf("script received %q, want %q", string(got), query)
+		}
+	}
+}
+
+// embeddingServer stubs an OpenAI-compatible embeddings endpoint. It embeds
+// each input as [its length, its index] and answers in reverse order, as
+// the API allows; status, when not 200, is returned with an error body.
+func embeddingServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s request with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Authorization %q, want the bearer token", auth)
+		}
+		var request httpEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if request.Model != "test-model" {
+			t.Errorf("model %q, want test-model", request.Model)
+		}
+		if status != http.StatusOK {
+			http.Error(w, "model overloaded", status)
+			return
+		}
+
+		var response httpEmbedResponse
+		for i := len(request.Input) - 1; i >= 0; i-- {
+			response.Data = append(response.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			}{i, []float32{float32(len(request.Input[i])), float32(i)}})
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPEmbedder(t *testing.T) {
+	server := embeddingServer(t, http.StatusOK)
+	embedder := NewHTTPEmbedder(server.URL, "test-model", "secret")
+
+	embeddings, err := embedder.EmbedBatch(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// put back in input order
+	want := [][]float32{{1, 0}, {2, 1}, {3, 2}}
+	if !reflect.DeepEqual(embeddings, want) {
+		t.Errorf("embeddings %v, want %v", embeddings, want)
+	}
+	embedding, err := embedder.Embed(context.Background(), "query")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(embedding, []float32{5, 0}) {
+		t.Errorf("embedding %v, want [5 0]", embedding)
+	}
+
+	failing := NewHTTPEmbedder(embeddingServer(t, http.StatusServiceUnavailable).URL, "test-model", "secret")
+	_, err = failing.Embed(context.Background(), "query")
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "model overloaded") {
+		t.Errorf("error %v, want one with the status and the server's message", err)
+	}
+}
+
+func TestHTTPEmbedderRejectsIncompleteResponses(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"missing", `{"data": [{"index": 0, "embedding": [1]}]}`, "missing text 1"},
+		{"out of range", `{"data": [{"index": 0, "embedding": [1]}, {"index": 2, "embedding": [1]}]}`, "index 2 for 2 texts"},
+		{"not json", `<html>`, "failed to parse"},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, tt.body)
+		}))
+		_, err := NewHTTPEmbedder(server.URL, "", "").EmbedBatch(context.Background(), []string{"a", "b"})
+		server.Close()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSearchWithHTTPEmbedder(t *testing.T) {
+	server := embeddingServer(t, http.StatusOK)
+	t.Setenv(EmbedderAPIKeyEnv, "secret")
+	t.Setenv(EmbedderModelEnv, "test-model")
+
+	// the stub embeds a 5-letter query as [5 0]
+	papers := []testPaper{
+		{ID: "along", Embedding: []float32{1, 0}},
+		{ID: "across", Embedding: []float32{0, 1}},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) {
+		c.Embedder = EmbedderHTTP
+		c.EmbedderURL = server.URL
+	})
+	if err := se.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	results, err := se.Search("query")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{"along", "across"}) {
+		t.Errorf("results %v, want [along across]", got)
+	}
+
+	se = testEngine(t, papers, func(c *SearchConfig) { c.Embedder = EmbedderHTTP })
+	t.Setenv(EmbedderURLEnv, "")
+	if err := se.Prepare(); err == nil || !strings.Contains(err.Error(), EmbedderURLEnv) {
+		t.Errorf("error %v without a URL, want one naming %s", err, EmbedderURLEnv)
+	}
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"strings"
+
+	"paper-rank/internal/data"
+)
+
+// the results returned for one query, for offline evaluation
+type QueryRun struct {
+	Query    string         `json:"query"`
+	Results  []SearchResult `json:"results"`
+	Stemming bool           `json:"stemming,omitempty"` // match query terms by Porter stem
+}
+
+// SnippetCoverage returns the fraction of returned snippets, over all runs,
+// that contain at least one query term. Snippets that only repeat the start
+// of an unrelated part of the abstract lower it.
+func SnippetCoverage(runs []QueryRun) float64 {
+	total, covered := 0, 0
+	for _, run := range runs {
+		for _, result := range run.Results {
+			total++
+			if snippetContainsQueryTerms(result.Snippet, run.Query, run.Stemming) {
+				covered++
+			}
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total)
+}
+
+// snippetContainsQueryTerms reports whether the snippet contains any content
+// term of the query. Queries made only of stopwords match on all their terms.
+func snippetContainsQueryTerms(snippet, query string, stemming bool) bool {
+	matcher := newQueryMatcher(query, stemming)
+	for _, token := range data.Tokenize(snippet) {
+		if matcher.matches(token) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryMatcher tells which words of a text are query terms, comparing Porter
+// stems with stemming on, so "translating" matches a query for
+// "translation", and exact lowercase words otherwise.
+type queryMatcher struct {
+	stemming bool
+	terms    map[string]bool
+}
+
+func newQueryMatcher(query string, stemming bool) queryMatcher {
+	terms := queryTerms(query)
+	matcher := queryMatcher{stemming: stemming, terms: make(map[string]bool, len(terms))}
+	for _, term := range terms {
+		matcher.terms[matcher.key(term)] = true
+	}
+	return matcher
+}
+
+func (m queryMatcher) key(token string) string {
+	if m.stemming {
+		return data.Stem(token)
+	}
+	return token
+}
+
+func (m queryMatcher) matches(word string) bool {
+	return m.terms[m.key(strings.ToLower(word))]
+}
+
+// queryTerms returns the content terms of a query, or all its terms when it
+// only has stopwords.
+func queryTerms(query string) []string {
+	terms := data.ContentTokens(query)
+	if len(terms) == 0 {
+		terms = data.Tokenize(query)
+	}
+	return terms
+}
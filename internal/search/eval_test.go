@@ -0,0 +1,59 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnippetMatchingWithStemming(t *testing.T) {
+	tests := []struct {
+		query, snippet string
+		exact, stemmed bool
+	}{
+		{"machine translation", "We study translating rare words.", false, true},
+		{"machine translation", "Translations of rare words.", false, true},
+		{"machine translation", "A new translation model.", true, true},
+		{"dependency parsing", "A fast parser for English.", false, false},
+		{"dependency parsing", "Sentences are parsed greedily.", false, true},
+		// stopwords are not terms, whatever their stem
+		{"the translation", "The model is fast.", false, false},
+	}
+	for _, tt := range tests {
+		for _, stemming := range []bool{false, true} {
+			want := tt.exact
+			if stemming {
+				want = tt.stemmed
+			}
+			if got := snippetContainsQueryTerms(tt.snippet, tt.query, stemming); got != want {
+				t.Errorf("query %q, snippet %q, stemming %v: matched %v, want %v", tt.query, tt.snippet, stemming, got, want)
+			}
+		}
+	}
+
+	runs := []QueryRun{{Query: "translation", Results: []SearchResult{
+		{Snippet: "translation"},
+		{Snippet: "translating"},
+		{Snippet: "parsing"},
+		{Snippet: "translations"},
+	}}}
+	if coverage := SnippetCoverage(runs); coverage != 0.25 {
+		t.Errorf("exact coverage %v, want 0.25", coverage)
+	}
+	runs[0].Stemming = true
+	if coverage := SnippetCoverage(runs); coverage != 0.75 {
+		t.Errorf("stemmed coverage %v, want 0.75", coverage)
+	}
+}
+
+func TestStemmingLeavesRelevanceAlone(t *testing.T) {
+	papers := randomPapers(50, 8, 7)
+	query := papers[0].Embedding
+	var runs [2][]SearchResult
+	for i, stemming := range []bool{false, true} {
+		se := testEngine(t, papers, func(c *SearchConfig) { c.Stemming = stemming })
+		runs[i] = se.SearchEmbedding(SearchQuery{Original: "translation"}, query)
+	}
+	if !reflect.DeepEqual(runs[0], runs[1]) {
+		t.Errorf("stemming changed the results: %v, without %v", resultIDs(runs[1]), resultIDs(runs[0]))
+	}
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+)
+
+// share of the gap to its seed's relevance an expanded paper closes at
+// proximity 1, so a paper pulled in through the graph never outranks the
+// hit it came from on relevance alone
+const expansionDiscount = 0.9
+
+// a paper close in the citation graph to one of the initial top hits
+type graphHit struct {
+	seed          string  // the top hit it is closest to
+	seedRelevance float64 // that hit's relevance
+	proximity     float64 // graph.Proximity to the hit, in [0, 1]
+}
+
+// relevance lifts a paper's own relevance toward its seed's in proportion
+// to their proximity; papers without an embedding start from 0.
+func (h graphHit) relevance(own float64) float64 {
+	if h.seedRelevance <= own {
+		return own
+	}
+	return own + expansionDiscount*h.proximity*(h.seedRelevance-own)
+}
+
+// graphExpansion finds the papers that cite, are cited by, or are coupled or
+// co-cited with the Config.ExpandSeeds best initial results, keeping the
+// Config.ExpandSize closest. The seeds themselves are left out.
+func (se *SearchEngine) graphExpansion(results []SearchResult) (map[string]graphHit, error) {
+	if se.Graph == nil {
+		return nil, fmt.Errorf("no citation graph attached")
+	}
+
+	seeds := results
+	if len(seeds) > se.Config.ExpandSeeds {
+		seeds = seeds[:se.Config.ExpandSeeds]
+	}
+	isSeed := make(map[string]bool, len(seeds))
+	for _, seed := range seeds {
+		isSeed[seed.Paper.ID] = true
+	}
+
+	citing := se.Graph.CitingIndex()
+	hits := make(map[string]graphHit)
+	for _, seed := range seeds {
+		for id, proximity := range se.Graph.Proximity(seed.Paper.ID, citing) {
+			if isSeed[id] {
+				continue
+			}
+			hit := graphHit{seed.Paper.ID, seed.RelevanceScore, proximity}
+			if best, ok := hits[id]; !ok || hit.proximity*hit.seedRelevance > best.proximity*best.seedRelevance {
+				hits[id] = hit
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(hits))
+	for id := range hits {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := hits[ids[i]], hits[ids[j]]
+		if a.proximity*a.seedRelevance != b.proximity*b.seedRelevance {
+			return a.proximity*a.seedRelevance > b.proximity*b.seedRelevance
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids[min(len(ids), se.Config.ExpandSize):] {
+		delete(hits, id)
+	}
+	return hits, nil
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+// TestGraphExpansionSurfacesCitedPaper searches a corpus where the two
+// hits for the query both cite a foundation paper whose abstract is about
+// something else. Papers loosely related to the query outrank it on
+// relevance, so it only makes the results when expansion follows the
+// citations.
+func TestGraphExpansionSurfacesCitedPaper(t *testing.T) {
+	query := []float32{1, 0}
+	papers := []testPaper{
+		{ID: "hit-1", Embedding: []float32{1, 0}},
+		{ID: "hit-2", Embedding: []float32{1, 0.1}},
+		{ID: "loose-1", Embedding: []float32{0.5, 0.8}},
+		{ID: "loose-2", Embedding: []float32{0.5, 0.9}},
+		{ID: "foundation", Embedding: []float32{0, 1}},
+	}
+	citations := []data.CitationEdge{
+		{From: "hit-1", To: "foundation"},
+		{From: "hit-2", To: "foundation"},
+	}
+
+	for _, expand := range []bool{false, true} {
+		se := testEngine(t, papers, func(c *SearchConfig) {
+			c.PageRankWeight = 0
+			c.RelevanceWeight = 1
+			c.MaxResults = 3
+			c.ExpandGraph = expand
+			c.ExpandSeeds = 2
+		})
+		se.Graph = graph.BuildGraphFromData(&data.ParsedData{Papers: se.Papers, Citations: citations}, graph.BuildConfig{})
+
+		results := se.SearchEmbedding(SearchQuery{}, query)
+		want := []string{"hit-1", "hit-2", "loose-1"}
+		if expand {
+			want = []string{"hit-1", "hit-2", "foundation"}
+		}
+		if got := resultIDs(results); !slices.Equal(got, want) {
+			t.Errorf("expand %v: results %v, want %v", expand, got, want)
+			continue
+		}
+		if expand {
+			if from := results[2].ExpandedFrom; from != "hit-1" {
+				t.Errorf("foundation expanded from %q, want hit-1", from)
+			}
+			// lifted toward its seed, never past it
+			if results[2].RelevanceScore >= results[0].RelevanceScore {
+				t.Errorf("foundation relevance %v not below its seed's %v", results[2].RelevanceScore, results[0].RelevanceScore)
+			}
+		}
+		for _, result := range results[:2] {
+			if result.ExpandedFrom != "" {
+				t.Errorf("expand %v: seed %s marked as expanded from %s", expand, result.Paper.ID, result.ExpandedFrom)
+			}
+		}
+	}
+}
+
+func TestGraphExpansionKeepsTheClosestPapers(t *testing.T) {
+	papers := []testPaper{
+		{ID: "hit", Embedding: []float32{1, 0}},
+		{ID: "cited", Embedding: []float32{0, 1}},
+		{ID: "coupled", Embedding: []float32{0, 1}},
+		{ID: "other", Embedding: []float32{0, 1}},
+	}
+	citations := []data.CitationEdge{
+		{From: "hit", To: "cited"},
+		{From: "hit", To: "other"},
+		// shares one of hit's two references
+		{From: "coupled", To: "other"},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) {
+		c.ExpandSeeds = 1
+		c.ExpandSize = 2
+	})
+	se.Graph = graph.BuildGraphFromData(&data.ParsedData{Papers: se.Papers, Citations: citations}, graph.BuildConfig{})
+
+	hits, err := se.graphExpansion([]SearchResult{{Paper: data.Paper{ID: "hit"}, RelevanceScore: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for id := range hits {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	if want := []string{"cited", "other"}; !slices.Equal(ids, want) {
+		t.Errorf("expanded with %v, want the directly cited %v", ids, want)
+	}
+
+	se.Graph = nil
+	if _, err := se.graphExpansion([]SearchResult{{Paper: data.Paper{ID: "hit"}}}); err == nil {
+		t.Error("expanded without a citation graph")
+	}
+}
@@ -0,0 +1,89 @@
+package search
+
+import "fmt"
+
+// classic Rocchio weights: the original query dominates, relevant papers
+// pull strongly and irrelevant ones push gently
+const (
+	DefaultRocchioAlpha = 1.0
+	DefaultRocchioBeta  = 0.75
+	DefaultRocchioGamma = 0.15
+)
+
+// Rocchio moves a query embedding toward the centroid of the relevant
+// embeddings and away from the centroid of the irrelevant ones:
+//
+//	alpha*query + beta*mean(relevant) - gamma*mean(irrelevant)
+//
+// An empty group contributes nothing. Every embedding must have the
+// query's length.
+func Rocchio(query []float32, relevant, irrelevant [][]float32, alpha, beta, gamma float64) ([]float32, error) {
+	adjusted := make([]float64, len(query))
+	for i, v := range query {
+		adjusted[i] = alpha * float64(v)
+	}
+	for _, group := range []struct {
+		embeddings [][]float32
+		weight     float64
+	}{{relevant, beta}, {irrelevant, -gamma}} {
+		if len(group.embeddings) == 0 {
+			continue
+		}
+		scale := group.weight / float64(len(group.embeddings))
+		for _, embedding := range group.embeddings {
+			if len(embedding) != len(query) {
+				return nil, fmt.Errorf("embedding has %d dimensions, the query %d", len(embedding), len(query))
+			}
+			for i, v := range embedding {
+				adjusted[i] += scale * float64(v)
+			}
+		}
+	}
+
+	out := make([]float32, len(adjusted))
+	for i, v := range adjusted {
+		out[i] = float32(v)
+	}
+	return out, nil
+}
+
+// applyFeedback adjusts the query embedding with Rocchio over the stored
+// embeddings of the Config.Relevant and Config.Irrelevant papers.
+func (se *SearchEngine) applyFeedback(queryEmbedding []float32) ([]float32, error) {
+	embeddings := make(map[string][]float32, len(se.Config.Relevant)+len(se.Config.Irrelevant))
+	for _, id := range append(append([]string{}, se.Config.Relevant...), se.Config.Irrelevant...) {
+		embeddings[id] = nil
+	}
+	for _, paper := range se.Papers {
+		if _, ok := embeddings[paper.ID]; ok {
+			embeddings[paper.ID] = se.embedding(paper)
+		}
+	}
+
+	lookup := func(ids []string) ([][]float32, error) {
+		vectors := make([][]float32, 0, len(ids))
+		for _, id := range ids {
+			embedding := embeddings[id]
+			if len(embedding) == 0 {
+				return nil, fmt.Errorf("paper %s is not in the corpus or has no %q embedding", id, se.Config.EmbeddingField)
+			}
+			vectors = append(vectors, embedding)
+		}
+		return vectors, nil
+	}
+	relevant, err := lookup(se.Config.Relevant)
+	if err != nil {
+		return nil, err
+	}
+	irrelevant, err := lookup(se.Config.Irrelevant)
+	if err != nil {
+		return nil, err
+	}
+
+	adjusted, err := Rocchio(queryEmbedding, relevant, irrelevant, se.Config.RocchioAlpha, se.Config.RocchioBeta, se.Config.RocchioGamma)
+	if err != nil {
+		return nil, fmt.Errorf("could not apply relevance feedback: %v", err)
+	}
+	fmt.Printf("Adjusted the query with %d relevant and %d irrelevant papers\n", len(relevant), len(irrelevant))
+	return adjusted, nil
+}
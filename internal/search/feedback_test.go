@@ -0,0 +1,90 @@
+package search
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestRocchio(t *testing.T) {
+	query := []float32{1, 0}
+	relevant := [][]float32{{0, 1}, {0, 3}}
+	irrelevant := [][]float32{{2, 0}}
+
+	got, err := Rocchio(query, relevant, irrelevant, 1, 0.5, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1*[1 0] + 0.5*[0 2] - 0.25*[2 0]
+	if want := []float32{0.5, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("adjusted query %v, want %v", got, want)
+	}
+	if got, _ := Rocchio(query, nil, nil, 2, 0.5, 0.25); !reflect.DeepEqual(got, []float32{2, 0}) {
+		t.Errorf("without feedback the query became %v, want [2 0]", got)
+	}
+	if _, err := Rocchio(query, [][]float32{{1, 2, 3}}, nil, 1, 1, 1); err == nil {
+		t.Error("a 3-dimensional embedding was mixed into a 2-dimensional query")
+	}
+}
+
+func TestRelevanceFeedbackMovesQueryTowardRelevantPapers(t *testing.T) {
+	query := []float32{1, 0, 0}
+	papers := []testPaper{
+		{ID: "match", Embedding: []float32{1, 0, 0}},
+		{ID: "off-topic", Embedding: []float32{0.9, 0, 0.4}},
+		{ID: "wanted", Embedding: []float32{0.6, 0.8, 0}},
+		{ID: "like-wanted", Embedding: []float32{0.5, 0.85, 0}},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) {
+		c.PageRankWeight = 0
+		c.RelevanceWeight = 1
+		c.Relevant = []string{"wanted"}
+		c.Irrelevant = []string{"off-topic"}
+	})
+
+	adjusted, err := se.applyFeedback(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// closer to the relevant paper, further from the irrelevant one
+	for _, tt := range []struct {
+		paper  []float32
+		closer bool
+	}{
+		{papers[2].Embedding, true},
+		{papers[1].Embedding, false},
+	} {
+		before, _ := cosineSimilarity(query, tt.paper)
+		after, _ := cosineSimilarity(adjusted, tt.paper)
+		if (after > before) != tt.closer {
+			t.Errorf("similarity to %v went from %v to %v", tt.paper, before, after)
+		}
+	}
+
+	se.Embedder = fixedEmbedder(query)
+	for _, tt := range []struct {
+		feedback bool
+		want     []string
+	}{
+		{false, []string{"match", "off-topic", "wanted", "like-wanted"}},
+		// the marked paper and the one like it overtake the off-topic one
+		{true, []string{"match", "wanted", "like-wanted", "off-topic"}},
+	} {
+		se.Config.Relevant, se.Config.Irrelevant = nil, nil
+		if tt.feedback {
+			se.Config.Relevant, se.Config.Irrelevant = []string{"wanted"}, []string{"off-topic"}
+		}
+		results, err := se.Search("query")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resultIDs(results); !slices.Equal(got, tt.want) {
+			t.Errorf("results with feedback %v: %v, want %v", tt.feedback, got, tt.want)
+		}
+	}
+
+	se.Config.Relevant = []string{"missing"}
+	if _, err := se.applyFeedback(query); err == nil {
+		t.Error("feedback from a paper not in the corpus accepted")
+	}
+}
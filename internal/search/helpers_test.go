@@ -0,0 +1,84 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+// testPaper is a paper with an abstract embedding and a PageRank score for
+// testEngine.
+type testPaper struct {
+	ID        string
+	Year      int
+	Authors   []string
+	Embedding []float32
+	PageRank  float64
+}
+
+// testEngine builds an in-memory engine over papers with the default
+// search configuration changed by configure, when given.
+func testEngine(t testing.TB, papers []testPaper, configure func(*SearchConfig)) *SearchEngine {
+	t.Helper()
+	config := DefaultSearchConfig()
+	if configure != nil {
+		configure(&config)
+	}
+	metric, err := similarityMetricFor(config.SimilarityMetric)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	se := &SearchEngine{
+		PageRank:  make(map[string]float64, len(papers)),
+		Citations: make(map[string]int, len(papers)),
+		Config:    config,
+		metric:    metric,
+	}
+	for _, p := range papers {
+		se.Papers = append(se.Papers, data.Paper{
+			ID:                p.ID,
+			Title:             fmt.Sprintf("Paper %s", p.ID),
+			Year:              p.Year,
+			Authors:           p.Authors,
+			Abstract:          fmt.Sprintf("Abstract of paper %s.", p.ID),
+			AbstractEmbedding: p.Embedding,
+		})
+		se.PageRank[p.ID] = p.PageRank
+	}
+	return se
+}
+
+// resultIDs lists the paper ids of results in order.
+func resultIDs(results []SearchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Paper.ID
+	}
+	return ids
+}
+
+// captureStdout runs fn with os.Stdout pointed at a file and returns what
+// was written to it.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	saved := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = saved }()
+	fn()
+
+	out, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
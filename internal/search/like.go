@@ -0,0 +1,50 @@
+package search
+
+import "fmt"
+
+// DefaultLikeWeight gives the text query and the example papers equal say.
+const DefaultLikeWeight = 0.5
+
+// BlendQuery mixes a query embedding with the centroid of example paper
+// embeddings: (1-weight)*query + weight*mean(examples). With no examples
+// the query is returned scaled by 1-weight, which leaves cosine similarity
+// unchanged.
+func BlendQuery(query []float32, examples [][]float32, weight float64) ([]float32, error) {
+	return Rocchio(query, examples, nil, 1-weight, weight, 0)
+}
+
+// applyLike blends the query embedding with the stored embeddings of the
+// Config.Like papers. Papers without an embedding are skipped with a
+// warning; when none is left the query is used alone.
+func (se *SearchEngine) applyLike(queryEmbedding []float32) ([]float32, error) {
+	wanted := make(map[string][]float32, len(se.Config.Like))
+	for _, id := range se.Config.Like {
+		wanted[id] = nil
+	}
+	for _, paper := range se.Papers {
+		if _, ok := wanted[paper.ID]; ok {
+			wanted[paper.ID] = se.embedding(paper)
+		}
+	}
+
+	examples := make([][]float32, 0, len(se.Config.Like))
+	for _, id := range se.Config.Like {
+		embedding := wanted[id]
+		if len(embedding) == 0 {
+			fmt.Printf("Warning: paper %s is not in the corpus or has no %q embedding; skipping it\n", id, se.Config.EmbeddingField)
+			continue
+		}
+		examples = append(examples, embedding)
+	}
+	if len(examples) == 0 {
+		fmt.Println("Warning: no example paper has an embedding; searching with the query alone")
+		return queryEmbedding, nil
+	}
+
+	blended, err := BlendQuery(queryEmbedding, examples, se.Config.LikeWeight)
+	if err != nil {
+		return nil, fmt.Errorf("could not blend the query with the example papers: %v", err)
+	}
+	fmt.Printf("Blended the query with %d example papers (weight %.2f)\n", len(examples), se.Config.LikeWeight)
+	return blended, nil
+}
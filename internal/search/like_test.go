@@ -0,0 +1,92 @@
+package search
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBlendQuery(t *testing.T) {
+	query := []float32{1, 0}
+	examples := [][]float32{{0, 2}, {0, 4}}
+	tests := []struct {
+		weight float64
+		want   []float32
+	}{
+		{0, []float32{1, 0}},
+		// 0.75*[1 0] + 0.25*[0 3]
+		{0.25, []float32{0.75, 0.75}},
+		{1, []float32{0, 3}},
+	}
+	for _, tt := range tests {
+		got, err := BlendQuery(query, examples, tt.weight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("weight %v: blended %v, want %v", tt.weight, got, tt.want)
+		}
+	}
+	if _, err := BlendQuery(query, [][]float32{{1, 2, 3}}, 0.5); err == nil {
+		t.Error("a 3-dimensional example was blended into a 2-dimensional query")
+	}
+}
+
+func TestLikeBlendsQueryWithExamples(t *testing.T) {
+	query := []float32{1, 0, 0}
+	papers := []testPaper{
+		{ID: "text", Embedding: []float32{1, 0, 0}},
+		{ID: "example", Embedding: []float32{0, 1, 0}},
+		{ID: "between", Embedding: []float32{0.7, 0.7, 0}},
+		{ID: "other", Embedding: []float32{0, 0, 1}},
+		{ID: "unembedded"},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) {
+		c.PageRankWeight = 0
+		c.RelevanceWeight = 1
+		c.Like = []string{"example"}
+	})
+
+	// the blend sits between the text query and the example: closer to
+	// each than they are to each other
+	blended, err := se.applyLike(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apart, _ := cosineSimilarity(query, papers[1].Embedding)
+	toText, _ := cosineSimilarity(blended, query)
+	toExample, _ := cosineSimilarity(blended, papers[1].Embedding)
+	if toText <= apart || toExample <= apart || blended[2] != 0 {
+		t.Errorf("blended query %v is not between %v and %v", blended, query, papers[1].Embedding)
+	}
+
+	se.Embedder = fixedEmbedder(query)
+	tests := []struct {
+		like []string
+		want []string
+		warn string
+	}{
+		{nil, []string{"text", "between", "example", "other"}, ""},
+		{[]string{"example"}, []string{"between", "example", "text", "other"}, ""},
+		// examples without an embedding are skipped
+		{[]string{"missing", "example", "unembedded"}, []string{"between", "example", "text", "other"},
+			"paper missing is not in the corpus"},
+		{[]string{"unembedded"}, []string{"text", "between", "example", "other"},
+			"no example paper has an embedding"},
+	}
+	for _, tt := range tests {
+		se.Config.Like = tt.like
+		var results []SearchResult
+		out := captureStdout(t, func() { results, err = se.Search("query") })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resultIDs(results); !slices.Equal(got, tt.want) {
+			t.Errorf("like %v: results %v, want %v", tt.like, got, tt.want)
+		}
+		if tt.warn != "" && !strings.Contains(out, tt.warn) {
+			t.Errorf("like %v: no warning %q in\n%s", tt.like, tt.warn, out)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+
+	"paper-rank/internal/data"
+)
+
+// DefaultProjectionFile is where 'embed --reduce-dim' keeps its projection,
+// next to the embeddings it reduced.
+const DefaultProjectionFile = "projection.json"
+
+// cap on orthogonal iterations in NewPCAProjection; the leading directions,
+// which carry most of the similarity structure, settle well before this
+const pcaIterations = 30
+
+// embeddings sampled to fit a PCA projection
+const pcaSample = 10000
+
+// values of Projection.Method
+const (
+	ProjectionPCA    = "pca"
+	ProjectionRandom = "random"
+)
+
+// a linear map from InputDim to OutputDim dimensions, fitted by PCA or
+// drawn at random; either roughly preserves angles, so similarity rankings
+// survive the reduction (PCA far better on real embeddings)
+type Projection struct {
+	Method    string      `json:"method"` // ProjectionPCA or ProjectionRandom
+	InputDim  int         `json:"input_dim"`
+	OutputDim int         `json:"output_dim"`
+	Seed      int64       `json:"seed"`
+	Matrix    [][]float32 `json:"matrix"` // OutputDim rows of InputDim entries
+}
+
+// NewRandomProjection draws the OutputDim x InputDim matrix from a normal
+// distribution scaled by 1/sqrt(outputDim), so projected vectors keep their
+// length in expectation.
+func NewRandomProjection(inputDim, outputDim int, seed int64) (*Projection, error) {
+	if outputDim <= 0 || outputDim >= inputDim {
+		return nil, fmt.Errorf("cannot reduce %d dimensions to %d", inputDim, outputDim)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	scale := 1 / math.Sqrt(float64(outputDim))
+	matrix := make([][]float32, outputDim)
+	for i := range matrix {
+		matrix[i] = make([]float32, inputDim)
+		for j := range matrix[i] {
+			matrix[i][j] = float32(rng.NormFloat64() * scale)
+		}
+	}
+
+	return &Projection{Method: ProjectionRandom, InputDim: inputDim, OutputDim: outputDim, Seed: seed, Matrix: matrix}, nil
+}
+
+// Apply projects v. Vectors that already have OutputDim dimensions are
+// returned unchanged, so embeddings can be reduced more than once safely.
+func (p *Projection) Apply(v []float32) ([]float32, error) {
+	if len(v) == p.OutputDim {
+		return v, nil
+	}
+	if len(v) != p.InputDim {
+		return nil, fmt.Errorf("embedding has %d dimensions, projection expects %d", len(v), p.InputDim)
+	}
+
+	out := make([]float32, p.OutputDim)
+	for i, row := range p.Matrix {
+		var sum float64
+		for j, x := range v {
+			sum += float64(row[j]) * float64(x)
+		}
+		out[i] = float32(sum)
+	}
+	return out, nil
+}
+
+// ReduceEmbeddings projects every embedding of every paper in place.
+func (p *Projection) ReduceEmbeddings(papers []data.Paper) error {
+	for i := range papers {
+		paper := &papers[i]
+		if len(paper.AbstractEmbedding) > 0 {
+			reduced, err := p.Apply(paper.AbstractEmbedding)
+			if err != nil {
+				return fmt.Errorf("paper %s: %v", paper.ID, err)
+			}
+			paper.AbstractEmbedding = reduced
+		}
+		for field, embedding := range paper.Embeddings {
+			reduced, err := p.Apply(embedding)
+			if err != nil {
+				return fmt.Errorf("paper %s, %s embedding: %v", paper.ID, field, err)
+			}
+			paper.Embeddings[field] = reduced
+		}
+	}
+	return nil
+}
+
+func SaveProjection(p *Projection, outputPath string) error {
+	return data.EncodeFile(outputPath, p, data.FormatJSON)
+}
+
+func LoadProjection(inputPath string) (*Projection, error) {
+	var p Projection
+	if err := data.DecodeFile(inputPath, &p); err != nil {
+		return nil, fmt.Errorf("failed to load projection: %v", err)
+	}
+	if len(p.Matrix) != p.OutputDim {
+		return nil, fmt.Errorf("projection %s has %d rows, want %d", inputPath, len(p.Matrix), p.OutputDim)
+	}
+	return &p, nil
+}
+
+// loadOrCreateProjection loads the projection at path, or fits one of the
+// given method to vectors and saves it there. An existing projection must
+// reduce to outputDim.
+func loadOrCreateProjection(path string, vectors [][]float32, outputDim int, method string) (*Projection, error) {
+	if _, err := os.Stat(path); err == nil {
+		p, err := LoadProjection(path)
+		if err != nil {
+			return nil, err
+		}
+		if p.OutputDim != outputDim {
+			return nil, fmt.Errorf("%s reduces to %d dimensions, not %d; delete it and re-embed to change", path, p.OutputDim, outputDim)
+		}
+		return p, nil
+	}
+
+	var p *Projection
+	var err error
+	switch method {
+	case ProjectionPCA, "":
+		fmt.Printf("Fitting PCA projection to %d dimensions...\n", outputDim)
+		p, err = NewPCAProjection(vectors, outputDim, pcaSample, 1)
+	case ProjectionRandom:
+		p, err = NewRandomProjection(len(vectors[0]), outputDim, 1)
+	default:
+		err = fmt.Errorf("unknown projection method %q (want pca or random)", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveProjection(p, path); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Saved %d -> %d dimension %s projection to: %s\n", p.InputDim, p.OutputDim, p.Method, path)
+	return p, nil
+}
+
+// NewPCAProjection fits the outputDim principal directions of vectors (about
+// the origin, so dot products and cosines are what is preserved) by
+// orthogonal iteration on their second-moment matrix. At most maxSample
+// vectors, picked with the seed, are used for the fit.
+func NewPCAProjection(vectors [][]float32, outputDim, maxSample int, seed int64) (*Projection, error) {
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no embeddings to fit a projection to")
+	}
+	inputDim := len(vectors[0])
+	if outputDim <= 0 || outputDim >= inputDim {
+		return nil, fmt.Errorf("cannot reduce %d dimensions to %d", inputDim, outputDim)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	sample := vectors
+	if maxSample > 0 && len(vectors) > maxSample {
+		sample = make([][]float32, maxSample)
+		for i, j := range rng.Perm(len(vectors))[:maxSample] {
+			sample[i] = vectors[j]
+		}
+	}
+
+	// second-moment matrix C = sum of v v^T, symmetric
+	c := make([][]float64, inputDim)
+	for i := range c {
+		c[i] = make([]float64, inputDim)
+	}
+	for _, v := range sample {
+		if len(v) != inputDim {
+			return nil, fmt.Errorf("embeddings have mixed dimensions (%d and %d)", inputDim, len(v))
+		}
+		for i, x := range v {
+			if x == 0 {
+				continue
+			}
+			row := c[i]
+			for j := i; j < inputDim; j++ {
+				row[j] += float64(x) * float64(v[j])
+			}
+		}
+	}
+	for i := range c {
+		for j := 0; j < i; j++ {
+			c[i][j] = c[j][i]
+		}
+	}
+
+	// orthogonal iteration: Q <- orth(C Q) converges to the top eigenvectors
+	q := make([][]float64, outputDim)
+	for k := range q {
+		q[k] = make([]float64, inputDim)
+		for i := range q[k] {
+			q[k][i] = rng.NormFloat64()
+		}
+	}
+	orthonormalize(q)
+	next := make([][]float64, outputDim)
+	for k := range next {
+		next[k] = make([]float64, inputDim)
+	}
+	for iter := 0; iter < pcaIterations; iter++ {
+		for k := range q {
+			for i, row := range c {
+				var sum float64
+				for j, x := range row {
+					sum += x * q[k][j]
+				}
+				next[k][i] = sum
+			}
+		}
+		orthonormalize(next)
+
+		// converged when every direction stopped turning
+		var drift float64
+		for k := range q {
+			var dot float64
+			for i := range q[k] {
+				dot += q[k][i] * next[k][i]
+			}
+			drift = max(drift, 1-math.Abs(dot))
+		}
+		q, next = next, q
+		if drift < 1e-6 {
+			break
+		}
+	}
+
+	matrix := make([][]float32, outputDim)
+	for k := range matrix {
+		matrix[k] = make([]float32, inputDim)
+		for i, x := range q[k] {
+			matrix[k][i] = float32(x)
+		}
+	}
+	return &Projection{Method: ProjectionPCA, InputDim: inputDim, OutputDim: outputDim, Seed: seed, Matrix: matrix}, nil
+}
+
+// orthonormalize applies modified Gram-Schmidt to the rows of q in place.
+func orthonormalize(q [][]float64) {
+	for k := range q {
+		for j := 0; j < k; j++ {
+			var dot float64
+			for i := range q[k] {
+				dot += q[k][i] * q[j][i]
+			}
+			for i := range q[k] {
+				q[k][i] -= dot * q[j][i]
+			}
+		}
+		var norm float64
+		for _, x := range q[k] {
+			norm += x * x
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			continue
+		}
+		for i := range q[k] {
+			q[k][i] /= norm
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fixedEmbedder embeds every query as the same vector.
+type fixedEmbedder []float32
+
+func (e fixedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e, nil
+}
+
+func (e fixedEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i := range embeddings {
+		embeddings[i] = e
+	}
+	return embeddings, nil
+}
+
+// lowRankVectors returns n dim-dimensional vectors mixing a few shared
+// directions plus a little noise, the shape real embeddings have.
+func lowRankVectors(rng *rand.Rand, n, dim, rank int) [][]float32 {
+	directions := make([][]float64, rank)
+	for k := range directions {
+		directions[k] = make([]float64, dim)
+		for i := range directions[k] {
+			directions[k][i] = rng.NormFloat64()
+		}
+	}
+	vectors := make([][]float32, n)
+	for v := range vectors {
+		vectors[v] = make([]float32, dim)
+		for i := range vectors[v] {
+			vectors[v][i] = float32(0.05 * rng.NormFloat64())
+		}
+		for _, direction := range directions {
+			weight := rng.NormFloat64()
+			for i, x := range direction {
+				vectors[v][i] += float32(weight * x)
+			}
+		}
+	}
+	return vectors
+}
+
+func TestReducedEmbeddingsKeepRecall(t *testing.T) {
+	const (
+		papers  = 300
+		queries = 20
+		dim     = 64
+		top     = 10
+	)
+	rng := rand.New(rand.NewSource(1))
+	vectors := lowRankVectors(rng, papers+queries, dim, 8)
+	corpus := make([]testPaper, papers)
+	for i := range corpus {
+		corpus[i] = testPaper{ID: fmt.Sprintf("P%03d", i), Embedding: vectors[i]}
+	}
+	configure := func(c *SearchConfig) {
+		c.PageRankWeight = 0
+		c.RelevanceWeight = 1
+		c.MaxResults = top
+	}
+
+	pca, err := NewPCAProjection(vectors[:papers], 16, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	random, err := NewRandomProjection(dim, 32, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		projection *Projection
+		minRecall  float64
+	}{
+		{pca, 0.9},
+		{random, 0.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.projection.Method, func(t *testing.T) {
+			full := testEngine(t, corpus, configure)
+			reduced := testEngine(t, corpus, configure)
+			if err := tt.projection.ReduceEmbeddings(reduced.Papers); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(reduced.Papers[0].AbstractEmbedding); got != tt.projection.OutputDim {
+				t.Fatalf("reduced embeddings have %d dimensions, want %d", got, tt.projection.OutputDim)
+			}
+			reduced.Projection = tt.projection
+
+			var found int
+			for _, query := range vectors[papers:] {
+				full.Embedder = fixedEmbedder(query)
+				reduced.Embedder = fixedEmbedder(query)
+				want, err := full.Search("query")
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := reduced.Search("query")
+				if err != nil {
+					t.Fatal(err)
+				}
+				relevant := make(map[string]bool, len(want))
+				for _, id := range resultIDs(want) {
+					relevant[id] = true
+				}
+				for _, id := range resultIDs(got) {
+					if relevant[id] {
+						found++
+					}
+				}
+			}
+			recall := float64(found) / (queries * top)
+			t.Logf("recall@%d with %d of %d dimensions: %.2f", top, tt.projection.OutputDim, dim, recall)
+			if recall < tt.minRecall {
+				t.Errorf("recall@%d %.2f, want at least %.2f", top, recall, tt.minRecall)
+			}
+		})
+	}
+}
+
+func TestProjectionApply(t *testing.T) {
+	p, err := NewRandomProjection(4, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reduced, err := p.Apply([]float32{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reduced) != 2 {
+		t.Fatalf("%d dimensions, want 2", len(reduced))
+	}
+	// already reduced vectors pass through
+	if again, err := p.Apply(reduced); err != nil || !reflect.DeepEqual(again, reduced) {
+		t.Errorf("reapplying gave %v, %v; want %v", again, err, reduced)
+	}
+	if _, err := p.Apply([]float32{1, 2, 3}); err == nil {
+		t.Error("a 3-dimensional vector was projected by a 4 -> 2 projection")
+	}
+	if _, err := NewRandomProjection(4, 4, 1); err == nil {
+		t.Error("a projection that does not reduce was created")
+	}
+}
+
+func TestProjectionSaveLoad(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	p, err := NewPCAProjection(lowRankVectors(rng, 50, 8, 2), 3, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), DefaultProjectionFile)
+	if err := SaveProjection(p, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadProjection(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, p) {
+		t.Errorf("loaded %+v, saved %+v", loaded, p)
+	}
+}
@@ -0,0 +1,239 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+// weights of the three recommendation signals, each scored in [0, 1]
+type RecommendConfig struct {
+	EmbeddingWeight float64 `json:"embedding_weight"` // similarity to the target's embedding
+	GraphWeight     float64 `json:"graph_weight"`     // citation-graph proximity, see graph.Proximity
+	PageRankWeight  float64 `json:"pagerank_weight"`  // PageRank over the highest PageRank
+	MaxResults      int     `json:"max_results"`
+
+	// leave out papers sharing an author with the target
+	ExcludeSameAuthors bool `json:"exclude_same_authors"`
+
+	// explain each recommendation, see RecommendExplanation
+	Explain bool `json:"explain"`
+}
+
+func DefaultRecommendConfig() RecommendConfig {
+	return RecommendConfig{
+		EmbeddingWeight: 0.5,
+		GraphWeight:     0.3,
+		PageRankWeight:  0.2,
+		MaxResults:      10,
+	}
+}
+
+type Recommendation struct {
+	Paper          data.Paper `json:"paper"`
+	Score          float64    `json:"score"`
+	EmbeddingScore float64    `json:"embedding_score"`
+	GraphScore     float64    `json:"graph_score"`
+	PageRankScore  float64    `json:"pagerank_score"` // normalized to the highest PageRank
+
+	Explanation *RecommendExplanation `json:"explanation,omitempty"`
+}
+
+// why a paper is related to the target: its citation-neighborhood overlap
+// with the target and the cosine similarity of their embeddings
+type RecommendExplanation struct {
+	graph.NeighborhoodOverlap
+	Cosine        float64 `json:"cosine"`
+	HasEmbeddings bool    `json:"has_embeddings"` // both papers have an embedding to compare
+}
+
+// Recommend ranks the papers to read after targetID by a weighted blend of
+// embedding similarity, citation-graph proximity and PageRank. When the
+// target has no embedding the embedding weight is moved onto the other two
+// signals in proportion. Papers with none of the graph or embedding signals
+// are left out, so an unconnected target does not just get the top PageRank
+// papers back. With ExcludeSameAuthors, so are papers sharing an author with
+// the target, compared by normalized name.
+func (se *SearchEngine) Recommend(targetID string, g *graph.Graph, config RecommendConfig) ([]Recommendation, error) {
+	var target *data.Paper
+	for i := range se.Papers {
+		if se.Papers[i].ID == targetID {
+			target = &se.Papers[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("paper %s not found", targetID)
+	}
+
+	metric := se.metric
+	if metric.similarity == nil {
+		metric = similarityMetrics[DefaultSimilarityMetric]
+	}
+
+	targetEmbedding := se.embedding(*target)
+	if len(targetEmbedding) == 0 && config.EmbeddingWeight > 0 {
+		fmt.Printf("Paper %s has no embedding; recommending from the citation graph and PageRank only\n", targetID)
+		rest := config.GraphWeight + config.PageRankWeight
+		if rest <= 0 {
+			return nil, fmt.Errorf("paper %s has no embedding and the graph and PageRank weights are zero", targetID)
+		}
+		scale := (rest + config.EmbeddingWeight) / rest
+		config.GraphWeight *= scale
+		config.PageRankWeight *= scale
+		config.EmbeddingWeight = 0
+	}
+
+	var citing map[string][]string
+	if config.GraphWeight > 0 || config.Explain {
+		citing = g.CitingIndex()
+	}
+	var proximity map[string]float64
+	if config.GraphWeight > 0 {
+		proximity = g.Proximity(targetID, citing)
+	}
+	maxPageRank := se.maxPageRank()
+
+	targetAuthors := make(map[string]bool, len(target.Authors))
+	if config.ExcludeSameAuthors {
+		for _, author := range target.Authors {
+			if key := data.NormalizeAuthor(author); key != "" {
+				targetAuthors[key] = true
+			}
+		}
+	}
+	sameAuthors := 0
+
+	var recs []Recommendation
+	for _, paper := range se.Papers {
+		if paper.ID == targetID {
+			continue
+		}
+
+		rec := Recommendation{Paper: paper, GraphScore: proximity[paper.ID]}
+		if config.EmbeddingWeight > 0 {
+			if embedding := se.embedding(paper); len(embedding) > 0 {
+				if similarity, err := metric.similarity(targetEmbedding, embedding); err == nil {
+					rec.EmbeddingScore = metric.relevance(similarity)
+				}
+			}
+		}
+		if rec.EmbeddingScore == 0 && rec.GraphScore == 0 {
+			continue
+		}
+		if len(targetAuthors) > 0 && sharesAuthor(paper, targetAuthors) {
+			sameAuthors++
+			continue
+		}
+		if maxPageRank > 0 {
+			rec.PageRankScore = se.PageRank[paper.ID] / maxPageRank
+		}
+
+		rec.Score = config.EmbeddingWeight*rec.EmbeddingScore +
+			config.GraphWeight*rec.GraphScore +
+			config.PageRankWeight*rec.PageRankScore
+		recs = append(recs, rec)
+	}
+
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].Paper.ID < recs[j].Paper.ID
+	})
+	if config.MaxResults > 0 && len(recs) > config.MaxResults {
+		recs = recs[:config.MaxResults]
+	}
+	if config.Explain {
+		for i := range recs {
+			recs[i].Explanation = se.explainRecommendation(*target, recs[i].Paper, g, citing)
+		}
+	}
+	if config.ExcludeSameAuthors {
+		fmt.Printf("Excluded %d papers sharing an author with %s\n", sameAuthors, targetID)
+	}
+	return recs, nil
+}
+
+// explainRecommendation counts the references and citers paper shares with
+// target and compares their embeddings by cosine, whatever metric ranked
+// them, so the number reads the same across --similarity settings.
+func (se *SearchEngine) explainRecommendation(target, paper data.Paper, g *graph.Graph, citing map[string][]string) *RecommendExplanation {
+	explanation := &RecommendExplanation{NeighborhoodOverlap: g.Overlap(target.ID, paper.ID, citing)}
+	a, b := se.embedding(target), se.embedding(paper)
+	if len(a) > 0 && len(b) > 0 {
+		if cosine, err := cosineSimilarity(a, b); err == nil {
+			explanation.Cosine = cosine
+			explanation.HasEmbeddings = true
+		}
+	}
+	return explanation
+}
+
+// String reads the explanation as a sentence, e.g. "shares 8 references,
+// cited together 3 times, 0.82 embedding similarity".
+func (e *RecommendExplanation) String() string {
+	var parts []string
+	switch {
+	case e.Cites && e.CitedBy:
+		parts = append(parts, "cites and is cited by the target paper")
+	case e.Cites:
+		parts = append(parts, "cited by the target paper")
+	case e.CitedBy:
+		parts = append(parts, "cites the target paper")
+	}
+	parts = append(parts,
+		fmt.Sprintf("shares %d %s", e.SharedReferences, plural(e.SharedReferences, "reference", "references")),
+		fmt.Sprintf("cited together %d %s", e.SharedCiters, plural(e.SharedCiters, "time", "times")))
+	if e.HasEmbeddings {
+		parts = append(parts, fmt.Sprintf("%.2f embedding similarity", e.Cosine))
+	} else {
+		parts = append(parts, "no embedding to compare")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// sharesAuthor reports whether one of the paper's authors is among the
+// normalized names in authors.
+func sharesAuthor(paper data.Paper, authors map[string]bool) bool {
+	for _, author := range paper.Authors {
+		if authors[data.NormalizeAuthor(author)] {
+			return true
+		}
+	}
+	return false
+}
+
+func PrintRecommendations(p graph.Printer, target data.Paper, recs []Recommendation) {
+	fmt.Printf("\nRecommendations for: %s (%d)\n", p.StyleTitle(target.Title), target.Year)
+	fmt.Printf("Found %d papers\n", len(recs))
+	fmt.Println("=" + strings.Repeat("=", 80))
+
+	for i, rec := range recs {
+		fmt.Printf("\n%d. %s (%d)\n", i+1, p.StyleTitle(rec.Paper.Title), rec.Paper.Year)
+		if len(rec.Paper.Authors) > 0 {
+			authors := rec.Paper.Authors
+			if len(authors) > 3 {
+				authors = append(authors[:3:3], "et al.")
+			}
+			fmt.Printf("   Authors: %s\n", strings.Join(authors, ", "))
+		}
+		fmt.Printf("   Score: %s (Embedding: %.3f, Graph: %.3f, PageRank: %.3f)\n",
+			p.StyleScore(fmt.Sprintf("%.4f", rec.Score)), rec.EmbeddingScore, rec.GraphScore, rec.PageRankScore)
+		if rec.Explanation != nil {
+			fmt.Printf("   Why: %s\n", rec.Explanation)
+		}
+		fmt.Printf("   ID: %s\n", rec.Paper.ID)
+	}
+	fmt.Println("\n" + strings.Repeat("=", 81))
+}
@@ -0,0 +1,215 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+// recommendFixture is an engine and citation graph around target T, which
+// cites R; S has T's embedding and no links, O the opposite embedding, and
+// U neither an embedding nor a link.
+func recommendFixture(t *testing.T) (*SearchEngine, *graph.Graph) {
+	t.Helper()
+	se := testEngine(t, []testPaper{
+		{ID: "T", Embedding: []float32{1, 0}, PageRank: 0.1},
+		{ID: "R", Embedding: []float32{0, 1}, PageRank: 0.2},
+		{ID: "S", Embedding: []float32{1, 0.1}, PageRank: 0.1},
+		{ID: "O", Embedding: []float32{-1, 0}, PageRank: 0.4},
+		{ID: "U", PageRank: 0.8},
+	}, nil)
+	g := graph.BuildGraphFromData(&data.ParsedData{
+		Papers:    se.Papers,
+		Citations: []data.CitationEdge{{From: "T", To: "R"}},
+	}, graph.BuildConfig{})
+	return se, g
+}
+
+func TestRecommend(t *testing.T) {
+	se, g := recommendFixture(t)
+	config := DefaultRecommendConfig()
+	recs, err := se.Recommend("T", g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the target, and papers with neither an embedding match nor a link,
+	// are left out whatever their PageRank
+	if got := fmt.Sprint(recommendationIDs(recs)); got != "[R S]" {
+		t.Errorf("recommended %s, want [R S]", got)
+	}
+	for _, rec := range recs {
+		want := config.EmbeddingWeight*rec.EmbeddingScore + config.GraphWeight*rec.GraphScore + config.PageRankWeight*rec.PageRankScore
+		if math.Abs(rec.Score-want) > 1e-12 {
+			t.Errorf("%s scored %v, want the weighted sum %v", rec.Paper.ID, rec.Score, want)
+		}
+	}
+	if r := recs[0]; r.GraphScore != 1 || r.PageRankScore != 0.25 {
+		t.Errorf("R: graph score %v and PageRank score %v, want 1 for a direct citation and 0.2/0.8", r.GraphScore, r.PageRankScore)
+	}
+
+	// each signal alone puts its own paper first
+	for _, tt := range []struct {
+		embedding, graph float64
+		want             string
+	}{
+		{1, 0, "S"},
+		{0, 1, "R"},
+	} {
+		recs, err := se.Recommend("T", g, RecommendConfig{EmbeddingWeight: tt.embedding, GraphWeight: tt.graph})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recs) == 0 || recs[0].Paper.ID != tt.want {
+			t.Errorf("embedding weight %v, graph weight %v: recommended %v, want %s first", tt.embedding, tt.graph, recommendationIDs(recs), tt.want)
+		}
+	}
+
+	config.MaxResults = 1
+	if recs, _ := se.Recommend("T", g, config); len(recs) != 1 {
+		t.Errorf("%d recommendations, want MaxResults 1", len(recs))
+	}
+	if _, err := se.Recommend("missing", g, config); err == nil {
+		t.Error("recommended for a paper that does not exist")
+	}
+}
+
+func TestRecommendWithoutTargetEmbedding(t *testing.T) {
+	se, g := recommendFixture(t)
+	se.Papers[0].AbstractEmbedding = nil
+
+	recs, err := se.Recommend("T", g, DefaultRecommendConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the embedding weight moves onto graph and PageRank, 0.3:0.2
+	if len(recs) != 1 || recs[0].Paper.ID != "R" || math.Abs(recs[0].Score-(0.6*1+0.4*0.25)) > 1e-12 {
+		t.Errorf("recommended %+v, want R scored 0.7", recs)
+	}
+
+	if _, err := se.Recommend("T", g, RecommendConfig{EmbeddingWeight: 1}); err == nil {
+		t.Error("recommended with only an embedding weight and no target embedding")
+	}
+}
+
+func TestRecommendExcludeSameAuthors(t *testing.T) {
+	se, g := recommendFixture(t)
+	authors := map[string][]string{
+		"T": {"Ada Lovelace", "Alan Turing"},
+		"R": {"Grace Hopper"},
+		// the closest paper, by T's second author written another way
+		"S": {"Turing, Alan", "Grace Hopper"},
+	}
+	for i := range se.Papers {
+		se.Papers[i].Authors = authors[se.Papers[i].ID]
+	}
+
+	for _, exclude := range []bool{false, true} {
+		config := RecommendConfig{EmbeddingWeight: 1, GraphWeight: 0.1, ExcludeSameAuthors: exclude}
+		recs, err := se.Recommend("T", g, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "[S R]"
+		if exclude {
+			want = "[R]"
+		}
+		if got := fmt.Sprint(recommendationIDs(recs)); got != want {
+			t.Errorf("exclude same authors %v: recommended %s, want %s", exclude, got, want)
+		}
+	}
+}
+
+func TestRecommendExplanation(t *testing.T) {
+	// T cites R1-R3 and is cited by C1 and C2; A shares R1 and R2 with T,
+	// and C1 and C2 of its three citers
+	se := testEngine(t, []testPaper{
+		{ID: "T", Embedding: []float32{1, 0}, PageRank: 0.1},
+		{ID: "A", Embedding: []float32{0.6, 0.8}, PageRank: 0.4},
+		{ID: "R1", Embedding: []float32{0, 1}, PageRank: 0.2},
+		{ID: "R2", PageRank: 0.1},
+		{ID: "R3", PageRank: 0.1},
+		{ID: "X", PageRank: 0.1},
+		{ID: "C1", PageRank: 0.1},
+		{ID: "C2", PageRank: 0.1},
+		{ID: "C3", PageRank: 0.1},
+	}, nil)
+	var citations []data.CitationEdge
+	for _, edge := range []string{"T>R1", "T>R2", "T>R3", "A>R1", "A>R2", "A>X", "C1>T", "C1>A", "C2>T", "C2>A", "C3>A"} {
+		from, to, _ := strings.Cut(edge, ">")
+		citations = append(citations, data.CitationEdge{From: from, To: to})
+	}
+	g := graph.BuildGraphFromData(&data.ParsedData{Papers: se.Papers, Citations: citations}, graph.BuildConfig{})
+
+	config := DefaultRecommendConfig()
+	config.Explain = true
+	recs, err := se.Recommend("T", g, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explained := make(map[string]Recommendation)
+	for _, rec := range recs {
+		if rec.Explanation == nil {
+			t.Fatalf("%s recommended without an explanation", rec.Paper.ID)
+		}
+		explained[rec.Paper.ID] = rec
+	}
+
+	tests := []struct {
+		id    string
+		want  RecommendExplanation
+		graph float64 // the graph score the overlap accounts for
+		text  string
+	}{
+		{"A", RecommendExplanation{NeighborhoodOverlap: graph.NeighborhoodOverlap{SharedReferences: 2, SharedCiters: 2}, Cosine: 0.6, HasEmbeddings: true},
+			// the larger of 2 shared of 3 and 3 references, and 2 shared
+			// of 2 and 3 citers
+			math.Max(2/math.Sqrt(3*3), 2/math.Sqrt(2*3)),
+			"shares 2 references, cited together 2 times, 0.60 embedding similarity"},
+		{"R1", RecommendExplanation{NeighborhoodOverlap: graph.NeighborhoodOverlap{Cites: true}, Cosine: 0, HasEmbeddings: true},
+			1, "cited by the target paper, shares 0 references, cited together 0 times, 0.00 embedding similarity"},
+		{"C1", RecommendExplanation{NeighborhoodOverlap: graph.NeighborhoodOverlap{CitedBy: true}},
+			1, "cites the target paper, shares 0 references, cited together 0 times, no embedding to compare"},
+	}
+	for _, tt := range tests {
+		rec, ok := explained[tt.id]
+		if !ok {
+			t.Errorf("%s not recommended: %v", tt.id, recommendationIDs(recs))
+			continue
+		}
+		got := *rec.Explanation
+		got.Cosine = math.Round(got.Cosine*1e6) / 1e6 // float32 embeddings
+		if got != tt.want {
+			t.Errorf("%s explained as %+v, want %+v", tt.id, got, tt.want)
+		}
+		if s := rec.Explanation.String(); s != tt.text {
+			t.Errorf("%s explained as %q, want %q", tt.id, s, tt.text)
+		}
+
+		// the explained numbers are the ones the score is made of
+		embedding := 0.0
+		if got.HasEmbeddings {
+			embedding = (rec.Explanation.Cosine + 1) / 2
+		}
+		if math.Abs(rec.EmbeddingScore-embedding) > 1e-9 || math.Abs(rec.GraphScore-tt.graph) > 1e-9 {
+			t.Errorf("%s: embedding score %v and graph score %v, want %v and %v from the explanation",
+				tt.id, rec.EmbeddingScore, rec.GraphScore, embedding, tt.graph)
+		}
+		want := config.EmbeddingWeight*embedding + config.GraphWeight*tt.graph + config.PageRankWeight*rec.PageRankScore
+		if math.Abs(rec.Score-want) > 1e-9 {
+			t.Errorf("%s scored %v, want %v from the explained numbers", tt.id, rec.Score, want)
+		}
+	}
+}
+
+func recommendationIDs(recs []Recommendation) []string {
+	ids := make([]string, len(recs))
+	for i, rec := range recs {
+		ids[i] = rec.Paper.ID
+	}
+	return ids
+}
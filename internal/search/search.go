@@ -1,10 +1,11 @@
 package search
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
-	"os/exec"
 	"regexp"
 	"sort"
 	"strings"
@@ -13,12 +14,35 @@ import (
 	"paper-rank/internal/graph"
 
 	"github.com/mitchellh/go-wordwrap"
+	"golang.org/x/sync/errgroup"
 )
 
 type SearchEngine struct {
-	Papers   []data.Paper       `json:"papers"`
-	PageRank map[string]float64 `json:"pagerank"`
-	Config   SearchConfig       `json:"config"`
+	Papers    []data.Paper       `json:"papers"`
+	PageRank  map[string]float64 `json:"pagerank"`
+	Citations map[string]int     `json:"citations"` // in-corpus citation counts from the PageRank results
+	Config    SearchConfig       `json:"config"`
+
+	// optional community assignment from 'rank --per-community', not cached
+	Communities     map[string]int `json:"-"`
+	CommunityLabels map[int]string `json:"-"`
+
+	// optional citation graph for Config.TopicSensitive, not cached
+	Graph *graph.Graph `json:"-"`
+
+	// projection the stored embeddings were reduced with, applied to query
+	// embeddings; nil for full-size embeddings
+	Projection *Projection `json:"-"`
+
+	// embeds queries; created from Config on the first query when nil
+	Embedder Embedder `json:"-"`
+
+	// embeddings read from a memory-mapped vector file instead of the
+	// papers, which are then loaded without them; see Config.VectorsPath
+	Vectors *data.VectorFile `json:"-"`
+
+	// resolved from Config.SimilarityMetric; cosine when unset
+	metric similarityMetric
 }
 
 type SearchConfig struct {
@@ -26,6 +50,137 @@ type SearchConfig struct {
 	RelevanceWeight float64 `json:"relevance_weight"`
 	MaxResults      int     `json:"max_results"`
 	SnippetLength   int     `json:"snippet_length"`
+	MinRelevance    float64 `json:"min_relevance"` // drop results whose rescaled relevance is below this
+
+	// keep papers with no known year (Year == 0) when a year filter is active
+	IncludeUnknownYear bool `json:"include_unknown_year"`
+
+	// cap on results sharing the same first author (0 = no cap)
+	MaxPerAuthor int `json:"max_per_author"`
+
+	// only return papers from this community (-1 = any)
+	Community int `json:"community"`
+
+	// fail instead of warning when ranked papers have no embedding
+	RequireAllEmbeddings bool `json:"require_all_embeddings"`
+
+	// which paper embedding to compare the query with ("abstract" by default)
+	EmbeddingField string `json:"embedding_field"`
+
+	// cosine, dot or euclidean; use what the embedding model was trained for
+	SimilarityMetric string `json:"similarity_metric"`
+
+	// replace global PageRank with a PageRank teleporting to the TopicSeeds
+	// most relevant papers, run for at most TopicIterations iterations;
+	// needs AttachGraph
+	TopicSensitive  bool `json:"topic_sensitive"`
+	TopicSeeds      int  `json:"topic_seeds"`
+	TopicIterations int  `json:"topic_iterations"`
+
+	// reorder the selected results by one of the Sort constants; the
+	// selection itself is always by combined score
+	SortBy         string `json:"sort_by"`
+	SortDescending bool   `json:"sort_descending"`
+
+	// neighboring results whose combined scores differ by less than this
+	// are flagged as tied
+	TieThreshold float64 `json:"tie_threshold"`
+
+	// after the initial ranking, lift the relevance of up to ExpandSize
+	// papers citing, cited by, or coupled or co-cited with the ExpandSeeds
+	// best hits, then rank again; needs AttachGraph
+	ExpandGraph bool `json:"expand_graph"`
+	ExpandSeeds int  `json:"expand_seeds"`
+	ExpandSize  int  `json:"expand_size"`
+
+	// python, hash or http, with the http endpoint and model (see
+	// NewEmbedder); empty values defer to the environment
+	Embedder      string `json:"embedder"`
+	EmbedderURL   string `json:"embedder_url"`
+	EmbedderModel string `json:"embedder_model"`
+
+	// how papers missing from the PageRank scores, e.g. added after the
+	// last 'rank', are scored; one of the MissingPageRank constants
+	MissingPageRank string `json:"missing_pagerank"`
+
+	// relevance feedback: move the query embedding toward the papers marked
+	// relevant and away from those marked irrelevant before ranking; see
+	// Rocchio for the weights
+	Relevant     []string `json:"relevant,omitempty"`
+	Irrelevant   []string `json:"irrelevant,omitempty"`
+	RocchioAlpha float64  `json:"rocchio_alpha"`
+	RocchioBeta  float64  `json:"rocchio_beta"`
+	RocchioGamma float64  `json:"rocchio_gamma"`
+
+	// search for papers like both the query and these example papers: the
+	// query embedding is blended with the examples' stored embeddings, the
+	// examples getting LikeWeight (see BlendQuery)
+	Like       []string `json:"like,omitempty"`
+	LikeWeight float64  `json:"like_weight"`
+
+	// match query terms to snippet words by Porter stem (see data.Stem) in
+	// the lexical checks: highlighting and snippet coverage; relevance
+	// comes from embeddings and is unaffected
+	Stemming bool `json:"stemming"`
+
+	// use the weights as absolute multipliers instead of shares summing to
+	// 1, and combine relevance with the PageRank score normalized to the
+	// highest score, so both components range 0-1 and equal weights mean
+	// equal influence
+	AbsoluteWeights bool `json:"absolute_weights"`
+
+	// return every paper passing the filters instead of the top MaxResults,
+	// without snippets, for counting; see SummarizeResults
+	CountOnly bool `json:"count_only"`
+
+	// low-memory mode: read EmbeddingField from this vector file (see
+	// data.WriteVectorFile) through a memory mapping instead of keeping
+	// every embedding in memory; such an engine is never cached
+	VectorsPath string `json:"vectors_path,omitempty"`
+}
+
+// values of SearchConfig.MissingPageRank
+const (
+	MissingPageRankZero = "zero" // score 0, the lowest possible
+	MissingPageRankMin  = "min"  // the lowest score of any ranked paper
+	MissingPageRankMean = "mean" // the mean score of the ranked papers
+	MissingPageRankSkip = "skip" // never return the paper
+)
+
+// ParseMissingPageRankPolicy validates a MissingPageRank value; "" means
+// zero.
+func ParseMissingPageRankPolicy(s string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(s)); policy {
+	case "", MissingPageRankZero:
+		return MissingPageRankZero, nil
+	case MissingPageRankMin, MissingPageRankMean, MissingPageRankSkip:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown missing PageRank policy %q (want zero, min, mean or skip)", s)
+}
+
+// DefaultTieThreshold is the combined score gap below which neighboring
+// results count as tied.
+const DefaultTieThreshold = 0.001
+
+// values of SearchConfig.SortBy
+const (
+	SortByScore     = "score"
+	SortByYear      = "year"
+	SortByCitations = "citations"
+	SortByRelevance = "relevance"
+	SortByPageRank  = "pagerank"
+)
+
+// ParseSortKey validates a --sort value.
+func ParseSortKey(s string) (string, error) {
+	switch key := strings.ToLower(strings.TrimSpace(s)); key {
+	case "", SortByScore:
+		return SortByScore, nil
+	case SortByYear, SortByCitations, SortByRelevance, SortByPageRank:
+		return key, nil
+	}
+	return "", fmt.Errorf("unknown sort key %q (want score, year, citations, relevance or pagerank)", s)
 }
 
 type SearchResult struct {
@@ -33,7 +188,29 @@ type SearchResult struct {
 	Score          float64    `json:"score"`           // relevence score + pageRank score
 	RelevanceScore float64    `json:"relevance_score"` // sentence similarity score
 	PageRankScore  float64    `json:"pagerank_score"`  // PageRank score
+	Citations      int        `json:"citations"`       // in-corpus citation count
 	Snippet        string     `json:"snippet"`
+
+	// score breakdown, shown by --explain
+	RawSimilarity      float64 `json:"raw_similarity"` // metric similarity before rescaling
+	SimilarityMetric   string  `json:"similarity_metric"`
+	NormalizedPageRank float64 `json:"normalized_pagerank"` // PageRank score / highest PageRank score
+	RelevanceWeight    float64 `json:"relevance_weight"`
+	PageRankWeight     float64 `json:"pagerank_weight"`
+	AbsoluteWeights    bool    `json:"absolute_weights,omitempty"` // PageRank entered the score normalized
+
+	CommunityID    int    `json:"community_id"` // -1 when no communities are loaded
+	CommunityLabel string `json:"community_label,omitempty"`
+
+	// the top hit this paper's relevance was lifted toward by graph
+	// expansion; empty when its relevance is its own
+	ExpandedFrom string `json:"expanded_from,omitempty"`
+
+	// combined score minus that of the next paper by score (0 when no paper
+	// follows), and whether either neighbor is within the tie threshold, so
+	// the order against it is effectively arbitrary
+	ScoreGap float64 `json:"score_gap"`
+	Tied     bool    `json:"tied"`
 }
 
 type SearchQuery struct {
@@ -47,14 +224,63 @@ func DefaultSearchConfig() SearchConfig {
 		RelevanceWeight: 0.7,
 		MaxResults:      20,
 		SnippetLength:   200,
+		Community:       -1,
+		EmbeddingField:  data.DefaultEmbeddingField,
+		SortBy:          SortByScore,
+		SortDescending:  true,
+		TieThreshold:    DefaultTieThreshold,
+		MissingPageRank: MissingPageRankZero,
+		ExpandSeeds:     10,
+		ExpandSize:      20,
+		LikeWeight:      DefaultLikeWeight,
+		RocchioAlpha:    DefaultRocchioAlpha,
+		RocchioBeta:     DefaultRocchioBeta,
+		RocchioGamma:    DefaultRocchioGamma,
+
+		SimilarityMetric: DefaultSimilarityMetric,
+		TopicSeeds:       50,
+		TopicIterations:  20,
 	}
 }
 
+// AttachCommunities lets results report, and be filtered by, the community
+// each paper belongs to.
+func (se *SearchEngine) AttachCommunities(result *graph.CommunityResult) {
+	se.Communities = result.Assignment
+	se.CommunityLabels = result.Labels
+}
+
+// AttachGraph gives the engine the citation graph for topic-sensitive
+// PageRank and graph expansion; expansion needs the adjacency list, so load
+// it with graph.LoadGraph.
+func (se *SearchEngine) AttachGraph(g *graph.Graph) {
+	se.Graph = g
+}
+
 func GetOrCreateEngine(papersPath, pagerankPath, cachePath string, config SearchConfig) (*SearchEngine, error) {
+	// the cache holds every embedding, which low-memory mode avoids
+	if config.VectorsPath != "" {
+		return NewSearchEngine(papersPath, pagerankPath, config)
+	}
+
 	if _, err := os.Stat(cachePath); err == nil {
 		fmt.Printf("Loading pre-built search engine from: %s\n", cachePath)
 		engine, err := LoadSearchEngine(cachePath)
+		if err == nil && engine.Citations == nil {
+			err = fmt.Errorf("cache predates citation counts")
+		}
 		if err == nil {
+			// the cache only stores data; always search with the current config
+			engine.Config = config
+			if engine.metric, err = similarityMetricFor(config.SimilarityMetric); err != nil {
+				return nil, err
+			}
+			if err := engine.checkEmbeddings(); err != nil {
+				return nil, err
+			}
+			if err := engine.checkPageRank(); err != nil {
+				return nil, err
+			}
 			return engine, nil
 		}
 		fmt.Printf("Warning: failed to load cached engine: %v. Rebuilding...\n", err)
@@ -75,50 +301,427 @@ func GetOrCreateEngine(papersPath, pagerankPath, cachePath string, config Search
 }
 
 func NewSearchEngine(papersPath, pagerankPath string, config SearchConfig) (*SearchEngine, error) {
+	metric, err := similarityMetricFor(config.SimilarityMetric)
+	if err != nil {
+		return nil, err
+	}
+
 	fmt.Printf("Loading search data...\n")
 
-	parsedData, err := data.LoadParsedData(papersPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load papers: %v", err)
+	// the two files are independent, so read them concurrently; Wait
+	// returns the first failure
+	var (
+		papers         []data.Paper
+		pagerankResult *graph.PageRankResult
+	)
+	var g errgroup.Group
+	g.Go(func() error {
+		// stream papers one at a time so the raw file and the decoded
+		// papers are never both held in memory
+		err := data.StreamPapers(papersPath, func(paper data.Paper) error {
+			if config.VectorsPath != "" {
+				paper.AbstractEmbedding, paper.Embeddings = nil, nil
+			}
+			papers = append(papers, paper)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load papers: %v", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		pagerankResult, err = graph.LoadPageRankResult(pagerankPath)
+		if err != nil {
+			return fmt.Errorf("failed to load PageRank results: %v", err)
+		}
+		return nil
+	})
+	var vectors *data.VectorFile
+	if config.VectorsPath != "" {
+		g.Go(func() error {
+			var err error
+			vectors, err = openVectors(config.VectorsPath, config.EmbeddingField)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		if vectors != nil {
+			vectors.Close()
+		}
+		return nil, err
 	}
 
-	pagerankResult, err := graph.LoadPageRankResult(pagerankPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load PageRank results: %v", err)
+	fmt.Printf("Loaded %d papers and PageRank scores\n", len(papers))
+	if vectors != nil {
+		fmt.Printf("Mapped %d %d-dimensional embeddings from %s\n", vectors.Len(), vectors.Dim, config.VectorsPath)
 	}
 
-	fmt.Printf("Loaded %d papers and PageRank scores\n", len(parsedData.Papers))
+	citations := make(map[string]int, len(pagerankResult.Rankings))
+	for _, ranking := range pagerankResult.Rankings {
+		citations[ranking.PaperID] = ranking.Citations
+	}
 
 	engine := &SearchEngine{
-		Papers:   parsedData.Papers,
-		PageRank: pagerankResult.Scores,
-		Config:   config,
+		Papers:    papers,
+		PageRank:  pagerankResult.Scores,
+		Citations: citations,
+		Config:    config,
+		Vectors:   vectors,
+		metric:    metric,
+	}
+	if err := engine.checkEmbeddings(); err != nil {
+		return nil, err
+	}
+	if err := engine.checkPageRank(); err != nil {
+		return nil, err
 	}
 
 	fmt.Println("Search engine ready.")
 	return engine, nil
 }
 
+// openVectors maps a vector file and checks it holds the embedding field
+// searched.
+func openVectors(path, field string) (*data.VectorFile, error) {
+	if field == "" {
+		field = data.DefaultEmbeddingField
+	}
+	vectors, err := data.OpenVectorFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if vectors.Field != field {
+		vectors.Close()
+		return nil, fmt.Errorf("%s holds %s embeddings, not %s", path, vectors.Field, field)
+	}
+	return vectors, nil
+}
+
+// embedding returns the paper's embedding for Config.EmbeddingField, from
+// the vector file in low-memory mode.
+func (se *SearchEngine) embedding(paper data.Paper) []float32 {
+	if se.Vectors != nil {
+		return se.Vectors.Vector(paper.ID)
+	}
+	return paper.Embedding(se.Config.EmbeddingField)
+}
+
+// MissingEmbeddings returns, sorted, the ids of papers that search can never
+// return because they have no embedding: papers loaded without one and
+// ranked papers missing from the embeddings file altogether.
+func (se *SearchEngine) MissingEmbeddings() []string {
+	var missing []string
+	loaded := make(map[string]bool, len(se.Papers))
+	for _, paper := range se.Papers {
+		loaded[paper.ID] = true
+		if len(se.embedding(paper)) == 0 {
+			missing = append(missing, paper.ID)
+		}
+	}
+	for paperID := range se.PageRank {
+		if !loaded[paperID] {
+			missing = append(missing, paperID)
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
+
+// checkEmbeddings warns about papers without embeddings, usually because
+// papers.json grew after the embedding script last ran, or fails under
+// RequireAllEmbeddings.
+func (se *SearchEngine) checkEmbeddings() error {
+	if se.Config.RelevanceWeight == 0 {
+		return nil // PageRank-only search does not use embeddings
+	}
+
+	missing := se.MissingEmbeddings()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	examples := missing
+	if len(examples) > 5 {
+		examples = examples[:5]
+	}
+	msg := fmt.Sprintf("%d papers have no embedding and will never be returned (e.g. %s); re-run create_embeddings.py",
+		len(missing), strings.Join(examples, ", "))
+
+	if se.Config.RequireAllEmbeddings {
+		return fmt.Errorf("%s", msg)
+	}
+	fmt.Printf("Warning: %s\n", msg)
+	return nil
+}
+
+// checkPageRank validates Config.MissingPageRank and warns about loaded
+// papers without a PageRank score, usually because papers.json grew after
+// the last 'rank'.
+func (se *SearchEngine) checkPageRank() error {
+	policy, err := ParseMissingPageRankPolicy(se.Config.MissingPageRank)
+	if err != nil {
+		return err
+	}
+	se.Config.MissingPageRank = policy
+	if se.Config.PageRankWeight == 0 {
+		return nil
+	}
+
+	missing := 0
+	for _, paper := range se.Papers {
+		if _, ok := se.PageRank[paper.ID]; !ok {
+			missing++
+		}
+	}
+	if missing > 0 {
+		treatment := fmt.Sprintf("scored as %s", policy)
+		if policy == MissingPageRankSkip {
+			treatment = "left out of the results"
+		}
+		fmt.Printf("Warning: %d papers have no PageRank score (%s); re-run 'acl-ranker rank'\n", missing, treatment)
+	}
+	if overlap := se.PageRankOverlap(); overlap < MinPageRankOverlap {
+		fmt.Printf("Warning: only %.1f%% of paper ids match the PageRank scores, so papers and scores likely come from different runs;\n"+
+			"re-run 'acl-ranker rank' and delete search_engine.cache.json to rebuild the engine\n", overlap*100)
+	}
+	return nil
+}
+
+// below this share of matching ids, papers and PageRank scores are taken
+// to come from different pipeline runs
+const MinPageRankOverlap = 0.9
+
+// PageRankOverlap is the share of ids, among the papers and the PageRank
+// scores together, found in both: 1 when they cover the same papers, 0 when
+// they share none.
+func (se *SearchEngine) PageRankOverlap() float64 {
+	loaded := make(map[string]bool, len(se.Papers))
+	both := 0
+	for _, paper := range se.Papers {
+		if loaded[paper.ID] {
+			continue
+		}
+		loaded[paper.ID] = true
+		if _, ok := se.PageRank[paper.ID]; ok {
+			both++
+		}
+	}
+	union := len(loaded) + len(se.PageRank) - both
+	if union == 0 {
+		return 1
+	}
+	return float64(both) / float64(union)
+}
+
+// missingPageRank returns the score a paper missing from pagerank gets
+// under Config.MissingPageRank, and false when such papers are skipped.
+func (se *SearchEngine) missingPageRank(pagerank map[string]float64) (float64, bool) {
+	switch se.Config.MissingPageRank {
+	case MissingPageRankSkip:
+		return 0, false
+	case MissingPageRankMin:
+		if len(pagerank) == 0 {
+			return 0, true
+		}
+		lowest := math.Inf(1)
+		for _, score := range pagerank {
+			lowest = math.Min(lowest, score)
+		}
+		return lowest, true
+	case MissingPageRankMean:
+		if len(pagerank) == 0 {
+			return 0, true
+		}
+		var sum float64
+		for _, score := range pagerank {
+			sum += score
+		}
+		return sum / float64(len(pagerank)), true
+	}
+	return 0, true
+}
+
+// queryEmbedder returns the engine's Embedder, creating the one named by
+// Config.Embedder on first use. The hash embedder is sized to match the
+// stored embeddings.
+func (se *SearchEngine) queryEmbedder() (Embedder, error) {
+	if se.Embedder != nil {
+		return se.Embedder, nil
+	}
+	dim := 0
+	for _, paper := range se.Papers {
+		if embedding := se.embedding(paper); len(embedding) > 0 {
+			dim = len(embedding)
+			break
+		}
+	}
+	if se.Projection != nil {
+		dim = se.Projection.InputDim
+	}
+	embedder, err := NewEmbedder(EmbedderConfig{
+		Name:  se.Config.Embedder,
+		URL:   se.Config.EmbedderURL,
+		Model: se.Config.EmbedderModel,
+		Dim:   dim,
+	})
+	if err != nil {
+		return nil, err
+	}
+	se.Embedder = embedder
+	return embedder, nil
+}
+
+// Prepare creates the query embedder up front. Search otherwise creates it
+// on first use, which races when several searches share the engine, as
+// under 'serve'.
+func (se *SearchEngine) Prepare() error {
+	if se.Config.RelevanceWeight == 0 {
+		return nil
+	}
+	_, err := se.queryEmbedder()
+	return err
+}
+
 func (se *SearchEngine) Search(queryStr string) ([]SearchResult, error) {
 	query := se.parseQuery(queryStr)
 	fmt.Printf("Searching for: \"%s\"\n", query.Original)
 
-	// 1) get the embedding for the query
-	queryEmbedding, err := getQueryEmbedding(query.Original)
-	if err != nil {
-		return nil, fmt.Errorf("could not get query embedding: %w", err)
+	// 1) get the embedding for the query; with no relevance weight the
+	// ranking is PageRank alone and the embedding model is not needed
+	var queryEmbedding []float32
+	if se.Config.RelevanceWeight > 0 {
+		embedder, err := se.queryEmbedder()
+		if err != nil {
+			return nil, err
+		}
+		queryEmbedding, err = embedder.Embed(context.Background(), query.Original)
+		if err != nil {
+			return nil, fmt.Errorf("could not get query embedding: %w", err)
+		}
+		if se.Projection != nil {
+			if queryEmbedding, err = se.Projection.Apply(queryEmbedding); err != nil {
+				return nil, fmt.Errorf("could not project query embedding: %w", err)
+			}
+		}
+		if len(se.Config.Like) > 0 {
+			if queryEmbedding, err = se.applyLike(queryEmbedding); err != nil {
+				return nil, err
+			}
+		}
+		if len(se.Config.Relevant) > 0 || len(se.Config.Irrelevant) > 0 {
+			if queryEmbedding, err = se.applyFeedback(queryEmbedding); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// 2) score and rank all papers against the query embedding
-	results := se.scoreAndRank(query, queryEmbedding)
+	return se.SearchEmbedding(query, queryEmbedding), nil
+}
 
-	// 3) limit the results
+// SearchEmbedding ranks papers against an already computed query embedding.
+func (se *SearchEngine) SearchEmbedding(query SearchQuery, queryEmbedding []float32) []SearchResult {
+	// 2) score and rank all papers against the query embedding, with
+	// PageRank personalized to the query when asked
+	pagerank := se.PageRank
+	if se.Config.TopicSensitive && queryEmbedding != nil {
+		if topic, err := se.topicPageRank(queryEmbedding); err != nil {
+			fmt.Printf("Warning: topic-sensitive PageRank failed (%v); using global PageRank\n", err)
+		} else {
+			pagerank = topic
+		}
+	}
+	limit := se.rankLimit()
+	results, yearExcluded := se.scoreAndRank(query, queryEmbedding, pagerank, nil, limit)
+	if se.Config.ExpandGraph && queryEmbedding != nil && len(results) > 0 {
+		if expansion, err := se.graphExpansion(results); err != nil {
+			fmt.Printf("Warning: graph expansion failed (%v); using the initial results\n", err)
+		} else {
+			fmt.Printf("Expanding with %d papers close to the top %d hits in the citation graph\n", len(expansion), min(len(results), se.Config.ExpandSeeds))
+			results, yearExcluded = se.scoreAndRank(query, queryEmbedding, pagerank, expansion, limit)
+		}
+	}
+	if len(results) == 0 && yearExcluded > 0 {
+		candidates := "candidate papers"
+		if se.Config.MinRelevance > 0 && queryEmbedding != nil {
+			candidates = fmt.Sprintf("papers with relevance >= %.3f", se.Config.MinRelevance)
+		}
+		fmt.Printf("0 of %d %s were from year %d; try removing the year.\n", yearExcluded, candidates, query.YearFilter)
+	}
+
+	if se.Config.CountOnly {
+		return results
+	}
+
+	// 3) diversify by author, flag near-equal scores, then limit the results
+	if se.Config.MaxPerAuthor > 0 {
+		results = limitPerAuthor(results, se.Config.MaxPerAuthor, se.Config.MaxResults)
+	}
+	markTies(results, se.Config.TieThreshold)
 	if len(results) > se.Config.MaxResults {
 		results = results[:se.Config.MaxResults]
 	}
+	for i := range results {
+		results[i].Snippet = se.createSnippet(results[i].Paper)
+	}
+	SortResults(results, se.Config.SortBy, se.Config.SortDescending)
 
 	fmt.Printf("Returning top %d results\n", len(results))
-	return results, nil
+	return results
+}
+
+// limitPerAuthor walks the ranked results and skips papers whose first author
+// already has maxPerAuthor papers in the selection, so lower-ranked papers by
+// other authors move up. Papers without authors are never skipped.
+func limitPerAuthor(results []SearchResult, maxPerAuthor, maxResults int) []SearchResult {
+	selected := make([]SearchResult, 0, maxResults)
+	perAuthor := make(map[string]int)
+
+	for _, result := range results {
+		if len(selected) >= maxResults {
+			break
+		}
+		if len(result.Paper.Authors) > 0 {
+			author := strings.ToLower(strings.TrimSpace(result.Paper.Authors[0]))
+			if perAuthor[author] >= maxPerAuthor {
+				continue
+			}
+			perAuthor[author]++
+		}
+		selected = append(selected, result)
+	}
+
+	return selected
+}
+
+// markTies fills in the score gaps of results sorted by combined score and
+// flags the results within threshold of a neighbor.
+func markTies(results []SearchResult, threshold float64) {
+	for i := range results {
+		results[i].ScoreGap = 0
+		results[i].Tied = false
+	}
+	for i := 0; i+1 < len(results); i++ {
+		gap := results[i].Score - results[i+1].Score
+		results[i].ScoreGap = gap
+		if gap < threshold {
+			results[i].Tied = true
+			results[i+1].Tied = true
+		}
+	}
+}
+
+// TiedResults counts the results flagged as tied with a neighbor.
+func TiedResults(results []SearchResult) int {
+	tied := 0
+	for _, result := range results {
+		if result.Tied {
+			tied++
+		}
+	}
+	return tied
 }
 
 func (se *SearchEngine) parseQuery(queryStr string) SearchQuery {
@@ -138,46 +741,181 @@ func (se *SearchEngine) parseQuery(queryStr string) SearchQuery {
 	return query
 }
 
-func (se *SearchEngine) scoreAndRank(query SearchQuery, queryEmbedding []float32) []SearchResult {
-	results := make([]SearchResult, 0, len(se.Papers))
+// scoreAndRank scores every paper against the query embedding, taking
+// PageRank scores from pagerank. Papers in expansion have their relevance
+// lifted toward the top hit they are close to in the citation graph, and
+// are kept even without an embedding. It also returns how many papers
+// passed every other filter but were dropped by the query's year filter.
+// Only the best limit results are kept, sorted by combined score, or all of
+// them when limit is 0; snippets are left to the caller.
+func (se *SearchEngine) scoreAndRank(query SearchQuery, queryEmbedding []float32, pagerank map[string]float64, expansion map[string]graphHit, limit int) ([]SearchResult, int) {
+	top := newTopResults(limit)
+	maxPageRank := maxScore(pagerank)
+	missingScore, keepMissing := se.missingPageRank(pagerank)
+
+	metric := se.metric
+	metricName := strings.ToLower(se.Config.SimilarityMetric)
+	if metric.similarity == nil {
+		metric = similarityMetrics[DefaultSimilarityMetric]
+		metricName = DefaultSimilarityMetric
+	}
 
+	yearExcluded := 0
 	for _, paper := range se.Papers {
 
-		if query.YearFilter > 0 && paper.Year != query.YearFilter {
+		// papers outside the year are still scored, to tell a year that
+		// matches nothing apart from a query that matches nothing
+		wrongYear := query.YearFilter > 0 && paper.Year != query.YearFilter &&
+			!(se.Config.IncludeUnknownYear && paper.Year == 0)
+
+		communityID := -1
+		if se.Communities != nil {
+			if id, ok := se.Communities[paper.ID]; ok {
+				communityID = id
+			}
+		}
+		if se.Config.Community >= 0 && communityID != se.Config.Community {
 			continue
 		}
 
-		if len(paper.AbstractEmbedding) == 0 {
-			continue
+		// a nil query embedding means PageRank-only ranking: every paper
+		// qualifies and relevance stays zero
+		var rawSimilarity, relevanceScore float64
+		var expandedFrom string
+		if queryEmbedding != nil {
+			hit, expanded := expansion[paper.ID]
+			paperEmbedding := se.embedding(paper)
+			if len(paperEmbedding) == 0 && !expanded {
+				continue
+			}
+
+			if len(paperEmbedding) > 0 {
+				var err error
+				rawSimilarity, err = metric.similarity(queryEmbedding, paperEmbedding)
+				if err != nil && !expanded {
+					continue
+				}
+				if err == nil {
+					// map the metric's raw similarity to a [0, 1] score
+					relevanceScore = metric.relevance(rawSimilarity)
+				}
+			}
+			if expanded {
+				if lifted := hit.relevance(relevanceScore); lifted > relevanceScore {
+					relevanceScore = lifted
+					expandedFrom = hit.seed
+				}
+			}
+			if relevanceScore < se.Config.MinRelevance {
+				continue
+			}
 		}
 
-		relevanceScore, err := cosineSimilarity(queryEmbedding, paper.AbstractEmbedding)
-		if err != nil {
+		pagerankScore, ranked := pagerank[paper.ID]
+		if !ranked {
+			if !keepMissing {
+				continue
+			}
+			pagerankScore = missingScore
+		}
+
+		// last, so only papers passing every other filter, the relevance
+		// threshold included, are counted
+		if wrongYear {
+			yearExcluded++
 			continue
 		}
+		pagerankComponent := pagerankScore
+		if se.Config.AbsoluteWeights {
+			pagerankComponent = 0
+			if maxPageRank > 0 {
+				pagerankComponent = pagerankScore / maxPageRank
+			}
+		}
+		combinedScore := se.Config.RelevanceWeight*relevanceScore + se.Config.PageRankWeight*pagerankComponent
 
-		// scale cosine similarity from [-1, 1] to [0, 1] score.
-		relevanceScore = (relevanceScore + 1) / 2
-		pagerankScore := se.PageRank[paper.ID]
-		combinedScore := se.Config.RelevanceWeight*relevanceScore + se.Config.PageRankWeight*pagerankScore
+		result := SearchResult{
+			Paper:            paper,
+			Score:            combinedScore,
+			RelevanceScore:   relevanceScore,
+			PageRankScore:    pagerankScore,
+			Citations:        se.Citations[paper.ID],
+			RawSimilarity:    rawSimilarity,
+			SimilarityMetric: metricName,
+			RelevanceWeight:  se.Config.RelevanceWeight,
+			PageRankWeight:   se.Config.PageRankWeight,
+			AbsoluteWeights:  se.Config.AbsoluteWeights,
+			CommunityID:      communityID,
+			ExpandedFrom:     expandedFrom,
+		}
+		if communityID >= 0 {
+			result.CommunityLabel = se.CommunityLabels[communityID]
+		}
+		if maxPageRank > 0 {
+			result.NormalizedPageRank = pagerankScore / maxPageRank
+		}
+		top.add(result)
+	}
 
-		snippet := se.createSnippet(paper)
+	return top.sorted(), yearExcluded
+}
 
-		result := SearchResult{
-			Paper:          paper,
-			Score:          combinedScore,
-			RelevanceScore: relevanceScore,
-			PageRankScore:  pagerankScore,
-			Snippet:        snippet,
+// rankLimit is how many of the best results scoreAndRank has to keep: all
+// of them for counting and for the per-author cap, which may skip any
+// number of them, otherwise one more than MaxResults, for the score gap of
+// the last result, and enough to seed graph expansion.
+func (se *SearchEngine) rankLimit() int {
+	if se.Config.CountOnly || se.Config.MaxPerAuthor > 0 || se.Config.MaxResults <= 0 {
+		return 0
+	}
+	limit := se.Config.MaxResults + 1
+	if se.Config.ExpandGraph {
+		limit = max(limit, se.Config.ExpandSeeds)
+	}
+	return limit
+}
+
+// SortResults reorders results by one of the Sort constants. Ties keep their
+// current order, which is by combined score. Sorting by score descending
+// leaves the results as they are.
+func SortResults(results []SearchResult, by string, descending bool) {
+	var key func(r SearchResult) float64
+	switch by {
+	case SortByYear:
+		key = func(r SearchResult) float64 { return float64(r.Paper.Year) }
+	case SortByCitations:
+		key = func(r SearchResult) float64 { return float64(r.Citations) }
+	case SortByRelevance:
+		key = func(r SearchResult) float64 { return r.RelevanceScore }
+	case SortByPageRank:
+		key = func(r SearchResult) float64 { return r.PageRankScore }
+	default:
+		if descending {
+			return
 		}
-		results = append(results, result)
+		key = func(r SearchResult) float64 { return r.Score }
 	}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
+	sort.SliceStable(results, func(i, j int) bool {
+		if descending {
+			return key(results[i]) > key(results[j])
+		}
+		return key(results[i]) < key(results[j])
 	})
+}
 
-	return results
+func (se *SearchEngine) maxPageRank() float64 {
+	return maxScore(se.PageRank)
+}
+
+func maxScore(scores map[string]float64) float64 {
+	var highest float64
+	for _, score := range scores {
+		if score > highest {
+			highest = score
+		}
+	}
+	return highest
 }
 
 func (se *SearchEngine) createSnippet(paper data.Paper) string {
@@ -195,46 +933,13 @@ func (se *SearchEngine) createSnippet(paper data.Paper) string {
 	return text
 }
 
-func getQueryEmbedding(query string) ([]float32, error) {
-	//run python script in a new process
-	cmd := exec.Command("python", "internal/sentenceEmbeddings/embed_query.py", query)
-
-	output, err := cmd.Output()
-	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("embedding script failed: %s, stderr: %s", err, string(exitError.Stderr))
-		}
-		return nil, fmt.Errorf("failed to run embedding script: %w", err)
-	}
-
-	var embedding []float32
-	if err := json.Unmarshal(output, &embedding); err != nil {
-		return nil, fmt.Errorf("failed to parse embedding from python script: %w", err)
-	}
-
-	return embedding, nil
-}
-
-func cosineSimilarity(a, b []float32) (float64, error) {
-	if len(a) != len(b) {
-		return 0, fmt.Errorf("vectors have different lengths")
-	}
-
-	var dotProduct float64
-	for i := 0; i < len(a); i++ {
-		dotProduct += float64(a[i] * b[i])
-	}
-
-	return dotProduct, nil
-}
-
-func PrintSearchResults(results []SearchResult, query string) {
+func PrintSearchResults(p graph.Printer, results []SearchResult, query string, explain, stemming bool) {
 	fmt.Printf("\nSearch Results for: \"%s\"\n", query)
 	fmt.Printf("Found %d results\n", len(results))
 	fmt.Println("=" + strings.Repeat("=", 80))
 
 	for i, result := range results {
-		fmt.Printf("\n%d. %s (%d)\n", i+1, result.Paper.Title, result.Paper.Year)
+		fmt.Printf("\n%d. %s (%d)\n", i+1, p.StyleTitle(result.Paper.Title), result.Paper.Year)
 
 		if len(result.Paper.Authors) > 0 {
 			authors := result.Paper.Authors
@@ -244,12 +949,21 @@ func PrintSearchResults(results []SearchResult, query string) {
 			fmt.Printf("   Authors: %s\n", strings.Join(authors, ", "))
 		}
 
-		fmt.Printf("   Score: %.4f (Relevance: %.3f, PageRank: %.6f)\n",
-			result.Score, result.RelevanceScore, result.PageRankScore)
+		fmt.Printf("   Score: %s (Relevance: %.3f, PageRank: %s)\n",
+			p.StyleScore(p.Score(result.Score)), result.RelevanceScore, p.Score(result.PageRankScore))
+		if result.CommunityID >= 0 {
+			fmt.Printf("   Community: %d (%s)\n", result.CommunityID, result.CommunityLabel)
+		}
+		if explain {
+			printExplanation(result)
+		}
 
 		if result.Snippet != "" {
 			wrappedSnippet := wordwrap.WrapString(result.Snippet, 80)
 			indentedSnippet := strings.ReplaceAll(wrappedSnippet, "\n", "\n   ")
+			if p.Color {
+				indentedSnippet = highlightTerms(p, indentedSnippet, newQueryMatcher(query, stemming))
+			}
 			fmt.Printf("   Snippet: %s\n", indentedSnippet)
 		}
 		fmt.Printf("   ID: %s\n", result.Paper.ID)
@@ -257,13 +971,63 @@ func PrintSearchResults(results []SearchResult, query string) {
 	fmt.Println("\n" + strings.Repeat("=", 81))
 }
 
+// wordPattern finds the words of a text the way data.Tokenize splits it
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// highlightTerms styles the words of text that match a query term.
+func highlightTerms(p graph.Printer, text string, matcher queryMatcher) string {
+	if len(matcher.terms) == 0 {
+		return text
+	}
+	return wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+		if matcher.matches(word) {
+			return p.StyleMatch(word)
+		}
+		return word
+	})
+}
+
+func printExplanation(result SearchResult) {
+	relevancePart := result.RelevanceWeight * result.RelevanceScore
+	pagerankComponent, pagerankFormat := result.PageRankScore, "%.6e"
+	if result.AbsoluteWeights {
+		pagerankComponent, pagerankFormat = result.NormalizedPageRank, "%.4f"
+	}
+	pagerankPart := result.PageRankWeight * pagerankComponent
+
+	fmt.Printf("   Explain:\n")
+	metric, err := similarityMetricFor(result.SimilarityMetric)
+	if err != nil {
+		metric = similarityMetrics[DefaultSimilarityMetric]
+	}
+	fmt.Printf("     %-20s %.4f\n", result.SimilarityMetric+" similarity:", result.RawSimilarity)
+	if result.ExpandedFrom != "" {
+		fmt.Printf("     relevance (0-1):     %s = %.4f\n", fmt.Sprintf(metric.formula, result.RawSimilarity), metric.relevance(result.RawSimilarity))
+		fmt.Printf("     graph expansion:     lifted to %.4f, toward top hit %s\n", result.RelevanceScore, result.ExpandedFrom)
+	} else {
+		fmt.Printf("     relevance (0-1):     %s = %.4f\n", fmt.Sprintf(metric.formula, result.RawSimilarity), result.RelevanceScore)
+	}
+	fmt.Printf("     PageRank raw:        %.6e\n", result.PageRankScore)
+	fmt.Printf("     PageRank normalized: %.4f (of highest score)\n", result.NormalizedPageRank)
+	fmt.Printf("     weights:             relevance %.3f, PageRank %.3f\n", result.RelevanceWeight, result.PageRankWeight)
+	fmt.Printf("     combined:            %.3f * %.4f + %.3f * "+pagerankFormat+" = %.4f + %.6f = %.4f\n",
+		result.RelevanceWeight, result.RelevanceScore,
+		result.PageRankWeight, pagerankComponent,
+		relevancePart, pagerankPart, result.Score)
+	tie := ""
+	if result.Tied {
+		tie = " (tied with a neighbor; order is arbitrary)"
+	}
+	fmt.Printf("     gap to next:         %.6f%s\n", result.ScoreGap, tie)
+}
+
 func SaveSearchEngine(engine *SearchEngine, outputPath string) error {
 	jsonData, err := json.MarshalIndent(engine, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal search engine: %v", err)
 	}
 
-	if err := os.WriteFile(outputPath, jsonData, 0644); err != nil {
+	if err := data.AtomicWriteFile(outputPath, jsonData); err != nil {
 		return fmt.Errorf("failed to write search engine file: %v", err)
 	}
 
@@ -0,0 +1,613 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+func TestSearchBreaksTiesByPaperID(t *testing.T) {
+	same := []float32{1, 0}
+	papers := []testPaper{
+		{ID: "d", Embedding: same, PageRank: 0.1},
+		{ID: "b", Embedding: same, PageRank: 0.1},
+		{ID: "top", Embedding: same, PageRank: 0.5},
+		{ID: "a", Embedding: same, PageRank: 0.1},
+		{ID: "c", Embedding: same, PageRank: 0.1},
+	}
+	se := testEngine(t, papers, nil)
+
+	first := fmt.Sprint(resultIDs(se.SearchEmbedding(SearchQuery{}, same)))
+	if first != "[top a b c d]" {
+		t.Errorf("results %s, want [top a b c d]", first)
+	}
+
+	// the same papers loaded in another order rank the same
+	for i, j := 0, len(papers)-1; i < j; i, j = i+1, j-1 {
+		papers[i], papers[j] = papers[j], papers[i]
+	}
+	se = testEngine(t, papers, nil)
+	if again := fmt.Sprint(resultIDs(se.SearchEmbedding(SearchQuery{}, same))); again != first {
+		t.Errorf("reordered papers ranked %s, first run %s", again, first)
+	}
+}
+
+func TestYearExcludedCountsOnlyRelevantPapers(t *testing.T) {
+	query := []float32{1, 0}
+	papers := []testPaper{
+		{ID: "relevant", Year: 2019, Embedding: []float32{1, 0}, PageRank: 0.1},
+		{ID: "close", Year: 2020, Embedding: []float32{1, 0.2}, PageRank: 0.1},
+		{ID: "unrelated", Year: 2019, Embedding: []float32{-1, 0}, PageRank: 0.1},
+		{ID: "orthogonal", Year: 2020, Embedding: []float32{0, 1}, PageRank: 0.1},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) { c.MinRelevance = 0.9 })
+
+	results, yearExcluded := se.scoreAndRank(SearchQuery{YearFilter: 2021}, query, se.PageRank, nil, 10)
+	if len(results) != 0 {
+		t.Errorf("results %v for a year with no papers", resultIDs(results))
+	}
+	if yearExcluded != 2 {
+		t.Errorf("%d papers counted as dropped by the year, want the 2 above the relevance threshold", yearExcluded)
+	}
+
+	// papers dropped for a missing PageRank score are not counted either
+	se.Config.MissingPageRank = MissingPageRankSkip
+	delete(se.PageRank, "close")
+	if _, yearExcluded := se.scoreAndRank(SearchQuery{YearFilter: 2021}, query, se.PageRank, nil, 10); yearExcluded != 1 {
+		t.Errorf("%d papers counted as dropped by the year, want 1", yearExcluded)
+	}
+}
+
+func TestIncludeUnknownYear(t *testing.T) {
+	query := []float32{1, 0}
+	papers := []testPaper{
+		{ID: "in-year", Year: 2019, Embedding: []float32{1, 0}, PageRank: 0.1},
+		{ID: "other-year", Year: 2020, Embedding: []float32{1, 0}, PageRank: 0.2},
+		{ID: "unknown-year", Embedding: []float32{1, 0}, PageRank: 0.3},
+	}
+	for _, include := range []bool{false, true} {
+		se := testEngine(t, papers, func(c *SearchConfig) { c.IncludeUnknownYear = include })
+		got := fmt.Sprint(resultIDs(se.SearchEmbedding(SearchQuery{YearFilter: 2019}, query)))
+		want := "[in-year]"
+		if include {
+			want = "[unknown-year in-year]"
+		}
+		if got != want {
+			t.Errorf("IncludeUnknownYear %v: results %s, want %s", include, got, want)
+		}
+	}
+
+	// without a year filter, unknown-year papers are always searched
+	se := testEngine(t, papers, nil)
+	if got := len(se.SearchEmbedding(SearchQuery{}, query)); got != 3 {
+		t.Errorf("%d results without a year filter, want 3", got)
+	}
+}
+
+func TestMaxPerAuthor(t *testing.T) {
+	query := []float32{1, 0}
+	papers := []testPaper{
+		{ID: "ada-1", Authors: []string{"Ada Lovelace"}, Embedding: []float32{1, 0}, PageRank: 0.5},
+		{ID: "ada-2", Authors: []string{"ada lovelace "}, Embedding: []float32{1, 0}, PageRank: 0.4},
+		{ID: "ada-3", Authors: []string{"Ada Lovelace", "Alan Turing"}, Embedding: []float32{1, 0}, PageRank: 0.3},
+		{ID: "alan", Authors: []string{"Alan Turing", "Ada Lovelace"}, Embedding: []float32{1, 0}, PageRank: 0.2},
+		{ID: "anonymous", Embedding: []float32{1, 0}, PageRank: 0.1},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) { c.MaxPerAuthor = 1 })
+
+	// only the first author counts, compared case-insensitively
+	got := fmt.Sprint(resultIDs(se.SearchEmbedding(SearchQuery{}, query)))
+	if got != "[ada-1 alan anonymous]" {
+		t.Errorf("results %s, want [ada-1 alan anonymous]", got)
+	}
+
+	se.Config.MaxResults = 2
+	if got := fmt.Sprint(resultIDs(se.SearchEmbedding(SearchQuery{}, query))); got != "[ada-1 alan]" {
+		t.Errorf("results %s with 2 results asked for, want [ada-1 alan]", got)
+	}
+}
+
+// unusedEmbedder fails the test if a query is ever embedded.
+type unusedEmbedder struct{ t *testing.T }
+
+func (e unusedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.t.Errorf("embedded %q with no relevance weight", text)
+	return nil, errors.New("unexpected embedding")
+}
+
+func (e unusedEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.t.Errorf("embedded %q with no relevance weight", texts)
+	return nil, errors.New("unexpected embedding")
+}
+
+func TestPageRankOnlySearchNeverEmbeds(t *testing.T) {
+	papers := []testPaper{
+		{ID: "low", Embedding: []float32{1, 0}, PageRank: 0.1},
+		{ID: "high", Embedding: []float32{0, 1}, PageRank: 0.5},
+		{ID: "unembedded", PageRank: 0.3},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) {
+		c.PageRankWeight = 1
+		c.RelevanceWeight = 0
+	})
+	se.Embedder = unusedEmbedder{t}
+
+	if err := se.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	results, err := se.Search("machine translation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// papers without embeddings are ranked too
+	if got := fmt.Sprint(resultIDs(results)); got != "[high unembedded low]" {
+		t.Errorf("results %s, want [high unembedded low]", got)
+	}
+}
+
+func TestSearchUsesEmbeddingField(t *testing.T) {
+	query := []float32{1, 0}
+	papers := []testPaper{
+		{ID: "abstract-match", Embedding: []float32{1, 0}, PageRank: 0.1},
+		{ID: "title-match", Embedding: []float32{0, 1}, PageRank: 0.1},
+		{ID: "no-title", Embedding: []float32{1, 0}, PageRank: 0.1},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) { c.EmbeddingField = "title" })
+	se.Papers[0].Embeddings = map[string][]float32{"title": {0, 1}}
+	se.Papers[1].Embeddings = map[string][]float32{"title": {1, 0}}
+
+	// papers without a title embedding are skipped, not matched on their
+	// abstract
+	if got := fmt.Sprint(resultIDs(se.SearchEmbedding(SearchQuery{}, query))); got != "[title-match abstract-match]" {
+		t.Errorf("title search results %s, want [title-match abstract-match]", got)
+	}
+	se.Config.EmbeddingField = ""
+	if got := fmt.Sprint(resultIDs(se.SearchEmbedding(SearchQuery{}, query))); got != "[abstract-match no-title title-match]" {
+		t.Errorf("abstract search results %s, want [abstract-match no-title title-match]", got)
+	}
+}
+
+func TestMappedVectorsSearchLikeInMemory(t *testing.T) {
+	dir := t.TempDir()
+	built := testEngine(t, randomPapers(200, 8, 4), nil)
+	papersPath := filepath.Join(dir, "papers_with_embeddings.json")
+	pagerankPath := filepath.Join(dir, "pagerank.json")
+	vectorsPath := filepath.Join(dir, "embeddings.abstract.vec")
+	if err := data.SaveParsedData(&data.ParsedData{Papers: built.Papers}, papersPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.SavePageRankResult(&graph.PageRankResult{Scores: built.PageRank}, pagerankPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := data.WriteVectorFile(papersPath, vectorsPath, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultSearchConfig()
+	config.MaxResults = 20
+	inMemory, err := NewSearchEngine(papersPath, pagerankPath, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.VectorsPath = vectorsPath
+	mapped, err := NewSearchEngine(papersPath, pagerankPath, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Vectors.Close()
+	if mapped.Papers[0].AbstractEmbedding != nil {
+		t.Fatal("papers loaded with their embeddings next to a vector file")
+	}
+
+	for _, query := range [][]float32{{1, 0, 0, 0, 0, 0, 0, 0}, {1, -1, 1, 0, 1, 0, -1, 1}} {
+		want := inMemory.SearchEmbedding(SearchQuery{}, query)
+		got := mapped.SearchEmbedding(SearchQuery{}, query)
+		if !slices.Equal(resultIDs(got), resultIDs(want)) {
+			t.Errorf("query %v: mapped results %v, in memory %v", query, resultIDs(got), resultIDs(want))
+			continue
+		}
+		for i := range got {
+			if got[i].Score != want[i].Score {
+				t.Errorf("query %v: %s scored %v mapped, %v in memory", query, got[i].Paper.ID, got[i].Score, want[i].Score)
+			}
+		}
+	}
+}
+
+func TestMinRelevance(t *testing.T) {
+	query := []float32{1, 0}
+	papers := []testPaper{
+		{ID: "same", Embedding: []float32{1, 0}, PageRank: 0.1},
+		{ID: "close", Embedding: []float32{1, 0.5}, PageRank: 0.2},
+		{ID: "orthogonal", Embedding: []float32{0, 1}, PageRank: 0.3},
+		{ID: "opposite", Embedding: []float32{-1, 0}, PageRank: 0.4},
+	}
+	tests := []struct {
+		minRelevance float64
+		want         []string
+	}{
+		// cosine relevance is (1 + cos) / 2, so every paper clears 0
+		{0, []string{"same", "close", "orthogonal", "opposite"}},
+		{0.9, []string{"same", "close"}},
+		// nothing is more relevant than the query itself
+		{1.01, nil},
+	}
+	for _, tt := range tests {
+		se := testEngine(t, papers, func(c *SearchConfig) { c.MinRelevance = tt.minRelevance })
+		got := resultIDs(se.SearchEmbedding(SearchQuery{}, query))
+		slices.Sort(got)
+		want := slices.Clone(tt.want)
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Errorf("MinRelevance %v kept %v, want %v", tt.minRelevance, got, want)
+		}
+	}
+}
+
+func TestSortResults(t *testing.T) {
+	// in combined score order, as search returns them
+	results := []SearchResult{
+		{Paper: data.Paper{ID: "a", Year: 2010}, Score: 0.9, Citations: 3, RelevanceScore: 0.5, PageRankScore: 0.4},
+		{Paper: data.Paper{ID: "b", Year: 2005}, Score: 0.8, Citations: 10, RelevanceScore: 0.7, PageRankScore: 0.1},
+		{Paper: data.Paper{ID: "c", Year: 2010}, Score: 0.7, Citations: 1, RelevanceScore: 0.6, PageRankScore: 0.1},
+		{Paper: data.Paper{ID: "d", Year: 2001}, Score: 0.6, Citations: 10, RelevanceScore: 0.2, PageRankScore: 0.9},
+	}
+	tests := []struct {
+		by         string
+		descending bool
+		want       []string
+	}{
+		{SortByScore, true, []string{"a", "b", "c", "d"}},
+		{SortByScore, false, []string{"d", "c", "b", "a"}},
+		// ties keep their order by score
+		{SortByYear, true, []string{"a", "c", "b", "d"}},
+		{SortByYear, false, []string{"d", "b", "a", "c"}},
+		{SortByCitations, true, []string{"b", "d", "a", "c"}},
+		{SortByCitations, false, []string{"c", "a", "b", "d"}},
+		{SortByRelevance, true, []string{"b", "c", "a", "d"}},
+		{SortByPageRank, true, []string{"d", "a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		sorted := slices.Clone(results)
+		SortResults(sorted, tt.by, tt.descending)
+		if got := resultIDs(sorted); !slices.Equal(got, tt.want) {
+			t.Errorf("sorted by %s (descending %v): %v, want %v", tt.by, tt.descending, got, tt.want)
+		}
+	}
+}
+
+func TestSearchSortsTheSelectedResults(t *testing.T) {
+	papers := []testPaper{
+		{ID: "new", Year: 2020, Embedding: []float32{1, 0}},
+		{ID: "old", Year: 1990, Embedding: []float32{1, 0.2}},
+		{ID: "oldest", Year: 1980, Embedding: []float32{0, 1}},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) {
+		c.MaxResults = 2
+		c.SortBy = SortByYear
+		c.SortDescending = false
+	})
+
+	// the two most relevant papers, oldest first; the oldest paper overall
+	// is not among them
+	got := resultIDs(se.SearchEmbedding(SearchQuery{}, []float32{1, 0}))
+	if want := []string{"old", "new"}; !slices.Equal(got, want) {
+		t.Errorf("results %v, want %v", got, want)
+	}
+}
+
+func TestMarkTies(t *testing.T) {
+	tests := []struct {
+		scores []float64
+		tied   []bool
+	}{
+		{[]float64{0.9, 0.8, 0.7}, []bool{false, false, false}},
+		{[]float64{0.9, 0.8995, 0.7}, []bool{true, true, false}},
+		{[]float64{0.9, 0.8, 0.7995}, []bool{false, true, true}},
+		// a chain of near-equal scores is tied throughout
+		{[]float64{0.9, 0.8995, 0.899, 0.5}, []bool{true, true, true, false}},
+		{[]float64{0.5}, []bool{false}},
+	}
+	for _, tt := range tests {
+		results := make([]SearchResult, len(tt.scores))
+		for i, score := range tt.scores {
+			results[i].Score = score
+		}
+		markTies(results, DefaultTieThreshold)
+		wantTied := 0
+		for i, result := range results {
+			wantGap := 0.0
+			if i+1 < len(results) {
+				wantGap = tt.scores[i] - tt.scores[i+1]
+			}
+			if result.Tied != tt.tied[i] || result.ScoreGap != wantGap {
+				t.Errorf("scores %v: result %d tied %v with gap %v, want %v with gap %v",
+					tt.scores, i, result.Tied, result.ScoreGap, tt.tied[i], wantGap)
+			}
+			if tt.tied[i] {
+				wantTied++
+			}
+		}
+		if got := TiedResults(results); got != wantTied {
+			t.Errorf("scores %v: %d tied results, want %d", tt.scores, got, wantTied)
+		}
+	}
+}
+
+func TestSearchFlagsNearIdenticalScores(t *testing.T) {
+	papers := []testPaper{
+		{ID: "a", Embedding: []float32{1, 0}},
+		{ID: "b", Embedding: []float32{1, 0.001}},
+		{ID: "c", Embedding: []float32{1, 1}},
+		{ID: "d", Embedding: []float32{0, 1}},
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) { c.MaxResults = 3 })
+
+	results := se.SearchEmbedding(SearchQuery{}, []float32{1, 0})
+	if got := resultIDs(results); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("results %v, want [a b c]", got)
+	}
+	var tied []bool
+	for _, result := range results {
+		tied = append(tied, result.Tied)
+	}
+	if want := []bool{true, true, false}; !slices.Equal(tied, want) {
+		t.Errorf("tied %v, want %v", tied, want)
+	}
+	if gap := results[0].ScoreGap; gap >= DefaultTieThreshold {
+		t.Errorf("gap between a and b %v, want below %v", gap, DefaultTieThreshold)
+	}
+	// the last result's gap is to the first paper past the limit
+	if gap := results[2].ScoreGap; gap <= 0 {
+		t.Errorf("gap from c to d %v, want positive", gap)
+	}
+}
+
+func TestMissingPageRankPolicies(t *testing.T) {
+	papers := []testPaper{
+		{ID: "low", PageRank: 0.2},
+		{ID: "mid", PageRank: 0.4},
+		{ID: "high", PageRank: 0.9},
+		{ID: "new"},
+	}
+	tests := []struct {
+		policy    string
+		want      []string
+		wantScore float64 // the PageRank score new is given
+	}{
+		{MissingPageRankZero, []string{"high", "mid", "low", "new"}, 0},
+		{MissingPageRankMin, nil, 0.2},
+		{MissingPageRankMean, []string{"high", "new", "mid", "low"}, 0.5},
+		{MissingPageRankSkip, []string{"high", "mid", "low"}, 0},
+	}
+	for _, tt := range tests {
+		se := testEngine(t, papers, func(c *SearchConfig) {
+			c.PageRankWeight = 1
+			c.RelevanceWeight = 0
+			c.MissingPageRank = tt.policy
+		})
+		delete(se.PageRank, "new")
+		if err := se.checkPageRank(); err != nil {
+			t.Fatalf("%s: %v", tt.policy, err)
+		}
+
+		results := se.SearchEmbedding(SearchQuery{}, nil)
+		got := resultIDs(results)
+		// min ties new with low, so only its score is checked
+		if tt.want != nil && !slices.Equal(got, tt.want) {
+			t.Errorf("%s: results %v, want %v", tt.policy, got, tt.want)
+		}
+		for _, result := range results {
+			if result.Paper.ID == "new" && math.Abs(result.PageRankScore-tt.wantScore) > 1e-12 {
+				t.Errorf("%s: new scored %v, want %v", tt.policy, result.PageRankScore, tt.wantScore)
+			}
+		}
+		if tt.policy != MissingPageRankSkip && !slices.Contains(got, "new") {
+			t.Errorf("%s: new left out of %v", tt.policy, got)
+		}
+	}
+
+	se := testEngine(t, papers, func(c *SearchConfig) { c.MissingPageRank = "median" })
+	if err := se.checkPageRank(); err == nil {
+		t.Error("unknown missing-PageRank policy accepted")
+	}
+}
+
+func TestAbsoluteWeightsScaleTheirComponent(t *testing.T) {
+	query := []float32{1, 0}
+	papers := []testPaper{
+		{ID: "close", Embedding: []float32{1, 0.2}, PageRank: 0.02},
+		{ID: "cited", Embedding: []float32{0.3, 1}, PageRank: 0.08},
+		{ID: "both", Embedding: []float32{1, 0.5}, PageRank: 0.05},
+	}
+	// each paper's score split into its relevance and PageRank parts
+	components := func(relevanceWeight, pagerankWeight float64) map[string][2]float64 {
+		se := testEngine(t, papers, func(c *SearchConfig) {
+			c.AbsoluteWeights = true
+			c.RelevanceWeight = relevanceWeight
+			c.PageRankWeight = pagerankWeight
+		})
+		parts := make(map[string][2]float64)
+		for _, result := range se.SearchEmbedding(SearchQuery{}, query) {
+			relevance := relevanceWeight * result.RelevanceScore
+			pagerank := pagerankWeight * result.NormalizedPageRank
+			if math.Abs(relevance+pagerank-result.Score) > 1e-9 {
+				t.Errorf("weights %v/%v: %s scored %v, not %v + %v",
+					relevanceWeight, pagerankWeight, result.Paper.ID, result.Score, relevance, pagerank)
+			}
+			parts[result.Paper.ID] = [2]float64{relevance, pagerank}
+		}
+		return parts
+	}
+
+	base := components(0.6, 0.7)
+	if len(base) != len(papers) {
+		t.Fatalf("%d results for %d papers", len(base), len(papers))
+	}
+	for _, tt := range []struct {
+		name    string
+		doubled map[string][2]float64
+		scale   [2]float64
+	}{
+		{"relevance", components(1.2, 0.7), [2]float64{2, 1}},
+		{"PageRank", components(0.6, 1.4), [2]float64{1, 2}},
+	} {
+		for id, parts := range base {
+			for i, part := range parts {
+				if got, want := tt.doubled[id][i], tt.scale[i]*part; math.Abs(got-want) > 1e-9 {
+					t.Errorf("doubled %s weight: %s component %d is %v, want %v", tt.name, id, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCountOnlyMatchesTheFilteredResults(t *testing.T) {
+	query := []float32{1, 0}
+	var papers []testPaper
+	for i := 0; i < 30; i++ {
+		angle := float64(i) * math.Pi / 60
+		papers = append(papers, testPaper{
+			ID:        fmt.Sprintf("p%02d", i),
+			Embedding: []float32{float32(math.Cos(angle)), float32(math.Sin(angle))},
+			PageRank:  0.01 * float64(i%7),
+		})
+	}
+	for _, minRelevance := range []float64{0, 0.8, 0.95, 1.1} {
+		se := testEngine(t, papers, func(c *SearchConfig) {
+			c.MinRelevance = minRelevance
+			c.MaxResults = len(papers)
+		})
+		want := se.SearchEmbedding(SearchQuery{}, query)
+
+		// the count is not capped by MaxResults
+		se = testEngine(t, papers, func(c *SearchConfig) {
+			c.MinRelevance = minRelevance
+			c.MaxResults = 5
+			c.CountOnly = true
+		})
+		counted := se.SearchEmbedding(SearchQuery{}, query)
+		if len(counted) != len(want) {
+			t.Errorf("min relevance %v: counted %d papers, search returned %d", minRelevance, len(counted), len(want))
+		}
+		for _, result := range counted {
+			if result.Snippet != "" {
+				t.Errorf("min relevance %v: snippet made for %s in a count", minRelevance, result.Paper.ID)
+				break
+			}
+		}
+
+		summary := SummarizeResults(counted)
+		if summary.Count != len(want) {
+			t.Errorf("min relevance %v: summary counts %d, want %d", minRelevance, summary.Count, len(want))
+		}
+		if len(want) > 0 && (summary.MinRelevance < minRelevance || summary.MaxScore != want[0].Score) {
+			t.Errorf("min relevance %v: summary %+v, best score %v", minRelevance, summary, want[0].Score)
+		}
+	}
+}
+
+func TestNewSearchEngineLoadsBothFiles(t *testing.T) {
+	dir := t.TempDir()
+	built := testEngine(t, []testPaper{
+		{ID: "a", Embedding: []float32{1, 0}, PageRank: 0.5},
+		{ID: "b", Embedding: []float32{0, 1}, PageRank: 0.3},
+		{ID: "c", Embedding: []float32{1, 1}, PageRank: 0.2},
+	}, nil)
+	papersPath := filepath.Join(dir, "papers_with_embeddings.json")
+	pagerankPath := filepath.Join(dir, "pagerank.json")
+	if err := data.SaveParsedData(&data.ParsedData{Papers: built.Papers}, papersPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	rankings := []graph.PaperScore{{PaperID: "a", Score: 0.5, Citations: 2}, {PaperID: "b", Score: 0.3, Citations: 1}}
+	if err := graph.SavePageRankResult(&graph.PageRankResult{Scores: built.PageRank, Rankings: rankings}, pagerankPath, data.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	se, err := NewSearchEngine(papersPath, pagerankPath, DefaultSearchConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(se.SearchEmbedding(SearchQuery{}, []float32{1, 0})); len(se.Papers) != 3 || ids[0] != "a" {
+		t.Errorf("loaded %d papers ranking %v, want 3 with a first", len(se.Papers), ids)
+	}
+	if se.PageRank["b"] != 0.3 || se.Citations["a"] != 2 {
+		t.Errorf("loaded PageRank %v and citations %v", se.PageRank, se.Citations)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte(`{"papers": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.json")
+	tests := []struct {
+		name, papers, pagerank string
+		want                   string
+	}{
+		{"missing papers", missing, pagerankPath, "failed to load papers"},
+		{"corrupt papers", corrupt, pagerankPath, "failed to load papers"},
+		{"missing PageRank", papersPath, missing, "failed to load PageRank results"},
+		{"corrupt PageRank", papersPath, corrupt, "failed to load PageRank results"},
+		// either failure may come first
+		{"both missing", missing, missing, "failed to load"},
+	}
+	for _, tt := range tests {
+		se, err := NewSearchEngine(tt.papers, tt.pagerank, DefaultSearchConfig())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want one containing %q", tt.name, err, tt.want)
+		}
+		if se != nil {
+			t.Errorf("%s: engine returned along with the error", tt.name)
+		}
+	}
+}
+
+func TestMismatchedPageRankIDsWarn(t *testing.T) {
+	papers := make([]testPaper, 10)
+	for i := range papers {
+		papers[i] = testPaper{ID: fmt.Sprintf("p%d", i), Embedding: []float32{1, 0}, PageRank: 0.1}
+	}
+	const warning = "paper ids match the PageRank scores"
+	tests := []struct {
+		name    string
+		rename  int // PageRank ids replaced by ids of no loaded paper
+		overlap float64
+		warn    bool
+	}{
+		{"same ids", 0, 1, false},
+		{"one renamed", 1, 9.0 / 11, true},
+		{"from another run", 10, 0, true},
+	}
+	for _, tt := range tests {
+		se := testEngine(t, papers, nil)
+		for i := 0; i < tt.rename; i++ {
+			id := fmt.Sprintf("p%d", i)
+			se.PageRank["other-"+id] = se.PageRank[id]
+			delete(se.PageRank, id)
+		}
+		if overlap := se.PageRankOverlap(); math.Abs(overlap-tt.overlap) > 1e-12 {
+			t.Errorf("%s: overlap %v, want %v", tt.name, overlap, tt.overlap)
+		}
+
+		var err error
+		out := captureStdout(t, func() { err = se.checkPageRank() })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if warned := strings.Contains(out, warning); warned != tt.warn {
+			t.Errorf("%s: warned %v, want %v:\n%s", tt.name, warned, tt.warn, out)
+		}
+		if tt.warn && !strings.Contains(out, "delete search_engine.cache.json") {
+			t.Errorf("%s: warning does not suggest rebuilding the engine:\n%s", tt.name, out)
+		}
+	}
+}
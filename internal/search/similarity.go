@@ -0,0 +1,106 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// a way of comparing a query embedding with a paper embedding
+type similarityMetric struct {
+	// raw similarity; higher is more similar
+	similarity func(a, b []float32) (float64, error)
+	// maps a raw similarity to a relevance score in [0, 1]
+	relevance func(raw float64) float64
+	// the relevance mapping for --explain, with a %.4f verb for the raw value
+	formula string
+}
+
+const DefaultSimilarityMetric = "cosine"
+
+var similarityMetrics = map[string]similarityMetric{
+	"cosine": {
+		similarity: cosineSimilarity,
+		relevance:  func(raw float64) float64 { return (raw + 1) / 2 },
+		formula:    "(%.4f + 1) / 2",
+	},
+	// equal to cosine for normalized embeddings; clamped otherwise
+	"dot": {
+		similarity: dotProduct,
+		relevance:  func(raw float64) float64 { return (math.Max(-1, math.Min(1, raw)) + 1) / 2 },
+		formula:    "(clamp(%.4f, -1, 1) + 1) / 2",
+	},
+	// negative L2 distance, so 0 is identical
+	"euclidean": {
+		similarity: negativeEuclidean,
+		relevance:  func(raw float64) float64 { return 1 / (1 - raw) },
+		formula:    "1 / (1 - (%.4f))",
+	},
+}
+
+func similarityMetricFor(name string) (similarityMetric, error) {
+	if name == "" {
+		name = DefaultSimilarityMetric
+	}
+	metric, ok := similarityMetrics[strings.ToLower(name)]
+	if !ok {
+		return metric, fmt.Errorf("unknown similarity metric %q (expected %s)", name, strings.Join(SimilarityMetrics(), ", "))
+	}
+	return metric, nil
+}
+
+// SimilarityMetrics lists the supported metric names.
+func SimilarityMetrics() []string {
+	names := make([]string, 0, len(similarityMetrics))
+	for name := range similarityMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cosineSimilarity(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectors have different lengths")
+	}
+
+	var dot, normA, normB float64
+	for i := 0; i < len(a); i++ {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+
+	return dot / math.Sqrt(normA*normB), nil
+}
+
+func dotProduct(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectors have different lengths")
+	}
+
+	var dot float64
+	for i := 0; i < len(a); i++ {
+		dot += float64(a[i]) * float64(b[i])
+	}
+
+	return dot, nil
+}
+
+func negativeEuclidean(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectors have different lengths")
+	}
+
+	var sum float64
+	for i := 0; i < len(a); i++ {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+
+	return -math.Sqrt(sum), nil
+}
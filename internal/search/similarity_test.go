@@ -0,0 +1,66 @@
+package search
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarityMetricsOnKnownVectors(t *testing.T) {
+	a := []float32{1, 0}
+	b := []float32{0, 2}
+	c := []float32{3, 4}
+	tests := []struct {
+		metric        string
+		x, y          []float32
+		raw, relevant float64 // float32 inputs, so compared to 1e-6
+	}{
+		{"cosine", a, a, 1, 1},
+		{"cosine", a, b, 0, 0.5},
+		{"cosine", a, []float32{-2, 0}, -1, 0},
+		{"cosine", a, c, 0.6, 0.8},
+		{"cosine", a, []float32{0, 0}, 0, 0.5}, // zero vector
+		{"dot", a, c, 3, 1},                    // clamped to 1
+		{"dot", []float32{0.6, 0.8}, a, 0.6, 0.8},
+		{"dot", []float32{-1, 0}, c, -3, 0},
+		{"euclidean", a, a, 0, 1},
+		{"euclidean", []float32{0, 0}, c, -5, 1.0 / 6},
+		{"euclidean", a, b, -math.Sqrt(5), 1 / (1 + math.Sqrt(5))},
+	}
+	for _, tt := range tests {
+		metric, err := similarityMetricFor(tt.metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := metric.similarity(tt.x, tt.y)
+		if err != nil {
+			t.Fatalf("%s(%v, %v): %v", tt.metric, tt.x, tt.y, err)
+		}
+		if math.Abs(raw-tt.raw) > 1e-6 {
+			t.Errorf("%s(%v, %v) = %g, want %g", tt.metric, tt.x, tt.y, raw, tt.raw)
+		}
+		if got := metric.relevance(raw); math.Abs(got-tt.relevant) > 1e-6 {
+			t.Errorf("%s relevance of %g = %g, want %g", tt.metric, raw, got, tt.relevant)
+		}
+	}
+}
+
+func TestSimilarityMetricsRejectMismatchedLengths(t *testing.T) {
+	for _, name := range SimilarityMetrics() {
+		metric, _ := similarityMetricFor(name)
+		if _, err := metric.similarity([]float32{1, 2}, []float32{1}); err == nil {
+			t.Errorf("%s accepted vectors of different lengths", name)
+		}
+	}
+}
+
+func TestSimilarityMetricFor(t *testing.T) {
+	if _, err := similarityMetricFor(""); err != nil {
+		t.Errorf("empty name: %v, want the default metric", err)
+	}
+	if _, err := similarityMetricFor("Cosine"); err != nil {
+		t.Errorf("names are case-insensitive: %v", err)
+	}
+	if _, err := similarityMetricFor("manhattan"); err == nil {
+		t.Error("unknown metric accepted")
+	}
+}
@@ -0,0 +1,49 @@
+package search
+
+import (
+	"fmt"
+	"math"
+)
+
+// how many papers qualified for a query and how their scores spread
+type ResultSummary struct {
+	Count         int     `json:"count"`
+	MinRelevance  float64 `json:"min_relevance"`
+	MeanRelevance float64 `json:"mean_relevance"`
+	MaxRelevance  float64 `json:"max_relevance"`
+	MinScore      float64 `json:"min_score"`
+	MeanScore     float64 `json:"mean_score"`
+	MaxScore      float64 `json:"max_score"`
+}
+
+// SummarizeResults counts the results and the range and mean of their
+// relevance and combined scores; the zero summary when there are none.
+func SummarizeResults(results []SearchResult) ResultSummary {
+	summary := ResultSummary{Count: len(results)}
+	if len(results) == 0 {
+		return summary
+	}
+
+	summary.MinRelevance, summary.MinScore = math.Inf(1), math.Inf(1)
+	summary.MaxRelevance, summary.MaxScore = math.Inf(-1), math.Inf(-1)
+	for _, result := range results {
+		summary.MinRelevance = math.Min(summary.MinRelevance, result.RelevanceScore)
+		summary.MaxRelevance = math.Max(summary.MaxRelevance, result.RelevanceScore)
+		summary.MeanRelevance += result.RelevanceScore
+		summary.MinScore = math.Min(summary.MinScore, result.Score)
+		summary.MaxScore = math.Max(summary.MaxScore, result.Score)
+		summary.MeanScore += result.Score
+	}
+	summary.MeanRelevance /= float64(len(results))
+	summary.MeanScore /= float64(len(results))
+	return summary
+}
+
+func PrintResultSummary(summary ResultSummary, query string) {
+	fmt.Printf("\nPapers matching \"%s\": %d\n", query, summary.Count)
+	if summary.Count == 0 {
+		return
+	}
+	fmt.Printf("Relevance: min %.4f, mean %.4f, max %.4f\n", summary.MinRelevance, summary.MeanRelevance, summary.MaxRelevance)
+	fmt.Printf("Score:     min %.4f, mean %.4f, max %.4f\n", summary.MinScore, summary.MeanScore, summary.MaxScore)
+}
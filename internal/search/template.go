@@ -0,0 +1,106 @@
+package search
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"paper-rank/internal/graph"
+)
+
+// the fields a --template sees for each result
+type TemplateResult struct {
+	Rank      int // 1-based position in the results
+	ID        string
+	Title     string
+	Year      int
+	Authors   []string
+	Score     float64 // combined score
+	Relevance float64 // relevance in [0, 1]
+	PageRank  float64 // raw PageRank score
+	Citations int     // in-corpus citation count
+	Snippet   string
+	Community int // -1 when no communities are loaded
+}
+
+// DefaultTemplate is the fixed PrintSearchResults layout.
+const DefaultTemplate = "default"
+
+// named templates for --template; "default" has no template text
+var builtinTemplates = map[string]string{
+	DefaultTemplate: "",
+	"compact":       `{{.Rank}}. {{.Title}} ({{.Year}}) {{score .Score}} [{{.ID}}]`,
+	"ids":           `{{.ID}}`,
+	OnelineTemplate: "{{.ID}}\t{{score .Score}}\t{{.Year}}\t{{oneline .Title}}",
+}
+
+// OnelineTemplate is the tab-separated id, score, year and title, one
+// result per line, for cut and awk.
+const OnelineTemplate = "oneline"
+
+func templateFuncs(p graph.Printer) template.FuncMap {
+	return template.FuncMap{
+		"join":    strings.Join,
+		"score":   p.Score,
+		"oneline": oneline,
+	}
+}
+
+// oneline replaces tabs and line breaks with spaces, so a field cannot split
+// a tab-separated line.
+func oneline(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return r == '\t' || r == '\n' || r == '\r'
+	}), " ")
+}
+
+// ParseResultTemplate resolves a built-in template name or parses text as a
+// text/template run once per result. It returns nil for "" and "default",
+// meaning the fixed layout. The score function formats with p.
+func ParseResultTemplate(text string, p graph.Printer) (*template.Template, error) {
+	if builtin, ok := builtinTemplates[text]; ok {
+		text = builtin
+	}
+	if text == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("result").Funcs(templateFuncs(p)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid result template: %v", err)
+	}
+	// field names are only resolved when the template runs, so try it on a
+	// sample result to report a misspelt field before searching
+	sample := TemplateResult{Rank: 1, Authors: []string{""}, Community: -1}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("invalid result template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// WriteTemplateResults renders every result through tmpl, one per line.
+func WriteTemplateResults(w io.Writer, results []SearchResult, tmpl *template.Template) error {
+	for i, result := range results {
+		view := TemplateResult{
+			Rank:      i + 1,
+			ID:        result.Paper.ID,
+			Title:     result.Paper.Title,
+			Year:      result.Paper.Year,
+			Authors:   result.Paper.Authors,
+			Score:     result.Score,
+			Relevance: result.RelevanceScore,
+			PageRank:  result.PageRankScore,
+			Citations: result.Citations,
+			Snippet:   result.Snippet,
+			Community: result.CommunityID,
+		}
+		if err := tmpl.Execute(w, view); err != nil {
+			return fmt.Errorf("failed to render result %d: %v", i+1, err)
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+func TestWriteTemplateResults(t *testing.T) {
+	results := []SearchResult{
+		{Paper: data.Paper{ID: "P1", Title: "Neural\tmachine\ntranslation", Year: 2019, Authors: []string{"Ada Lovelace", "Alan Turing"}},
+			Score: 0.75, RelevanceScore: 0.5, PageRankScore: 0.001, Citations: 3, CommunityID: 2},
+		{Paper: data.Paper{ID: "P2", Title: "Parsing", Year: 2020}, Score: 0.25, CommunityID: -1},
+	}
+	p := graph.Printer{Precision: 4}
+	tests := []struct {
+		template, want string
+	}{
+		{"ids", "P1\nP2\n"},
+		{"compact", "1. Neural\tmachine\ntranslation (2019) 0.7500 [P1]\n2. Parsing (2020) 0.2500 [P2]\n"},
+		{"oneline", "P1\t0.7500\t2019\tNeural machine translation\nP2\t0.2500\t2020\tParsing\n"},
+		{`{{.Rank}} {{join .Authors ", "}} {{.Citations}} {{.Community}} {{score .PageRank}}`,
+			"1 Ada Lovelace, Alan Turing 3 2 1.00e-03\n2  0 -1 0.0000\n"},
+	}
+	for _, tt := range tests {
+		tmpl, err := ParseResultTemplate(tt.template, p)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.template, err)
+		}
+		var out bytes.Buffer
+		if err := WriteTemplateResults(&out, results, tmpl); err != nil {
+			t.Fatalf("%q: %v", tt.template, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%q rendered %q, want %q", tt.template, out.String(), tt.want)
+		}
+	}
+
+	for _, text := range []string{"", DefaultTemplate} {
+		if tmpl, err := ParseResultTemplate(text, p); tmpl != nil || err != nil {
+			t.Errorf("%q parsed to %v, %v; want the fixed layout", text, tmpl, err)
+		}
+	}
+}
+
+func TestParseResultTemplateRejectsBadTemplates(t *testing.T) {
+	for _, text := range []string{"{{.Titel}}", "{{.Title", "{{nosuchfunc .Title}}"} {
+		_, err := ParseResultTemplate(text, graph.Printer{})
+		if err == nil || !strings.Contains(err.Error(), "invalid result template") {
+			t.Errorf("%q: got error %v, want an invalid template", text, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"paper-rank/internal/graph"
+)
+
+// topicPageRank runs a PageRank teleporting to the Config.TopicSeeds papers
+// most relevant to the query, weighted by relevance, so the graph signal
+// favors papers central to the query's topic rather than to the whole
+// corpus.
+func (se *SearchEngine) topicPageRank(queryEmbedding []float32) (map[string]float64, error) {
+	if se.Graph == nil {
+		return nil, fmt.Errorf("no citation graph attached")
+	}
+
+	metric := se.metric
+	if metric.similarity == nil {
+		metric = similarityMetrics[DefaultSimilarityMetric]
+	}
+
+	type seed struct {
+		id        string
+		relevance float64
+	}
+	var seeds []seed
+	for _, paper := range se.Papers {
+		embedding := se.embedding(paper)
+		if len(embedding) == 0 {
+			continue
+		}
+		similarity, err := metric.similarity(queryEmbedding, embedding)
+		if err != nil {
+			continue
+		}
+		seeds = append(seeds, seed{paper.ID, metric.relevance(similarity)})
+	}
+	sort.Slice(seeds, func(i, j int) bool {
+		if seeds[i].relevance != seeds[j].relevance {
+			return seeds[i].relevance > seeds[j].relevance
+		}
+		return seeds[i].id < seeds[j].id
+	})
+	if len(seeds) > se.Config.TopicSeeds {
+		seeds = seeds[:se.Config.TopicSeeds]
+	}
+
+	personalization := make(map[string]float64, len(seeds))
+	for _, s := range seeds {
+		personalization[s.id] = s.relevance
+	}
+
+	result, err := graph.PersonalizedPageRank(se.Graph, graph.PageRankConfig{
+		DampingFactor:  0.85,
+		MaxIterations:  se.Config.TopicIterations,
+		Tolerance:      1e-6,
+		HandleDangling: true,
+	}, personalization, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return result.Scores, nil
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"paper-rank/internal/data"
+	"paper-rank/internal/graph"
+)
+
+// TestTopicPageRankFollowsTheQuery ranks two topics, each with a hub cited
+// by the rest of its papers. Parsing has the larger following, so global
+// PageRank puts its hub first; each topic's PageRank must put its own hub
+// first instead.
+func TestTopicPageRankFollowsTheQuery(t *testing.T) {
+	translation, parsing := []float32{1, 0}, []float32{0, 1}
+	papers := []testPaper{{ID: "mt-hub", Embedding: translation}, {ID: "parse-hub", Embedding: parsing}}
+	var citations []data.CitationEdge
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("mt-%d", i)
+		papers = append(papers, testPaper{ID: id, Embedding: translation})
+		citations = append(citations, data.CitationEdge{From: id, To: "mt-hub"})
+	}
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("parse-%d", i)
+		papers = append(papers, testPaper{ID: id, Embedding: parsing})
+		citations = append(citations, data.CitationEdge{From: id, To: "parse-hub"})
+	}
+	se := testEngine(t, papers, func(c *SearchConfig) { c.TopicSeeds = 4 })
+	se.Graph = graph.BuildGraphFromData(&data.ParsedData{Papers: se.Papers, Citations: citations}, graph.BuildConfig{})
+
+	global, err := graph.CalculatePageRank(se.Graph, graph.PageRankConfig{
+		DampingFactor:  0.85,
+		MaxIterations:  100,
+		Tolerance:      1e-9,
+		HandleDangling: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if top := global.Rankings[0].PaperID; top != "parse-hub" {
+		t.Fatalf("global PageRank puts %s first, want parse-hub", top)
+	}
+
+	for _, tt := range []struct {
+		query []float32
+		want  string
+	}{
+		{translation, "mt-hub"},
+		{parsing, "parse-hub"},
+	} {
+		scores, err := se.topicPageRank(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		top := ""
+		for id, score := range scores {
+			if top == "" || score > scores[top] {
+				top = id
+			}
+		}
+		if top != tt.want {
+			t.Errorf("query %v: topic PageRank puts %s first, want %s", tt.query, top, tt.want)
+		}
+		if scores[tt.want] <= global.Scores[tt.want] {
+			t.Errorf("query %v: %s scores %v, no higher than its global %v", tt.query, tt.want, scores[tt.want], global.Scores[tt.want])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// rankedBefore orders results by combined score, highest first, ties by
+// paper id, so rankings are reproducible.
+func rankedBefore(a, b *SearchResult) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.Paper.ID < b.Paper.ID
+}
+
+// the best limit results seen so far, in a min-heap with the worst kept
+// result on top, so a corpus-sized result slice is never allocated; with a
+// limit of 0 every result is kept
+type topResults struct {
+	limit   int
+	results resultHeap
+}
+
+func newTopResults(limit int) *topResults {
+	return &topResults{limit: limit, results: make(resultHeap, 0, max(limit, 0))}
+}
+
+func (t *topResults) add(result SearchResult) {
+	switch {
+	case t.limit <= 0:
+		t.results = append(t.results, result)
+	case len(t.results) < t.limit:
+		heap.Push(&t.results, result)
+	case rankedBefore(&result, &t.results[0]):
+		t.results[0] = result
+		heap.Fix(&t.results, 0)
+	}
+}
+
+// sorted returns the kept results best first.
+func (t *topResults) sorted() []SearchResult {
+	results := []SearchResult(t.results)
+	sort.Slice(results, func(i, j int) bool {
+		return rankedBefore(&results[i], &results[j])
+	})
+	return results
+}
+
+type resultHeap []SearchResult
+
+func (h resultHeap) Len() int           { return len(h) }
+func (h resultHeap) Less(i, j int) bool { return rankedBefore(&h[j], &h[i]) }
+func (h resultHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *resultHeap) Push(x any)        { *h = append(*h, x.(SearchResult)) }
+func (h *resultHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+
+	"paper-rank/internal/data"
+)
+
+// randomPapers returns n papers with random dim-dimensional embeddings and
+// PageRank scores drawn from a few values, so scores tie.
+func randomPapers(n, dim int, seed int64) []testPaper {
+	rng := rand.New(rand.NewSource(seed))
+	papers := make([]testPaper, n)
+	for i := range papers {
+		embedding := make([]float32, dim)
+		for j := range embedding {
+			embedding[j] = float32(rng.Intn(3) - 1)
+		}
+		papers[i] = testPaper{
+			ID:        fmt.Sprintf("P%06d", i),
+			Embedding: embedding,
+			PageRank:  float64(rng.Intn(4)) / 10,
+		}
+	}
+	return papers
+}
+
+func TestTopResultsMatchesFullSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	all := make([]SearchResult, 200)
+	for i := range all {
+		all[i] = SearchResult{
+			Paper: data.Paper{ID: fmt.Sprintf("P%03d", i)},
+			Score: float64(rng.Intn(20)) / 20, // plenty of ties
+		}
+	}
+	rng.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
+	want := slices.Clone(all)
+	sort.Slice(want, func(i, j int) bool { return rankedBefore(&want[i], &want[j]) })
+
+	for _, limit := range []int{0, 1, 7, 199, 200, 250} {
+		top := newTopResults(limit)
+		for _, result := range all {
+			top.add(result)
+		}
+		wantIDs := resultIDs(want)
+		if limit > 0 && limit < len(want) {
+			wantIDs = wantIDs[:limit]
+		}
+		if got := resultIDs(top.sorted()); !slices.Equal(got, wantIDs) {
+			t.Errorf("limit %d: kept %v, want %v", limit, got, wantIDs)
+		}
+	}
+}
+
+func TestSearchTopKMatchesFullRanking(t *testing.T) {
+	papers := randomPapers(500, 8, 2)
+	query := []float32{1, 1, 0, -1, 0, 1, 0, 0}
+	se := testEngine(t, papers, nil)
+
+	full, _ := se.scoreAndRank(SearchQuery{}, query, se.PageRank, nil, 0)
+	if len(full) != len(papers) {
+		t.Fatalf("full ranking has %d results, want %d", len(full), len(papers))
+	}
+	for _, maxResults := range []int{1, 10, 100} {
+		se.Config.MaxResults = maxResults
+		got := se.SearchEmbedding(SearchQuery{}, query)
+		want := full[:maxResults]
+		if !slices.Equal(resultIDs(got), resultIDs(want)) {
+			t.Errorf("top %d: %v, want %v", maxResults, resultIDs(got), resultIDs(want))
+			continue
+		}
+		for i := range got {
+			if got[i].Score != want[i].Score || got[i].Snippet == "" {
+				t.Errorf("top %d: result %d scored %v with snippet %q, want %v with a snippet",
+					maxResults, i, got[i].Score, got[i].Snippet, want[i].Score)
+			}
+		}
+	}
+}
+
+func BenchmarkScoreAndRank(b *testing.B) {
+	papers := randomPapers(100000, 64, 3)
+	query := papers[0].Embedding
+	se := testEngine(b, papers, nil)
+
+	for _, limit := range []int{11, 0} {
+		name := fmt.Sprintf("top%d", limit)
+		if limit == 0 {
+			name = "full-sort"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				se.scoreAndRank(SearchQuery{}, query, se.PageRank, nil, limit)
+			}
+		})
+	}
+}
@@ -1,18 +1,47 @@
 import json
+import os
 from sentence_transformers import SentenceTransformer
 from tqdm import tqdm
 import sys
 
 MODEL_NAME = 'all-MiniLM-L6-v2'
 
-def create_and_save_embeddings(input_path, output_path):
+def load_papers(input_path):
+    """
+    Loads papers from a JSON or JSONL (one {"kind", "data"} record per line) file.
+    Returns the papers and whether the file was JSONL.
+    """
+    with open(input_path, 'r', encoding='utf-8') as f:
+        first_line = f.readline()
+        f.seek(0)
+        if first_line.startswith('{"kind"'):
+            papers = []
+            for line in f:
+                record = json.loads(line)
+                if record.get("kind") == "paper":
+                    papers.append(record["data"])
+            return papers, True
+        return json.load(f).get("papers", []), False
+
+
+def load_full_abstracts(path):
+    """
+    Loads the full text of the abstracts 'parse --max-abstract-chars' truncated,
+    by paper id; empty when parse truncated nothing.
+    """
+    if not os.path.exists(path):
+        return {}
+    with open(path, 'r', encoding='utf-8') as f:
+        return json.load(f).get("abstracts") or {}
+
+
+def create_and_save_embeddings(input_path, output_path, full_abstracts_path):
     """
     Loads papers from a JSON file, generates sentence embeddings for their abstracts,
-    and saves the augmented data to a new JSON file.
+    and saves the augmented data to a new file in the same format (JSON or JSONL).
     """
     try:
-        with open(input_path, 'r', encoding='utf-8') as f:
-            papers = json.load(f).get("papers", [])
+        papers, is_jsonl = load_papers(input_path)
     except FileNotFoundError:
         print(f"Error: Input file not found at {input_path}")
         return
@@ -25,8 +54,10 @@ def create_and_save_embeddings(input_path, output_path):
 
     model = SentenceTransformer(MODEL_NAME)
 
+    # embed truncated abstracts from their full text; the stored one stays short
+    full_abstracts = load_full_abstracts(full_abstracts_path)
     abstracts = [
-        paper.get('abstract') or paper.get('title', '')
+        full_abstracts.get(paper.get('id')) or paper.get('abstract') or paper.get('title', '')
         for paper in papers
     ]
 
@@ -44,14 +75,17 @@ def create_and_save_embeddings(input_path, output_path):
     for i, paper in enumerate(papers):
         paper['abstract_embedding'] = embeddings[i].tolist()
 
-    output_data = {"papers": papers}
-
     print(f"Saving augmented data with embeddings to: {output_path}")
     with open(output_path, 'w', encoding='utf-8') as f:
-        json.dump(output_data, f, indent=2)
+        if is_jsonl:
+            for paper in papers:
+                f.write(json.dumps({"kind": "paper", "data": paper}) + "\n")
+        else:
+            json.dump({"papers": papers}, f, indent=2)
 
 
 if __name__ == "__main__":
     input_file = "data/processed/papers.json"
     output_file = "data/processed/papers_with_embeddings.json"
-    create_and_save_embeddings(input_file, output_file)
\ No newline at end of file
+    full_abstracts_file = "data/processed/abstracts.json"
+    create_and_save_embeddings(input_file, output_file, full_abstracts_file)
\ No newline at end of file
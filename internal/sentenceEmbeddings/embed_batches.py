@@ -0,0 +1,34 @@
+import sys
+import json
+from sentence_transformers import SentenceTransformer
+
+MODEL_NAME = 'all-MiniLM-L6-v2'
+
+def embed_batches():
+    """
+    Batch protocol used by 'acl-ranker embed'. Reads one JSON request per
+    line from stdin:
+        {"ids": ["P1", "P2"], "texts": ["abstract 1", "abstract 2"]}
+    and answers each with one JSON line on stdout, in the same order:
+        {"ids": ["P1", "P2"], "embeddings": [[...], [...]]}
+    The model is loaded once and stdout is flushed after every batch.
+    """
+
+    model = SentenceTransformer(MODEL_NAME)
+
+    for line in sys.stdin:
+        if not line.strip():
+            continue
+        request = json.loads(line)
+
+        embeddings = model.encode(
+            request["texts"],
+            normalize_embeddings=True
+        )
+
+        response = {"ids": request["ids"], "embeddings": embeddings.tolist()}
+        sys.stdout.write(json.dumps(response) + "\n")
+        sys.stdout.flush()
+
+if __name__ == "__main__":
+    embed_batches()
@@ -6,15 +6,14 @@ MODEL_NAME = 'all-MiniLM-L6-v2'
 
 def embed_query():
     """
-    Reads a query from a command-line argument, generates its embedding,
+    Reads a query from stdin, generates its embedding,
     and prints it to stdout as a JSON array.
     """
 
-    if len(sys.argv) < 2:
-        print("Error: No query provided. Please pass the query as an argument.", file=sys.stderr)
+    query = sys.stdin.read()
+    if not query.strip():
+        print("Error: No query provided. Please write the query to stdin.", file=sys.stderr)
         sys.exit(1)
-
-    query = sys.argv[1]
     
     model = SentenceTransformer(MODEL_NAME)
     